@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"net"
+	"sync"
+)
+
+// defaultWriteQueueSize is the default size of per-worker queues of asyncWriter.
+const defaultWriteQueueSize = 256
+
+// asyncWriteJob is a packet queued for encryption. The writeBuffer is owned by the job, and it is
+// returned to the pool after the packet is sent.
+type asyncWriteJob struct {
+	wbuf      *writeBuffer
+	headerLen int
+	size      int
+}
+
+// asyncWriter encrypts and sends RTP packets written to SessionSRTP in a pool of worker goroutines,
+// see Config.WriteWorkers. Packets of the same SSRC are always handled by the same worker, so their
+// order is preserved. Queues are bounded, so writers block when workers cannot keep up.
+type asyncWriter struct {
+	ctx     *ConcurrentContext
+	conn    net.Conn
+	onError func(err error)
+
+	queues []chan asyncWriteJob
+	// closed is closed first by close, to unblock enqueue calls waiting for a full queue. Workers
+	// are stopped by closing stop, after all pending enqueue calls have returned, so no packet
+	// is queued after workers sent the remaining ones.
+	closed    chan struct{}
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	// mu is held for reading by enqueue, and for writing by close to set closing.
+	mu      sync.RWMutex
+	closing bool
+}
+
+func newAsyncWriter(ctx *ConcurrentContext, conn net.Conn, workers, queueSize int, onError func(err error)) *asyncWriter {
+	if queueSize <= 0 {
+		queueSize = defaultWriteQueueSize
+	}
+
+	writer := &asyncWriter{
+		ctx:     ctx,
+		conn:    conn,
+		onError: onError,
+		queues:  make([]chan asyncWriteJob, workers),
+		closed:  make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	for i := range writer.queues {
+		writer.queues[i] = make(chan asyncWriteJob, queueSize)
+		writer.wg.Add(1)
+		go writer.run(writer.queues[i])
+	}
+
+	return writer
+}
+
+// enqueue queues the packet prepared in wbuf for encryption. wbuf.header must be parsed from the packet.
+// It blocks while the queue of the worker is full.
+func (w *asyncWriter) enqueue(wbuf *writeBuffer, headerLen, size int) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closing {
+		writeBufferPool.Put(wbuf)
+
+		return errStreamAlreadyClosed
+	}
+
+	queue := w.queues[wbuf.header.SSRC%uint32(len(w.queues))] //nolint:gosec // G115
+	select {
+	case queue <- asyncWriteJob{wbuf: wbuf, headerLen: headerLen, size: size}:
+		return nil
+	case <-w.closed:
+		writeBufferPool.Put(wbuf)
+
+		return errStreamAlreadyClosed
+	}
+}
+
+func (w *asyncWriter) run(queue chan asyncWriteJob) {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case job := <-queue:
+			w.send(job)
+		case <-w.stop:
+			// Packets queued before close are still sent.
+			for {
+				select {
+				case job := <-queue:
+					w.send(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *asyncWriter) send(job asyncWriteJob) {
+	defer writeBufferPool.Put(job.wbuf)
+
+	buf, header := job.wbuf.buf, &job.wbuf.header
+	var encrypted []byte
+	err := w.ctx.do(header.SSRC, func(ctx *Context) (err error) {
+		encrypted, err = ctx.encryptRTP(buf, header, job.headerLen, buf[:job.size])
+
+		return err
+	})
+	if err == nil {
+		_, err = w.conn.Write(encrypted)
+	}
+	if err != nil {
+		w.onError(err)
+	}
+}
+
+// close stops accepting new packets, and waits until already queued ones are sent.
+func (w *asyncWriter) close() {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+		w.mu.Lock()
+		w.closing = true
+		w.mu.Unlock()
+		close(w.stop)
+	})
+	w.wg.Wait()
+}
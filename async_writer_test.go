@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingConn is net.Conn which counts written packets.
+type countingConn struct {
+	net.Conn
+	written atomic.Int64
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	c.written.Add(1)
+
+	return len(b), nil
+}
+
+// TestAsyncWriterCloseDuringEnqueue checks that every packet accepted by enqueue is sent, even when
+// close is called concurrently.
+func TestAsyncWriterCloseDuringEnqueue(t *testing.T) {
+	for range 10 {
+		ctx, err := buildTestContext(profileCTR)
+		require.NoError(t, err)
+		conn := &countingConn{}
+		writer := newAsyncWriter(NewConcurrentContext(ctx), conn, 2, 1, func(err error) {
+			assert.NoError(t, err)
+		})
+
+		var accepted atomic.Int64
+		var writers sync.WaitGroup
+		for ssrc := range uint32(4) {
+			writers.Add(1)
+			go func() {
+				defer writers.Done()
+				for seq := uint16(0); ; seq++ {
+					wbuf, _ := writeBufferPool.Get().(*writeBuffer)
+					pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: seq}, Payload: []byte{0x01}}
+					size, errMarshal := pkt.MarshalTo(wbuf.grow(pkt.MarshalSize()))
+					if !assert.NoError(t, errMarshal) {
+						return
+					}
+					wbuf.header = pkt.Header
+					if errEnqueue := writer.enqueue(wbuf, pkt.Header.MarshalSize(), size); errEnqueue != nil {
+						assert.ErrorIs(t, errEnqueue, errStreamAlreadyClosed)
+
+						return
+					}
+					accepted.Add(1)
+				}
+			}()
+		}
+
+		require.Eventually(t, func() bool { return accepted.Load() >= 50 }, time.Second, time.Millisecond)
+		writer.close()
+		writers.Wait()
+		assert.Equal(t, accepted.Load(), conn.written.Load())
+	}
+}
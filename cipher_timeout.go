@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// ContextCipher is a Cipher which can abort encryption and decryption when the context is done.
+// Custom cipher must implement it to be used with CipherTimeout option.
+//
+// Methods work like ones of Cipher without the context. When ctx is done before the operation
+// finishes, they must stop and return an error wrapping ctx.Err(). Output written to dst
+// by aborted operation is discarded.
+type ContextCipher interface {
+	Cipher
+
+	EncryptRTPContext(
+		ctx context.Context, dst []byte, header *rtp.Header, headerLen int, plaintext []byte, roc uint32,
+	) ([]byte, error)
+	DecryptRTPContext(
+		ctx context.Context, dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32,
+	) ([]byte, error)
+	EncryptRTCPContext(ctx context.Context, dst, plaintext []byte, srtcpIndex, ssrc uint32) ([]byte, error)
+	DecryptRTCPContext(ctx context.Context, dst, ciphertext []byte, srtcpIndex, ssrc uint32) ([]byte, error)
+}
+
+// timeoutCipher adapts ContextCipher to srtpCipher interface, and limits time of encryption
+// and decryption operations.
+type timeoutCipher struct {
+	customCipher
+	cipher  ContextCipher
+	timeout time.Duration
+}
+
+// newTimeoutCipher wraps cipher with timeoutCipher. It fails when cipher does not implement ContextCipher.
+func newTimeoutCipher(cipher Cipher, timeout time.Duration) (*timeoutCipher, error) {
+	contextCipher, ok := cipher.(ContextCipher)
+	if !ok {
+		return nil, fmt.Errorf("%w: CipherTimeout requires ContextCipher", errCustomCipherOptionNotSupported)
+	}
+
+	return &timeoutCipher{customCipher: customCipher{cipher}, cipher: contextCipher, timeout: timeout}, nil
+}
+
+// result returns ErrCipherTimeout wrapped error when the operation was aborted because of timeout.
+func (t *timeoutCipher) result(out []byte, err error) ([]byte, error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return nil, fmt.Errorf("%w: %w", ErrCipherTimeout, err)
+	case err != nil:
+		return nil, err
+	default:
+		return out, nil
+	}
+}
+
+func (t *timeoutCipher) encryptRTP(
+	dst []byte, header *rtp.Header, headerLen int, plaintext []byte, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	if rocInAuthTag {
+		return nil, errCustomCipherOptionNotSupported
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	return t.result(t.cipher.EncryptRTPContext(ctx, dst, header, headerLen, plaintext, roc))
+}
+
+func (t *timeoutCipher) decryptRTP(
+	dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	if rocInAuthTag {
+		return nil, errCustomCipherOptionNotSupported
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	return t.result(t.cipher.DecryptRTPContext(ctx, dst, ciphertext, header, headerLen, roc))
+}
+
+func (t *timeoutCipher) encryptRTCP(dst, decrypted []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	return t.result(t.cipher.EncryptRTCPContext(ctx, dst, decrypted, srtcpIndex, ssrc))
+}
+
+func (t *timeoutCipher) decryptRTCP(dst, encrypted []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	return t.result(t.cipher.DecryptRTCPContext(ctx, dst, encrypted, srtcpIndex, ssrc))
+}
+
+// clone returns the same cipher, like customCipher.
+func (t *timeoutCipher) clone() srtpCipher {
+	return t
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowCipher implements ContextCipher, and delays every operation of the wrapped cipher, to simulate
+// slow custom cipher.
+type slowCipher struct {
+	testCustomCipher
+	delay time.Duration
+}
+
+func (s slowCipher) wait(ctx context.Context) error {
+	select {
+	case <-time.After(s.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s slowCipher) EncryptRTPContext(
+	ctx context.Context, dst []byte, header *rtp.Header, headerLen int, plaintext []byte, roc uint32,
+) ([]byte, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.EncryptRTP(dst, header, headerLen, plaintext, roc)
+}
+
+func (s slowCipher) DecryptRTPContext(
+	ctx context.Context, dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32,
+) ([]byte, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.DecryptRTP(dst, ciphertext, header, headerLen, roc)
+}
+
+func (s slowCipher) EncryptRTCPContext(
+	ctx context.Context, dst, plaintext []byte, srtcpIndex, ssrc uint32,
+) ([]byte, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.EncryptRTCP(dst, plaintext, srtcpIndex, ssrc)
+}
+
+func (s slowCipher) DecryptRTCPContext(
+	ctx context.Context, dst, ciphertext []byte, srtcpIndex, ssrc uint32,
+) ([]byte, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.DecryptRTCP(dst, ciphertext, srtcpIndex, ssrc)
+}
+
+func TestCipherTimeout(t *testing.T) {
+	baseCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	pkt := &rtp.Packet{Header: rtp.Header{SSRC: defaultSsrc, SequenceNumber: 1}, Payload: rtpTestCaseDecrypted()}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	rtcpRaw, err := (&rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2}).Marshal()
+	assert.NoError(t, err)
+
+	t.Run("Fast", func(t *testing.T) {
+		ctx, err := CreateContextWithCipher(slowCipher{testCustomCipher: testCustomCipher{baseCtx.cipher}},
+			CipherTimeout(time.Second))
+		assert.NoError(t, err)
+		assert.IsType(t, &timeoutCipher{}, ctx.cipher)
+
+		encrypted, err := ctx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		expected, err := baseCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, encrypted)
+
+		decrypted, err := ctx.DecryptRTP(encrypted, encrypted, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, pktRaw, decrypted)
+
+		encryptedRTCP, err := ctx.EncryptRTCP(nil, rtcpRaw, nil)
+		assert.NoError(t, err)
+		decryptedRTCP, err := ctx.DecryptRTCP(nil, encryptedRTCP, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, rtcpRaw, decryptedRTCP)
+	})
+
+	t.Run("Slow", func(t *testing.T) {
+		ctx, err := CreateContextWithCipher(
+			slowCipher{testCustomCipher: testCustomCipher{baseCtx.cipher.clone()}, delay: time.Minute},
+			CipherTimeout(10*time.Millisecond))
+		assert.NoError(t, err)
+
+		_, err = ctx.EncryptRTP(nil, pktRaw, nil)
+		assert.ErrorIs(t, err, ErrCipherTimeout)
+		_, err = ctx.EncryptRTCP(nil, rtcpRaw, nil)
+		assert.ErrorIs(t, err, ErrCipherTimeout)
+
+		encrypted, err := baseCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		_, err = ctx.DecryptRTP(nil, encrypted, nil)
+		assert.ErrorIs(t, err, ErrCipherTimeout)
+		encryptedRTCP, err := baseCtx.EncryptRTCP(nil, rtcpRaw, nil)
+		assert.NoError(t, err)
+		_, err = ctx.DecryptRTCP(nil, encryptedRTCP, nil)
+		assert.ErrorIs(t, err, ErrCipherTimeout)
+	})
+
+	t.Run("NotContextCipher", func(t *testing.T) {
+		_, err := CreateContextWithCipher(testCustomCipher{baseCtx.cipher}, CipherTimeout(time.Second))
+		assert.ErrorIs(t, err, errCustomCipherOptionNotSupported)
+	})
+}
+
+func TestCipherTimeoutBuiltInCiphers(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			ctx, err := buildTestContext(profile, CipherTimeout(time.Nanosecond))
+			assert.NoError(t, err)
+			_, isTimeoutCipher := ctx.cipher.(*timeoutCipher)
+			assert.False(t, isTimeoutCipher)
+
+			pkt := &rtp.Packet{Header: rtp.Header{SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			encrypted, err := ctx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			decrypted, err := ctx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, pktRaw, decrypted)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"sync"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// ConcurrentContext is a SRTP cryptographic context which can be used from multiple goroutines without
+// external synchronization. It keeps a separate Context for each SSRC, cloned from the template Context
+// with Context.Clone, so session keys are derived only once. Packets of different SSRCs are encrypted
+// and decrypted in parallel, and packets of the same SSRC are serialized by a per-SSRC mutex.
+//
+// Each SSRC has its own ROC, SRTCP index and replay protection state, like in Context. When a SSRC is
+// removed, its newest indexes are remembered like by Context.RemoveSSRC, and they are restored when
+// the SSRC is used again, so SRTCP indexes are not reused. Options which
+// keep state across SSRCs, like SRTPSingleSSRC or OnKeyExpired, apply to each SSRC separately.
+// Callbacks and recorders set by options may be called from multiple goroutines concurrently.
+type ConcurrentContext struct {
+	// Newest indexes of removed SSRCs are kept in evictedSSRCs of the template.
+	template *Context
+
+	mu    sync.RWMutex
+	ssrcs map[uint32]*ssrcContext
+	// Stats of removed per-SSRC contexts.
+	retiredStats Stats
+}
+
+// ssrcContext is a Context used for packets of a single SSRC.
+type ssrcContext struct {
+	mu  sync.Mutex
+	ctx *Context
+	// removed is set when the context is removed from ConcurrentContext, so goroutines waiting
+	// for the mutex look it up again.
+	removed bool
+}
+
+// NewConcurrentContext creates ConcurrentContext which uses configuration and keys of the template
+// Context. Per-SSRC state of the template is not used. The template must not be used after the call.
+func NewConcurrentContext(template *Context) *ConcurrentContext {
+	return &ConcurrentContext{
+		template: template,
+		ssrcs:    map[uint32]*ssrcContext{},
+	}
+}
+
+// EncryptRTP marshals and encrypts a RTP packet, like Context.EncryptRTP.
+func (c *ConcurrentContext) EncryptRTP(dst []byte, plaintext []byte, header *rtp.Header) ([]byte, error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	err = c.do(header.SSRC, func(ctx *Context) error {
+		out, err = ctx.encryptRTP(dst, header, headerLen, plaintext)
+
+		return err
+	})
+
+	return out, err
+}
+
+// DecryptRTP decrypts a RTP packet with an encrypted payload, like Context.DecryptRTP.
+func (c *ConcurrentContext) DecryptRTP(dst, encrypted []byte, header *rtp.Header) ([]byte, error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(encrypted)
+	if err != nil {
+		return nil, c.headerFailure(err, encrypted, false)
+	}
+
+	var out []byte
+	err = c.do(header.SSRC, func(ctx *Context) error {
+		out, err = ctx.decryptRTP(dst, encrypted, header, headerLen)
+
+		return err
+	})
+
+	return out, err
+}
+
+// EncryptRTCP encrypts a RTCP packet, like Context.EncryptRTCP.
+func (c *ConcurrentContext) EncryptRTCP(dst, decrypted []byte, header *rtcp.Header) ([]byte, error) {
+	if header == nil {
+		header = &rtcp.Header{}
+	}
+
+	if err := header.Unmarshal(decrypted); err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	err := c.do(rtcpSSRC(decrypted), func(ctx *Context) (err error) {
+		out, err = ctx.encryptRTCP(dst, decrypted)
+
+		return err
+	})
+
+	return out, err
+}
+
+// DecryptRTCP decrypts a buffer that contains a RTCP packet, like Context.DecryptRTCP.
+func (c *ConcurrentContext) DecryptRTCP(dst, encrypted []byte, header *rtcp.Header) ([]byte, error) {
+	if header == nil {
+		header = &rtcp.Header{}
+	}
+
+	if err := header.Unmarshal(encrypted); err != nil {
+		return nil, c.headerFailure(err, encrypted, true)
+	}
+
+	var out []byte
+	err := c.do(rtcpSSRC(encrypted), func(ctx *Context) (err error) {
+		out, err = ctx.decryptRTCP(dst, encrypted)
+
+		return err
+	})
+
+	return out, err
+}
+
+// UpdateMasterKey replaces master key and salt used for all SSRCs, like Context.UpdateMasterKey.
+// Session keys are derived once and shared by contexts of all SSRCs.
+func (c *ConcurrentContext) UpdateMasterKey(masterKey, masterSalt []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cipher, err := c.template.newMasterKeyCipher(masterKey, masterSalt)
+	if err != nil {
+		return err
+	}
+	c.setMasterKeyCipher(cipher, masterKey)
+
+	return nil
+}
+
+// setMasterKeyCipher sets cipher for the new master key in the template and all per-SSRC contexts.
+// It must be called with the lock held.
+func (c *ConcurrentContext) setMasterKeyCipher(cipher srtpCipher, masterKey []byte) {
+	c.template.setMasterKeyCipher(cipher)
+	if c.template.ektKeys != nil {
+		c.template.ektMasterKey = append([]byte{}, masterKey...)
+	}
+
+	for _, s := range c.ssrcs {
+		s.mu.Lock()
+		s.ctx.setMasterKeyCipher(cipher.clone())
+		s.ctx.ektMasterKey = c.template.ektMasterKey
+		s.mu.Unlock()
+	}
+}
+
+// RemoveSSRC removes state of the SSRC, e.g. after the stream ended.
+func (c *ConcurrentContext) RemoveSSRC(ssrc uint32) {
+	c.mu.RLock()
+	s, ok := c.ssrcs[ssrc]
+	c.mu.RUnlock()
+	if ok {
+		c.remove(ssrc, s, false)
+	}
+}
+
+// lastSRTPIndex returns SRTP packet index of the highest packet of the SSRC, like Context.lastSRTPIndex.
+func (c *ConcurrentContext) lastSRTPIndex(ssrc uint32) (uint64, bool) {
+	c.mu.RLock()
+	s, ok := c.ssrcs[ssrc]
+	c.mu.RUnlock()
+	if !ok {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ctx.lastSRTPIndex(ssrc)
+}
+
+// Stats returns counters of packets processed for all SSRCs.
+func (c *ConcurrentContext) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := c.retiredStats
+	stats.ROC = map[uint32]uint32{}
+	for _, s := range c.ssrcs {
+		s.mu.Lock()
+		stats.add(s.ctx.Stats())
+		s.mu.Unlock()
+	}
+
+	return stats
+}
+
+// do calls fn with Context of the SSRC, holding its mutex. Context is created on the first use.
+// When fn fails and the Context has no SRTP and SRTCP state, e.g. because a packet with unknown
+// SSRC failed authentication, the Context is removed, so forged packets cannot grow the SSRC map.
+func (c *ConcurrentContext) do(ssrc uint32, fn func(ctx *Context) error) error {
+	for {
+		s := c.getOrCreate(ssrc)
+
+		s.mu.Lock()
+		if s.removed {
+			s.mu.Unlock()
+
+			continue
+		}
+		err := fn(s.ctx)
+		empty := err != nil && !s.ctx.hasSSRCState()
+		s.mu.Unlock()
+
+		if empty {
+			c.remove(ssrc, s, true)
+		}
+
+		return err
+	}
+}
+
+func (c *ConcurrentContext) getOrCreate(ssrc uint32) *ssrcContext {
+	c.mu.RLock()
+	s, ok := c.ssrcs[ssrc]
+	c.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok = c.ssrcs[ssrc]; !ok {
+		ctx := c.template.cloneConfig()
+		if evicted, ok := c.template.evictedSSRCs[ssrc]; ok {
+			evictedCopy := *evicted
+			ctx.evictedSSRCs = map[uint32]*evictedSSRC{ssrc: &evictedCopy}
+		}
+		s = &ssrcContext{ctx: ctx}
+		c.ssrcs[ssrc] = s
+	}
+
+	return s
+}
+
+// remove removes Context of the SSRC, and keeps its stats and newest indexes. When onlyEmpty is set,
+// Context is removed only if it has no SRTP and SRTCP state.
+func (c *ConcurrentContext) remove(ssrc uint32, s *ssrcContext, onlyEmpty bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.removed || onlyEmpty && s.ctx.hasSSRCState() {
+		return
+	}
+	s.removed = true
+	if c.ssrcs[ssrc] == s {
+		delete(c.ssrcs, ssrc)
+	}
+	s.ctx.RemoveSSRC(ssrc)
+	if evicted, ok := s.ctx.evictedSSRCs[ssrc]; ok {
+		*c.template.evictedSSRC(ssrc) = *evicted
+	}
+	stats := s.ctx.Stats()
+	stats.ROC = nil
+	c.retiredStats.add(stats)
+}
+
+// headerFailure handles error of parsing the header of received packet with the template, like
+// Context.DecryptRTP does. Packets without a valid header do not have a SSRC, so the template is used.
+func (c *ConcurrentContext) headerFailure(err error, packet []byte, isRTCP bool) error {
+	if !c.template.uniformDecryptFailures {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.template.headerFailure(err, packet, isRTCP)
+}
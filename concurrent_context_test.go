@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentContext(t *testing.T) {
+	const ssrcCount, packetCount = 8, 100
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			template, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			encryptCtx := NewConcurrentContext(template)
+			decryptTemplate, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			decryptCtx := NewConcurrentContext(decryptTemplate)
+			referenceCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+
+			marshal := func(ssrc uint32, seq uint16) []byte {
+				pkt := &rtp.Packet{Header: rtp.Header{SSRC: ssrc, SequenceNumber: seq}, Payload: []byte{0x00, 0x01}}
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+
+				return pktRaw
+			}
+
+			var wg sync.WaitGroup
+			encrypted := make([][][]byte, ssrcCount)
+			for i := range ssrcCount {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ssrc := uint32(i + 1) //nolint:gosec // G115
+					for seq := range uint16(packetCount) {
+						out, errEncrypt := encryptCtx.EncryptRTP(nil, marshal(ssrc, 65500+seq), nil)
+						assert.NoError(t, errEncrypt)
+						encrypted[i] = append(encrypted[i], out)
+
+						decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, out, nil)
+						assert.NoError(t, errDecrypt)
+						assert.Equal(t, marshal(ssrc, 65500+seq), decrypted)
+					}
+				}()
+			}
+			wg.Wait()
+
+			// Each SSRC has its own state, like in a single Context.
+			for i := range ssrcCount {
+				ssrc := uint32(i + 1) //nolint:gosec // G115
+				for seq := range uint16(packetCount) {
+					expected, errEncrypt := referenceCtx.EncryptRTP(nil, marshal(ssrc, 65500+seq), nil)
+					assert.NoError(t, errEncrypt)
+					assert.Equal(t, expected, encrypted[i][seq])
+				}
+			}
+
+			_, err = decryptCtx.DecryptRTP(nil, encrypted[0][0], nil)
+			assert.ErrorIs(t, err, errDuplicated)
+
+			stats := decryptCtx.Stats()
+			assert.Equal(t, uint64(ssrcCount*packetCount), stats.SRTPDecrypted)
+			assert.Equal(t, uint64(1), stats.ReplayDrops)
+			assert.Len(t, stats.ROC, ssrcCount)
+			assert.Equal(t, uint32(1), stats.ROC[1])
+		})
+	}
+}
+
+func TestConcurrentContextFailedAuthDoesNotGrowSSRCs(t *testing.T) {
+	template, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	encryptCtx := NewConcurrentContext(template)
+	decryptTemplate, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx := NewConcurrentContext(decryptTemplate)
+
+	pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: 1}, Payload: []byte{0x00, 0x01}}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+
+	forged := append([]byte{}, encrypted...)
+	forged[len(forged)-1] ^= 0xff
+	_, err = decryptCtx.DecryptRTP(nil, forged, nil)
+	assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+	assert.Empty(t, decryptCtx.ssrcs)
+	assert.Equal(t, uint64(1), decryptCtx.Stats().AuthFailures)
+
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Len(t, decryptCtx.ssrcs, 1)
+
+	// Failures of known SSRCs keep their state.
+	_, err = decryptCtx.DecryptRTP(nil, forged, nil)
+	assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+	assert.Len(t, decryptCtx.ssrcs, 1)
+
+	decryptCtx.RemoveSSRC(1)
+	assert.Empty(t, decryptCtx.ssrcs)
+	stats := decryptCtx.Stats()
+	assert.Equal(t, uint64(1), stats.SRTPDecrypted)
+	assert.Equal(t, uint64(2), stats.AuthFailures)
+}
+
+func TestConcurrentContextRemoveSSRCKeepsIndexes(t *testing.T) {
+	template, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	encryptCtx := NewConcurrentContext(template)
+	decryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	rtcpRaw, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{SenderSSRC: 1}})
+	assert.NoError(t, err)
+	encryptRTCP := func() []byte {
+		encrypted, errEncrypt := encryptCtx.EncryptRTCP(nil, rtcpRaw, nil)
+		assert.NoError(t, errEncrypt)
+
+		return encrypted
+	}
+
+	first := encryptRTCP()
+	encryptCtx.RemoveSSRC(1)
+	assert.Empty(t, encryptCtx.ssrcs)
+
+	// SRTCP index continues after the SSRC was removed, so the IV is not reused.
+	second := encryptRTCP()
+	assert.NotEqual(t, first, second)
+	_, err = decryptCtx.DecryptRTCP(nil, first, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTCP(nil, second, nil)
+	assert.NoError(t, err)
+}
+
+func TestConcurrentContextUpdateMasterKey(t *testing.T) {
+	newKey, newSalt := make([]byte, 16), make([]byte, 14)
+	newKey[0] = 1
+
+	template, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	encryptCtx := NewConcurrentContext(template)
+	decryptCtx, err := CreateContext(newKey, newSalt, profileCTR)
+	assert.NoError(t, err)
+
+	encrypt := func(ssrc uint32) []byte {
+		pkt := &rtp.Packet{Header: rtp.Header{SSRC: ssrc, SequenceNumber: 1}, Payload: []byte{0x00, 0x01}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+
+		return encrypted
+	}
+
+	encrypt(1)
+	assert.Error(t, encryptCtx.UpdateMasterKey(newKey[:15], newSalt))
+	assert.NoError(t, encryptCtx.UpdateMasterKey(newKey, newSalt))
+
+	// Both existing and new SSRCs use the new key.
+	for _, ssrc := range []uint32{1, 2} {
+		_, err = decryptCtx.DecryptRTP(nil, encrypt(ssrc), nil)
+		assert.NoError(t, err)
+	}
+}
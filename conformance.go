@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/rtp"
+)
+
+// DerivedSessionKeys contains session keys derived from the master key and salt, as defined by
+// RFC 3711 section 4.3. Authentication keys are empty for AEAD profiles.
+type DerivedSessionKeys struct {
+	SRTPEncryptionKey      []byte
+	SRTPAuthenticationKey  []byte
+	SRTPSalt               []byte
+	SRTCPEncryptionKey     []byte
+	SRTCPAuthenticationKey []byte
+	SRTCPSalt              []byte
+}
+
+// GCMNonceFunc returns 12-byte IV used by AEAD profiles for SRTP packet with given SSRC and packet
+// index (2^16 * ROC + SEQ), or for SRTCP packet with given SSRC and SRTCP index. sessionSalt is the SRTP
+// or SRTCP session salt. See UnsafeGCMNonceSource option.
+type GCMNonceFunc func(sessionSalt [12]byte, ssrc uint32, index uint64, isRTCP bool) [12]byte
+
+// fixedSessionKeysFunc is KeyDerivationFunc which returns given session keys.
+type fixedSessionKeysFunc struct {
+	keys DerivedSessionKeys
+}
+
+// NewFixedSessionKeysFunc returns KeyDerivationFunc which returns given session keys instead of deriving
+// them, for use with ExternalKeyDerivation option. It allows to validate the Context against test vectors
+// which specify session keys, like ones from RFC 7714. The same keys are returned for all "index DIV kdr"
+// values, and derivation of missing keys fails.
+func NewFixedSessionKeysFunc(keys DerivedSessionKeys) KeyDerivationFunc {
+	return &fixedSessionKeysFunc{keys: keys}
+}
+
+func (f *fixedSessionKeysFunc) DeriveKey(label byte, _ uint64, _ int) ([]byte, error) {
+	var key []byte
+	switch label {
+	case labelSRTPEncryption:
+		key = f.keys.SRTPEncryptionKey
+	case labelSRTPAuthenticationTag:
+		key = f.keys.SRTPAuthenticationKey
+	case labelSRTPSalt:
+		key = f.keys.SRTPSalt
+	case labelSRTCPEncryption:
+		key = f.keys.SRTCPEncryptionKey
+	case labelSRTCPAuthenticationTag:
+		key = f.keys.SRTCPAuthenticationKey
+	case labelSRTCPSalt:
+		key = f.keys.SRTCPSalt
+	}
+
+	// Length of the key is checked by the caller.
+	return append([]byte{}, key...), nil
+}
+
+// conformanceCipher is implemented by ciphers which expose intermediate values for conformance testing.
+type conformanceCipher interface {
+	derivedSessionKeys() DerivedSessionKeys
+	// debugRTPIV and debugRTCPIV return IV for AEAD and f8 profiles, and initial counter block for AES-CM ones.
+	debugRTPIV(header *rtp.Header, roc uint32) []byte
+	debugRTCPIV(srtcpIndex, ssrc uint32) ([]byte, error)
+}
+
+// DebugSessionKeys returns session keys derived for the current master key. It is intended for
+// validation against test vectors only, and it requires UnsafeConformanceTesting option.
+// DO NOT use it in production.
+//
+// Keys derived by KeyDerivationRate option for non-zero "index DIV kdr" values are not returned.
+func (c *Context) DebugSessionKeys() (DerivedSessionKeys, error) {
+	cipher, err := c.conformanceCipher()
+	if err != nil {
+		return DerivedSessionKeys{}, err
+	}
+
+	return cipher.derivedSessionKeys(), nil
+}
+
+// DebugRTPIV returns IV used for SRTP packet with given header and ROC, or initial counter block
+// for AES-CM profiles. For f8 profile it returns IV before its encryption to IV'. It is intended for
+// validation against test vectors only, and it requires UnsafeConformanceTesting option.
+// DO NOT use it in production.
+//
+// Inputs of the IV may be fixed with SetROC, and with SSRC and sequence number of the packet header.
+func (c *Context) DebugRTPIV(header *rtp.Header, roc uint32) ([]byte, error) {
+	cipher, err := c.conformanceCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.debugRTPIV(header, roc), nil
+}
+
+// DebugRTCPIV returns IV used for SRTCP packet with given SSRC and SRTCP index, or initial counter
+// block for AES-CM profiles. It is intended for validation against test vectors only, and it requires
+// UnsafeConformanceTesting option. DO NOT use it in production. It is not supported for f8 profile,
+// which uses the whole RTCP header in IV.
+//
+// SRTCP index of the next sent packet may be fixed with SetIndex.
+func (c *Context) DebugRTCPIV(ssrc, srtcpIndex uint32) ([]byte, error) {
+	cipher, err := c.conformanceCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.debugRTCPIV(srtcpIndex, ssrc)
+}
+
+func (c *Context) conformanceCipher() (conformanceCipher, error) {
+	if !c.conformanceTesting {
+		return nil, errConformanceTestingDisabled
+	}
+
+	cipher := c.cipher
+	if k, ok := cipher.(*kdrCipher); ok {
+		cipher = k.srtpCipher
+	}
+	conformance, ok := cipher.(conformanceCipher)
+	if !ok {
+		return nil, errConformanceNotSupported
+	}
+
+	return conformance, nil
+}
+
+func (s *srtpCipherAesCmHmacSha1) derivedSessionKeys() DerivedSessionKeys {
+	return DerivedSessionKeys{
+		SRTPEncryptionKey:      append([]byte{}, s.srtpSessionKey...),
+		SRTPAuthenticationKey:  append([]byte{}, s.srtpSessionAuthKey...),
+		SRTPSalt:               append([]byte{}, s.srtpSessionSalt...),
+		SRTCPEncryptionKey:     append([]byte{}, s.srtcpSessionKey...),
+		SRTCPAuthenticationKey: append([]byte{}, s.srtcpSessionAuthKey...),
+		SRTCPSalt:              append([]byte{}, s.srtcpSessionSalt...),
+	}
+}
+
+func (s *srtpCipherAesCmHmacSha1) debugRTPIV(header *rtp.Header, roc uint32) []byte {
+	if s.srtpF8Block != nil {
+		iv := f8RTPIV(header, roc)
+
+		return iv[:]
+	}
+	counter := generateCounter(header.SequenceNumber, roc, header.SSRC, s.srtpSessionSalt)
+
+	return counter[:]
+}
+
+func (s *srtpCipherAesCmHmacSha1) debugRTCPIV(srtcpIndex, ssrc uint32) ([]byte, error) {
+	if s.srtcpF8Block != nil {
+		return nil, errConformanceNotSupported
+	}
+	counter := generateCounter(uint16(srtcpIndex&0xffff), srtcpIndex>>16, ssrc, s.srtcpSessionSalt) //nolint:gosec // G115
+
+	return counter[:], nil
+}
+
+func (s *srtpCipherAeadAesGcm) derivedSessionKeys() DerivedSessionKeys {
+	return DerivedSessionKeys{
+		SRTPEncryptionKey:  append([]byte{}, s.srtpSessionKey...),
+		SRTPSalt:           append([]byte{}, s.srtpSessionSalt...),
+		SRTCPEncryptionKey: append([]byte{}, s.srtcpSessionKey...),
+		SRTCPSalt:          append([]byte{}, s.srtcpSessionSalt...),
+	}
+}
+
+func (s *srtpCipherAeadAesGcm) debugRTPIV(header *rtp.Header, roc uint32) []byte {
+	s.rtpInitializationVector(header, roc)
+
+	return append([]byte{}, s.rtpIV[:]...)
+}
+
+func (s *srtpCipherAeadAesGcm) debugRTCPIV(srtcpIndex, ssrc uint32) ([]byte, error) {
+	s.rtcpInitializationVector(srtcpIndex, ssrc)
+
+	return append([]byte{}, s.rtcpIV[:]...), nil
+}
+
+// DefaultGCMNonce computes IV for AEAD profiles as defined by RFC 7714 sections 8.1 and 9.1. It may be
+// used by GCMNonceFunc to compute IV with some of the inputs fixed.
+func DefaultGCMNonce(sessionSalt [12]byte, ssrc uint32, index uint64, isRTCP bool) (iv [12]byte) {
+	buildGCMNonce(&iv, &sessionSalt, ssrc, index, isRTCP)
+
+	return iv
+}
+
+// buildGCMNonce writes IV computed by DefaultGCMNonce to iv. It is used by GCM cipher to build IVs
+// in place, using two XORs of machine words instead of per-byte XOR of slices.
+func buildGCMNonce(iv *[12]byte, sessionSalt *[gcmSessionSaltLen]byte, ssrc uint32, index uint64, isRTCP bool) {
+	// 2 zero octets and SSRC, followed by 2 zero octets for SRTCP or 2 high octets of ROC for SRTP.
+	high := uint64(ssrc) << 16
+	if !isRTCP {
+		high |= (index >> 32) & 0xffff
+	}
+	binary.BigEndian.PutUint64(iv[:8], high^binary.BigEndian.Uint64(sessionSalt[:8]))
+	binary.BigEndian.PutUint32(iv[8:], uint32(index)^binary.BigEndian.Uint32(sessionSalt[8:])) //nolint:gosec // G115
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugSessionKeys(t *testing.T) {
+	// RFC 3711 appendix B.3.
+	masterKey := fromHex(t, `E1F97A0D3E018BE0D64FA32C06DE4139`)
+	masterSalt := fromHex(t, `0EC675AD498AFEEBB6960B3AABE6`)
+
+	ctx, err := CreateContext(masterKey, masterSalt, ProtectionProfileAes128CmHmacSha1_80, UnsafeConformanceTesting())
+	assert.NoError(t, err)
+
+	keys, err := ctx.DebugSessionKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, fromHex(t, `C61E7A93744F39EE10734AFE3FF7A087`), keys.SRTPEncryptionKey)
+	assert.Equal(t, fromHex(t, `30CBBC08863D8C85D49DB34A9AE1`), keys.SRTPSalt)
+	assert.Equal(t, fromHex(t, `CEBE321F6FF7716B6FD4AB49AF256A156D38BAA4`), keys.SRTPAuthenticationKey)
+	assert.Len(t, keys.SRTCPEncryptionKey, 16)
+	assert.Len(t, keys.SRTCPSalt, 14)
+	assert.Len(t, keys.SRTCPAuthenticationKey, 20)
+
+	// Returned keys are copies.
+	keys.SRTPEncryptionKey[0] ^= 0xff
+	keys, err = ctx.DebugSessionKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0xC6), keys.SRTPEncryptionKey[0])
+
+	// AES-CM IV is the initial counter block, see RFC 3711 section 4.1.1.
+	iv, err := ctx.DebugRTPIV(&rtp.Header{SSRC: 0x01020304, SequenceNumber: 0x0506}, 0x0708090a)
+	assert.NoError(t, err)
+	expected := make([]byte, 16)
+	copy(expected, keys.SRTPSalt)
+	for i, b := range []byte{0x01, 0x02, 0x03, 0x04, 0x07, 0x08, 0x09, 0x0a, 0x05, 0x06} {
+		expected[4+i] ^= b
+	}
+	assert.Equal(t, expected, iv)
+}
+
+func TestConformanceRFC7714(t *testing.T) {
+	// RFC 7714 sections 16 and 17.
+	keys := DerivedSessionKeys{
+		SRTPEncryptionKey:  fromHex(t, `000102030405060708090a0b0c0d0e0f`),
+		SRTPSalt:           fromHex(t, `517569642070726f2071756f`),
+		SRTCPEncryptionKey: fromHex(t, `000102030405060708090a0b0c0d0e0f`),
+		SRTCPSalt:          fromHex(t, `517569642070726f2071756f`),
+	}
+	decryptedRTP := fromHex(t, `8040f17b 8041f8d3 5501a0b2 47616c6c 69612065 7374206f 6d6e6973 20646976
+		69736120 696e2070 61727465 73207472 6573`)
+	encryptedRTP := fromHex(t, `8040f17b 8041f8d3 5501a0b2 f24de3a3 fb34de6c acba861c 9d7e4bca be633bd5
+		0d294e6f 42a5f47a 51c7d19b 36de3adf 8833899d 7f27beb1 6a9152cf 765ee439 0cce`)
+	decryptedRTCP := fromHex(t, `81c8000d 4d617273 4e545031 4e545032 52545020 0000042a 0000e930 4c756e61
+		deadbeef deadbeef deadbeef deadbeef deadbeef`)
+	encryptedRTCP := fromHex(t, `81c8000d 4d617273 63e94885 dcdab67c a727d766 2f6b7e99 7ff5c0f7 6c06f32d
+		c676a5f1 730d6fda 4ce09b46 86303ded 0bb9275b c84aa458 96cf4d2f c5abf872 45d9eade 800005d4`)
+
+	ctx, err := CreateContext(nil, nil, ProtectionProfileAeadAes128Gcm,
+		ExternalKeyDerivation(NewFixedSessionKeysFunc(keys)), UnsafeConformanceTesting())
+	assert.NoError(t, err)
+
+	debugKeys, err := ctx.DebugSessionKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, keys, debugKeys)
+
+	iv, err := ctx.DebugRTPIV(&rtp.Header{SSRC: 0x5501a0b2, SequenceNumber: 0xf17b}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, fromHex(t, `51753c6580c2726f20718414`), iv)
+
+	iv, err = ctx.DebugRTCPIV(0x4d617273, 0x5d4)
+	assert.NoError(t, err)
+	assert.Equal(t, fromHex(t, `517524055203726f207170bb`), iv)
+
+	actual, err := ctx.EncryptRTP(nil, decryptedRTP, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, encryptedRTP, actual)
+
+	ctx.SetIndex(0x4d617273, 0x5d3)
+	actual, err = ctx.EncryptRTCP(nil, decryptedRTCP, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, encryptedRTCP, actual)
+
+	// Missing keys cannot be derived.
+	_, err = CreateContext(nil, nil, ProtectionProfileAes128CmHmacSha1_80,
+		ExternalKeyDerivation(NewFixedSessionKeysFunc(keys)))
+	assert.ErrorIs(t, err, errInvalidDerivedKeyLength)
+}
+
+func TestGCMNonceSource(t *testing.T) {
+	type call struct {
+		ssrc   uint32
+		index  uint64
+		isRTCP bool
+	}
+	var calls []call
+	fixedIndex := func(sessionSalt [12]byte, ssrc uint32, index uint64, isRTCP bool) [12]byte {
+		calls = append(calls, call{ssrc, index, isRTCP})
+
+		return DefaultGCMNonce(sessionSalt, ssrc, 0, isRTCP)
+	}
+
+	encryptCtx, err := buildTestContext(profileGCM, UnsafeGCMNonceSource(fixedIndex))
+	assert.NoError(t, err)
+	encryptCtx.SetROC(1, 2)
+
+	pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: 3}, Payload: []byte{1, 2, 3, 4}}
+	raw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, raw, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []call{{1, 2<<16 | 3, false}}, calls)
+
+	// Packet encrypted with IV for index 0 is decrypted as such.
+	decryptCtx, err := buildTestContext(profileGCM)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+	calls = nil
+	decryptCtx, err = buildTestContext(profileGCM, UnsafeGCMNonceSource(fixedIndex))
+	assert.NoError(t, err)
+	decryptCtx.SetROC(1, 2)
+	decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, raw, decrypted)
+	assert.Equal(t, []call{{1, 2<<16 | 3, false}}, calls)
+
+	calls = nil
+	rtcpPacket := []byte{0x80, 0xc8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05}
+	_, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []call{{5, 1, true}}, calls)
+}
+
+func TestDefaultGCMNonce(t *testing.T) {
+	salt := [12]byte{0x51, 0x75, 0x69, 0x64, 0x20, 0x70, 0x72, 0x6f, 0x20, 0x71, 0x75, 0x6f}
+	xorSalt := func(iv [12]byte) [12]byte {
+		for i := range iv {
+			iv[i] ^= salt[i]
+		}
+
+		return iv
+	}
+
+	// 2 zero octets, SSRC, ROC and SEQ for SRTP.
+	assert.Equal(t,
+		xorSalt([12]byte{0, 0, 0xca, 0xfe, 0xba, 0xbe, 0xff, 0xee, 0xdd, 0xcc, 0x12, 0x34}),
+		DefaultGCMNonce(salt, 0xcafebabe, 0xffeeddcc1234, false),
+	)
+	// 2 zero octets, SSRC, 2 zero octets and SRTCP index for SRTCP.
+	assert.Equal(t,
+		xorSalt([12]byte{0, 0, 0xca, 0xfe, 0xba, 0xbe, 0, 0, 0x7f, 0xee, 0xdd, 0xcc}),
+		DefaultGCMNonce(salt, 0xcafebabe, 0x7feeddcc, true),
+	)
+}
+
+func TestConformanceTestingErrors(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	_, err = ctx.DebugSessionKeys()
+	assert.ErrorIs(t, err, errConformanceTestingDisabled)
+	_, err = ctx.DebugRTPIV(&rtp.Header{}, 0)
+	assert.ErrorIs(t, err, errConformanceTestingDisabled)
+	_, err = ctx.DebugRTCPIV(0, 0)
+	assert.ErrorIs(t, err, errConformanceTestingDisabled)
+
+	ctx, err = CreateContext(make([]byte, 32), make([]byte, 24), ProtectionProfileDoubleAeadAes128Gcm,
+		UnsafeConformanceTesting())
+	assert.NoError(t, err)
+	_, err = ctx.DebugSessionKeys()
+	assert.ErrorIs(t, err, errConformanceNotSupported)
+
+	// UnsafeConformanceTesting enables keystream export too.
+	ctx, err = buildTestContext(profileCTR, UnsafeConformanceTesting())
+	assert.NoError(t, err)
+	_, err = ctx.DebugKeystreamRTP(&rtp.Header{}, 10, 0)
+	assert.NoError(t, err)
+}
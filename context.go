@@ -5,8 +5,12 @@ package srtp
 
 import (
 	"bytes"
+	"container/list"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/pion/rtp"
 	"github.com/pion/transport/v4/replaydetector"
 )
 
@@ -19,11 +23,24 @@ const (
 	labelSRTCPAuthenticationTag = 0x04
 	labelSRTCPSalt              = 0x05
 
+	labelSRTPHeaderEncryption = 0x06
+	labelSRTPHeaderSalt       = 0x07
+
 	maxSequenceNumber = 65535
 	maxROC            = (1 << 32) - 1
 
+	// Length of default 80-bit SRTP auth tag of AES-CM and NULL profiles. Shorter tags are truncated.
+	fullAuthTagRTPLen = 10
+
 	seqNumMedian = 1 << 15
 	seqNumMax    = 1 << 16
+
+	rtpVersion = 2
+
+	defaultFailureSampleSize = 64
+
+	// Payload length of packets used by Context.Warmup.
+	warmupPayloadLen = 64
 )
 
 // Encrypt/Decrypt state for a single SRTP SSRC.
@@ -31,14 +48,41 @@ type srtpSSRCState struct {
 	ssrc                 uint32
 	rolloverHasProcessed bool
 	index                uint64
-	replayDetector       replaydetector.ReplayDetector
+	replayGuard          *replayGuard
+
+	// Timestamp regression guard, configured by SetTimestampGuard.
+	timestampGuard         bool
+	maxTimestampRegression uint32
+	lastTimestamp          uint32
+	hasLastTimestamp       bool
+
+	// Auth failure tracking, configured by SRTPAuthFailureLimit option.
+	authFailures           uint
+	authFailureWindowStart time.Time
+	blacklistedUntil       time.Time
+
+	// Number of sent packets with EKT Field, used to schedule Full EKT Fields.
+	ektPacketCount uint64
+
+	// Time of the last use, and element of the LRU list, tracked when SSRCStateLimit option is set.
+	lastUsed time.Time
+	lruElem  *list.Element
 }
 
 // Encrypt/Decrypt state for a single SRTCP SSRC.
 type srtcpSSRCState struct {
-	srtcpIndex     uint32
-	ssrc           uint32
-	replayDetector replaydetector.ReplayDetector
+	srtcpIndex  uint32
+	ssrc        uint32
+	replayGuard *replayGuard
+
+	// Value of Context.keyGeneration when the current sequence of SRTCP indexes was started.
+	keyGeneration uint64
+	// indexWarned is set after OnSRTCPIndexWarning callback was called, until the index is set again.
+	indexWarned bool
+
+	// Time of the last use, and element of the LRU list, tracked when SSRCStateLimit option is set.
+	lastUsed time.Time
+	lruElem  *list.Element
 }
 
 // RCCMode is the mode of Roll-over Counter Carrying Transform from RFC 4771.
@@ -58,6 +102,18 @@ const (
 	RCCMode3
 )
 
+// TruncatedAuthTagPolicy controls use of SRTP authentication tags of AES-CM and NULL profiles which are
+// shorter than the default 80 bits, e.g. tags of ProtectionProfile*HmacSha1_32 profiles or ones set by
+// SRTPAuthenticationTagLength option. See SRTPTruncatedAuthTagPolicy option.
+type TruncatedAuthTagPolicy int
+
+const (
+	// TruncatedAuthTagAllowed (default) allows truncated SRTP authentication tags.
+	TruncatedAuthTagAllowed TruncatedAuthTagPolicy = iota
+	// TruncatedAuthTagRejected rejects SRTP packets with truncated authentication tags.
+	TruncatedAuthTagRejected
+)
+
 // CryptexMode is the mode of Cryptex support for SRTP packets from RFC 9335.
 type CryptexMode int
 
@@ -78,22 +134,37 @@ const (
 // it must either used ONLY for encryption or ONLY for decryption.
 // Note that Context does not provide any concurrency protection:
 // access to a Context from multiple goroutines requires external
-// synchronization.
+// synchronization. Use ConcurrentContext to process packets of different
+// SSRCs in parallel.
 type Context struct {
 	cipher srtpCipher
 
 	srtpSSRCStates  map[uint32]*srtpSSRCState
 	srtcpSSRCStates map[uint32]*srtcpSSRCState
 
-	newSRTCPReplayDetector func() replaydetector.ReplayDetector
-	newSRTPReplayDetector  func() replaydetector.ReplayDetector
+	newSRTCPReplayDetector func(ssrc uint32) replaydetector.ReplayDetector
+	newSRTPReplayDetector  func(ssrc uint32) replaydetector.ReplayDetector
+
+	// srtcpIndexSource is nil for the default explicitSRTCPIndex.
+	srtcpIndexSource srtcpIndexSource
+
+	srtpReplayWindowSize  uint
+	srtcpReplayWindowSize uint
 
 	profile ProtectionProfile
 
 	// Master Key Identifier used for encrypting RTP/RTCP packets. Set to nil if MKI is not enabled.
 	sendMKI []byte
 	// Master Key Identifier to cipher mapping. Used for decrypting packets. Empty if MKI is not enabled.
-	mkis map[string]srtpCipher
+	// It must be changed by setMKICipher and deleteMKICipher, which also drop mkiLookup.
+	mkis      map[string]srtpCipher
+	mkiLookup *mkiLookup
+	// Key lifetimes of receive keys added by AddReceiveKey, indexed by MKI.
+	mkiLifetimes map[string]mkiLifetime
+	// SRTP state with index not above keyExpiryBlockedTo, the earliest end of lifetime of receive keys.
+	// Receive keys are not checked for expiration until index of the state passes it.
+	keyExpiryBlocker   *srtpSSRCState
+	keyExpiryBlockedTo uint64
 
 	encryptSRTP  bool
 	encryptSRTCP bool
@@ -103,7 +174,135 @@ type Context struct {
 
 	authTagRTPLen *int
 
+	// Key derivation rate set by KeyDerivationRate option. Zero means that session keys are derived once.
+	keyDerivationRate uint64
+	// keyDerivationFunc is set by ExternalKeyDerivation option.
+	keyDerivationFunc KeyDerivationFunc
+
+	encryptTruncatedAuthTagPolicy TruncatedAuthTagPolicy
+	decryptTruncatedAuthTagPolicy TruncatedAuthTagPolicy
+
 	cryptexMode CryptexMode
+
+	now func() time.Time
+
+	keySelector     func(header *rtp.Header) (SessionKeys, ProtectionProfile, error)
+	selectedCiphers map[selectedCipherKey]srtpCipher
+
+	authFailureLimit    uint
+	authFailureWindow   time.Duration
+	authFailureCooldown time.Duration
+
+	debugKeystream bool
+	// conformanceTesting is set by UnsafeConformanceTesting option.
+	conformanceTesting bool
+	gcmNonceFunc       GCMNonceFunc
+	gcmAADFunc         GCMAdditionalDataFunc
+
+	onNewSSRC func(ssrc uint32, isRTCP bool)
+
+	// Number of packets encrypted with the current send key, and soft limit set by OnKeyExpired option.
+	keyUsage          uint64
+	keyUsageThreshold uint64
+	onKeyExpired      func(mki []byte)
+	traceHook         func(event TraceEvent)
+
+	// keyManager and rekeyThreshold are set by KeyEvents option.
+	keyManager     KeyManager
+	rekeyThreshold uint64
+	// sendKeyOverridden is set while a packet is encrypted with other key than the send key.
+	sendKeyOverridden bool
+	// keyGeneration is incremented when the send key is changed by UpdateMasterKey or SetSendMKI.
+	keyGeneration uint64
+
+	// Set by OnSRTCPIndexWarning and SRTCPIndexRolloverOnRekey options.
+	srtcpIndexWarning   uint32
+	onSRTCPIndexWarning func(ssrc, index uint32)
+	srtcpIndexRollover  bool
+
+	latencyRecorder LatencyRecorder
+
+	gcmMasterSaltLen int
+
+	headerExtensionID    uint8
+	headerExtensionValue func(header *rtp.Header) []byte
+
+	failureSampler    func(reason error, sample []byte)
+	failureSampleSize int
+	failureSample     []byte
+
+	singleSSRC  bool
+	hasSendSSRC bool
+	sendSSRC    uint32
+
+	// cipherTimeout limits duration of operations of custom ciphers. Zero means no limit.
+	cipherTimeout time.Duration
+
+	plaintextPassthrough bool
+	// requireFIPS is set by RequireFIPS option.
+	requireFIPS bool
+
+	hasMaxRollovers bool
+	maxRollovers    uint32
+
+	// rocRecovery is set by SRTPROCRecovery option. rocRecoveryBuf keeps copy of packet decrypted
+	// in place, so its decryption can be retried.
+	rocRecovery    bool
+	rocRecoveryBuf []byte
+	// Set by UniformDecryptFailures option. failureTimingBuf receives auth tags computed to equalize
+	// time of decryption failures.
+	uniformDecryptFailures bool
+	onDecryptFailure       func(err error, isRTCP bool)
+	failureTimingBuf       []byte
+
+	// authScratchBuf receives packets decrypted by VerifyRTP, when cipher cannot only authenticate them.
+	authScratchBuf []byte
+
+	// Per-SSRC state limits set by SSRCStateLimit option, indexes of evicted SSRCs, and states ordered
+	// from the most to the least recently used one.
+	maxSSRCStates   int
+	ssrcIdleTimeout time.Duration
+	evictedSSRCs    map[uint32]*evictedSSRC
+	srtpLRU         *list.List
+	srtcpLRU        *list.List
+
+	strictSRTCPEncryptionFlag bool
+	rejectUnencryptedSRTCP    bool
+	validateRTCPCompound      bool
+
+	// Limits of received packets, set by StrictParsing option.
+	strictParsing bool
+	parsingLimits ParsingLimits
+
+	// EKT state, configured by EncryptedKeyTransport option. ektKeys is nil when EKT is disabled.
+	ektSendKey           EKTKey
+	ektKeys              map[uint16]EKTKey
+	ektFullFieldInterval uint
+	ektMasterKey         []byte
+	ektReceiveStates     map[uint32]*ektReceiveState
+
+	// Master keys by MKI and their sealer, kept for MarshalBinary when KeyPersistence option is set.
+	persistedKeys map[string]persistedKey
+	keySealer     KeySealer
+	// Set by SRTCPIndexAdvanceOnRestore option.
+	srtcpIndexRestoreAdvance uint32
+
+	// Ciphers and their profiles for keys set by SetSSRCKeys.
+	ssrcCiphers  map[uint32]srtpCipher
+	ssrcProfiles map[uint32]ProtectionProfile
+
+	// IDs of header extensions encrypted as defined in RFC 6904.
+	encryptedHeaderExtensionIDs []uint8
+
+	stats         contextStats
+	statsRecorder StatsRecorder
+}
+
+// LatencyRecorder receives durations of SRTP packet decryption, e.g. to build per-SSRC histograms.
+type LatencyRecorder interface {
+	// Observe is called after decryption and authentication of each SRTP packet, including ones
+	// which failed authentication.
+	Observe(ssrc uint32, d time.Duration)
 }
 
 // CreateContext creates a new SRTP Context.
@@ -118,6 +317,10 @@ func CreateContext(
 	profile ProtectionProfile,
 	opts ...ContextOption,
 ) (c *Context, err error) {
+	if !profile.isSupported() {
+		return nil, fmt.Errorf("%w: %#v", ErrUnsupportedProfile, profile)
+	}
+
 	c = &Context{
 		srtpSSRCStates:  map[uint32]*srtpSSRCState{},
 		srtcpSSRCStates: map[uint32]*srtcpSSRCState{},
@@ -142,6 +345,9 @@ func CreateContext(
 	if err = c.checkRCCMode(); err != nil {
 		return nil, err
 	}
+	if err = c.checkFIPS(); err != nil {
+		return nil, err
+	}
 
 	if c.authTagRTPLen != nil {
 		var authKeyLen int
@@ -154,22 +360,85 @@ func CreateContext(
 		}
 	}
 
-	c.cipher, err = c.createCipher(c.sendMKI, masterKey, masterSalt, c.encryptSRTP, c.encryptSRTCP)
+	if c.ektKeys != nil && len(c.sendMKI) != 0 {
+		return nil, errEKTWithMKI
+	}
+	if c.ektKeys != nil && len(masterKey) == 0 && c.keyDerivationFunc != nil {
+		// EKT sends the master key, so it must be known.
+		return nil, errExternalKDFNotSupported
+	}
+
+	c.cipher, err = c.createCipher(c.profile, c.sendMKI, masterKey, masterSalt, c.encryptSRTP, c.encryptSRTCP)
 	if err != nil {
 		return nil, err
 	}
 	if len(c.sendMKI) != 0 {
-		c.mkis[string(c.sendMKI)] = c.cipher
+		c.setMKICipher(string(c.sendMKI), c.cipher)
+	}
+	c.recordMasterKey(c.profile, c.sendMKI, masterKey, masterSalt)
+	if c.ektKeys != nil {
+		c.ektMasterKey = append([]byte{}, masterKey...)
 	}
 
 	return c, nil
 }
 
+// UpdateMasterKey replaces master key and salt used by the Context, and derives new session keys from
+// them. ROC, SRTCP index, replay protection and other per-SSRC state is kept intact, so long-lived
+// streams can be re-keyed before reaching the packet limit from RFC 3711 section 9.2, without
+// losing sequence state. Packets are encrypted and decrypted with the new keys immediately after
+// the call. When MKI is enabled, keys of the current send MKI are replaced; to keep accepting
+// packets protected with old keys, use AddCipherForMKI and SetSendMKI instead.
+// Operation is not thread-safe, you need to provide synchronization with encrypting and decrypting packets.
+func (c *Context) UpdateMasterKey(masterKey, masterSalt []byte) error {
+	cipher, err := c.newMasterKeyCipher(masterKey, masterSalt)
+	if err != nil {
+		return err
+	}
+	c.setMasterKeyCipher(cipher)
+	c.recordMasterKey(c.profile, c.sendMKI, masterKey, masterSalt)
+	if c.ektKeys != nil {
+		c.ektMasterKey = append([]byte{}, masterKey...)
+	}
+
+	return nil
+}
+
+// newMasterKeyCipher creates cipher for new master key and salt of the Context, without using it.
+func (c *Context) newMasterKeyCipher(masterKey, masterSalt []byte) (srtpCipher, error) {
+	return c.createCipher(c.profile, c.sendMKI, masterKey, masterSalt, c.encryptSRTP, c.encryptSRTCP)
+}
+
+// setMasterKeyCipher replaces cipher created by newMasterKeyCipher.
+func (c *Context) setMasterKeyCipher(cipher srtpCipher) {
+	c.cipher = cipher
+	c.keyUsage = 0
+	c.keyGeneration++
+	if len(c.sendMKI) != 0 {
+		c.setMKICipher(string(c.sendMKI), cipher)
+	}
+}
+
 // AddCipherForMKI adds new MKI with associated masker key and salt.
 // Context must be created with MasterKeyIndicator option
 // to enable MKI support. MKI must be unique and have the same length as the one used for creating Context.
 // Operation is not thread-safe, you need to provide synchronization with decrypting packets.
 func (c *Context) AddCipherForMKI(mki, masterKey, masterSalt []byte) error {
+	return c.AddCipherForMKIWithProfile(mki, masterKey, masterSalt, c.profile)
+}
+
+// AddCipherForMKIWithProfile adds new MKI with associated masker key, salt and protection profile.
+// It works like AddCipherForMKI, but allows to use protection profile different than the one used
+// for creating Context, e.g. during migration from AES-CM to AES-GCM. Different profiles cannot be
+// mixed when Rollover Counter Carrying Transform is enabled.
+// Operation is not thread-safe, you need to provide synchronization with decrypting packets.
+func (c *Context) AddCipherForMKIWithProfile(mki, masterKey, masterSalt []byte, profile ProtectionProfile) error {
+	if !profile.isSupported() {
+		return fmt.Errorf("%w: %#v", ErrUnsupportedProfile, profile)
+	}
+	if profile != c.profile && c.rccMode != RCCModeNone {
+		return errUnsupportedRccMode
+	}
 	if len(c.mkis) == 0 {
 		return errMKIIsNotEnabled
 	}
@@ -180,25 +449,178 @@ func (c *Context) AddCipherForMKI(mki, masterKey, masterSalt []byte) error {
 		return errMKIAlreadyInUse
 	}
 
-	cipher, err := c.createCipher(mki, masterKey, masterSalt, c.encryptSRTP, c.encryptSRTCP)
+	cipher, err := c.createCipher(profile, mki, masterKey, masterSalt, c.encryptSRTP, c.encryptSRTCP)
 	if err != nil {
 		return err
 	}
-	c.mkis[string(mki)] = cipher
+	c.setMKICipher(string(mki), cipher)
+	c.recordMasterKey(profile, mki, masterKey, masterSalt)
 
 	return nil
 }
 
-func (c *Context) createCipher(mki, masterKey, masterSalt []byte, encryptSRTP, encryptSRTCP bool) (srtpCipher, error) {
-	keyLen, err := c.profile.KeyLen()
+// mkiLengths are auth tag and MKI lengths used by one or more ciphers for MKIs.
+type mkiLengths struct {
+	tagLen int
+	mkiLen int
+}
+
+// mkiLookup keeps distinct auth tag and MKI lengths of ciphers for MKIs, for SRTP and SRTCP, sorted
+// by auth tag length and then by MKI length. It is built when needed by findCipherForMKI, and dropped
+// when ciphers for MKIs are changed.
+type mkiLookup struct {
+	rtp  []mkiLengths
+	rtcp []mkiLengths
+}
+
+// setMKICipher sets cipher for the MKI.
+func (c *Context) setMKICipher(mki string, cipher srtpCipher) {
+	c.mkis[mki] = cipher
+	c.mkiLookup = nil
+}
+
+// deleteMKICipher removes cipher for the MKI.
+func (c *Context) deleteMKICipher(mki string) {
+	delete(c.mkis, mki)
+	c.mkiLookup = nil
+}
+
+// getMKILookup returns mkiLookup for the current ciphers for MKIs.
+func (c *Context) getMKILookup() *mkiLookup {
+	if c.mkiLookup != nil {
+		return c.mkiLookup
+	}
+
+	lookup := &mkiLookup{}
+	for mki, cipher := range c.mkis {
+		if tagLen, err := cipher.AuthTagRTPLen(); err == nil {
+			lookup.rtp = append(lookup.rtp, mkiLengths{tagLen: tagLen, mkiLen: len(mki)})
+		}
+		if tagLen, err := cipher.AuthTagRTCPLen(); err == nil {
+			lookup.rtcp = append(lookup.rtcp, mkiLengths{tagLen: tagLen, mkiLen: len(mki)})
+		}
+	}
+	for _, lengths := range []*[]mkiLengths{&lookup.rtp, &lookup.rtcp} {
+		slices.SortFunc(*lengths, func(a, b mkiLengths) int {
+			if a.tagLen != b.tagLen {
+				return a.tagLen - b.tagLen
+			}
+
+			return a.mkiLen - b.mkiLen
+		})
+		*lengths = slices.Compact(*lengths)
+	}
+	c.mkiLookup = lookup
+
+	return lookup
+}
+
+// findCipherForMKI finds cipher for MKI of SRTP or SRTCP packet, and returns it with the MKI.
+// MKI is placed before the auth tag, so its position depends on auth tag length of the cipher.
+// For SRTP, adjustTagLen returns length of the auth tag in the packet for auth tag length of the cipher.
+// Only MKIs found at position matching their cipher are accepted. Ciphers may use different auth tag
+// lengths, and receive keys added by AddReceiveKey may use MKIs of different lengths. Lengths used by
+// the current cipher are tried first, and then all other ones in ascending order, so packets matching
+// more of them are always resolved to the same cipher.
+// Packet must have at least minLen bytes before MKI.
+func (c *Context) findCipherForMKI(
+	packet []byte, minLen int, isRTCP bool, adjustTagLen func(int) int,
+) (srtpCipher, []byte, error) {
+	cipherTagLen := srtpCipher.AuthTagRTPLen
+	candidates := c.getMKILookup().rtp
+	if isRTCP {
+		cipherTagLen = srtpCipher.AuthTagRTCPLen
+		candidates = c.getMKILookup().rtcp
+	}
+	packetTagLen := func(tagLen int) int {
+		if adjustTagLen == nil {
+			return tagLen
+		}
+
+		return adjustTagLen(tagLen)
+	}
+	lookup := func(lengths mkiLengths) (srtpCipher, []byte, bool) {
+		end := len(packet) - packetTagLen(lengths.tagLen)
+		if end-lengths.mkiLen < minLen {
+			return nil, nil, false
+		}
+		mki := packet[end-lengths.mkiLen : end]
+		cipher, ok := c.mkis[string(mki)]
+		if !ok {
+			return nil, nil, false
+		}
+		tagLen, err := cipherTagLen(cipher)
+
+		return cipher, mki, err == nil && tagLen == lengths.tagLen
+	}
+
+	// Usually all ciphers use the same profile and MKI length, so try ones of the current cipher first.
+	defaultTagLen, err := cipherTagLen(c.cipher)
+	if err != nil {
+		return nil, nil, err
+	}
+	defaultLengths := mkiLengths{tagLen: defaultTagLen, mkiLen: len(c.sendMKI)}
+	if cipher, mki, ok := lookup(defaultLengths); ok {
+		return cipher, mki, nil
+	}
+	for _, lengths := range candidates {
+		if lengths == defaultLengths {
+			continue
+		}
+		if cipher, mki, ok := lookup(lengths); ok {
+			return cipher, mki, nil
+		}
+	}
+
+	if len(packet)-packetTagLen(defaultTagLen)-len(c.sendMKI) < minLen {
+		// Let the caller report too short packet.
+		return c.cipher, c.sendMKI, nil
+	}
+
+	return nil, nil, ErrMKINotFound
+}
+
+func (c *Context) createCipher(
+	profile ProtectionProfile,
+	mki, masterKey, masterSalt []byte,
+	encryptSRTP, encryptSRTCP bool,
+) (srtpCipher, error) {
+	if c.requireFIPS && !profile.isFIPSApproved() {
+		return nil, fmt.Errorf("%w: profile %s", ErrNotFIPSApproved, profile)
+	}
+
+	keyLen, err := profile.KeyLen()
 	if err != nil {
 		return nil, err
 	}
 
-	saltLen, err := c.profile.SaltLen()
+	saltLen, err := profile.SaltLen()
 	if err != nil {
 		return nil, err
 	}
+	if c.gcmMasterSaltLen != 0 && profile.isAEAD() && !profile.isDoubleAEAD() {
+		saltLen = c.gcmMasterSaltLen
+	}
+
+	profileWithArgs := protectionProfileWithArgs{
+		ProtectionProfile: profile,
+		authTagRTPLen:     c.authTagRTPLen,
+		fips:              c.requireFIPS,
+		gcmNonceFunc:      c.gcmNonceFunc,
+		gcmAADFunc:        c.gcmAADFunc,
+	}
+	if c.keyDerivationFunc != nil {
+		if len(masterKey) != 0 || len(masterSalt) != 0 {
+			return nil, errExternalKDFMasterKey
+		}
+		if profile.isDoubleAEAD() {
+			return nil, errExternalKDFNotSupported
+		}
+		// Session keys are derived by the external function. Placeholders only pass lengths of the
+		// master key and salt to the ciphers.
+		profileWithArgs.kdf = c.keyDerivationFunc
+		masterKey, masterSalt = make([]byte, keyLen), make([]byte, saltLen)
+	}
 
 	if masterKeyLen := len(masterKey); masterKeyLen != keyLen {
 		return nil, fmt.Errorf("%w expected(%d) actual(%d)", errShortSrtpMasterKey, keyLen, masterKeyLen)
@@ -206,27 +628,118 @@ func (c *Context) createCipher(mki, masterKey, masterSalt []byte, encryptSRTP, e
 		return nil, fmt.Errorf("%w expected(%d) actual(%d)", errShortSrtpMasterSalt, saltLen, masterSaltLen)
 	}
 
-	profileWithArgs := protectionProfileWithArgs{
-		ProtectionProfile: c.profile,
-		authTagRTPLen:     c.authTagRTPLen,
+	cipher, err := c.createCipherForIndex(profileWithArgs, mki, masterKey, masterSalt, encryptSRTP, encryptSRTCP)
+	if err != nil || c.keyDerivationRate == 0 {
+		return cipher, err
 	}
 
+	return newKDRCipher(cipher, c.keyDerivationRate, func(indexOverKdr uint64) (srtpCipher, error) {
+		profileForIndex := profileWithArgs
+		profileForIndex.indexOverKdr = indexOverKdr
+
+		return c.createCipherForIndex(profileForIndex, mki, masterKey, masterSalt, encryptSRTP, encryptSRTCP)
+	}), nil
+}
+
+// createCipherForIndex creates cipher with session keys derived for "index DIV kdr" value of the profile.
+func (c *Context) createCipherForIndex(
+	profileWithArgs protectionProfileWithArgs,
+	mki, masterKey, masterSalt []byte,
+	encryptSRTP, encryptSRTCP bool,
+) (srtpCipher, error) {
+	profile := profileWithArgs.ProtectionProfile
 	useCryptex := c.cryptexMode != CryptexModeDisabled && encryptSRTP
-	switch c.profile {
-	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm:
+	if profile.isAEAD() && len(c.encryptedHeaderExtensionIDs) != 0 && encryptSRTP {
+		return nil, errHeaderExtensionEncryptionNotSupported
+	}
+
+	switch profile {
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes192Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm, ProtectionProfileSeed128Gcm96,
+		ProtectionProfileAeadSm4Gcm:
 		return newSrtpCipherAeadAesGcm(profileWithArgs, masterKey, masterSalt, mki, encryptSRTP, encryptSRTCP, useCryptex)
+	case ProtectionProfileDoubleAeadAes128Gcm, ProtectionProfileDoubleAeadAes256Gcm:
+		if useCryptex {
+			return nil, errDoubleAEADCryptex
+		}
+
+		return newSrtpCipherDoubleAeadAesGcm(profileWithArgs, masterKey, masterSalt, mki, encryptSRTP, encryptSRTCP)
 	case ProtectionProfileAes128CmHmacSha1_32,
 		ProtectionProfileAes128CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_32,
-		ProtectionProfileAes256CmHmacSha1_80:
-		return newSrtpCipherAesCmHmacSha1(profileWithArgs, masterKey, masterSalt, mki, encryptSRTP, encryptSRTCP, useCryptex)
+		ProtectionProfileAes256CmHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_32, ProtectionProfileAria128CtrHmacSha1_80,
+		ProtectionProfileAria192CtrHmacSha1_32, ProtectionProfileAria192CtrHmacSha1_80,
+		ProtectionProfileAria256CtrHmacSha1_32, ProtectionProfileAria256CtrHmacSha1_80,
+		ProtectionProfileSeedCtrHmacSha1_80, ProtectionProfileSm4CtrHmacSm3_80, ProtectionProfileAes128F8HmacSha1_80:
+		cipher, errCipher := newSrtpCipherAesCmHmacSha1(
+			profileWithArgs, masterKey, masterSalt, mki, encryptSRTP, encryptSRTCP, useCryptex,
+		)
+		if errCipher != nil || len(c.encryptedHeaderExtensionIDs) == 0 || !encryptSRTP {
+			return cipher, errCipher
+		}
+		if useCryptex || profile.isF8() {
+			return nil, errHeaderExtensionEncryptionNotSupported
+		}
+		cipher.headerExtensionEncryption, errCipher = newHeaderExtensionEncryption(
+			profileWithArgs, masterKey, masterSalt, c.encryptedHeaderExtensionIDs,
+		)
+
+		return cipher, errCipher
 	case ProtectionProfileNullHmacSha1_32, ProtectionProfileNullHmacSha1_80:
 		return newSrtpCipherAesCmHmacSha1(profileWithArgs, masterKey, masterSalt, mki, false, false, false)
 	default:
-		return nil, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, c.profile)
+		return nil, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, profile)
 	}
 }
 
+// selectedCipherCacheSize limits number of ciphers created for keys returned by SRTPKeySelector
+// and kept by the Context. The selector is called with unauthenticated headers, so a remote peer
+// could otherwise make the Context keep a cipher for every distinct key it can make the selector pick.
+const selectedCipherCacheSize = 16
+
+// selectedCipherKey identifies cipher created for keys returned by SRTPKeySelector.
+type selectedCipherKey struct {
+	profile    ProtectionProfile
+	masterKey  string
+	masterSalt string
+}
+
+// selectCipher returns cipher for keys chosen by SRTPKeySelector for the packet.
+func (c *Context) selectCipher(header *rtp.Header) (srtpCipher, error) {
+	keys, profile, err := c.keySelector(header)
+	if err != nil {
+		return nil, err
+	}
+
+	key := selectedCipherKey{
+		profile:    profile,
+		masterKey:  string(keys.RemoteMasterKey),
+		masterSalt: string(keys.RemoteMasterSalt),
+	}
+	if cipher, ok := c.selectedCiphers[key]; ok {
+		return cipher, nil
+	}
+
+	cipher, err := c.createCipher(profile, c.sendMKI, keys.RemoteMasterKey, keys.RemoteMasterSalt,
+		c.encryptSRTP, c.encryptSRTCP)
+	if err != nil {
+		return nil, err
+	}
+	if c.selectedCiphers == nil {
+		c.selectedCiphers = map[selectedCipherKey]srtpCipher{}
+	}
+	if len(c.selectedCiphers) >= selectedCipherCacheSize {
+		clear(c.selectedCiphers)
+	}
+	c.selectedCiphers[key] = cipher
+
+	return cipher, nil
+}
+
 // RemoveMKI removes one of MKIs. You cannot remove last MKI and one used for encrypting RTP/RTCP packets.
 // Operation is not thread-safe, you need to provide synchronization with decrypting packets.
 func (c *Context) RemoveMKI(mki []byte) error {
@@ -236,7 +749,9 @@ func (c *Context) RemoveMKI(mki []byte) error {
 	if bytes.Equal(mki, c.sendMKI) {
 		return errMKIAlreadyInUse
 	}
-	delete(c.mkis, string(mki))
+	c.deleteMKICipher(string(mki))
+	delete(c.mkiLifetimes, string(mki))
+	delete(c.persistedKeys, string(mki))
 
 	return nil
 }
@@ -248,12 +763,80 @@ func (c *Context) SetSendMKI(mki []byte) error {
 	if !ok {
 		return ErrMKINotFound
 	}
+	if cipher != c.cipher {
+		c.keyGeneration++
+	}
 	c.sendMKI = mki
 	c.cipher = cipher
+	c.keyUsage = 0
+	c.keyExpiryBlocker = nil
 
 	return nil
 }
 
+// countKeyUsage counts packet encrypted with the current send key, and notifies about its expiration.
+func (c *Context) countKeyUsage() {
+	if (c.onKeyExpired == nil && c.keyManager == nil) || c.sendKeyOverridden {
+		return
+	}
+	c.keyUsage++
+	if c.onKeyExpired != nil && c.keyUsage == c.keyUsageThreshold {
+		c.onKeyExpired(c.sendMKI)
+	}
+	if c.keyUsage == c.rekeyThreshold {
+		c.emitKeyEvent(KeyEvent{Type: RekeyRequired, MKI: c.sendMKI})
+	}
+}
+
+// takeFailureSample stores the beginning of the packet, to be reported if its decryption fails.
+func (c *Context) takeFailureSample(packet []byte) {
+	c.failureSample = append(c.failureSample[:0], packet[:min(len(packet), c.failureSampleSize)]...)
+}
+
+// reportFailureSample passes a copy of the sample stored by takeFailureSample to the failure sampler.
+func (c *Context) reportFailureSample(reason error) {
+	c.failureSampler(reason, append([]byte(nil), c.failureSample...))
+}
+
+func (c *Context) currentTime() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+
+	return c.now()
+}
+
+// isBlacklisted checks if SSRC is blacklisted due to too many auth failures.
+func (c *Context) isBlacklisted(state *srtpSSRCState) bool {
+	if c.authFailureWindow == 0 || state.blacklistedUntil.IsZero() {
+		return false
+	}
+	if c.currentTime().Before(state.blacklistedUntil) {
+		return true
+	}
+	state.blacklistedUntil = time.Time{}
+
+	return false
+}
+
+// recordAuthFailure counts auth failure for SSRC, and blacklists it when limit is exceeded.
+func (c *Context) recordAuthFailure(state *srtpSSRCState) {
+	if c.authFailureWindow == 0 {
+		return
+	}
+
+	now := c.currentTime()
+	if state.authFailures == 0 || now.Sub(state.authFailureWindowStart) > c.authFailureWindow {
+		state.authFailures = 0
+		state.authFailureWindowStart = now
+	}
+	state.authFailures++
+	if state.authFailures > c.authFailureLimit {
+		state.authFailures = 0
+		state.blacklistedUntil = now.Add(c.authFailureCooldown)
+	}
+}
+
 // https://tools.ietf.org/html/rfc3550#appendix-A.1
 func (s *srtpSSRCState) nextRolloverCount(sequenceNumber uint16) (roc uint32, diff int64, overflow bool) {
 	seq := int32(sequenceNumber)
@@ -311,15 +894,20 @@ func (s *srtpSSRCState) updateRolloverCount(sequenceNumber uint16, difference in
 func (c *Context) getSRTPSSRCState(ssrc uint32, keepNew bool) (*srtpSSRCState, bool) {
 	state, ok := c.srtpSSRCStates[ssrc]
 	if ok {
+		if c.hasSSRCStateLimit() {
+			c.touchSRTPSSRCState(state)
+		}
+
 		return state, true
 	}
 
 	state = &srtpSSRCState{
-		ssrc:           ssrc,
-		replayDetector: c.newSRTPReplayDetector(),
+		ssrc:        ssrc,
+		replayGuard: newReplayGuard(c.newSRTPReplayDetector(ssrc)),
 	}
+	c.restoreEvictedSRTPState(state)
 	if keepNew {
-		c.srtpSSRCStates[ssrc] = state
+		c.setSRTPSSRCState(state)
 	}
 
 	return state, false
@@ -328,26 +916,210 @@ func (c *Context) getSRTPSSRCState(ssrc uint32, keepNew bool) (*srtpSSRCState, b
 func (c *Context) getSRTCPSSRCState(ssrc uint32, keepNew bool) (*srtcpSSRCState, bool) {
 	state, ok := c.srtcpSSRCStates[ssrc]
 	if ok {
+		if c.hasSSRCStateLimit() {
+			c.touchSRTCPSSRCState(state)
+		}
+
 		return state, true
 	}
 
 	state = &srtcpSSRCState{
-		ssrc:           ssrc,
-		replayDetector: c.newSRTCPReplayDetector(),
+		ssrc:          ssrc,
+		replayGuard:   newReplayGuard(c.newSRTCPReplayDetector(ssrc)),
+		keyGeneration: c.keyGeneration,
 	}
+	c.restoreEvictedSRTCPState(state)
 	if keepNew {
-		c.srtcpSSRCStates[ssrc] = state
+		c.setSRTCPSSRCState(state)
 	}
 
 	return state, false
 }
 
 func (c *Context) setSRTPSSRCState(state *srtpSSRCState) {
+	c.forgetEvictedSSRC(state.ssrc, false)
+	if c.hasSSRCStateLimit() {
+		c.addSRTPSSRCStateToLRU(state)
+	}
 	c.srtpSSRCStates[state.ssrc] = state
+	if c.onNewSSRC != nil {
+		c.onNewSSRC(state.ssrc, false)
+	}
 }
 
 func (c *Context) setSRTCPSSRCState(state *srtcpSSRCState) {
+	c.forgetEvictedSSRC(state.ssrc, true)
+	if c.hasSSRCStateLimit() {
+		c.addSRTCPSSRCStateToLRU(state)
+	}
 	c.srtcpSSRCStates[state.ssrc] = state
+	if c.onNewSSRC != nil {
+		c.onNewSSRC(state.ssrc, true)
+	}
+}
+
+// Warmup runs throwaway encryption and decryption of RTP and RTCP packets with all ciphers of
+// the Context, to trigger lazy initialization of ciphers and internal buffers before the first real
+// packet is processed. It is useful for latency-critical first packets. Warmup does not modify
+// the SSRC state, and its output is discarded.
+func (c *Context) Warmup() error {
+	ciphers := []srtpCipher{c.cipher}
+	for _, cipher := range c.mkis {
+		if cipher != c.cipher {
+			ciphers = append(ciphers, cipher)
+		}
+	}
+
+	header := &rtp.Header{Version: 2}
+	headerLen := header.MarshalSize()
+	plaintext := make([]byte, headerLen+warmupPayloadLen)
+	if _, err := header.MarshalTo(plaintext); err != nil {
+		return err
+	}
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}
+
+	for _, cipher := range ciphers {
+		encrypted, err := cipher.encryptRTP(nil, header, headerLen, plaintext, 0, false)
+		if err != nil {
+			return err
+		}
+		decrypted := make([]byte, len(plaintext))
+		if _, err = cipher.decryptRTP(decrypted, encrypted, header, headerLen, 0, false); err != nil {
+			return err
+		}
+
+		encrypted, err = cipher.encryptRTCP(nil, rtcpPacket, 0, 0)
+		if err != nil {
+			return err
+		}
+		if _, err = cipher.decryptRTCP(nil, encrypted, 0, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplayWindowSize returns SRTP replay protection window size set by SRTPReplayProtection option.
+// It returns zero when replay protection is disabled, what is the default for CreateContext,
+// or when custom replay detector is set by SRTPReplayDetectorFactory.
+func (c *Context) ReplayWindowSize() uint {
+	return c.srtpReplayWindowSize
+}
+
+// SRTCPReplayWindowSize returns SRTCP replay protection window size set by SRTCPReplayProtection option.
+// It returns zero when replay protection is disabled, or when custom replay detector is set by
+// SRTCPReplayDetectorFactory.
+func (c *Context) SRTCPReplayWindowSize() uint {
+	return c.srtcpReplayWindowSize
+}
+
+// RTPOverhead returns maximum number of bytes added to RTP packet by EncryptRTP with the configuration
+// of the Context: authentication tag or AEAD authentication tag, MKI, ROC sent by RCCm3, empty header
+// extension added by Cryptex, and Full EKT Field. When SetSSRCKeys is used with other profiles, it is
+// the maximum for all of them. Header extension added by SRTPHeaderExtensionInserter is not included.
+// Together with MaxRTPPlaintextSize, it allows to keep protected packets within MTU when other layers
+// also expand packets, e.g. when payload is already end-to-end encrypted with SFrame and SRTPNoEncryption
+// is used to apply only hop-by-hop authentication. dst buffer passed to EncryptRTP with capacity of
+// the packet size plus RTPOverhead is used without allocation of a new buffer.
+func (c *Context) RTPOverhead() int {
+	overhead := c.rtpTagsOverhead(c.profile)
+	for _, profile := range c.ssrcProfiles {
+		overhead = max(overhead, c.rtpTagsOverhead(profile))
+	}
+	if c.rccMode == RCCMode3 {
+		overhead += 4
+	}
+	if c.cryptexMode != CryptexModeDisabled && c.encryptSRTP {
+		overhead += extensionHeaderSize
+	}
+	if c.ektKeys != nil {
+		// Plaintext is padded to the AES Key Wrap block size, and the wrapping adds one more block.
+		plaintextLen := ektPlaintextOverhead + len(c.ektMasterKey)
+		ciphertextLen := (plaintextLen+aesKeyWrapBlockLen-1)/aesKeyWrapBlockLen*aesKeyWrapBlockLen + aesKeyWrapBlockLen
+		overhead += ciphertextLen + ektFullFieldTrailerLen
+	}
+
+	return overhead
+}
+
+// rtpTagsOverhead returns number of bytes of auth tags and MKI added to RTP packet protected with the profile.
+func (c *Context) rtpTagsOverhead(profile ProtectionProfile) int {
+	if profile == 0 {
+		// Custom cipher, see CreateContextWithCipher.
+		return cipherRTPOverhead(c.cipher, len(c.sendMKI))
+	}
+
+	overhead := profile.RTPOverhead(len(c.sendMKI))
+	if c.authTagRTPLen != nil && !profile.isAEAD() {
+		authTagLen, _ := profile.AuthTagRTPLen()
+		overhead += *c.authTagRTPLen - authTagLen
+	}
+
+	return overhead
+}
+
+// RTCPOverhead returns maximum number of bytes added to RTCP packet by EncryptRTCP with the configuration
+// of the Context: SRTCP index, authentication tag or AEAD authentication tag, and MKI. When SetSSRCKeys
+// is used with other profiles, it is the maximum for all of them.
+func (c *Context) RTCPOverhead() int {
+	if c.profile == 0 {
+		return cipherRTCPOverhead(c.cipher, len(c.sendMKI))
+	}
+
+	overhead := c.profile.RTCPOverhead(len(c.sendMKI))
+	for _, profile := range c.ssrcProfiles {
+		overhead = max(overhead, profile.RTCPOverhead(len(c.sendMKI)))
+	}
+
+	return overhead
+}
+
+// MaxOverhead returns maximum number of bytes added to RTP or RTCP packet by encryption with
+// the configuration of the Context, see RTPOverhead and RTCPOverhead.
+func (c *Context) MaxOverhead() int {
+	return max(c.RTPOverhead(), c.RTCPOverhead())
+}
+
+// EncryptedLen returns maximum size of RTP or RTCP packet with plainLen bytes after it is encrypted.
+// When dst buffer passed to EncryptRTP or EncryptRTCP has capacity of at least EncryptedLen bytes,
+// the packet is encrypted without allocation of a new buffer. When SRTPHeaderExtensionInserter option
+// is used, plainLen must include the inserted header extension.
+func (c *Context) EncryptedLen(plainLen int) int {
+	return plainLen + c.MaxOverhead()
+}
+
+// reserveEncryptBuffer returns dst with capacity for encrypted packet of the given size. The buffer
+// is allocated once here, so it is not reallocated when parts of the packet, e.g. EKT Field, are
+// appended to it after encryption.
+func reserveEncryptBuffer(dst []byte, size int) []byte {
+	if size <= cap(dst) {
+		return dst
+	}
+
+	return make([]byte, 0, size)
+}
+
+// MaxRTPPlaintextSize returns maximum size of RTP packet, including RTP header, which fits in mtu bytes
+// after it is encrypted by EncryptRTP. It returns zero when RTPOverhead exceeds mtu.
+func (c *Context) MaxRTPPlaintextSize(mtu int) int {
+	return max(mtu-c.RTPOverhead(), 0)
+}
+
+// lastSRTPIndex returns SRTP packet index of the highest packet of the SSRC processed by the Context.
+// It returns false when no packet of the SSRC was processed.
+func (c *Context) lastSRTPIndex(ssrc uint32) (uint64, bool) {
+	state, ok := c.srtpSSRCStates[ssrc]
+	if !ok || !state.rolloverHasProcessed {
+		return 0, false
+	}
+
+	return state.index, true
+}
+
+// hasSSRCState returns true when the Context has SRTP or SRTCP state of any SSRC.
+func (c *Context) hasSSRCState() bool {
+	return len(c.srtpSSRCStates) != 0 || len(c.srtcpSSRCStates) != 0
 }
 
 // ROC returns SRTP rollover counter value of specified SSRC.
@@ -360,6 +1132,21 @@ func (c *Context) ROC(ssrc uint32) (uint32, bool) {
 	return uint32(state.index >> 16), true //nolint:gosec // G115
 }
 
+// PacketID returns 48-bit SRTP packet index (ROC << 16 | sequence number) of a packet with given SSRC
+// and sequence number. ROC is estimated from the current state of the SSRC, in the same way as for
+// received packets, so the index is stable when sequence numbers wrap around. Index is unique within
+// a single SSRC only, use it together with SSRC (e.g. in a struct) to identify packets of many SSRCs.
+// PacketID does not modify the state. It returns false when there is no state for the SSRC.
+func (c *Context) PacketID(ssrc uint32, seq uint16) (uint64, bool) {
+	state, ok := c.srtpSSRCStates[ssrc]
+	if !ok {
+		return 0, false
+	}
+	roc, _, _ := state.nextRolloverCount(seq)
+
+	return uint64(roc)<<16 | uint64(seq), true
+}
+
 // SetROC sets SRTP rollover counter value of specified SSRC.
 func (c *Context) SetROC(ssrc uint32, roc uint32) {
 	state, _ := c.getSRTPSSRCState(ssrc, true)
@@ -367,6 +1154,77 @@ func (c *Context) SetROC(ssrc uint32, roc uint32) {
 	state.rolloverHasProcessed = false
 }
 
+// FinalizeSSRC returns final SRTP rollover counter and the highest sequence number of specified SSRC,
+// and removes its SRTP and SRTCP state from the Context. It is intended to be called at the end of
+// stream, e.g. to store values needed to resume decryption of a recording. ok is false when
+// the Context has no SRTP state for the SSRC.
+func (c *Context) FinalizeSSRC(ssrc uint32) (roc uint32, highestSeq uint16, ok bool) {
+	if state, ok := c.srtcpSSRCStates[ssrc]; ok {
+		c.deleteSRTCPSSRCState(state)
+	}
+	state, ok := c.srtpSSRCStates[ssrc]
+	if !ok {
+		return 0, 0, false
+	}
+	c.deleteSRTPSSRCState(state)
+
+	return uint32(state.index >> 16), uint16(state.index), true //nolint:gosec // G115
+}
+
+// SetMaxRollovers limits number of SRTP ROC rollovers allowed for every SSRC. When ROC of a packet
+// exceeds maxRollovers, EncryptRTP and DecryptRTP fail with an error wrapping ErrTooManyRollovers,
+// and the stream must be rekeyed with a new Context. ROC is counted from the start of the stream,
+// or from the value set with SetROC.
+func (c *Context) SetMaxRollovers(maxRollovers uint32) {
+	c.hasMaxRollovers = true
+	c.maxRollovers = maxRollovers
+}
+
+// checkRollovers verifies that ROC does not exceed the limit set by SetMaxRollovers.
+func (c *Context) checkRollovers(ssrc, roc uint32) error {
+	if c.hasMaxRollovers && roc > c.maxRollovers {
+		return fmt.Errorf("%w: ssrc=%d roc=%d max=%d", ErrTooManyRollovers, ssrc, roc, c.maxRollovers)
+	}
+
+	return nil
+}
+
+// SetTimestampGuard enables RTP timestamp regression check for specified SSRC. After it is set,
+// decrypting a packet whose timestamp goes backward by more than maxRegression, compared to the
+// timestamp of the newest packet accepted so far, returns an error wrapping ErrTimestampRegression.
+// The packet passed authentication, so it is still accepted: decrypted packet is returned together
+// with the error, and ROC and replay protection state is updated, but its timestamp is not used as
+// reference for next packets. The check is not done by VerifyRTP and FilterAuthenticRTP.
+func (c *Context) SetTimestampGuard(ssrc uint32, maxRegression uint32) {
+	state, _ := c.getSRTPSSRCState(ssrc, true)
+	state.timestampGuard = true
+	state.maxTimestampRegression = maxRegression
+}
+
+// checkTimestamp verifies that timestamp did not go backward beyond the configured threshold.
+func (s *srtpSSRCState) checkTimestamp(timestamp uint32) error {
+	if !s.timestampGuard || !s.hasLastTimestamp {
+		return nil
+	}
+
+	regression := s.lastTimestamp - timestamp
+	if int32(regression) > 0 && regression > s.maxTimestampRegression { //nolint:gosec // G115
+		return &timestampRegressionError{
+			SSRC: s.ssrc, Timestamp: timestamp, LastTimestamp: s.lastTimestamp,
+		}
+	}
+
+	return nil
+}
+
+// updateTimestamp remembers timestamp of accepted packet if it is the newest one for this SSRC.
+func (s *srtpSSRCState) updateTimestamp(timestamp uint32, newest bool) {
+	if s.timestampGuard && (newest || !s.hasLastTimestamp) {
+		s.lastTimestamp = timestamp
+		s.hasLastTimestamp = true
+	}
+}
+
 // Index returns SRTCP index value of specified SSRC.
 func (c *Context) Index(ssrc uint32) (uint32, bool) {
 	state, ok := c.srtcpSSRCStates[ssrc]
@@ -381,6 +1239,18 @@ func (c *Context) Index(ssrc uint32) (uint32, bool) {
 func (c *Context) SetIndex(ssrc uint32, index uint32) {
 	state, _ := c.getSRTCPSSRCState(ssrc, true)
 	state.srtcpIndex = index % (maxSRTCPIndex + 1)
+	state.indexWarned = false
+}
+
+// SRTCPIndex returns SRTCP index of specified SSRC, like Index.
+func (c *Context) SRTCPIndex(ssrc uint32) (uint32, bool) {
+	return c.Index(ssrc)
+}
+
+// SetSRTCPIndex sets SRTCP index of specified SSRC, like SetIndex. By default, the next SRTCP packet
+// of the SSRC is sent with the set index plus one.
+func (c *Context) SetSRTCPIndex(ssrc uint32, index uint32) {
+	c.SetIndex(ssrc, index)
 }
 
 //nolint:cyclop
@@ -394,15 +1264,21 @@ func (c *Context) checkRCCMode() error {
 	}
 
 	switch c.profile {
-	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm:
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes192Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm, ProtectionProfileSeed128Gcm96,
+		ProtectionProfileAeadSm4Gcm:
 		// AEAD profiles support RCCMode3 only
 		if c.rccMode != RCCMode3 {
 			return errUnsupportedRccMode
 		}
 
 	case ProtectionProfileAes128CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_32,
 		ProtectionProfileAes256CmHmacSha1_32,
-		ProtectionProfileNullHmacSha1_32:
+		ProtectionProfileNullHmacSha1_32,
+		ProtectionProfileAria128CtrHmacSha1_32, ProtectionProfileAria192CtrHmacSha1_32,
+		ProtectionProfileAria256CtrHmacSha1_32:
 		if c.authTagRTPLen == nil {
 			// ROC completely replaces auth tag for _32 profiles. If you really want to use 4-byte
 			// SRTP auth tag with RCC, use SRTPAuthenticationTagLength(4) option.
@@ -412,9 +1288,13 @@ func (c *Context) checkRCCMode() error {
 		fallthrough // Checks below are common for _32 and _80 profiles.
 
 	case ProtectionProfileAes128CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_80,
-		ProtectionProfileNullHmacSha1_80:
-		// AES-CM and NULL profiles support RCCMode2 only
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_80,
+		ProtectionProfileAria256CtrHmacSha1_80, ProtectionProfileSeedCtrHmacSha1_80,
+		ProtectionProfileSm4CtrHmacSm3_80, ProtectionProfileAes128F8HmacSha1_80:
+		// AES-CM, AES-F8, ARIA-CTR, SEED-CTR, SM4-CTR and NULL profiles support RCCMode2 only
 		if c.rccMode != RCCMode2 {
 			return errUnsupportedRccMode
 		}
@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+const (
+	binaryStateVersion    = 1
+	binaryStateFlagSealed = 0x01
+	binaryStateFlagKeys   = 0x02
+	binaryStateHeaderLen  = 2
+	binaryKeyDataLenLen   = 4
+)
+
+// KeySealer encrypts data serialized by Context.MarshalBinary, e.g. with a key kept in KMS or HSM,
+// so master keys are not stored in plain text, and the state cannot be modified unnoticed. Open must
+// authenticate sealed data, e.g. by using AEAD cipher. See KeyPersistence option.
+type KeySealer interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+// persistedKey is master key of the Context kept for MarshalBinary.
+type persistedKey struct {
+	profile               ProtectionProfile
+	masterKey, masterSalt []byte
+}
+
+// recordMasterKey keeps master key used with MKI for MarshalBinary, when KeyPersistence option is set.
+func (c *Context) recordMasterKey(profile ProtectionProfile, mki, masterKey, masterSalt []byte) {
+	if c.persistedKeys == nil {
+		return
+	}
+	if len(masterKey) == 0 {
+		// Session keys are derived by ExternalKeyDerivation, so the master key is not known.
+		delete(c.persistedKeys, string(mki))
+
+		return
+	}
+	c.persistedKeys[string(mki)] = persistedKey{
+		profile:    profile,
+		masterKey:  bytes.Clone(masterKey),
+		masterSalt: bytes.Clone(masterSalt),
+	}
+}
+
+// MarshalBinary returns encoding of per-SSRC state of the Context, e.g. to checkpoint it, or to migrate
+// a live session to another process. For every SSRC, encoding contains ROC and the highest sequence
+// number, or SRTCP index, and a summary of the last 64 received packet indexes; SRTP state is encoded
+// like by MarshalCompactState. Other state, like timestamp guard or auth failure counters, is not included.
+//
+// With KeyPersistence option, encoding contains master keys of the Context with their MKIs too, including
+// keys added by AddCipherForMKI and AddReceiveKey, so media servers can restart or hand off sockets
+// to another process without dropping SRTP sessions. The whole payload, with master keys, ROCs, SRTCP
+// indexes and replay protection state, is sealed with KeySealer passed to KeyPersistence option, when
+// it is set. Configuration of the Context, lifetimes of receive keys, keys set by SetSSRCKeys and keys
+// learned from EKT Fields are never included.
+//
+// Format: version (1 byte), flags (1 byte) and payload, optionally sealed. Payload contains length of
+// key data (4 bytes) and key data when master keys are included, followed by the state. Key data
+// contains protection profile (2 bytes), MKI length (1 byte) and MKI used for sending, number of keys
+// (2 bytes), and for every key its protection profile (2 bytes), MKI length (1 byte) and MKI, master key
+// length (1 byte) and master key, and master salt length (1 byte) and master salt. Keys are sorted by MKI.
+// State contains number of SRTP states (4 bytes), for each of them SSRC (4 bytes), flags (1 byte), 48-bit
+// packet index (ROC << 16 | SEQ) of the newest packet, and 64-bit bitmap of received packets, where bit i
+// is set when packet with index (newest index - i) was accepted; then number of SRTCP states (4 bytes),
+// for each of them SSRC (4 bytes), SRTCP index (4 bytes), flags (1 byte), the newest received SRTCP index
+// (4 bytes) and 64-bit bitmap of received SRTCP indexes. States are sorted by SSRC. All fields are
+// in Big Endian format.
+func (c *Context) MarshalBinary() ([]byte, error) {
+	var flags byte
+	var payload []byte
+	if c.persistedKeys != nil {
+		keyData, err := c.marshalPersistedKeys()
+		if err != nil {
+			return nil, err
+		}
+		payload = binary.BigEndian.AppendUint32(payload, uint32(len(keyData))) //nolint:gosec // G115
+		payload = append(payload, keyData...)
+		flags |= binaryStateFlagKeys
+	}
+	payload = append(payload, c.marshalState()...)
+
+	if c.keySealer != nil {
+		var err error
+		if payload, err = c.keySealer.Seal(payload); err != nil {
+			return nil, err
+		}
+		flags |= binaryStateFlagSealed
+	}
+
+	return append([]byte{binaryStateVersion, flags}, payload...), nil
+}
+
+// marshalPersistedKeys returns key data encoded by MarshalBinary.
+func (c *Context) marshalPersistedKeys() ([]byte, error) {
+	mkis := make([]string, 0, len(c.persistedKeys))
+	for mki := range c.persistedKeys {
+		if _, ok := c.mkis[mki]; ok || (mki == "" && len(c.sendMKI) == 0) {
+			mkis = append(mkis, mki)
+		}
+	}
+	if _, ok := c.persistedKeys[string(c.sendMKI)]; !ok {
+		return nil, errMasterKeyUnknown
+	}
+	sort.Strings(mkis)
+
+	keyData := binary.BigEndian.AppendUint16(nil, uint16(c.profile))
+	keyData = append(keyData, byte(len(c.sendMKI)))
+	keyData = append(keyData, c.sendMKI...)
+	keyData = binary.BigEndian.AppendUint16(keyData, uint16(len(mkis))) //nolint:gosec // G115
+	for _, mki := range mkis {
+		key := c.persistedKeys[mki]
+		keyData = binary.BigEndian.AppendUint16(keyData, uint16(key.profile))
+		for _, field := range [][]byte{[]byte(mki), key.masterKey, key.masterSalt} {
+			keyData = append(keyData, byte(len(field)))
+			keyData = append(keyData, field...)
+		}
+	}
+
+	return keyData, nil
+}
+
+// UnmarshalBinary restores per-SSRC state, and master keys when they are included, from encoding
+// returned by MarshalBinary. The Context must be created with the same protection profile and options
+// as the one which state was marshaled. When master keys are not included, it must be created with
+// the same master keys too, otherwise e.g. with zero master key and salt: its master keys and MKI used
+// for sending are replaced with the restored ones. All existing per-SSRC state is replaced. Sealed
+// payload is opened with KeySealer passed to KeyPersistence option.
+//
+// Replay protection is restored with some accuracy loss: only the last 64 packet indexes are restored
+// exactly. When replay window is bigger than 64 packets, all older indexes within the window are
+// marked as received, so late packets which were not received before the state was marshaled are
+// rejected. This is never less secure than the original state. With custom replay detector set by
+// SRTPReplayDetectorFactory only the last 64 indexes are restored.
+//
+// The encoding is a snapshot: packets sent after it was taken are not known to the restored Context.
+// Their SRTCP indexes would be used again, and with the same keys it reuses IVs, which breaks encryption.
+// Use SRTCPIndexAdvanceOnRestore option to skip SRTCP indexes which could have been sent since the
+// snapshot. ROC is estimated from sequence numbers of sent packets like from received ones, so it is
+// correct only when fewer than 32768 packets of the SSRC were sent since the snapshot.
+func (c *Context) UnmarshalBinary(data []byte) error {
+	if len(data) < binaryStateHeaderLen {
+		return fmt.Errorf("%w: invalid length %d", errInvalidState, len(data))
+	}
+	if data[0] != binaryStateVersion {
+		return fmt.Errorf("%w: unsupported version %d", errInvalidState, data[0])
+	}
+	flags := data[1]
+	data = data[binaryStateHeaderLen:]
+
+	if flags&binaryStateFlagSealed != 0 {
+		if c.keySealer == nil {
+			return errKeySealerRequired
+		}
+		opened, err := c.keySealer.Open(data)
+		if err != nil {
+			return err
+		}
+		data = opened
+	}
+
+	if flags&binaryStateFlagKeys == 0 {
+		return c.unmarshalState(data)
+	}
+
+	if len(data) < binaryKeyDataLenLen {
+		return fmt.Errorf("%w: invalid length %d", errInvalidState, len(data))
+	}
+	keyDataLen := int(binary.BigEndian.Uint32(data))
+	data = data[binaryKeyDataLenLen:]
+	if keyDataLen > len(data) {
+		return fmt.Errorf("%w: invalid length of key data %d", errInvalidState, keyDataLen)
+	}
+	keyData, state := data[:keyDataLen], data[keyDataLen:]
+
+	sendMKI, keys, err := c.unmarshalPersistedKeys(keyData)
+	if err != nil {
+		return err
+	}
+	ciphers := make(map[string]srtpCipher, len(keys))
+	for mki, key := range keys {
+		if ciphers[mki], err = c.createCipher(
+			key.profile, []byte(mki), key.masterKey, key.masterSalt, c.encryptSRTP, c.encryptSRTCP,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err = c.unmarshalState(state); err != nil {
+		return err
+	}
+
+	for mki, cipher := range ciphers {
+		if mki != "" {
+			c.setMKICipher(mki, cipher)
+		}
+		key := keys[mki]
+		c.recordMasterKey(key.profile, []byte(mki), key.masterKey, key.masterSalt)
+	}
+	c.sendMKI = sendMKI
+	c.cipher = ciphers[string(sendMKI)]
+	c.keyUsage = 0
+	c.keyGeneration++
+	if c.ektKeys != nil {
+		c.ektMasterKey = bytes.Clone(keys[string(sendMKI)].masterKey)
+	}
+
+	return nil
+}
+
+// unmarshalPersistedKeys decodes key data encoded by MarshalBinary, and checks that it matches
+// configuration of the Context.
+func (c *Context) unmarshalPersistedKeys(data []byte) ([]byte, map[string]persistedKey, error) {
+	errTruncated := fmt.Errorf("%w: truncated key data", errInvalidState)
+	readField := func() ([]byte, bool) {
+		if len(data) < 1 || len(data) < 1+int(data[0]) {
+			return nil, false
+		}
+		field := data[1 : 1+int(data[0])]
+		data = data[1+len(field):]
+
+		return field, true
+	}
+
+	if len(data) < 2 {
+		return nil, nil, errTruncated
+	}
+	if profile := ProtectionProfile(binary.BigEndian.Uint16(data)); profile != c.profile {
+		return nil, nil, fmt.Errorf("%w: protection profile %#v does not match %#v", errInvalidState, profile, c.profile)
+	}
+	data = data[2:]
+	sendMKI, ok := readField()
+	if !ok || len(data) < 2 {
+		return nil, nil, errTruncated
+	}
+	if len(sendMKI) != len(c.sendMKI) {
+		return nil, nil, fmt.Errorf("%w: MKI length %d does not match %d", errInvalidState, len(sendMKI), len(c.sendMKI))
+	}
+	count := int(binary.BigEndian.Uint16(data))
+	data = data[2:]
+
+	keys := make(map[string]persistedKey, count)
+	for range count {
+		if len(data) < 2 {
+			return nil, nil, errTruncated
+		}
+		key := persistedKey{profile: ProtectionProfile(binary.BigEndian.Uint16(data))}
+		data = data[2:]
+		mki, okMKI := readField()
+		var okKey, okSalt bool
+		key.masterKey, okKey = readField()
+		key.masterSalt, okSalt = readField()
+		if !okMKI || !okKey || !okSalt {
+			return nil, nil, errTruncated
+		}
+		keys[string(mki)] = key
+	}
+	if len(data) != 0 {
+		return nil, nil, fmt.Errorf("%w: invalid length of key data", errInvalidState)
+	}
+	if _, ok := keys[string(sendMKI)]; !ok {
+		return nil, nil, errMasterKeyUnknown
+	}
+
+	return bytes.Clone(sendMKI), keys, nil
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// xorKeySealer is KeySealer used by tests. It is NOT secure.
+type xorKeySealer struct{}
+
+func (xorKeySealer) Seal(plaintext []byte) ([]byte, error) {
+	sealed := bytes.Clone(plaintext)
+	for i := range sealed {
+		sealed[i] ^= 0x5a
+	}
+
+	return sealed, nil
+}
+
+func (s xorKeySealer) Open(sealed []byte) ([]byte, error) {
+	return s.Seal(sealed)
+}
+
+func TestContextMarshalBinary(t *testing.T) {
+	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+	newKey := bytes.Repeat([]byte{0x01}, 16)
+	options := func() []ContextOption {
+		return []ContextOption{
+			MasterKeyIndicator([]byte{0x01}), KeyPersistence(xorKeySealer{}),
+			SRTPReplayProtection(64), SRTCPReplayProtection(64),
+		}
+	}
+	newCtx := func() *Context {
+		ctx, err := CreateContext(make([]byte, 16), make([]byte, 14), profileCTR, options()...)
+		require.NoError(t, err)
+
+		return ctx
+	}
+
+	encryptCtx, err := CreateContext(masterKey, masterSalt, profileCTR, options()...)
+	require.NoError(t, err)
+	decryptCtx, err := CreateContext(masterKey, masterSalt, profileCTR, options()...)
+	require.NoError(t, err)
+	for _, ctx := range []*Context{encryptCtx, decryptCtx} {
+		assert.NoError(t, ctx.AddCipherForMKI([]byte{0x02}, newKey, masterSalt))
+		assert.NoError(t, ctx.AddCipherForMKI([]byte{0x03}, newKey, masterSalt))
+		assert.NoError(t, ctx.RemoveMKI([]byte{0x03}))
+	}
+	assert.NoError(t, encryptCtx.SetSendMKI([]byte{0x02}))
+
+	rtpPacket := func(seq uint16) []byte {
+		pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: seq, SSRC: 1}}
+		raw, errMarshal := pkt.Marshal()
+		require.NoError(t, errMarshal)
+
+		return raw
+	}
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+
+	var lastRTP, lastRTCP []byte
+	for seq := uint16(65534); seq != 2; seq++ {
+		lastRTP, err = encryptCtx.EncryptRTP(nil, rtpPacket(seq), nil)
+		require.NoError(t, err)
+		_, err = decryptCtx.DecryptRTP(nil, lastRTP, nil)
+		require.NoError(t, err)
+	}
+	lastRTCP, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	require.NoError(t, err)
+	_, err = decryptCtx.DecryptRTCP(nil, lastRTCP, nil)
+	require.NoError(t, err)
+
+	encryptData, err := encryptCtx.MarshalBinary()
+	require.NoError(t, err)
+	decryptData, err := decryptCtx.MarshalBinary()
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(decryptData, masterKey), "master key must be sealed")
+	state := decryptCtx.marshalState()
+	assert.False(t, bytes.Contains(decryptData, state), "state must be sealed")
+
+	restoredEncryptCtx, restoredDecryptCtx := newCtx(), newCtx()
+	require.NoError(t, restoredEncryptCtx.UnmarshalBinary(encryptData))
+	require.NoError(t, restoredDecryptCtx.UnmarshalBinary(decryptData))
+	assert.Equal(t, []byte{0x02}, restoredEncryptCtx.sendMKI)
+	assert.Len(t, restoredDecryptCtx.mkis, 2)
+	restoredDecryptData, err := restoredDecryptCtx.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, decryptData, restoredDecryptData)
+
+	// Replayed packets are rejected.
+	_, err = restoredDecryptCtx.DecryptRTP(nil, lastRTP, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+	_, err = restoredDecryptCtx.DecryptRTCP(nil, lastRTCP, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+
+	// Streams continue with restored keys, ROC and SRTCP index.
+	encrypted, err := restoredEncryptCtx.EncryptRTP(nil, rtpPacket(2), nil)
+	require.NoError(t, err)
+	decrypted, err := restoredDecryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtpPacket(2), decrypted)
+	roc, ok := restoredDecryptCtx.ROC(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), roc)
+
+	encrypted, err = restoredEncryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	require.NoError(t, err)
+	decrypted, err = restoredDecryptCtx.DecryptRTCP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpPacket, decrypted)
+
+	// Restored Context can be marshaled again.
+	data, err := restoredDecryptCtx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, newCtx().UnmarshalBinary(data))
+
+	// SRTCP indexes of a stale snapshot are advanced.
+	index, _ := encryptCtx.Index(1)
+	advancedCtx, err := CreateContext(
+		make([]byte, 16), make([]byte, 14), profileCTR, append(options(), SRTCPIndexAdvanceOnRestore(100))...,
+	)
+	require.NoError(t, err)
+	require.NoError(t, advancedCtx.UnmarshalBinary(encryptData))
+	advancedIndex, _ := advancedCtx.Index(1)
+	assert.Equal(t, index+100, advancedIndex)
+}
+
+func TestContextMarshalBinaryErrors(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	require.NoError(t, err)
+	stateOnly, err := ctx.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{binaryStateVersion, 0}, stateOnly[:binaryStateHeaderLen])
+
+	sealedCtx, err := buildTestContext(profileCTR, KeyPersistence(xorKeySealer{}))
+	require.NoError(t, err)
+	sealed, err := sealedCtx.MarshalBinary()
+	require.NoError(t, err)
+	assert.ErrorIs(t, ctx.UnmarshalBinary(sealed), errKeySealerRequired)
+
+	plainCtx, err := buildTestContext(profileCTR, KeyPersistence(nil))
+	require.NoError(t, err)
+	plain, err := plainCtx.MarshalBinary()
+	require.NoError(t, err)
+	assert.NoError(t, ctx.UnmarshalBinary(plain))
+
+	gcmCtx, err := buildTestContext(profileGCM, KeyPersistence(nil))
+	require.NoError(t, err)
+	assert.ErrorIs(t, gcmCtx.UnmarshalBinary(plain), errInvalidState)
+
+	mkiCtx, err := buildTestContext(profileCTR, MasterKeyIndicator([]byte{0x01}))
+	require.NoError(t, err)
+	assert.ErrorIs(t, mkiCtx.UnmarshalBinary(plain), errInvalidState)
+
+	for name, data := range map[string][]byte{
+		"Empty":           {},
+		"InvalidVersion":  append([]byte{2}, plain[1:]...),
+		"TruncatedKeys":   plain[:20],
+		"InvalidKeyCount": append(append([]byte{}, plain[:9]...), append([]byte{0, 2}, plain[11:]...)...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, ctx.UnmarshalBinary(data), errInvalidState)
+		})
+	}
+}
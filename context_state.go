@@ -0,0 +1,371 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// SSRCStateInfo describes internal SRTP/SRTCP state of a single SSRC. It is intended for diagnostics only.
+type SSRCStateInfo struct {
+	// HasSRTP is true when the Context has SRTP state for the SSRC.
+	HasSRTP bool
+	// Initialized is true when at least one SRTP packet was processed for the SSRC.
+	Initialized bool
+	// ROC is the SRTP rollover counter.
+	ROC uint32
+	// HighestSequenceNumber is the sequence number of the newest processed SRTP packet.
+	HighestSequenceNumber uint16
+	// ReplayTop is the 48-bit packet index (ROC << 16 | SEQ) of the newest processed SRTP packet.
+	ReplayTop uint64
+
+	// HasSRTCP is true when the Context has SRTCP state for the SSRC.
+	HasSRTCP bool
+	// SRTCPIndex is the SRTCP index.
+	SRTCPIndex uint32
+}
+
+// SSRCStateDiff describes a difference between states of a single SSRC in two snapshots.
+type SSRCStateDiff struct {
+	SSRC uint32
+	// A and B are states from the first and the second snapshot. nil means the SSRC is missing in the snapshot.
+	A, B *SSRCStateInfo
+	// Fields contains names of SSRCStateInfo fields which differ. It is empty when SSRC is missing in one snapshot.
+	Fields []string
+}
+
+// StateSnapshot returns a copy of the per-SSRC state of the Context. It is intended for diagnostics only.
+func (c *Context) StateSnapshot() map[uint32]SSRCStateInfo {
+	snapshot := make(map[uint32]SSRCStateInfo, len(c.srtpSSRCStates)+len(c.srtcpSSRCStates))
+
+	for ssrc, state := range c.srtpSSRCStates {
+		info := snapshot[ssrc]
+		info.HasSRTP = true
+		info.Initialized = state.rolloverHasProcessed
+		info.ROC = uint32(state.index >> 16)             //nolint:gosec // G115
+		info.HighestSequenceNumber = uint16(state.index) //nolint:gosec // G115
+		info.ReplayTop = state.index
+		snapshot[ssrc] = info
+	}
+
+	for ssrc, state := range c.srtcpSSRCStates {
+		info := snapshot[ssrc]
+		info.HasSRTCP = true
+		info.SRTCPIndex = state.srtcpIndex
+		snapshot[ssrc] = info
+	}
+
+	return snapshot
+}
+
+// DiffStates compares two snapshots returned by Context.StateSnapshot. It returns differences sorted by SSRC.
+func DiffStates(a, b map[uint32]SSRCStateInfo) []SSRCStateDiff {
+	var diffs []SSRCStateDiff
+
+	for ssrc, infoA := range a {
+		infoB, ok := b[ssrc]
+		if !ok {
+			diffs = append(diffs, SSRCStateDiff{SSRC: ssrc, A: &infoA})
+
+			continue
+		}
+		if fields := diffSSRCStateInfo(infoA, infoB); len(fields) > 0 {
+			diffs = append(diffs, SSRCStateDiff{SSRC: ssrc, A: &infoA, B: &infoB, Fields: fields})
+		}
+	}
+
+	for ssrc, infoB := range b {
+		if _, ok := a[ssrc]; !ok {
+			diffs = append(diffs, SSRCStateDiff{SSRC: ssrc, B: &infoB})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].SSRC < diffs[j].SSRC })
+
+	return diffs
+}
+
+func diffSSRCStateInfo(a, b SSRCStateInfo) []string {
+	var fields []string
+	if a.HasSRTP != b.HasSRTP {
+		fields = append(fields, "HasSRTP")
+	}
+	if a.Initialized != b.Initialized {
+		fields = append(fields, "Initialized")
+	}
+	if a.ROC != b.ROC {
+		fields = append(fields, "ROC")
+	}
+	if a.HighestSequenceNumber != b.HighestSequenceNumber {
+		fields = append(fields, "HighestSequenceNumber")
+	}
+	if a.ReplayTop != b.ReplayTop {
+		fields = append(fields, "ReplayTop")
+	}
+	if a.HasSRTCP != b.HasSRTCP {
+		fields = append(fields, "HasSRTCP")
+	}
+	if a.SRTCPIndex != b.SRTCPIndex {
+		fields = append(fields, "SRTCPIndex")
+	}
+
+	return fields
+}
+
+const (
+	stateCountLen            = 4
+	stateSSRCLen             = 4
+	stateSRTPLen             = 15
+	stateSRTCPLen            = 17
+	stateSRTPFlagInitialized = 0x01
+	stateSRTCPFlagReceived   = 0x01
+)
+
+const (
+	compactStateVersion = 1
+	compactStateLen     = 1 + stateSRTPLen
+)
+
+// MarshalCompactState returns compact encoding of SRTP state of the SSRC, intended for frequent,
+// low-overhead checkpointing. Encoding is 16 bytes long and contains ROC, the highest sequence
+// number and a summary of the last 64 received packet indexes. Other state, like SRTCP index,
+// timestamp guard or auth failure counters, is not included.
+//
+// Format: version (1 byte), flags (1 byte), 48-bit packet index (ROC << 16 | SEQ) of the newest
+// packet, and 64-bit bitmap of received packets, where bit i is set when packet with index
+// (newest index - i) was accepted. All fields are in Big Endian format. It is the same as encoding
+// of SRTP state of the SSRC in MarshalState, preceded by the version.
+func (c *Context) MarshalCompactState(ssrc uint32) ([]byte, error) {
+	state, ok := c.srtpSSRCStates[ssrc]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", errSSRCStateNotFound, ssrc)
+	}
+
+	return appendSRTPState(append(make([]byte, 0, compactStateLen), compactStateVersion), state), nil
+}
+
+// UnmarshalCompactState restores SRTP state of the SSRC from encoding returned by MarshalCompactState.
+// Existing state of the SSRC is replaced. ROC and the highest sequence number are restored exactly.
+//
+// Replay protection is restored with some accuracy loss: only the last 64 packet indexes are restored
+// exactly. When replay window is bigger than 64 packets, all older indexes within the window are
+// marked as received, so late packets which were not received before checkpoint are rejected.
+// This is never less secure than the original state. With custom replay detector set by
+// SRTPReplayDetectorFactory only the last 64 indexes are restored.
+func (c *Context) UnmarshalCompactState(ssrc uint32, data []byte) error {
+	if len(data) != compactStateLen {
+		return fmt.Errorf("%w: invalid length %d", errInvalidCompactState, len(data))
+	}
+	if data[0] != compactStateVersion {
+		return fmt.Errorf("%w: unsupported version %d", errInvalidCompactState, data[0])
+	}
+
+	state := c.parseSRTPState(ssrc, data[1:])
+	if prev, ok := c.srtpSSRCStates[ssrc]; ok {
+		state.lastUsed, state.lruElem = prev.lastUsed, prev.lruElem
+		if state.lruElem != nil {
+			state.lruElem.Value = state
+		}
+		c.srtpSSRCStates[ssrc] = state
+	} else {
+		c.setSRTPSSRCState(state)
+	}
+
+	return nil
+}
+
+// appendSRTPState appends encoding of SRTP state, without SSRC, to data.
+func appendSRTPState(data []byte, state *srtpSSRCState) []byte {
+	if state.rolloverHasProcessed {
+		data = append(data, stateSRTPFlagInitialized)
+	} else {
+		data = append(data, 0)
+	}
+	data = binary.BigEndian.AppendUint16(data, uint16(state.index>>32)) //nolint:gosec // G115
+	data = binary.BigEndian.AppendUint32(data, uint32(state.index))     //nolint:gosec // G115
+
+	return binary.BigEndian.AppendUint64(data, state.replayGuard.summary(state.index))
+}
+
+// parseSRTPState returns SRTP state of the SSRC decoded from encoding appended by appendSRTPState.
+func (c *Context) parseSRTPState(ssrc uint32, data []byte) *srtpSSRCState {
+	state := &srtpSSRCState{
+		ssrc:                 ssrc,
+		rolloverHasProcessed: data[0]&stateSRTPFlagInitialized != 0,
+		index:                uint64(binary.BigEndian.Uint16(data[1:]))<<32 | uint64(binary.BigEndian.Uint32(data[3:])),
+		replayGuard:          newReplayGuard(c.newSRTPReplayDetector(ssrc)),
+	}
+	restoreReplayGuard(state.replayGuard, c.srtpReplayWindowSize, state.index, binary.BigEndian.Uint64(data[7:]))
+
+	return state
+}
+
+const stateVersion = 1
+
+// MarshalState returns encoding of SRTP and SRTCP state of all SSRCs of the Context, e.g. to migrate
+// a live session to another process. For every SSRC it contains the same SRTP state as
+// MarshalCompactState, and the SRTCP index with a summary of the last 64 received SRTCP indexes.
+// Keys and configuration of the Context, as well as timestamp guard or auth failure counters,
+// are not included: the state must be restored to a Context created with the same keys and options.
+// Use MarshalBinary with KeyPersistence option to include master keys too.
+//
+// Format: version (1 byte), followed by the state in the same format as in MarshalBinary.
+func (c *Context) MarshalState() ([]byte, error) {
+	return append([]byte{stateVersion}, c.marshalState()...), nil
+}
+
+// UnmarshalState restores state of all SSRCs from encoding returned by MarshalState. All existing
+// per-SSRC state of the Context is replaced, and it is kept unchanged when an error is returned.
+// Replay protection is restored with the same accuracy loss as in UnmarshalCompactState. SRTCP
+// indexes are advanced as set by SRTCPIndexAdvanceOnRestore option.
+func (c *Context) UnmarshalState(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("%w: invalid length %d", errInvalidState, len(data))
+	}
+	if data[0] != stateVersion {
+		return fmt.Errorf("%w: unsupported version %d", errInvalidState, data[0])
+	}
+
+	return c.unmarshalState(data[1:])
+}
+
+// marshalState returns encoding of SRTP and SRTCP state of all SSRCs of the Context, used by MarshalState
+// and MarshalBinary.
+//
+// Format: number of SRTP states (4 bytes), for each of them SSRC (4 bytes), flags (1 byte), 48-bit
+// packet index (ROC << 16 | SEQ) of the newest packet, and 64-bit bitmap of received packets, where bit i
+// is set when packet with index (newest index - i) was accepted; then number of SRTCP states (4 bytes),
+// for each of them SSRC (4 bytes), SRTCP index (4 bytes), flags (1 byte), the newest received SRTCP index
+// (4 bytes) and 64-bit bitmap of received SRTCP indexes. States are sorted by SSRC. All fields are
+// in Big Endian format.
+func (c *Context) marshalState() []byte {
+	srtpSSRCs := sortedKeys(c.srtpSSRCStates)
+	srtcpSSRCs := sortedKeys(c.srtcpSSRCStates)
+
+	data := make([]byte, 0, 2*stateCountLen+
+		len(srtpSSRCs)*(stateSSRCLen+stateSRTPLen)+len(srtcpSSRCs)*(stateSSRCLen+stateSRTCPLen))
+
+	data = binary.BigEndian.AppendUint32(data, uint32(len(srtpSSRCs))) //nolint:gosec // G115
+	for _, ssrc := range srtpSSRCs {
+		data = binary.BigEndian.AppendUint32(data, ssrc)
+		data = appendSRTPState(data, c.srtpSSRCStates[ssrc])
+	}
+
+	data = binary.BigEndian.AppendUint32(data, uint32(len(srtcpSSRCs))) //nolint:gosec // G115
+	for _, ssrc := range srtcpSSRCs {
+		state := c.srtcpSSRCStates[ssrc]
+		data = binary.BigEndian.AppendUint32(data, ssrc)
+		data = binary.BigEndian.AppendUint32(data, state.srtcpIndex)
+		top, ok := state.replayGuard.top()
+		if ok {
+			data = append(data, stateSRTCPFlagReceived)
+		} else {
+			data = append(data, 0)
+		}
+		data = binary.BigEndian.AppendUint32(data, uint32(top)) //nolint:gosec // G115
+		data = binary.BigEndian.AppendUint64(data, state.replayGuard.summary(top))
+	}
+
+	return data
+}
+
+// unmarshalState restores state of all SSRCs from encoding returned by marshalState. All existing
+// per-SSRC state of the Context is replaced, after the whole encoding is validated. SRTCP indexes
+// are advanced as set by SRTCPIndexAdvanceOnRestore option.
+//
+// Replay protection is restored with some accuracy loss: only the last 64 packet indexes are restored
+// exactly. When replay window is bigger than 64 packets, all older indexes within the window are
+// marked as received, so late packets which were not received before the state was marshaled are
+// rejected. This is never less secure than the original state. With custom replay detector set by
+// SRTPReplayDetectorFactory only the last 64 indexes are restored.
+func (c *Context) unmarshalState(data []byte) error {
+	if len(data) < stateCountLen {
+		return fmt.Errorf("%w: invalid length %d", errInvalidState, len(data))
+	}
+	srtpCount := int(binary.BigEndian.Uint32(data))
+	data = data[stateCountLen:]
+	if srtpCount > len(data)/(stateSSRCLen+stateSRTPLen) {
+		return fmt.Errorf("%w: too many SRTP states %d", errInvalidState, srtpCount)
+	}
+	srtpData := data[:srtpCount*(stateSSRCLen+stateSRTPLen)]
+	data = data[len(srtpData):]
+
+	if len(data) < stateCountLen {
+		return fmt.Errorf("%w: missing SRTCP states", errInvalidState)
+	}
+	srtcpCount := int(binary.BigEndian.Uint32(data))
+	data = data[stateCountLen:]
+	if len(data) != srtcpCount*(stateSSRCLen+stateSRTCPLen) {
+		return fmt.Errorf("%w: invalid length of SRTCP states", errInvalidState)
+	}
+
+	c.srtpSSRCStates = make(map[uint32]*srtpSSRCState, srtpCount)
+	c.srtcpSSRCStates = make(map[uint32]*srtcpSSRCState, srtcpCount)
+	c.srtpLRU, c.srtcpLRU = nil, nil
+	for ; len(srtpData) > 0; srtpData = srtpData[stateSSRCLen+stateSRTPLen:] {
+		ssrc := binary.BigEndian.Uint32(srtpData)
+		c.setSRTPSSRCState(c.parseSRTPState(ssrc, srtpData[stateSSRCLen:stateSSRCLen+stateSRTPLen]))
+	}
+
+	for ; len(data) > 0; data = data[stateSSRCLen+stateSRTCPLen:] {
+		ssrc := binary.BigEndian.Uint32(data)
+		state := &srtcpSSRCState{
+			ssrc:          ssrc,
+			srtcpIndex:    c.restoredSRTCPIndex(binary.BigEndian.Uint32(data[4:])),
+			replayGuard:   newReplayGuard(c.newSRTCPReplayDetector(ssrc)),
+			keyGeneration: c.keyGeneration,
+		}
+		if data[8]&stateSRTCPFlagReceived != 0 {
+			restoreReplayGuard(state.replayGuard, c.srtcpReplayWindowSize,
+				uint64(binary.BigEndian.Uint32(data[9:])), binary.BigEndian.Uint64(data[13:]))
+		}
+		c.setSRTCPSSRCState(state)
+	}
+
+	return nil
+}
+
+// restoreReplayGuard marks indexes from replay summary relative to top as seen. When replay window is
+// bigger than the summary, all older indexes within the window are marked as seen too.
+func restoreReplayGuard(guard *replayGuard, windowSize uint, top, summary uint64) {
+	if summary == 0 {
+		return
+	}
+	if window := uint64(windowSize); window > replaySummaryBits {
+		first := uint64(0)
+		if top >= window {
+			first = top - window + 1
+		}
+		for index := first; index+replaySummaryBits <= top; index++ {
+			guard.markSeen(index)
+		}
+	}
+	// Mark indexes in increasing order, so the replay window moves forward only.
+	for i := replaySummaryBits - 1; i >= 0; i-- {
+		if summary&(1<<i) != 0 && uint64(i) <= top {
+			guard.markSeen(top - uint64(i))
+		}
+	}
+}
+
+// restoredSRTCPIndex returns SRTCP index restored by UnmarshalState or UnmarshalBinary, advanced by the value set by
+// SRTCPIndexAdvanceOnRestore option.
+func (c *Context) restoredSRTCPIndex(index uint32) uint32 {
+	advanced := uint64(index%(maxSRTCPIndex+1)) + uint64(c.srtcpIndexRestoreAdvance)
+
+	return uint32(min(advanced, maxSRTCPIndex)) //nolint:gosec // G115
+}
+
+// sortedKeys returns sorted SSRCs of the state map.
+func sortedKeys[T any](states map[uint32]T) []uint32 {
+	ssrcs := make([]uint32, 0, len(states))
+	for ssrc := range states {
+		ssrcs = append(ssrcs, ssrc)
+	}
+	sort.Slice(ssrcs, func(i, j int) bool { return ssrcs[i] < ssrcs[j] })
+
+	return ssrcs
+}
@@ -0,0 +1,400 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextStateSnapshotAndDiff(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx1, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx2, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	for seq := uint16(65534); seq != 3; seq++ {
+		pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: seq, SSRC: 1}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+
+		_, err = decryptCtx1.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, err)
+		if seq == 65535 { // decryptCtx2 does not see packets after rollover
+			_, err = decryptCtx2.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+		}
+	}
+
+	decryptCtx1.SetROC(2, 5)
+	decryptCtx2.SetIndex(3, 7)
+
+	snapshot1 := decryptCtx1.StateSnapshot()
+	snapshot2 := decryptCtx2.StateSnapshot()
+
+	assert.Equal(t, SSRCStateInfo{
+		HasSRTP: true, Initialized: true, ROC: 1, HighestSequenceNumber: 2, ReplayTop: 1<<16 | 2,
+	}, snapshot1[1])
+	assert.Equal(t, SSRCStateInfo{HasSRTP: true, ROC: 5, ReplayTop: 5 << 16}, snapshot1[2])
+	assert.Equal(t, SSRCStateInfo{HasSRTCP: true, SRTCPIndex: 7}, snapshot2[3])
+
+	assert.Empty(t, DiffStates(snapshot1, decryptCtx1.StateSnapshot()))
+
+	diffs := DiffStates(snapshot1, snapshot2)
+	assert.Len(t, diffs, 3)
+
+	assert.Equal(t, uint32(1), diffs[0].SSRC)
+	assert.Equal(t, []string{"ROC", "HighestSequenceNumber", "ReplayTop"}, diffs[0].Fields)
+	assert.Equal(t, uint16(65535), diffs[0].B.HighestSequenceNumber)
+
+	assert.Equal(t, uint32(2), diffs[1].SSRC)
+	assert.NotNil(t, diffs[1].A)
+	assert.Nil(t, diffs[1].B)
+
+	assert.Equal(t, uint32(3), diffs[2].SSRC)
+	assert.Nil(t, diffs[2].A)
+	assert.NotNil(t, diffs[2].B)
+}
+
+func TestContextCompactState(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(1024))
+	assert.NoError(t, err)
+
+	encrypted := map[uint16][]byte{}
+	for seq := uint16(65000); seq != 100; seq++ {
+		pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: seq, SSRC: 1}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted[seq], err = encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+
+		// Packets 10 and 80 are lost before checkpoint.
+		if seq == 10 || seq == 80 {
+			continue
+		}
+		_, err = decryptCtx.DecryptRTP(nil, encrypted[seq], nil)
+		assert.NoError(t, err)
+	}
+
+	data, err := decryptCtx.MarshalCompactState(1)
+	assert.NoError(t, err)
+	assert.Len(t, data, 16)
+
+	restoredCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(1024))
+	assert.NoError(t, err)
+	assert.NoError(t, restoredCtx.UnmarshalCompactState(1, data))
+
+	restored := restoredCtx.StateSnapshot()[1]
+	assert.Equal(t, uint32(1), restored.ROC)
+	assert.Equal(t, uint16(99), restored.HighestSequenceNumber)
+	assert.Equal(t, decryptCtx.StateSnapshot()[1], restored)
+
+	// Replayed packets are rejected.
+	for _, seq := range []uint16{99, 50, 36, 65535} {
+		_, err = restoredCtx.DecryptRTP(nil, encrypted[seq], nil)
+		assert.ErrorIs(t, err, errDuplicated, "seq %d", seq)
+	}
+	// Lost packet within the last 64 indexes is accepted. Older one is rejected, because only
+	// the last 64 indexes are restored exactly.
+	_, err = restoredCtx.DecryptRTP(nil, encrypted[80], nil)
+	assert.NoError(t, err)
+	_, err = restoredCtx.DecryptRTP(nil, encrypted[10], nil)
+	assert.ErrorIs(t, err, errDuplicated)
+
+	// Stream continues.
+	pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: 100, SSRC: 1}}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	next, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	decrypted, err := restoredCtx.DecryptRTP(nil, next, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pktRaw, decrypted)
+
+	// Compact state of the encrypting side restores ROC too.
+	data, err = encryptCtx.MarshalCompactState(1)
+	assert.NoError(t, err)
+	assert.NoError(t, restoredCtx.UnmarshalCompactState(1, data))
+	restored = restoredCtx.StateSnapshot()[1]
+	assert.Equal(t, uint32(1), restored.ROC)
+	assert.Equal(t, uint16(100), restored.HighestSequenceNumber)
+}
+
+func TestContextCompactStateErrors(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	_, err = ctx.MarshalCompactState(1)
+	assert.ErrorIs(t, err, errSSRCStateNotFound)
+
+	assert.ErrorIs(t, ctx.UnmarshalCompactState(1, make([]byte, 15)), errInvalidCompactState)
+	assert.ErrorIs(t, ctx.UnmarshalCompactState(1, make([]byte, 16)), errInvalidCompactState)
+	_, ok := ctx.ROC(1)
+	assert.False(t, ok)
+}
+
+func TestContextMarshalState(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(128), SRTCPReplayProtection(128))
+	assert.NoError(t, err)
+
+	var lastRTP, lastRTCP []byte
+	for _, ssrc := range []uint32{2, 1} {
+		for seq := uint16(65530); seq != 10; seq++ {
+			pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: seq, SSRC: ssrc}}
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(t, errMarshal)
+			lastRTP, err = encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTP(nil, lastRTP, nil)
+			assert.NoError(t, err)
+		}
+		for i := 0; i < 3; i++ {
+			rtcpRaw := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, byte(ssrc)}
+			lastRTCP, err = encryptCtx.EncryptRTCP(nil, rtcpRaw, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTCP(nil, lastRTCP, nil)
+			assert.NoError(t, err)
+		}
+	}
+
+	data, err := decryptCtx.MarshalState()
+	assert.NoError(t, err)
+	assert.Len(t, data, 1+2*stateCountLen+2*(stateSSRCLen+stateSRTPLen)+2*(stateSSRCLen+stateSRTCPLen))
+
+	restoredCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(128), SRTCPReplayProtection(128))
+	assert.NoError(t, err)
+	restoredCtx.SetROC(3, 1)
+	assert.NoError(t, restoredCtx.UnmarshalState(data))
+	assert.Equal(t, decryptCtx.StateSnapshot(), restoredCtx.StateSnapshot())
+
+	// Replayed packets are rejected.
+	_, err = restoredCtx.DecryptRTP(nil, lastRTP, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+	_, err = restoredCtx.DecryptRTCP(nil, lastRTCP, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+
+	// Streams continue.
+	pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: 10, SSRC: 1}}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	next, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	decrypted, err := restoredCtx.DecryptRTP(nil, next, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pktRaw, decrypted)
+
+	rtcpRaw := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	next, err = encryptCtx.EncryptRTCP(nil, rtcpRaw, nil)
+	assert.NoError(t, err)
+	decrypted, err = restoredCtx.DecryptRTCP(nil, next, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpRaw, decrypted)
+
+	// State of the encrypting side can be restored too.
+	data, err = encryptCtx.MarshalState()
+	assert.NoError(t, err)
+	otherEncryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	assert.NoError(t, otherEncryptCtx.UnmarshalState(data))
+	assert.Equal(t, encryptCtx.StateSnapshot(), otherEncryptCtx.StateSnapshot())
+}
+
+func TestContextUnmarshalStateErrors(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	ctx.SetROC(1, 5)
+
+	for name, data := range map[string][]byte{
+		"Empty":              {},
+		"InvalidVersion":     {2, 0, 0, 0, 0, 0, 0, 0, 0},
+		"TooManySRTPStates":  {1, 0, 0, 0, 1, 0, 0, 0, 0},
+		"MissingSRTCPStates": {1, 0, 0, 0, 0},
+		"TooManySRTCPStates": {1, 0, 0, 0, 0, 0, 0, 0, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, ctx.UnmarshalState(data), errInvalidState)
+			roc, ok := ctx.ROC(1)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(5), roc)
+		})
+	}
+}
+
+func TestContextStateRestoreReplayWindow(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(1024))
+	assert.NoError(t, err)
+
+	encrypted := map[uint16][]byte{}
+	for seq := uint16(65000); seq != 100; seq++ {
+		pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: seq, SSRC: 1}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted[seq], err = encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+
+		// Packets 10 and 80 are lost before checkpoint.
+		if seq == 10 || seq == 80 {
+			continue
+		}
+		_, err = decryptCtx.DecryptRTP(nil, encrypted[seq], nil)
+		assert.NoError(t, err)
+	}
+
+	// State is serialized without master keys when KeyPersistence is not set.
+	data, err := decryptCtx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{binaryStateVersion, 0}, data[:binaryStateHeaderLen])
+	assert.Len(t, data, binaryStateHeaderLen+2*stateCountLen+stateSSRCLen+stateSRTPLen)
+
+	restoredCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(1024))
+	assert.NoError(t, err)
+	assert.NoError(t, restoredCtx.UnmarshalBinary(data))
+
+	roc, ok := restoredCtx.ROC(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), roc)
+	restoredData, err := restoredCtx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, data, restoredData)
+
+	// Replayed packets are rejected.
+	for _, seq := range []uint16{99, 50, 36, 65535} {
+		_, err = restoredCtx.DecryptRTP(nil, encrypted[seq], nil)
+		assert.ErrorIs(t, err, errDuplicated, "seq %d", seq)
+	}
+	// Lost packet within the last 64 indexes is accepted. Older one is rejected, because only
+	// the last 64 indexes are restored exactly.
+	_, err = restoredCtx.DecryptRTP(nil, encrypted[80], nil)
+	assert.NoError(t, err)
+	_, err = restoredCtx.DecryptRTP(nil, encrypted[10], nil)
+	assert.ErrorIs(t, err, errDuplicated)
+
+	// Stream continues.
+	pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: 100, SSRC: 1}}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	next, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	decrypted, err := restoredCtx.DecryptRTP(nil, next, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pktRaw, decrypted)
+}
+
+func TestContextStateRestore(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(128), SRTCPReplayProtection(128))
+	assert.NoError(t, err)
+
+	var lastRTP, lastRTCP []byte
+	for _, ssrc := range []uint32{2, 1} {
+		for seq := uint16(65530); seq != 10; seq++ {
+			pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: seq, SSRC: ssrc}}
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(t, errMarshal)
+			lastRTP, err = encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTP(nil, lastRTP, nil)
+			assert.NoError(t, err)
+		}
+		for i := 0; i < 3; i++ {
+			rtcpRaw := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, byte(ssrc)}
+			lastRTCP, err = encryptCtx.EncryptRTCP(nil, rtcpRaw, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTCP(nil, lastRTCP, nil)
+			assert.NoError(t, err)
+		}
+	}
+
+	data, err := decryptCtx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Len(t, data, binaryStateHeaderLen+2*stateCountLen+2*(stateSSRCLen+stateSRTPLen)+
+		2*(stateSSRCLen+stateSRTCPLen))
+
+	// Existing state is replaced.
+	restoredCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(128), SRTCPReplayProtection(128))
+	assert.NoError(t, err)
+	restoredCtx.SetROC(3, 1)
+	assert.NoError(t, restoredCtx.UnmarshalBinary(data))
+	_, ok := restoredCtx.ROC(3)
+	assert.False(t, ok)
+	restoredData, err := restoredCtx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, data, restoredData)
+
+	// Replayed packets are rejected.
+	_, err = restoredCtx.DecryptRTP(nil, lastRTP, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+	_, err = restoredCtx.DecryptRTCP(nil, lastRTCP, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+
+	// Streams continue.
+	pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: 10, SSRC: 1}}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	next, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	decrypted, err := restoredCtx.DecryptRTP(nil, next, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pktRaw, decrypted)
+
+	rtcpRaw := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	next, err = encryptCtx.EncryptRTCP(nil, rtcpRaw, nil)
+	assert.NoError(t, err)
+	decrypted, err = restoredCtx.DecryptRTCP(nil, next, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpRaw, decrypted)
+
+	// State of the encrypting side can be restored too.
+	data, err = encryptCtx.MarshalBinary()
+	assert.NoError(t, err)
+	otherEncryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	assert.NoError(t, otherEncryptCtx.UnmarshalBinary(data))
+	for _, ssrc := range []uint32{1, 2} {
+		expectedROC, _ := encryptCtx.ROC(ssrc)
+		roc, ok := otherEncryptCtx.ROC(ssrc)
+		assert.True(t, ok)
+		assert.Equal(t, expectedROC, roc)
+		expectedIndex, _ := encryptCtx.Index(ssrc)
+		index, ok := otherEncryptCtx.Index(ssrc)
+		assert.True(t, ok)
+		assert.Equal(t, expectedIndex, index)
+	}
+}
+
+func TestContextStateRestoreErrors(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	ctx.SetROC(1, 5)
+
+	for name, state := range map[string][]byte{
+		"Empty":              {},
+		"Short":              {0, 0, 0},
+		"TooManySRTPStates":  {0, 0, 0, 1, 0, 0, 0, 0},
+		"MissingSRTCPStates": {0, 0, 0, 0},
+		"TooManySRTCPStates": {0, 0, 0, 0, 0, 0, 0, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := append([]byte{binaryStateVersion, 0}, state...)
+			assert.ErrorIs(t, ctx.UnmarshalBinary(data), errInvalidState)
+			roc, ok := ctx.ROC(1)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(5), roc)
+		})
+	}
+}
@@ -4,8 +4,12 @@
 package srtp
 
 import (
+	"bytes"
+	"encoding/binary"
 	"testing"
 
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/replaydetector"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,6 +39,24 @@ func TestContextIndex(t *testing.T) {
 	assert.Equal(t, index, uint32(100))
 }
 
+func TestContextSRTCPIndex(t *testing.T) {
+	c, err := CreateContext(make([]byte, 16), make([]byte, 14), profileCTR)
+	assert.NoError(t, err)
+
+	_, ok := c.SRTCPIndex(123)
+	assert.False(t, ok, "SRTCPIndex must return false for unused SSRC")
+
+	c.SetSRTCPIndex(123, 100)
+	index, ok := c.SRTCPIndex(123)
+	assert.True(t, ok, "SRTCPIndex must return true for used SSRC")
+	assert.Equal(t, uint32(100), index)
+
+	encrypted, err := c.EncryptRTCP(nil, []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 123}, nil)
+	assert.NoError(t, err)
+	sentIndex := binary.BigEndian.Uint32(encrypted[len(encrypted)-srtcpIndexSize-10:])
+	assert.Equal(t, uint32(101), sentIndex&^(srtcpEncryptionFlag<<24))
+}
+
 func TestContextWithoutMKI(t *testing.T) {
 	ctx, err := CreateContext(make([]byte, 16), make([]byte, 14), profileCTR)
 	assert.NoError(t, err)
@@ -90,6 +112,70 @@ func TestAddMKIToContextWithMKI(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestAddMKIWithDifferentProfile(t *testing.T) {
+	mkiCTR := []byte{1, 2, 3, 4}
+	mkiGCM := []byte{2, 3, 4, 5}
+	keyCTR, saltCTR := bytes.Repeat([]byte{1}, 16), bytes.Repeat([]byte{2}, 14)
+	keyGCM, saltGCM := bytes.Repeat([]byte{3}, 16), bytes.Repeat([]byte{4}, 12)
+
+	encryptCTR, err := CreateContext(keyCTR, saltCTR, profileCTR, MasterKeyIndicator(mkiCTR))
+	assert.NoError(t, err)
+	encryptGCM, err := CreateContext(keyGCM, saltGCM, profileGCM, MasterKeyIndicator(mkiGCM))
+	assert.NoError(t, err)
+
+	decryptCtx, err := CreateContext(keyCTR, saltCTR, profileCTR, MasterKeyIndicator(mkiCTR))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, decryptCtx.AddCipherForMKIWithProfile(mkiGCM, keyGCM, saltGCM, 0x1234), ErrUnsupportedProfile)
+	assert.Error(t, decryptCtx.AddCipherForMKIWithProfile(mkiGCM, keyGCM, saltCTR, profileGCM))
+	assert.NoError(t, decryptCtx.AddCipherForMKIWithProfile(mkiGCM, keyGCM, saltGCM, profileGCM))
+
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	for seq, encryptCtx := range []*Context{encryptCTR, encryptGCM, encryptCTR, encryptGCM} {
+		pkt := &rtp.Packet{
+			Header:  rtp.Header{SequenceNumber: uint16(seq), SSRC: 1}, //nolint:gosec // G115
+			Payload: []byte{0x00, 0x01, 0x02, 0x03},
+		}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+		decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, errDecrypt)
+		assert.Equal(t, pktRaw, decrypted)
+
+		encrypted, errEncrypt = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+		assert.NoError(t, errEncrypt)
+		decrypted, errDecrypt = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+		assert.NoError(t, errDecrypt)
+		assert.Equal(t, rtcpPacket, decrypted)
+	}
+
+	// RTP header followed by GCM MKI, but without AEAD auth tag.
+	tooShort := append([]byte{0x80, 0x00, 0x00, 0x10, 0, 0, 0, 0, 0, 0, 0, 1}, mkiGCM...)
+	_, err = decryptCtx.DecryptRTP(nil, tooShort, nil)
+	assert.ErrorIs(t, err, errTooShortRTP)
+
+	tooShort = append(append([]byte{}, rtcpPacket...), 0x80, 0x00, 0x00, 0x01)
+	tooShort = append(tooShort, mkiGCM...)
+	_, err = decryptCtx.DecryptRTCP(nil, tooShort, nil)
+	assert.ErrorIs(t, err, errTooShortRTCP)
+
+	unknownMKI := append(append([]byte{}, tooShort...), make([]byte, 16)...)
+	_, err = decryptCtx.DecryptRTCP(nil, unknownMKI, nil)
+	assert.ErrorIs(t, err, ErrMKINotFound)
+}
+
+func TestAddMKIWithDifferentProfileAndRCC(t *testing.T) {
+	ctx, err := CreateContext(make([]byte, 16), make([]byte, 14), profileCTR,
+		MasterKeyIndicator([]byte{1}), RolloverCounterCarryingTransform(RCCMode2, 10))
+	assert.NoError(t, err)
+
+	err = ctx.AddCipherForMKIWithProfile([]byte{2}, make([]byte, 16), make([]byte, 12), profileGCM)
+	assert.ErrorIs(t, err, errUnsupportedRccMode)
+	assert.NoError(t, ctx.AddCipherForMKIWithProfile([]byte{2}, make([]byte, 16), make([]byte, 14), profileCTR))
+}
+
 func TestContextSetSendMKI(t *testing.T) {
 	mki1 := []byte{1, 2, 3, 4}
 	mki2 := []byte{2, 3, 4, 5}
@@ -147,11 +233,14 @@ func TestInvalidContextOptions(t *testing.T) {
 	profiles := []ProtectionProfile{
 		ProtectionProfileAes128CmHmacSha1_80,
 		ProtectionProfileAes128CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_32,
 		ProtectionProfileAes256CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_32,
 		ProtectionProfileNullHmacSha1_80,
 		ProtectionProfileNullHmacSha1_32,
 		ProtectionProfileAeadAes128Gcm,
+		ProtectionProfileAeadAes192Gcm,
 		ProtectionProfileAeadAes256Gcm,
 	}
 
@@ -216,3 +305,692 @@ func TestInvalidContextOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestContextOnNewSSRC(t *testing.T) {
+	type call struct {
+		ssrc   uint32
+		isRTCP bool
+	}
+	var calls []call
+	onNewSSRC := OnNewSSRC(func(ssrc uint32, isRTCP bool) {
+		calls = append(calls, call{ssrc, isRTCP})
+	})
+
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, onNewSSRC)
+	assert.NoError(t, err)
+
+	for _, ssrc := range []uint32{1, 2} {
+		for seq := range uint16(3) {
+			pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: ssrc}, Payload: rtpTestCaseDecrypted()}
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(t, errMarshal)
+			encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, errEncrypt)
+
+			if seq == 0 {
+				// Packets failing authentication must not create state.
+				tampered := append([]byte{}, encrypted...)
+				tampered[len(tampered)-1] ^= 0xFF
+				_, errDecrypt := decryptCtx.DecryptRTP(nil, tampered, nil)
+				assert.ErrorIs(t, errDecrypt, ErrFailedToVerifyAuthTag)
+			}
+
+			_, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, errDecrypt)
+		}
+
+		rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0, 0, 0, byte(ssrc)}
+		for range 2 {
+			encrypted, errEncrypt := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, errEncrypt)
+			_, errDecrypt := decryptCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.NoError(t, errDecrypt)
+		}
+	}
+
+	assert.Equal(t, []call{{1, false}, {1, true}, {2, false}, {2, true}}, calls)
+
+	decryptCtx.SetROC(3, 1)
+	decryptCtx.SetROC(3, 2)
+	assert.Equal(t, call{3, false}, calls[len(calls)-1])
+	assert.Len(t, calls, 5)
+}
+
+func TestContextFinalizeSSRC(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	for seq := uint16(65530); seq != 10; seq++ {
+		pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: 1}, Payload: []byte{0x00, 0x01}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+		_, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, errDecrypt)
+	}
+	encrypted, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+	assert.NoError(t, err)
+
+	roc, highestSeq, ok := decryptCtx.FinalizeSSRC(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), roc)
+	assert.Equal(t, uint16(9), highestSeq)
+
+	_, ok = decryptCtx.ROC(1)
+	assert.False(t, ok)
+	_, ok = decryptCtx.Index(1)
+	assert.False(t, ok)
+
+	_, _, ok = decryptCtx.FinalizeSSRC(1)
+	assert.False(t, ok)
+}
+
+func TestContextReplayWindowSize(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), ctx.ReplayWindowSize())
+
+	ctx, err = buildTestContext(profileCTR, SRTPReplayProtection(128))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(128), ctx.ReplayWindowSize())
+
+	ctx, err = buildTestContext(profileCTR, SRTPReplayProtection(128), SRTPNoReplayProtection())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), ctx.ReplayWindowSize())
+
+	ctx, err = buildTestContext(profileCTR, SRTPReplayProtection(4096))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4096), ctx.ReplayWindowSize())
+
+	for _, windowSize := range []uint{0, maxReplayWindowSize + 1} {
+		_, err = buildTestContext(profileCTR, SRTPReplayProtection(windowSize))
+		assert.ErrorIs(t, err, errInvalidReplayWindowSize)
+		_, err = buildTestContext(profileCTR, SRTCPReplayProtection(windowSize))
+		assert.ErrorIs(t, err, errInvalidReplayWindowSize)
+	}
+
+	ctx, err = buildTestContext(profileCTR, SRTPReplayProtection(128), SRTPReplayDetectorFactory(
+		func() replaydetector.ReplayDetector { return replaydetector.New(32, maxSequenceNumber) },
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), ctx.ReplayWindowSize())
+
+	ctx, err = buildTestContext(profileCTR, SRTPReplayProtection(128), SRTPReplayDetectorFactoryForSSRC(
+		func(uint32) replaydetector.ReplayDetector { return replaydetector.New(32, maxSequenceNumber) },
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), ctx.ReplayWindowSize())
+
+	aSession, bSession := buildSessionSRTPPair(t)
+	assert.Equal(t, uint(defaultSessionSRTPReplayProtectionWindow), aSession.session.remoteContext.ReplayWindowSize())
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestContextPacketID(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	_, ok := ctx.PacketID(1, 0)
+	assert.False(t, ok, "PacketID must return false for unused SSRC")
+
+	ids := map[uint64]uint16{}
+	for seq := uint16(65530); seq != 5; seq++ {
+		pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: 1}, Payload: []byte{0x00, 0x01}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		_, errEncrypt := ctx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+
+		id, idOk := ctx.PacketID(1, seq)
+		assert.True(t, idOk)
+		assert.NotContains(t, ids, id, "distinct packets must have distinct IDs")
+		ids[id] = seq
+	}
+
+	// IDs of packets from both sides of the wrap boundary are stable.
+	for id, seq := range ids {
+		stableID, idOk := ctx.PacketID(1, seq)
+		assert.True(t, idOk)
+		assert.Equal(t, id, stableID)
+	}
+	id, _ := ctx.PacketID(1, 65535)
+	assert.Equal(t, uint64(65535), id)
+	id, _ = ctx.PacketID(1, 0)
+	assert.Equal(t, uint64(1<<16), id)
+
+	// PacketID does not modify the state.
+	roc, _ := ctx.ROC(1)
+	assert.Equal(t, uint32(1), roc)
+	id, _ = ctx.PacketID(1, 40000)
+	assert.Equal(t, uint64(40000), id)
+	roc, _ = ctx.ROC(1)
+	assert.Equal(t, uint32(1), roc)
+}
+
+func TestContextSetMaxRollovers(t *testing.T) {
+	const maxRollovers = 2
+
+	unlimitedCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	encryptCtx.SetMaxRollovers(maxRollovers)
+	decryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx.SetMaxRollovers(maxRollovers)
+
+	var packets []RTPPacketWithROC
+	for roc := uint32(0); roc <= maxRollovers; roc++ {
+		packets = append(packets, RTPPacketWithROC{
+			Header:  &rtp.Header{SSRC: 1, SequenceNumber: 65535},
+			Payload: []byte{0x00, 0x01},
+			ROC:     roc,
+		})
+	}
+	encrypted, err := encryptCtx.EncryptRTPSequence(packets)
+	assert.NoError(t, err)
+	decryptCtx.SetROC(1, maxRollovers)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted[len(encrypted)-1], nil)
+	assert.NoError(t, err)
+
+	// Next packet causes rollover K+1.
+	pktRaw, err := (&rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: 0}, Payload: []byte{0x00, 0x01}}).Marshal()
+	assert.NoError(t, err)
+	_, err = encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.ErrorIs(t, err, ErrTooManyRollovers)
+	roc, ok := encryptCtx.ROC(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(maxRollovers), roc)
+
+	_, err = unlimitedCtx.EncryptRTPSequence(packets[len(packets)-1:])
+	assert.NoError(t, err)
+	tooMany, err := unlimitedCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, tooMany, nil)
+	assert.ErrorIs(t, err, ErrTooManyRollovers)
+	roc, ok = decryptCtx.ROC(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(maxRollovers), roc)
+}
+
+func TestContextWarmup(t *testing.T) {
+	pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 1}, Payload: make([]byte, 100)}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			const runs = 20
+
+			// Every measured run encrypts the first packet of a new Context. SSRC state is created before,
+			// so only initialization of ciphers can allocate.
+			firstEncryptAllocs := func(warmup bool) float64 {
+				ctxs := make([]*Context, runs+1)
+				for i := range ctxs {
+					ctx, errCtx := buildTestContext(profile, MasterKeyIndicator([]byte{1}))
+					assert.NoError(t, errCtx)
+					saltLen, errSalt := profile.SaltLen()
+					assert.NoError(t, errSalt)
+					assert.NoError(t, ctx.AddCipherForMKI([]byte{2}, make([]byte, 16), make([]byte, saltLen)))
+					if warmup {
+						assert.NoError(t, ctx.Warmup())
+					}
+					ctx.SetROC(1, 0)
+					ctxs[i] = ctx
+				}
+				dst := make([]byte, 0, 1500)
+				header := &rtp.Header{}
+				i := 0
+
+				return testing.AllocsPerRun(runs, func() {
+					_, errEncrypt := ctxs[i].EncryptRTP(dst, pktRaw, header)
+					assert.NoError(t, errEncrypt)
+					i++
+				})
+			}
+
+			// Ciphers are initialized by Warmup, so the first EncryptRTP does not allocate.
+			assert.Zero(t, firstEncryptAllocs(true))
+			assert.LessOrEqual(t, firstEncryptAllocs(true), firstEncryptAllocs(false))
+
+			// Warmup does not modify the state, so the first real packets are processed normally.
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			assert.NoError(t, encryptCtx.Warmup())
+			decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			assert.NoError(t, decryptCtx.Warmup())
+			assert.Empty(t, encryptCtx.StateSnapshot())
+			assert.Empty(t, decryptCtx.StateSnapshot())
+
+			encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, pktRaw, decrypted)
+		})
+	}
+}
+
+func TestContextSeparateReplayWindows(t *testing.T) {
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	encryptRTP := func(seq uint16) []byte {
+		pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: seq}, Payload: []byte{0x00, 0x01}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+
+		return encrypted
+	}
+	oldRTP, newRTP := encryptRTP(100), encryptRTP(300)
+	oldRTCP, err := encryptCtx.EncryptRTCPWithIndex(nil, rtcpPacket, 100)
+	assert.NoError(t, err)
+	newRTCP, err := encryptCtx.EncryptRTCPWithIndex(nil, rtcpPacket, 300)
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name            string
+		srtpWindow      uint
+		srtcpWindow     uint
+		oldRTPAccepted  bool
+		oldRTCPAccepted bool
+	}{
+		{"LargeSRTPSmallSRTCP", 1024, 64, true, false},
+		{"SmallSRTPLargeSRTCP", 64, 1024, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			decryptCtx, err := buildTestContext(profileCTR,
+				SRTPReplayProtection(test.srtpWindow), SRTCPReplayProtection(test.srtcpWindow))
+			assert.NoError(t, err)
+			assert.Equal(t, test.srtpWindow, decryptCtx.ReplayWindowSize())
+			assert.Equal(t, test.srtcpWindow, decryptCtx.SRTCPReplayWindowSize())
+
+			_, err = decryptCtx.DecryptRTP(nil, newRTP, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTP(nil, oldRTP, nil)
+			if test.oldRTPAccepted {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errDuplicated)
+			}
+
+			_, err = decryptCtx.DecryptRTCP(nil, newRTCP, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTCP(nil, oldRTCP, nil)
+			if test.oldRTCPAccepted {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, errDuplicated)
+			}
+		})
+	}
+
+	ctx, err := buildTestContext(profileCTR, SRTCPReplayProtection(64), SRTCPNoReplayProtection())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), ctx.SRTCPReplayWindowSize())
+
+	aSession, bSession := buildSessionSRTCPPair(t)
+	assert.Equal(t, uint(defaultSessionSRTCPReplayProtectionWindow),
+		aSession.session.remoteContext.SRTCPReplayWindowSize())
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestContextUpdateMasterKey(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			keyLen, err := profile.KeyLen()
+			assert.NoError(t, err)
+			saltLen, err := profile.SaltLen()
+			assert.NoError(t, err)
+			newKey, newSalt := bytes.Repeat([]byte{0x11}, keyLen), bytes.Repeat([]byte{0x22}, saltLen)
+
+			for _, mki := range [][]byte{nil, {0x01}} {
+				encryptCtx, err := buildTestContext(profile, MasterKeyIndicator(mki))
+				assert.NoError(t, err)
+				decryptCtx, err := buildTestContext(profile, MasterKeyIndicator(mki), SRTPReplayProtection(64))
+				assert.NoError(t, err)
+				referenceCtx, err := CreateContext(newKey, newSalt, profile, MasterKeyIndicator(mki))
+				assert.NoError(t, err)
+
+				encrypt := func(ctx *Context, seq uint16) ([]byte, []byte) {
+					pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: seq}, Payload: []byte{0x00, 0x01}}
+					pktRaw, errMarshal := pkt.Marshal()
+					assert.NoError(t, errMarshal)
+					encrypted, errEncrypt := ctx.EncryptRTP(nil, pktRaw, nil)
+					assert.NoError(t, errEncrypt)
+
+					return pktRaw, encrypted
+				}
+
+				_, encrypted := encrypt(encryptCtx, 65535)
+				_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+				assert.NoError(t, err)
+				_, oldKeyPacket := encrypt(encryptCtx, 1)
+
+				assert.Error(t, encryptCtx.UpdateMasterKey(newKey[:keyLen-1], newSalt))
+				assert.NoError(t, encryptCtx.UpdateMasterKey(newKey, newSalt))
+				assert.NoError(t, decryptCtx.UpdateMasterKey(newKey, newSalt))
+
+				// ROC is kept, so packet after rollover uses ROC 1 and the new keys.
+				pktRaw, encrypted := encrypt(encryptCtx, 0)
+				referenceCtx.SetROC(1, 1)
+				_, expected := encrypt(referenceCtx, 0)
+				assert.Equal(t, expected, encrypted)
+
+				decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, pktRaw, decrypted)
+
+				// Packets protected with old keys are rejected.
+				_, err = decryptCtx.DecryptRTP(nil, oldKeyPacket, nil)
+				assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+			}
+		})
+	}
+}
+
+func TestContextOnKeyExpired(t *testing.T) {
+	mki1 := []byte{1}
+	mki2 := []byte{2}
+	var expired [][]byte
+	ctx, err := buildTestContext(profileCTR, MasterKeyIndicator(mki1), OnKeyExpired(3, func(mki []byte) {
+		expired = append(expired, mki)
+	}))
+	assert.NoError(t, err)
+	assert.NoError(t, ctx.AddCipherForMKI(mki2, make([]byte, 16), make([]byte, 14)))
+
+	encryptRTP := func(seq uint16) {
+		pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: seq}, Payload: []byte{0x00}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		_, errEncrypt := ctx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+	}
+	encryptRTCP := func() {
+		_, errEncrypt := ctx.EncryptRTCP(nil, []byte{0x81, 0xc8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, nil)
+		assert.NoError(t, errEncrypt)
+	}
+
+	// SRTP and SRTCP packets are counted together.
+	encryptRTP(1)
+	encryptRTCP()
+	assert.Empty(t, expired)
+	encryptRTP(2)
+	assert.Equal(t, [][]byte{mki1}, expired)
+	// Callback is called once per key.
+	encryptRTP(3)
+	assert.Equal(t, [][]byte{mki1}, expired)
+
+	// Counter is reset after changing the key.
+	assert.NoError(t, ctx.SetSendMKI(mki2))
+	encryptRTP(4)
+	encryptRTP(5)
+	assert.Len(t, expired, 1)
+	encryptRTCP()
+	assert.Equal(t, [][]byte{mki1, mki2}, expired)
+
+	assert.NoError(t, ctx.UpdateMasterKey(make([]byte, 16), make([]byte, 14)))
+	encryptRTP(6)
+	encryptRTP(7)
+	assert.Len(t, expired, 2)
+	encryptRTP(8)
+	assert.Equal(t, [][]byte{mki1, mki2, mki2}, expired)
+}
+
+func TestContextRTPOverhead(t *testing.T) {
+	ektKey := EKTKey{SPI: 1, Key: make([]byte, 16)}
+
+	for name, test := range map[string]struct {
+		profile ProtectionProfile
+		opts    []ContextOption
+	}{
+		"CTR":              {profileCTR, nil},
+		"CTRNoEncryption":  {profileCTR, []ContextOption{SRTPNoEncryption()}},
+		"CTRAuthTagLength": {profileCTR, []ContextOption{SRTPAuthenticationTagLength(16)}},
+		"CTRMKI":           {profileCTR, []ContextOption{MasterKeyIndicator([]byte{1, 2, 3})}},
+		"CTRCryptex":       {profileCTR, []ContextOption{Cryptex(CryptexModeEnabled)}},
+		"CTREKT":           {profileCTR, []ContextOption{EncryptedKeyTransport(ektKey, 0)}},
+		"GCM":              {profileGCM, nil},
+		"GCMNoEncryption":  {profileGCM, []ContextOption{SRTPNoEncryption()}},
+		"GCMRCC":           {profileGCM, []ContextOption{RolloverCounterCarryingTransform(RCCMode3, 1)}},
+		"GCMEKT":           {ProtectionProfileAeadAes256Gcm, []ContextOption{EncryptedKeyTransport(ektKey, 0)}},
+		"DoubleGCM":        {ProtectionProfileDoubleAeadAes128Gcm, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			keyLen, err := test.profile.KeyLen()
+			assert.NoError(t, err)
+			saltLen, err := test.profile.SaltLen()
+			assert.NoError(t, err)
+			ctx, err := CreateContext(make([]byte, keyLen), make([]byte, saltLen), test.profile, test.opts...)
+			assert.NoError(t, err)
+
+			// Packet with CSRC and without header extension gets the largest overhead with Cryptex.
+			pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, CSRC: []uint32{2}}, Payload: []byte{0x00, 0x01}}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			encrypted, err := ctx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, len(encrypted)-len(pktRaw), ctx.RTPOverhead())
+
+			assert.Equal(t, 1200-ctx.RTPOverhead(), ctx.MaxRTPPlaintextSize(1200))
+			assert.Equal(t, 0, ctx.MaxRTPPlaintextSize(1))
+
+			rtcpRaw := []byte{0x80, 0xc8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			encrypted, err = ctx.EncryptRTCP(nil, rtcpRaw, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, len(encrypted)-len(rtcpRaw), ctx.RTCPOverhead())
+			assert.Equal(t, max(ctx.RTPOverhead(), ctx.RTCPOverhead()), ctx.MaxOverhead())
+
+			// Buffer of EncryptedLen bytes is used without reallocation.
+			dst := make([]byte, ctx.EncryptedLen(len(pktRaw)))
+			encrypted, err = ctx.EncryptRTP(dst[:0], pktRaw, nil)
+			assert.NoError(t, err)
+			assert.Same(t, &dst[0], &encrypted[0])
+			dst = make([]byte, ctx.EncryptedLen(len(rtcpRaw)))
+			encrypted, err = ctx.EncryptRTCP(dst[:0], rtcpRaw, nil)
+			assert.NoError(t, err)
+			assert.Same(t, &dst[0], &encrypted[0])
+		})
+	}
+}
+
+func TestContextRTPOverheadSSRCKeys(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	rtpOverhead, rtcpOverhead := ctx.RTPOverhead(), ctx.RTCPOverhead()
+
+	// Overhead is the maximum for all profiles used for sending.
+	keyLen, err := ProtectionProfileDoubleAeadAes128Gcm.KeyLen()
+	assert.NoError(t, err)
+	saltLen, err := ProtectionProfileDoubleAeadAes128Gcm.SaltLen()
+	assert.NoError(t, err)
+	assert.NoError(t, ctx.SetSSRCKeys(2, ProtectionProfileDoubleAeadAes128Gcm,
+		make([]byte, keyLen), make([]byte, saltLen)))
+	encrypted := encryptTestRTPForSSRC(t, ctx, 2, 1)
+	assert.Equal(t, len(encrypted)-16, ctx.RTPOverhead())
+	encrypted, err = ctx.EncryptRTCP(nil, []byte{0x80, 0xc8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(encrypted)-8, ctx.RTCPOverhead())
+
+	ctx.RemoveSSRCKeys(2)
+	assert.Equal(t, rtpOverhead, ctx.RTPOverhead())
+	assert.Equal(t, rtcpOverhead, ctx.RTCPOverhead())
+}
+
+func TestContextKeyDerivationRate(t *testing.T) {
+	for _, kdr := range []uint64{3, 1<<24 + 1, 1 << 25} {
+		_, err := buildTestContext(profileCTR, KeyDerivationRate(kdr))
+		assert.ErrorIs(t, err, errInvalidKeyDerivationRate)
+	}
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile, KeyDerivationRate(4))
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, KeyDerivationRate(4))
+			assert.NoError(t, err)
+			noKDRCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+
+			// Sequence numbers wrap around, so keys are derived for packet index, including ROC.
+			for i, seq := range []uint16{0, 1, 2, 3, 4, 5, 65534, 65535, 0, 1} {
+				pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: seq}, Payload: []byte{0x00, 0x01}}
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+				encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+				assert.NoError(t, errEncrypt)
+
+				decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, pktRaw, decrypted)
+
+				// Only packets with index below kdr use the same keys as without key derivation rate.
+				_, errDecrypt = noKDRCtx.DecryptRTP(nil, encrypted, nil)
+				if i < 4 {
+					assert.NoError(t, errDecrypt)
+				} else {
+					assert.ErrorIs(t, errDecrypt, ErrFailedToVerifyAuthTag)
+				}
+			}
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			for index := uint32(1); index < 10; index++ {
+				encrypted, errEncrypt := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+				assert.NoError(t, errEncrypt)
+				decrypted, errDecrypt := decryptCtx.DecryptRTCP(nil, encrypted, nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, rtcpPacket, decrypted)
+
+				_, errDecrypt = noKDRCtx.DecryptRTCP(nil, encrypted, nil)
+				if index < 4 {
+					assert.NoError(t, errDecrypt)
+				} else {
+					assert.ErrorIs(t, errDecrypt, ErrFailedToVerifyAuthTag)
+				}
+			}
+
+			// Clones share the master key, and derive session keys independently.
+			clone := encryptCtx.Clone()
+			clone.SetIndex(1, 9)
+			encrypted, err := clone.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+type countingKeyDerivationFunc struct {
+	KeyDerivationFunc
+	labels []byte
+	outLen int
+}
+
+func (c *countingKeyDerivationFunc) DeriveKey(label byte, indexOverKdr uint64, outLen int) ([]byte, error) {
+	c.labels = append(c.labels, label)
+	if c.outLen != 0 {
+		outLen = c.outLen
+	}
+
+	return c.KeyDerivationFunc.DeriveKey(label, indexOverKdr, outLen)
+}
+
+func TestContextExternalKeyDerivation(t *testing.T) {
+	masterKey := []byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89}
+	masterSalt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			saltLen, err := profile.SaltLen()
+			assert.NoError(t, err)
+			kdf := &countingKeyDerivationFunc{
+				KeyDerivationFunc: NewAESCMKeyDerivationFunc(masterKey, masterSalt[:saltLen]),
+			}
+
+			opts := []ContextOption{KeyDerivationRate(4)}
+			if profile == profileCTR {
+				opts = append(opts, SRTPEncryptedHeaderExtensions(1))
+			}
+
+			encryptCtx, err := CreateContext(nil, nil, profile, append(opts, ExternalKeyDerivation(kdf))...)
+			assert.NoError(t, err)
+			assert.Contains(t, kdf.labels, byte(labelSRTPEncryption))
+			assert.Contains(t, kdf.labels, byte(labelSRTCPEncryption))
+
+			decryptCtx, err := buildTestContext(profile, opts...)
+			assert.NoError(t, err)
+
+			for seq := uint16(0); seq < 10; seq++ {
+				pkt := &rtp.Packet{
+					Header:  rtp.Header{SSRC: 1, SequenceNumber: seq},
+					Payload: []byte{0x00, 0x01},
+				}
+				assert.NoError(t, pkt.Header.SetExtension(1, []byte{0x02, 0x03}))
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+
+				encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+				assert.NoError(t, errEncrypt)
+				decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, pktRaw, decrypted)
+			}
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			encrypted, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			decrypted, err := decryptCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+		})
+	}
+
+	t.Run("InvalidKeyLength", func(t *testing.T) {
+		kdf := &countingKeyDerivationFunc{
+			KeyDerivationFunc: NewAESCMKeyDerivationFunc(masterKey, masterSalt),
+			outLen:            5,
+		}
+		_, err := CreateContext(nil, nil, profileCTR, ExternalKeyDerivation(kdf))
+		assert.ErrorIs(t, err, errInvalidDerivedKeyLength)
+	})
+
+	t.Run("DoubleAEAD", func(t *testing.T) {
+		kdf := NewAESCMKeyDerivationFunc(masterKey, masterSalt)
+		_, err := CreateContext(nil, nil, ProtectionProfileDoubleAeadAes128Gcm, ExternalKeyDerivation(kdf))
+		assert.ErrorIs(t, err, errExternalKDFNotSupported)
+	})
+
+	t.Run("MasterKeyConflict", func(t *testing.T) {
+		kdf := NewAESCMKeyDerivationFunc(masterKey, masterSalt)
+		_, err := CreateContext(masterKey, masterSalt, profileCTR, ExternalKeyDerivation(kdf))
+		assert.ErrorIs(t, err, errExternalKDFMasterKey)
+
+		ctx, err := CreateContext(nil, nil, profileCTR, ExternalKeyDerivation(kdf), MasterKeyIndicator([]byte{1}))
+		assert.NoError(t, err)
+		assert.ErrorIs(t, ctx.AddCipherForMKI([]byte{2}, masterKey, masterSalt), errExternalKDFMasterKey)
+		assert.NoError(t, ctx.AddCipherForMKI([]byte{2}, nil, nil))
+	})
+
+	t.Run("KeyPersistence", func(t *testing.T) {
+		kdf := NewAESCMKeyDerivationFunc(masterKey, masterSalt)
+		ctx, err := CreateContext(nil, nil, profileCTR, ExternalKeyDerivation(kdf), KeyPersistence(nil))
+		assert.NoError(t, err)
+		assert.Empty(t, ctx.persistedKeys)
+		_, err = ctx.MarshalBinary()
+		assert.ErrorIs(t, err, errMasterKeyUnknown)
+	})
+}
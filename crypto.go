@@ -20,7 +20,8 @@ func incrementCTR(ctr []byte) {
 	}
 }
 
-const xorBufferSize = 32
+// xorBufferSize fits three cipher blocks used by xorBytesF8.
+const xorBufferSize = 48
 
 var xorBufferPool = sync.Pool{ // nolint:gochecknoglobals
 	New: func() any {
@@ -29,8 +30,10 @@ var xorBufferPool = sync.Pool{ // nolint:gochecknoglobals
 }
 
 // xorBytesCTR performs CTR encryption and decryption.
-// It is equivalent to cipher.NewCTR followed by XORKeyStream.
-func xorBytesCTR(block cipher.Block, iv []byte, dst, src []byte) error {
+// It is equivalent to cipher.NewCTR followed by XORKeyStream, but it does not allocate the stream for every
+// packet: the keystream is generated block by block into a pooled buffer. When fips is set, cipher.NewCTR
+// is used, so encryption is done by the FIPS 140-3 validated implementation, see RequireFIPS option.
+func xorBytesCTR(block cipher.Block, iv []byte, dst, src []byte, fips bool) error {
 	if len(iv) != block.BlockSize() || (len(iv)+block.BlockSize()) > xorBufferSize {
 		return errBadIVLength
 	}
@@ -44,6 +47,12 @@ func xorBytesCTR(block cipher.Block, iv []byte, dst, src []byte) error {
 
 	ctr := buffer[:len(iv)]
 	copy(ctr, iv)
+	if fips && len(dst) >= len(src) {
+		// Pooled copy of IV is passed, so IV of the caller does not escape to the heap.
+		cipher.NewCTR(block, ctr).XORKeyStream(dst, src)
+
+		return nil
+	}
 	bs := block.BlockSize()
 	stream := buffer[len(iv) : len(iv)+bs]
 
@@ -60,3 +69,43 @@ func xorBytesCTR(block cipher.Block, iv []byte, dst, src []byte) error {
 
 	return nil
 }
+
+// xorBytesF8 performs f8-mode encryption and decryption, see RFC 3711 section 4.1.2.1. ivBlock is the block
+// cipher keyed with the session key XORed with the masking key, which encrypts iv to IV'. Keystream blocks
+// are S(j) = E(k_e, IV' XOR j XOR S(j-1)), with S(-1) equal to zero.
+func xorBytesF8(block, ivBlock cipher.Block, iv []byte, dst, src []byte) error {
+	bs := block.BlockSize()
+	if len(iv) != bs || 3*bs > xorBufferSize {
+		return errBadIVLength
+	}
+
+	xorBuf := xorBufferPool.Get()
+	defer xorBufferPool.Put(xorBuf)
+	buffer, ok := xorBuf.([]byte)
+	if !ok {
+		return errFailedTypeAssertion
+	}
+
+	// Pooled copy of IV is encrypted, so IV of the caller does not escape to the heap.
+	ivPrime, stream, in := buffer[:bs], buffer[bs:2*bs], buffer[2*bs:3*bs]
+	copy(in, iv)
+	ivBlock.Encrypt(ivPrime, in)
+	clear(stream)
+
+	var j uint32
+	for i := 0; i < len(src); j++ {
+		xor.XorBytes(in, ivPrime, stream)
+		in[bs-4] ^= byte(j >> 24)
+		in[bs-3] ^= byte(j >> 16) //nolint:gosec // G115
+		in[bs-2] ^= byte(j >> 8)  //nolint:gosec // G115
+		in[bs-1] ^= byte(j)       //nolint:gosec // G115
+		block.Encrypt(stream, in)
+		n := xor.XorBytes(dst[i:], src[i:], stream)
+		if n == 0 {
+			break
+		}
+		i += n
+	}
+
+	return nil
+}
@@ -7,8 +7,10 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"math/rand"
+	"strconv"
 	"testing"
 
+	"github.com/pion/rtp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -19,7 +21,7 @@ func xorBytesCTRReference(block cipher.Block, iv []byte, dst, src []byte) {
 }
 
 func benchmarkAESCTR(block cipher.Block, iv []byte, dst, src []byte) {
-	_ = xorBytesCTR(block, iv, dst, src)
+	_ = xorBytesCTR(block, iv, dst, src, false)
 }
 
 func BenchmarkAES128CTRAlloc(b *testing.B) {
@@ -52,6 +54,22 @@ func BenchmarkAES256CTRAlloc(b *testing.B) {
 	}
 }
 
+func BenchmarkXorBytesCTR(b *testing.B) {
+	block, err := aes.NewCipher(make([]byte, 16))
+	require.NoError(b, err)
+	iv := make([]byte, block.BlockSize())
+	for _, size := range []int{64, 256, 1200} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			buf := make([]byte, size)
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = xorBytesCTR(block, iv, buf, buf, false)
+			}
+		})
+	}
+}
+
 func TestXorBytesCTR(t *testing.T) {
 	for keysize := 16; keysize < 64; keysize *= 2 {
 		key := make([]byte, keysize)
@@ -72,12 +90,16 @@ func TestXorBytesCTR(t *testing.T) {
 			_, err = rand.Read(src) //nolint: gosec,staticcheck
 			require.NoError(t, err)
 
-			assert.NoError(t, xorBytesCTR(block, iv, dst, src))
+			assert.NoError(t, xorBytesCTR(block, iv, dst, src, false))
 			xorBytesCTRReference(block, iv, reference, src)
 			require.Equal(t, dst, reference)
 
+			// FIPS mode uses cipher.NewCTR
+			assert.NoError(t, xorBytesCTR(block, iv, dst, src, true))
+			require.Equal(t, dst, reference)
+
 			// test overlap
-			assert.NoError(t, xorBytesCTR(block, iv, dst, dst))
+			assert.NoError(t, xorBytesCTR(block, iv, dst, dst, false))
 			xorBytesCTRReference(block, iv, reference, reference)
 			require.Equal(t, dst, reference)
 		}
@@ -93,9 +115,39 @@ func TestXorBytesCTRInvalidIvLength(t *testing.T) {
 	dst := make([]byte, 1024)
 
 	test := func(iv []byte) {
-		assert.Error(t, errBadIVLength, xorBytesCTR(block, iv, dst, src))
+		assert.Error(t, errBadIVLength, xorBytesCTR(block, iv, dst, src, false))
 	}
 
 	test(make([]byte, block.BlockSize()-1))
 	test(make([]byte, block.BlockSize()+1))
 }
+
+func TestXorBytesF8(t *testing.T) {
+	// RFC 3711 appendix B.2.
+	key := fromHex(t, `234829008467be186c3de14aae72d62c`)
+	salt := fromHex(t, `32f2870d`)
+	header := &rtp.Header{}
+	_, err := header.Unmarshal(fromHex(t, `806e5cba50681de55c621599`))
+	require.NoError(t, err)
+	plaintext := fromHex(t, `70736575646f72616e646f6d6e657373 20697320746865206e65787420626573 74207468696e67`)
+	ciphertext := fromHex(t, `019ce7a26e7854014a6366aa95d4eefd 1ad4172a14f9faf455b7f1d4b62bd08f 562c0eef7c4802`)
+
+	iv := f8RTPIV(header, 0xd462564a)
+	assert.Equal(t, fromHex(t, `006e5cba50681de55c621599d462564a`), iv[:])
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	ivBlock, err := newF8IVBlock(protectionProfileWithArgs{ProtectionProfile: ProtectionProfileAes128F8HmacSha1_80},
+		key, salt)
+	require.NoError(t, err)
+
+	dst := make([]byte, len(plaintext))
+	assert.NoError(t, xorBytesF8(block, ivBlock, iv[:], dst, plaintext))
+	assert.Equal(t, ciphertext, dst)
+
+	// In place decryption.
+	assert.NoError(t, xorBytesF8(block, ivBlock, iv[:], dst, dst))
+	assert.Equal(t, plaintext, dst)
+
+	assert.ErrorIs(t, xorBytesF8(block, ivBlock, iv[:15], dst, dst), errBadIVLength)
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"fmt"
+
+	"github.com/pion/rtp"
+)
+
+// Cipher is a SRTP transform provided by the user, e.g. an experimental one. It is used by Context
+// created with CreateContextWithCipher. The Context keeps ROC, SRTCP index and replay protection state,
+// and the Cipher protects packets with values provided by the Context.
+//
+// Layout of protected packets must follow RFC 3711: the RTP header is not encrypted, the payload is
+// followed by the AEAD auth tag (AEADAuthTagLen bytes), MKI (when MasterKeyIndicator option is used)
+// and the auth tag (AuthTagRTPLen bytes). SRTCP packets are followed by the AEAD auth tag,
+// the E-flag and SRTCP index word, MKI and the auth tag (AuthTagRTCPLen bytes). The Cipher is
+// responsible for writing and skipping MKI.
+//
+// Methods must append output to dst[:0], growing it if it is too small, and return the resulting slice.
+// dst may be the same buffer as the input. Decryption must fail with an error wrapping
+// ErrFailedToVerifyAuthTag when the packet is not authentic. Cipher used by ConcurrentContext or
+// by clones of the Context must be safe for concurrent use.
+type Cipher interface {
+	// AuthTagRTPLen and AuthTagRTCPLen return length of the auth tag placed at the end of packets.
+	AuthTagRTPLen() (int, error)
+	AuthTagRTCPLen() (int, error)
+	// AEADAuthTagLen returns length of the AEAD auth tag placed after the encrypted payload.
+	AEADAuthTagLen() (int, error)
+	// RTCPIndex returns SRTCP index, without the E-flag, read from the protected SRTCP packet.
+	RTCPIndex(packet []byte) uint32
+
+	// EncryptRTP protects RTP packet plaintext, whose header of headerLen bytes is parsed to header.
+	EncryptRTP(dst []byte, header *rtp.Header, headerLen int, plaintext []byte, roc uint32) ([]byte, error)
+	// DecryptRTP verifies and decrypts SRTP packet, whose header of headerLen bytes is parsed to header.
+	// Returned packet does not contain auth tags and MKI.
+	DecryptRTP(dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32) ([]byte, error)
+	// EncryptRTCP protects RTCP packet with given SRTCP index and sender SSRC.
+	EncryptRTCP(dst, plaintext []byte, srtcpIndex, ssrc uint32) ([]byte, error)
+	// DecryptRTCP verifies and decrypts SRTCP packet with given SRTCP index and sender SSRC.
+	// Returned packet does not contain the SRTCP trailer.
+	DecryptRTCP(dst, ciphertext []byte, srtcpIndex, ssrc uint32) ([]byte, error)
+}
+
+// CreateContextWithCipher creates a new SRTP Context which protects packets with the given Cipher instead
+// of one of built-in protection profiles. Options which need master keys or a known protection profile,
+// like KeyDerivationRate, EncryptedKeyTransport, SRTPEncryptedHeaderExtensions, RCC or RequireFIPS, are
+// not supported, and methods like UpdateMasterKey or AddCipherForMKI fail. Encryption and length of
+// the auth tag are decided by the Cipher, so SRTPNoEncryption, SRTCPNoEncryption and
+// SRTPAuthenticationTagLength are not supported either. ProtectionProfile of the Context is zero.
+func CreateContextWithCipher(cipher Cipher, opts ...ContextOption) (*Context, error) {
+	c := &Context{
+		srtpSSRCStates:  map[uint32]*srtpSSRCState{},
+		srtcpSSRCStates: map[uint32]*srtcpSSRCState{},
+		mkis:            map[string]srtpCipher{},
+	}
+
+	for _, o := range append(
+		[]ContextOption{ // Default options
+			SRTPNoReplayProtection(),
+			SRTCPNoReplayProtection(),
+			SRTPEncryption(),
+			SRTCPEncryption(),
+		},
+		opts..., // User specified options
+	) {
+		if err := o(c); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case c.requireFIPS:
+		return nil, fmt.Errorf("%w: custom cipher", ErrNotFIPSApproved)
+	case c.keyDerivationRate != 0 || c.keyDerivationFunc != nil || c.ektKeys != nil ||
+		len(c.encryptedHeaderExtensionIDs) != 0 || c.rccMode != RCCModeNone || c.gcmNonceFunc != nil,
+		!c.encryptSRTP || !c.encryptSRTCP || c.authTagRTPLen != nil:
+		return nil, errCustomCipherOptionNotSupported
+	}
+
+	c.cipher = customCipher{cipher}
+	if c.cipherTimeout > 0 {
+		wrapped, err := newTimeoutCipher(cipher, c.cipherTimeout)
+		if err != nil {
+			return nil, err
+		}
+		c.cipher = wrapped
+	}
+	if len(c.sendMKI) != 0 {
+		c.setMKICipher(string(c.sendMKI), c.cipher)
+	}
+
+	return c, nil
+}
+
+// cipherRTPOverhead and cipherRTCPOverhead return number of bytes added to packets by the cipher, like
+// ProtectionProfile.RTPOverhead and RTCPOverhead. They are used for custom ciphers, which have no profile.
+func cipherRTPOverhead(cipher srtpCipher, mkiLen int) int {
+	authTagLen, _ := cipher.AuthTagRTPLen()
+	aeadAuthTagLen, _ := cipher.AEADAuthTagLen()
+
+	return authTagLen + aeadAuthTagLen + mkiLen
+}
+
+func cipherRTCPOverhead(cipher srtpCipher, mkiLen int) int {
+	authTagLen, _ := cipher.AuthTagRTCPLen()
+	aeadAuthTagLen, _ := cipher.AEADAuthTagLen()
+
+	return authTagLen + aeadAuthTagLen + srtcpIndexSize + mkiLen
+}
+
+// customCipher adapts Cipher provided by the user to srtpCipher interface.
+type customCipher struct {
+	Cipher
+}
+
+func (c customCipher) getRTCPIndex(packet []byte) uint32 {
+	return c.RTCPIndex(packet)
+}
+
+func (c customCipher) encryptRTP(
+	dst []byte, header *rtp.Header, headerLen int, plaintext []byte, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	if rocInAuthTag {
+		return nil, errCustomCipherOptionNotSupported
+	}
+
+	return c.EncryptRTP(dst, header, headerLen, plaintext, roc)
+}
+
+func (c customCipher) decryptRTP(
+	dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	if rocInAuthTag {
+		return nil, errCustomCipherOptionNotSupported
+	}
+
+	return c.DecryptRTP(dst, ciphertext, header, headerLen, roc)
+}
+
+func (c customCipher) encryptRTCP(dst, decrypted []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	return c.EncryptRTCP(dst, decrypted, srtcpIndex, ssrc)
+}
+
+func (c customCipher) decryptRTCP(dst, encrypted []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	return c.DecryptRTCP(dst, encrypted, srtcpIndex, ssrc)
+}
+
+func (customCipher) resignRTP([]byte, *rtp.Header, int, uint32, bool) ([]byte, error) {
+	return nil, errCustomCipherOptionNotSupported
+}
+
+func (customCipher) keystreamRTP(*rtp.Header, int, uint32) ([]byte, error) {
+	return nil, errCustomCipherOptionNotSupported
+}
+
+// srtcpEncryptionEnabled returns true, custom ciphers are assumed to encrypt SRTCP packets.
+func (customCipher) srtcpEncryptionEnabled() bool {
+	return true
+}
+
+// clone returns the same cipher, Cipher must be safe for concurrent use when the Context is cloned.
+func (c customCipher) clone() srtpCipher {
+	return c
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCustomCipher implements Cipher using a built-in cipher, so its output can be checked
+// against the built-in implementation.
+type testCustomCipher struct {
+	srtpCipher
+}
+
+func (c testCustomCipher) RTCPIndex(packet []byte) uint32 {
+	return c.getRTCPIndex(packet)
+}
+
+func (c testCustomCipher) EncryptRTP(
+	dst []byte, header *rtp.Header, headerLen int, plaintext []byte, roc uint32,
+) ([]byte, error) {
+	return c.encryptRTP(dst, header, headerLen, plaintext, roc, false)
+}
+
+func (c testCustomCipher) DecryptRTP(
+	dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32,
+) ([]byte, error) {
+	return c.decryptRTP(dst, ciphertext, header, headerLen, roc, false)
+}
+
+func (c testCustomCipher) EncryptRTCP(dst, plaintext []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	return c.encryptRTCP(dst, plaintext, srtcpIndex, ssrc)
+}
+
+func (c testCustomCipher) DecryptRTCP(dst, ciphertext []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	return c.decryptRTCP(dst, ciphertext, srtcpIndex, ssrc)
+}
+
+func TestCreateContextWithCipher(t *testing.T) {
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	mki := []byte{0x01, 0x02}
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			builtinCtx, err := buildTestContext(profile, MasterKeyIndicator(mki))
+			assert.NoError(t, err)
+			peerCtx, err := buildTestContext(profile, MasterKeyIndicator(mki))
+			assert.NoError(t, err)
+
+			customCtx, err := CreateContextWithCipher(
+				testCustomCipher{builtinCtx.cipher}, MasterKeyIndicator(mki), SRTPReplayProtection(64),
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, ProtectionProfile(0), customCtx.profile)
+
+			// Packets protected by the custom cipher can be decrypted by the built-in one, and vice versa.
+			encrypted := encryptTestRTP(t, customCtx, 1)
+			assert.Equal(t, encryptTestRTP(t, peerCtx, 1), encrypted)
+			decrypted, err := peerCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			encrypted = encryptTestRTP(t, peerCtx, 2)
+			decrypted2, err := customCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, decrypted[12:], decrypted2[12:])
+			// Replay protection is done by the Context.
+			_, err = customCtx.DecryptRTP(nil, encrypted, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+
+			encrypted, err = customCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			decrypted, err = peerCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+			encrypted, err = peerCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			decrypted, err = customCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+
+			// Overhead is taken from the auth tag lengths of the cipher.
+			assert.Equal(t, builtinCtx.RTPOverhead(), customCtx.RTPOverhead())
+			assert.Equal(t, builtinCtx.RTCPOverhead(), customCtx.RTCPOverhead())
+			assert.Equal(t, 1200-builtinCtx.RTPOverhead(), customCtx.MaxRTPPlaintextSize(1200))
+
+			assert.Error(t, customCtx.UpdateMasterKey(make([]byte, 16), make([]byte, 14)))
+		})
+	}
+}
+
+func TestCreateContextWithCipherUnsupportedOptions(t *testing.T) {
+	builtinCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	cipher := testCustomCipher{builtinCtx.cipher}
+
+	_, err = CreateContextWithCipher(cipher, RequireFIPS())
+	assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	for _, opt := range []ContextOption{
+		KeyDerivationRate(1 << 16),
+		RolloverCounterCarryingTransform(RCCMode2, 10),
+		SRTPEncryptedHeaderExtensions(1),
+		SRTPNoEncryption(),
+		SRTCPNoEncryption(),
+		SRTPAuthenticationTagLength(4),
+	} {
+		_, err = CreateContextWithCipher(cipher, opt)
+		assert.ErrorIs(t, err, errCustomCipherOptionNotSupported)
+	}
+}
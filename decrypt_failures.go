@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// uniformDecryptFailure replaces error of failed decryption with ErrDecryptFailed when UniformDecryptFailures
+// option is used, and passes the original error to its callback. Packets rejected before authentication
+// are authenticated with the send key anyway, so they take about the same time as packets which fail it.
+func (c *Context) uniformDecryptFailure(err error, packet []byte, isRTCP bool) error {
+	if err == nil || !c.uniformDecryptFailures {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, ErrFailedToVerifyAuthTag):
+	case errors.Is(err, ErrMKINotFound), errors.Is(err, errTooShortRTP), errors.Is(err, errTooShortRTCP),
+		errors.Is(err, errMalformedHeader):
+		c.equalizeFailureTiming(packet, isRTCP)
+	default:
+		return err
+	}
+	if c.onDecryptFailure != nil {
+		c.onDecryptFailure(err, isRTCP)
+	}
+
+	return ErrDecryptFailed
+}
+
+// headerFailure handles error of parsing the header of received packet like uniformDecryptFailure,
+// so packets too short to hold a header cannot be distinguished from ones which fail authentication.
+func (c *Context) headerFailure(err error, packet []byte, isRTCP bool) error {
+	if !c.uniformDecryptFailures {
+		return err
+	}
+
+	return c.uniformDecryptFailure(fmt.Errorf("%w: %w", errMalformedHeader, err), packet, isRTCP)
+}
+
+// equalizeFailureTiming authenticates packet with the send key and discards the result.
+func (c *Context) equalizeFailureTiming(packet []byte, isRTCP bool) {
+	cipher := c.cipher
+	if k, ok := cipher.(*kdrCipher); ok {
+		cipher = k.srtpCipher
+	}
+
+	switch cipher := cipher.(type) {
+	case *srtpCipherAesCmHmacSha1:
+		if isRTCP {
+			_, _ = cipher.generateSrtcpAuthTag(packet)
+		} else {
+			_, _ = cipher.generateSrtpAuthTag(packet, 0, false)
+		}
+	case *srtpCipherAeadAesGcm:
+		// Authentication of the packet as AAD takes about the same time as its decryption. The IV is not
+		// unique, but the tag is discarded without being exposed, it is computed only for its timing.
+		aead, iv := cipher.srtpCipher, cipher.rtpIV[:]
+		if isRTCP {
+			aead, iv = cipher.srtcpCipher, cipher.rtcpIV[:]
+		}
+		c.failureTimingBuf = aead.Seal(c.failureTimingBuf[:0], iv, nil, packet)
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUniformDecryptFailures(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			var failures []error
+			encryptCtx, err := buildTestContext(profile, MasterKeyIndicator([]byte{1}))
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, MasterKeyIndicator([]byte{1}), SRTPReplayProtection(64),
+				UniformDecryptFailures(func(err error, _ bool) {
+					failures = append(failures, err)
+				}))
+			assert.NoError(t, err)
+
+			rtpPacket, err := (&rtp.Packet{
+				Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 1}, Payload: rtpTestCaseDecrypted(),
+			}).Marshal()
+			assert.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTP(nil, rtpPacket, nil)
+			assert.NoError(t, err)
+
+			authTagLen, err := profile.AuthTagRTPLen()
+			assert.NoError(t, err)
+			forged := append([]byte{}, encrypted...)
+			forged[12] ^= 0x01
+			unknownMKI := append([]byte{}, encrypted...)
+			unknownMKI[len(unknownMKI)-authTagLen-1] ^= 0x01
+			for _, packet := range [][]byte{forged, unknownMKI, encrypted[:14]} {
+				_, err = decryptCtx.DecryptRTP(nil, packet, nil)
+				assert.Equal(t, ErrDecryptFailed, err)
+			}
+			if assert.Len(t, failures, 3) {
+				assert.ErrorIs(t, failures[0], ErrFailedToVerifyAuthTag)
+				assert.ErrorIs(t, failures[1], ErrMKINotFound)
+				// MKI of too short packet may not be found, depending on the length of auth tag.
+				assert.True(t, errors.Is(failures[2], errTooShortRTP) || errors.Is(failures[2], ErrMKINotFound))
+			}
+
+			// Other errors are returned as they are.
+			_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			encrypted, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			forged = append([]byte{}, encrypted...)
+			forged[8] ^= 0x01
+			for _, packet := range [][]byte{forged, encrypted[:9]} {
+				_, err = decryptCtx.DecryptRTCP(nil, packet, nil)
+				assert.Equal(t, ErrDecryptFailed, err)
+			}
+			assert.Len(t, failures, 5)
+
+			// Packets too short to hold a header fail the same way.
+			_, err = decryptCtx.DecryptRTP(nil, encrypted[:4], nil)
+			assert.Equal(t, ErrDecryptFailed, err)
+			_, err = decryptCtx.DecryptRTCP(nil, encrypted[:2], nil)
+			assert.Equal(t, ErrDecryptFailed, err)
+			_, err = NewConcurrentContext(decryptCtx).DecryptRTP(nil, encrypted[:4], nil)
+			assert.Equal(t, ErrDecryptFailed, err)
+			if assert.Len(t, failures, 8) {
+				assert.ErrorIs(t, failures[5], errMalformedHeader)
+				assert.ErrorIs(t, failures[6], errMalformedHeader)
+			}
+		})
+	}
+}
+
+// TestConstantTimeTagComparison checks that auth tags are never compared with functions which return
+// early on the first difference, and that HMAC tags of SRTP and SRTCP packets are compared in constant time.
+func TestConstantTimeTagComparison(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	fset := token.NewFileSet()
+	constantTimeFuncs := map[string]bool{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, errParse := parser.ParseFile(fset, file, nil, 0)
+		require.NoError(t, errParse)
+
+		for _, decl := range parsed.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				switch callName(call) {
+				case "subtle.ConstantTimeCompare":
+					constantTimeFuncs[fn.Name.Name] = true
+				case "bytes.Equal", "bytes.Compare", "reflect.DeepEqual":
+					for _, arg := range call.Args {
+						assert.NotContains(t, strings.ToLower(exprString(arg)), "tag",
+							"%s: auth tag compared in variable time", fset.Position(call.Pos()))
+					}
+				}
+
+				return true
+			})
+		}
+	}
+
+	// RTP, RTCP and truncated GCM tag paths.
+	for _, fn := range []string{"authenticateRTP", "decryptRTCP", "Open"} {
+		assert.True(t, constantTimeFuncs[fn], "%s does not use constant time comparison", fn)
+	}
+}
+
+func callName(call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+
+	return exprString(sel)
+}
+
+func exprString(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return exprString(expr.X) + "." + expr.Sel.Name
+	case *ast.SliceExpr:
+		return exprString(expr.X)
+	case *ast.IndexExpr:
+		return exprString(expr.X)
+	case *ast.CallExpr:
+		return exprString(expr.Fun)
+	default:
+		return ""
+	}
+}
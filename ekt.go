@@ -0,0 +1,342 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	ektMsgTypeShort = 0x00
+	ektMsgTypeFull  = 0x02
+
+	// Length of SPI, EKTMsgLength and Message Type fields of Full EKT Field.
+	ektFullFieldTrailerLen = 5
+	// Length of SRTPMasterKeyLength, SSRC and ROC fields of EKTPlaintext.
+	ektPlaintextOverhead = 9
+
+	aesKeyWrapBlockLen = 8
+	aesKeyWrapRounds   = 6
+)
+
+// aesKeyWrapPadIV is alternative initial value from RFC 5649 section 3.
+var aesKeyWrapPadIV = []byte{0xa6, 0x59, 0x59, 0xa6} // nolint:gochecknoglobals
+
+// EKTKey is a key used by Encrypted Key Transport from RFC 8870 to protect SRTP master keys
+// sent in-band with SRTP packets. It is usually delivered with DTLS-SRTP EKTKey message.
+type EKTKey struct {
+	// SPI (Security Parameter Index) identifies the key in Full EKT Fields.
+	SPI uint16
+	// Key is AESKW128 or AESKW256 key, 16 or 32 bytes long.
+	Key []byte
+	// MasterSalt is SRTP master salt used together with SRTP master keys received with the key.
+	MasterSalt []byte
+}
+
+// EKTPlaintext is content of EKTCiphertext of Full EKT Field, see RFC 8870 section 4.1.
+type EKTPlaintext struct {
+	MasterKey []byte
+	SSRC      uint32
+	ROC       uint32
+}
+
+// EKTField is EKT Field appended to SRTP packet, see RFC 8870 section 4.1. Short EKT Field
+// has no SPI and ciphertext.
+type EKTField struct {
+	Full       bool
+	SPI        uint16
+	Ciphertext []byte
+}
+
+// Marshal returns binary representation of the EKT Field.
+func (f EKTField) Marshal() ([]byte, error) {
+	if !f.Full {
+		return []byte{ektMsgTypeShort}, nil
+	}
+
+	fieldLen := len(f.Ciphertext) + ektFullFieldTrailerLen
+	if fieldLen > 0xffff {
+		return nil, errInvalidEKTField
+	}
+	out := make([]byte, fieldLen)
+	n := copy(out, f.Ciphertext)
+	binary.BigEndian.PutUint16(out[n:], f.SPI)
+	binary.BigEndian.PutUint16(out[n+2:], uint16(fieldLen)) //nolint:gosec // G115
+	out[n+4] = ektMsgTypeFull
+
+	return out, nil
+}
+
+// ParseEKTField parses EKT Field at the end of SRTP packet. It returns the field and its length.
+// Ciphertext of returned field refers to the packet.
+func ParseEKTField(packet []byte) (EKTField, int, error) {
+	if len(packet) == 0 {
+		return EKTField{}, 0, errInvalidEKTField
+	}
+
+	switch packet[len(packet)-1] {
+	case ektMsgTypeShort:
+		return EKTField{}, 1, nil
+	case ektMsgTypeFull:
+		if len(packet) < ektFullFieldTrailerLen {
+			return EKTField{}, 0, errInvalidEKTField
+		}
+		trailer := packet[len(packet)-ektFullFieldTrailerLen:]
+		fieldLen := int(binary.BigEndian.Uint16(trailer[2:]))
+		if fieldLen <= ektFullFieldTrailerLen || fieldLen > len(packet) {
+			return EKTField{}, 0, errInvalidEKTField
+		}
+
+		return EKTField{
+			Full:       true,
+			SPI:        binary.BigEndian.Uint16(trailer),
+			Ciphertext: packet[len(packet)-fieldLen : len(packet)-ektFullFieldTrailerLen],
+		}, fieldLen, nil
+	default:
+		return EKTField{}, 0, fmt.Errorf("%w: unknown message type %d", errInvalidEKTField, packet[len(packet)-1])
+	}
+}
+
+// Encrypt returns EKTCiphertext with given plaintext, encrypted with AES Key Wrap with Padding.
+func (k EKTKey) Encrypt(plaintext EKTPlaintext) ([]byte, error) {
+	if len(plaintext.MasterKey) > 0xff {
+		return nil, errInvalidEKTField
+	}
+
+	buf := make([]byte, 1+len(plaintext.MasterKey)+8)
+	buf[0] = byte(len(plaintext.MasterKey))
+	n := 1 + copy(buf[1:], plaintext.MasterKey)
+	binary.BigEndian.PutUint32(buf[n:], plaintext.SSRC)
+	binary.BigEndian.PutUint32(buf[n+4:], plaintext.ROC)
+
+	block, err := newEKTBlockCipher(k.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return aesKeyWrapWithPadding(block, buf), nil
+}
+
+// Decrypt verifies and decrypts EKTCiphertext.
+func (k EKTKey) Decrypt(ciphertext []byte) (EKTPlaintext, error) {
+	block, err := newEKTBlockCipher(k.Key)
+	if err != nil {
+		return EKTPlaintext{}, err
+	}
+	buf, err := aesKeyUnwrapWithPadding(block, ciphertext)
+	if err != nil {
+		return EKTPlaintext{}, err
+	}
+	if len(buf) < ektPlaintextOverhead || int(buf[0]) != len(buf)-ektPlaintextOverhead {
+		return EKTPlaintext{}, errInvalidEKTField
+	}
+
+	n := 1 + int(buf[0])
+
+	return EKTPlaintext{
+		MasterKey: buf[1:n],
+		SSRC:      binary.BigEndian.Uint32(buf[n:]),
+		ROC:       binary.BigEndian.Uint32(buf[n+4:]),
+	}, nil
+}
+
+// aesKeyWrapWithPadding implements AES Key Wrap with Padding from RFC 5649.
+func aesKeyWrapWithPadding(block cipher.Block, plaintext []byte) []byte {
+	blocks := (len(plaintext) + aesKeyWrapBlockLen - 1) / aesKeyWrapBlockLen
+	out := make([]byte, aesKeyWrapBlockLen*(blocks+1))
+	copy(out, aesKeyWrapPadIV)
+	binary.BigEndian.PutUint32(out[4:], uint32(len(plaintext))) //nolint:gosec // G115
+	copy(out[aesKeyWrapBlockLen:], plaintext)
+
+	if blocks == 1 {
+		block.Encrypt(out, out)
+
+		return out
+	}
+
+	var buf [aes.BlockSize]byte
+	for j := range aesKeyWrapRounds {
+		for i := 1; i <= blocks; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[8*i:8*i+8])
+			block.Encrypt(buf[:], buf[:])
+			t := uint64(blocks*j + i) //nolint:gosec // G115
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[8*i:], buf[8:])
+		}
+	}
+
+	return out
+}
+
+// aesKeyUnwrapWithPadding implements AES Key Unwrap with Padding from RFC 5649.
+func aesKeyUnwrapWithPadding(block cipher.Block, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 2*aesKeyWrapBlockLen || len(ciphertext)%aesKeyWrapBlockLen != 0 {
+		return nil, errEKTCiphertextAuth
+	}
+
+	blocks := len(ciphertext)/aesKeyWrapBlockLen - 1
+	out := make([]byte, len(ciphertext))
+	if blocks == 1 {
+		block.Decrypt(out, ciphertext)
+	} else {
+		copy(out, ciphertext)
+		var buf [aes.BlockSize]byte
+		for j := aesKeyWrapRounds - 1; j >= 0; j-- {
+			for i := blocks; i >= 1; i-- {
+				t := uint64(blocks*j + i) //nolint:gosec // G115
+				binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+				copy(buf[8:], out[8*i:8*i+8])
+				block.Decrypt(buf[:], buf[:])
+				copy(out[:8], buf[:8])
+				copy(out[8*i:], buf[8:])
+			}
+		}
+	}
+
+	// Verify the alternative initial value, message length indicator and padding.
+	if subtle.ConstantTimeCompare(out[:4], aesKeyWrapPadIV) != 1 {
+		return nil, errEKTCiphertextAuth
+	}
+	plaintextLen := int(binary.BigEndian.Uint32(out[4:8]))
+	if plaintextLen <= aesKeyWrapBlockLen*(blocks-1) || plaintextLen > aesKeyWrapBlockLen*blocks {
+		return nil, errEKTCiphertextAuth
+	}
+	plaintext := out[aesKeyWrapBlockLen:]
+	for _, b := range plaintext[plaintextLen:] {
+		if b != 0 {
+			return nil, errEKTCiphertextAuth
+		}
+	}
+
+	return plaintext[:plaintextLen], nil
+}
+
+func newEKTBlockCipher(key []byte) (cipher.Block, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, fmt.Errorf("%w: %d", errInvalidEKTKeyLength, len(key))
+	}
+
+	return aes.NewCipher(key)
+}
+
+// ektReceiveState keeps cipher created for SRTP master key received in Full EKT Field.
+type ektReceiveState struct {
+	masterKey []byte
+	cipher    srtpCipher
+}
+
+// AddEKTKey adds EKT key used for decrypting Full EKT Fields of received SRTP packets. Key with
+// the same SPI is replaced. EKT must be enabled with EncryptedKeyTransport option.
+// Operation is not thread-safe, you need to provide synchronization with decrypting packets.
+func (c *Context) AddEKTKey(key EKTKey) error {
+	if c.ektKeys == nil {
+		return errEKTIsNotEnabled
+	}
+	if _, err := newEKTBlockCipher(key.Key); err != nil {
+		return err
+	}
+	c.ektKeys[key.SPI] = key
+
+	return nil
+}
+
+// RemoveEKTKey removes EKT key with given SPI. Key used for sending cannot be removed.
+// Operation is not thread-safe, you need to provide synchronization with decrypting packets.
+func (c *Context) RemoveEKTKey(spi uint16) error {
+	if _, ok := c.ektKeys[spi]; !ok {
+		return ErrEKTKeyNotFound
+	}
+	if spi == c.ektSendKey.SPI {
+		return errEKTKeyAlreadyInUse
+	}
+	delete(c.ektKeys, spi)
+
+	return nil
+}
+
+// appendEKTField appends EKT Field to encrypted SRTP packet. Full EKT Field is added to the first
+// packet of each SSRC, and then every ektFullFieldInterval packets; other packets get Short EKT Field.
+func (c *Context) appendEKTField(packet []byte, state *srtpSSRCState, ssrc, roc uint32) ([]byte, error) {
+	field := EKTField{}
+	if c.ektFullFieldInterval == 0 || state.ektPacketCount%uint64(c.ektFullFieldInterval) == 0 {
+		ciphertext, err := c.ektSendKey.Encrypt(EKTPlaintext{MasterKey: c.ektMasterKey, SSRC: ssrc, ROC: roc})
+		if err != nil {
+			return nil, err
+		}
+		field = EKTField{Full: true, SPI: c.ektSendKey.SPI, Ciphertext: ciphertext}
+	}
+	state.ektPacketCount++
+
+	encoded, err := field.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(packet, encoded...), nil
+}
+
+// stripEKTField removes EKT Field from received SRTP packet. When the packet has Full EKT Field
+// for its SSRC, it returns decrypted EKT plaintext and the cipher for the master key from it.
+func (c *Context) stripEKTField(packet []byte, ssrc uint32) ([]byte, *EKTPlaintext, srtpCipher, error) {
+	field, fieldLen, err := ParseEKTField(packet)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	packet = packet[:len(packet)-fieldLen]
+	if !field.Full {
+		return packet, nil, nil, nil
+	}
+
+	key, ok := c.ektKeys[field.SPI]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %d", ErrEKTKeyNotFound, field.SPI)
+	}
+	plaintext, err := key.Decrypt(field.Ciphertext)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if plaintext.SSRC != ssrc {
+		// RFC 8870 section 4.3.2: EKT Field with other SSRC is ignored.
+		return packet, nil, nil, nil
+	}
+
+	if state, ok := c.ektReceiveStates[ssrc]; ok && bytes.Equal(state.masterKey, plaintext.MasterKey) {
+		return packet, &plaintext, state.cipher, nil
+	}
+	if bytes.Equal(c.ektMasterKey, plaintext.MasterKey) {
+		return packet, &plaintext, c.cipher, nil
+	}
+	keyCipher, err := c.createCipher(c.profile, c.sendMKI, plaintext.MasterKey, key.MasterSalt,
+		c.encryptSRTP, c.encryptSRTCP)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return packet, &plaintext, keyCipher, nil
+}
+
+// updateEKTReceiveState remembers cipher used to authenticate packet with Full EKT Field.
+func (c *Context) updateEKTReceiveState(ssrc uint32, masterKey []byte, keyCipher srtpCipher) {
+	if keyCipher == c.cipher {
+		delete(c.ektReceiveStates, ssrc)
+
+		return
+	}
+	if state, ok := c.ektReceiveStates[ssrc]; !ok || !bytes.Equal(state.masterKey, masterKey) {
+		c.emitKeyEvent(KeyEvent{
+			Type: KeyReady, Source: KeySourceEKT, Profile: c.profile,
+			Keys: SessionKeys{RemoteMasterKey: append([]byte{}, masterKey...)}, HasSSRC: true, SSRC: ssrc,
+		})
+	}
+	if c.ektReceiveStates == nil {
+		c.ektReceiveStates = map[uint32]*ektReceiveState{}
+	}
+	c.ektReceiveStates[ssrc] = &ektReceiveState{masterKey: append([]byte{}, masterKey...), cipher: keyCipher}
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"crypto/aes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAESKeyWrapWithPadding(t *testing.T) {
+	// RFC 5649 section 6.
+	kek := fromHex(t, `5840df6e29b02af1 ab493b705bf16ea1 ae8338f4dcc176a8`)
+	block, err := aes.NewCipher(kek)
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		plaintext, ciphertext []byte
+	}{
+		{
+			plaintext:  fromHex(t, `c37b7e6492584340 bed1220780894115 5068f738`),
+			ciphertext: fromHex(t, `138bdeaa9b8fa7fc 61f97742e72248ee 5ae6ae5360d1ae6a 5f54f373fa543b6a`),
+		},
+		{
+			plaintext:  fromHex(t, `466f7250617369`),
+			ciphertext: fromHex(t, `afbeb0f07dfbf541 9200f2ccb50bb24f`),
+		},
+	} {
+		assert.Equal(t, test.ciphertext, aesKeyWrapWithPadding(block, test.plaintext))
+
+		plaintext, err := aesKeyUnwrapWithPadding(block, test.ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, test.plaintext, plaintext)
+
+		test.ciphertext[0] ^= 0x01
+		_, err = aesKeyUnwrapWithPadding(block, test.ciphertext)
+		assert.ErrorIs(t, err, errEKTCiphertextAuth)
+	}
+
+	_, err = aesKeyUnwrapWithPadding(block, make([]byte, 12))
+	assert.ErrorIs(t, err, errEKTCiphertextAuth)
+}
+
+func TestEKTField(t *testing.T) {
+	key := EKTKey{SPI: 0x1234, Key: make([]byte, 16)}
+	plaintext := EKTPlaintext{MasterKey: make([]byte, 16), SSRC: 0xcafebabe, ROC: 7}
+	ciphertext, err := key.Encrypt(plaintext)
+	assert.NoError(t, err)
+
+	full, err := EKTField{Full: true, SPI: key.SPI, Ciphertext: ciphertext}.Marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, len(ciphertext)+5, len(full))
+
+	packet := append([]byte{0x80, 0x60}, full...)
+	field, n, err := ParseEKTField(packet)
+	assert.NoError(t, err)
+	assert.Equal(t, len(full), n)
+	assert.True(t, field.Full)
+	assert.Equal(t, key.SPI, field.SPI)
+
+	decrypted, err := key.Decrypt(field.Ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	short, err := EKTField{}.Marshal()
+	assert.NoError(t, err)
+	field, n, err = ParseEKTField(append(packet, short...))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.False(t, field.Full)
+
+	for _, invalid := range [][]byte{
+		{},
+		{0x01},
+		{0x00, 0x02},
+		{0x00, 0x00, 0x00, 0x05, 0x02},
+		{0x00, 0x00, 0x00, 0x10, 0x02},
+	} {
+		_, _, err = ParseEKTField(invalid)
+		assert.ErrorIs(t, err, errInvalidEKTField)
+	}
+
+	_, err = EKTKey{Key: make([]byte, 24)}.Encrypt(plaintext)
+	assert.ErrorIs(t, err, errInvalidEKTKeyLength)
+}
+
+func TestContextEKT(t *testing.T) {
+	profile := ProtectionProfileAes128CmHmacSha1_80
+	senderKey := make([]byte, 16)
+	for i := range senderKey {
+		senderKey[i] = byte(i)
+	}
+	salt := make([]byte, 14)
+	ektKey := EKTKey{SPI: 1, Key: make([]byte, 16), MasterSalt: salt}
+
+	encryptCtx, err := CreateContext(senderKey, salt, profile, EncryptedKeyTransport(ektKey, 2))
+	assert.NoError(t, err)
+	// Receiver has its own master key, sender's one is learned from EKT.
+	decryptCtx, err := CreateContext(make([]byte, 16), salt, profile, EncryptedKeyTransport(ektKey, 2))
+	assert.NoError(t, err)
+
+	const ssrc = 0xcafebabe
+	encryptCtx.SetROC(ssrc, 5)
+
+	for seq := range uint16(4) {
+		packet := &rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: seq, SSRC: ssrc},
+			Payload: []byte{0x01, 0x02, 0x03, 0x04},
+		}
+		plaintext, errMarshal := packet.Marshal()
+		assert.NoError(t, errMarshal)
+
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, plaintext, nil)
+		assert.NoError(t, errEncrypt)
+		field, _, errParse := ParseEKTField(encrypted)
+		assert.NoError(t, errParse)
+		assert.Equal(t, seq%2 == 0, field.Full)
+
+		decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, errDecrypt)
+		assert.Equal(t, plaintext, decrypted)
+	}
+
+	roc, ok := decryptCtx.ROC(ssrc)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(5), roc)
+
+	// Packets without EKT Field are rejected.
+	plaintext, err := (&rtp.Packet{Header: rtp.Header{Version: 2, SequenceNumber: 10, SSRC: ssrc}}).Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, plaintext, nil)
+	assert.NoError(t, err)
+	encrypted[len(encrypted)-1] = 0x01
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, errInvalidEKTField)
+
+	// Unknown SPI.
+	otherCtx, err := CreateContext(senderKey, salt, profile,
+		EncryptedKeyTransport(EKTKey{SPI: 2, Key: make([]byte, 16)}, 0))
+	assert.NoError(t, err)
+	otherCtx.SetROC(ssrc, 5)
+	encrypted, err = otherCtx.EncryptRTP(nil, plaintext, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, ErrEKTKeyNotFound)
+
+	assert.NoError(t, decryptCtx.AddEKTKey(EKTKey{SPI: 2, Key: make([]byte, 16), MasterSalt: salt}))
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, decryptCtx.RemoveEKTKey(1), errEKTKeyAlreadyInUse)
+	assert.NoError(t, decryptCtx.RemoveEKTKey(2))
+	assert.ErrorIs(t, decryptCtx.RemoveEKTKey(2), ErrEKTKeyNotFound)
+}
+
+func TestContextEKTErrors(t *testing.T) {
+	profile := ProtectionProfileAes128CmHmacSha1_80
+	ctx, err := CreateContext(make([]byte, 16), make([]byte, 14), profile)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, ctx.AddEKTKey(EKTKey{Key: make([]byte, 16)}), errEKTIsNotEnabled)
+
+	_, err = CreateContext(make([]byte, 16), make([]byte, 14), profile,
+		EncryptedKeyTransport(EKTKey{Key: make([]byte, 8)}, 0))
+	assert.ErrorIs(t, err, errInvalidEKTKeyLength)
+
+	_, err = CreateContext(make([]byte, 16), make([]byte, 14), profile,
+		EncryptedKeyTransport(EKTKey{Key: make([]byte, 16)}, 0), MasterKeyIndicator([]byte{1}))
+	assert.ErrorIs(t, err, errEKTWithMKI)
+}
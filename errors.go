@@ -13,17 +13,70 @@ var (
 	ErrFailedToVerifyAuthTag = errors.New("failed to verify auth tag")
 	// ErrMKINotFound is returned when decryption fails due to unknown MKI value in packet.
 	ErrMKINotFound = errors.New("MKI not found")
+	// ErrTimestampRegression is returned together with decrypted packet when RTP timestamp went backward
+	// more than allowed by Context.SetTimestampGuard.
+	ErrTimestampRegression = errors.New("RTP timestamp regression")
+	// ErrSSRCBlacklisted is returned when decryption fails because the SSRC is temporarily
+	// blacklisted due to too many authentication failures. See SRTPAuthFailureLimit option.
+	ErrSSRCBlacklisted = errors.New("SSRC is temporarily blacklisted")
+	// ErrTooManySSRCs is returned when a packet of a new SSRC is rejected, because the Context keeps
+	// the maximum number of states set by SSRCStateLimit option, and cannot remember indexes of more
+	// evicted SSRCs.
+	ErrTooManySSRCs = errors.New("too many SSRCs")
+	// ErrUnsupportedProfile is returned when Context is created with unknown protection profile.
+	ErrUnsupportedProfile = errors.New("unsupported SRTP protection profile")
+	// ErrCipherTimeout is returned when custom cipher does not finish encryption or decryption
+	// within the time set by CipherTimeout option.
+	ErrCipherTimeout = errors.New("cipher operation timed out")
+	// ErrTooManyRollovers is returned when ROC of SRTP packet exceeds limit set by Context.SetMaxRollovers.
+	ErrTooManyRollovers = errors.New("too many ROC rollovers")
+	// ErrSRTCPEncryptionFlagMismatch is returned when E-flag of received SRTCP packet does not match
+	// configuration of the Context. See SRTCPStrictEncryptionFlag option.
+	ErrSRTCPEncryptionFlagMismatch = errors.New("SRTCP encryption flag mismatch")
+	// ErrSRTCPNotEncrypted is returned when received SRTCP packet has E-flag cleared, and such packets are
+	// not accepted. See SRTCPAcceptUnencrypted option.
+	ErrSRTCPNotEncrypted = errors.New("SRTCP packet is not encrypted")
+	// ErrEKTKeyNotFound is returned when decryption fails due to unknown SPI in Full EKT Field.
+	ErrEKTKeyNotFound = errors.New("EKT key not found")
+	// ErrKeyLifetimeExceeded is returned when decryption fails because index of SRTP packet is outside
+	// of lifetime of the key selected by MKI. See Context.AddReceiveKey.
+	ErrKeyLifetimeExceeded = errors.New("packet index outside of key lifetime")
+	// ErrTruncatedAuthTag is returned when SRTP packet would be protected with authentication tag
+	// shorter than 80 bits, and this is disallowed by SRTPTruncatedAuthTagPolicy option.
+	ErrTruncatedAuthTag = errors.New("truncated SRTP auth tag is not allowed")
+	// ErrMalformedRTCP is returned when decrypted SRTCP packet is not a well-formed RTCP compound packet.
+	// See SRTCPValidateCompound option.
+	ErrMalformedRTCP = errors.New("malformed RTCP compound packet")
+	// ErrNotFIPSApproved is returned when RequireFIPS option is used, and the Context is configured
+	// with protection profile or option which is not allowed in FIPS mode.
+	ErrNotFIPSApproved = errors.New("not allowed in FIPS mode")
+	// ErrSRTCPIndexExhausted is returned when SRTCP packet cannot be encrypted, because all 2^31 SRTCP
+	// indexes of its SSRC were used with the current key. See SRTCPIndexRolloverOnRekey option.
+	ErrSRTCPIndexExhausted = errors.New("SRTCP index exhausted")
+	// ErrReadStreamBufferFull is reported by Config.OnDecryptError when received packet is dropped because
+	// buffer of its read stream is full, i.e. the stream is not read fast enough. See Config.ReadStreamBufferSize.
+	ErrReadStreamBufferFull = errors.New("read stream buffer is full")
+	// ErrDecryptFailed is returned instead of errors of authentication failures, unknown MKIs, too short
+	// packets and malformed headers when UniformDecryptFailures option is used.
+	ErrDecryptFailed = errors.New("failed to decrypt packet")
+	// ErrPacketLimitExceeded is returned when received packet exceeds a limit set by StrictParsing option.
+	ErrPacketLimitExceeded = errors.New("packet exceeds parsing limit")
 
 	errDuplicated                    = errors.New("duplicated packet")
 	errShortSrtpMasterKey            = errors.New("SRTP master key is not long enough")
 	errShortSrtpMasterSalt           = errors.New("SRTP master salt is not long enough")
 	errNoSuchSRTPProfile             = errors.New("no such SRTP Profile")
-	errNonZeroKDRNotSupported        = errors.New("indexOverKdr > 0 is not supported yet")
+	errInvalidKeyDerivationRate      = errors.New("invalid key derivation rate")
+	errInvalidDerivedKeyLength       = errors.New("key derivation function returned key of invalid length")
+	errExternalKDFNotSupported       = errors.New("external key derivation is not supported with this configuration")
+	errExternalKDFMasterKey          = errors.New("master key and salt must be empty with external key derivation")
 	errExporterWrongLabel            = errors.New("exporter called with wrong label")
 	errNoConfig                      = errors.New("no config provided")
 	errNoConn                        = errors.New("no conn provided")
 	errTooShortRTP                   = errors.New("packet is too short to be RTP packet")
 	errTooShortRTCP                  = errors.New("packet is too short to be RTCP packet")
+	errNilRTPHeader                  = errors.New("RTP header is nil")
+	errMalformedHeader               = errors.New("malformed packet header")
 	errPayloadDiffers                = errors.New("payload differs")
 	errStartedChannelUsedIncorrectly = errors.New("started channel used incorrectly, should only be closed")
 	errBadIVLength                   = errors.New("bad iv length in xorBytesCTR")
@@ -33,6 +86,7 @@ var (
 	errInvalidMKILength              = errors.New("invalid MKI length")
 	errTooLongSRTPAuthTag            = errors.New("SRTP auth tag is too long")
 	errTooShortSRTPAuthTag           = errors.New("SRTP auth tag is too short")
+	errInvalidParsingLimits          = errors.New("parsing limits must not be negative")
 
 	errStreamNotInited     = errors.New("stream has not been inited, unable to close")
 	errStreamAlreadyClosed = errors.New("stream is already closed")
@@ -46,6 +100,55 @@ var (
 	errHeaderLengthMismatch         = errors.New("header length mismatch")
 	errUnencryptedHeaderExtAndCSRCs = errors.New("unencrypted header extensions and CSRCs are not allowed")
 	errCryptexDisabled              = errors.New("cryptex is disabled")
+
+	errSharedCipherConfigMismatch = errors.New("context options do not match shared cipher configuration")
+	errInvalidAuthFailureLimit    = errors.New("auth failure window and cooldown must be positive")
+	errInvalidSRTCPIndex          = errors.New("invalid SRTCP index")
+	errSRTCPIndexReused           = errors.New("SRTCP index already used")
+	errInvalidGCMMasterSaltLength = errors.New("invalid GCM master salt length")
+	errResignCryptex              = errors.New("packets encrypted with cryptex cannot be resigned")
+	errResignAEAD                 = errors.New("packets of AEAD profiles cannot be resigned")
+	errUnexpectedSSRC             = errors.New("unexpected SSRC")
+	errKeystreamExportDisabled    = errors.New("keystream export is disabled")
+	errKeystreamNotAvailable      = errors.New("keystream is not available when SRTP encryption is disabled")
+	errSSRCStateNotFound          = errors.New("no state for SSRC")
+	errInvalidCompactState        = errors.New("invalid compact state")
+	errInvalidState               = errors.New("invalid context state")
+	errMasterKeyUnknown           = errors.New("master key of the Context is not known")
+	errKeySealerRequired          = errors.New("KeySealer is required to open sealed state")
+	errInvalidRepairStream        = errors.New("invalid repair stream association")
+	errFrameTooLarge              = errors.New("packet is too large for RFC 4571 frame")
+	errSessionClosed              = errors.New("session is closed")
+	errRewrittenIndexUsed         = errors.New("rewritten sequence number was already used in the target stream")
+	errInvalidRTPVersion          = errors.New("invalid RTP version")
+	errInvalidOHB                 = errors.New("invalid original header block")
+	errDoubleAEADCryptex          = errors.New("cryptex is not supported with double AEAD profiles")
+	errInvalidEKTField            = errors.New("invalid EKT field")
+	errInvalidEKTKeyLength        = errors.New("invalid EKT key length")
+	errEKTCiphertextAuth          = errors.New("failed to verify EKT ciphertext")
+	errEKTIsNotEnabled            = errors.New("EKT is not enabled")
+	errEKTKeyAlreadyInUse         = errors.New("EKT key already in use")
+	errEKTWithMKI                 = errors.New("EKT cannot be used with MKI")
+	errInvalidKeyLifetime         = errors.New("invalid key lifetime")
+	errMKIAmbiguous               = errors.New("MKI is a suffix of another MKI")
+	errInvalidCryptoAttribute     = errors.New("invalid SDES crypto attribute")
+	errInvalidReplayWindowSize    = errors.New("replay protection window size must be from 1 to 32768")
+	errSDESProfileMismatch        = errors.New("SDES crypto attributes use different protection profiles")
+	errUnsupportedSessionParam    = errors.New("unsupported SDES session parameter")
+	errRTPHeaderLengthMismatch    = errors.New("RTP header length does not match header bytes")
+	errInvalidSSRCStateLimit      = errors.New("SSRC state limit and idle timeout must not be negative")
+	errConformanceTestingDisabled = errors.New("conformance testing is disabled")
+	errConformanceNotSupported    = errors.New("conformance testing is not supported by the cipher")
+
+	errCustomCipherOptionNotSupported = errors.New("operation is not supported with custom cipher")
+
+	errRTPHeaderMismatch    = errors.New("SSRC or sequence number does not match RTP header")
+	errParsedHeaderRequired = errors.New(
+		"parsed RTP header is required with cryptex or header extension encryption",
+	)
+	errHeaderExtensionEncryptionNotSupported = errors.New(
+		"header extension encryption is supported only for AES-CM profiles without cryptex",
+	)
 )
 
 type duplicatedError struct {
@@ -61,3 +164,60 @@ func (e *duplicatedError) Error() string {
 func (e *duplicatedError) Unwrap() error {
 	return errDuplicated
 }
+
+type timestampRegressionError struct {
+	SSRC          uint32
+	Timestamp     uint32
+	LastTimestamp uint32
+}
+
+func (e *timestampRegressionError) Error() string {
+	return fmt.Sprintf("srtp ssrc=%d timestamp=%d last=%d: %v",
+		e.SSRC, e.Timestamp, e.LastTimestamp, ErrTimestampRegression)
+}
+
+func (e *timestampRegressionError) Unwrap() error {
+	return ErrTimestampRegression
+}
+
+type srtcpIndexExhaustedError struct {
+	SSRC uint32
+}
+
+func (e *srtcpIndexExhaustedError) Error() string {
+	return fmt.Sprintf("srtcp ssrc=%d: %v", e.SSRC, ErrSRTCPIndexExhausted)
+}
+
+// Unwrap returns errExceededMaxPackets too, which is returned when SRTP packet index is exhausted.
+func (e *srtcpIndexExhaustedError) Unwrap() []error {
+	return []error{ErrSRTCPIndexExhausted, errExceededMaxPackets}
+}
+
+type readStreamBufferFullError struct {
+	Proto string // srtp or srtcp
+	SSRC  uint32
+	Size  int // size of dropped packet
+}
+
+func (e *readStreamBufferFullError) Error() string {
+	return fmt.Sprintf("%s ssrc=%d size=%d: %v", e.Proto, e.SSRC, e.Size, ErrReadStreamBufferFull)
+}
+
+func (e *readStreamBufferFullError) Unwrap() error {
+	return ErrReadStreamBufferFull
+}
+
+type packetLimitError struct {
+	Proto string // srtp or srtcp
+	Limit string // name of exceeded limit
+	Value int
+	Max   int
+}
+
+func (e *packetLimitError) Error() string {
+	return fmt.Sprintf("%s %s=%d max=%d: %v", e.Proto, e.Limit, e.Value, e.Max, ErrPacketLimitExceeded)
+}
+
+func (e *packetLimitError) Unwrap() error {
+	return ErrPacketLimitExceeded
+}
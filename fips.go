@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import "fmt"
+
+// minFIPSAuthTagLen is the shortest HMAC authentication tag allowed by NIST SP 800-107 (32 bits).
+const minFIPSAuthTagLen = 4
+
+// isFIPSApproved returns true for profiles which use only FIPS approved algorithms: AES in counter
+// or Galois/Counter mode, and HMAC-SHA1.
+func (p ProtectionProfile) isFIPSApproved() bool {
+	switch {
+	case p == ProtectionProfileNullHmacSha1_80, p == ProtectionProfileNullHmacSha1_32, p.isARIA(), p.isSEED(),
+		p.isSM4(), p.isF8():
+		return false
+	default:
+		return p.isSupported()
+	}
+}
+
+// checkFIPS checks that configuration of the Context is allowed by RequireFIPS option.
+func (c *Context) checkFIPS() error {
+	if !c.requireFIPS {
+		return nil
+	}
+
+	var reason string
+	switch {
+	case !c.profile.isFIPSApproved():
+		reason = "profile " + c.profile.String()
+	case !c.encryptSRTP || !c.encryptSRTCP:
+		reason = "disabled encryption"
+	case c.authTagRTPLen != nil && *c.authTagRTPLen < minFIPSAuthTagLen && !c.profile.isAEAD():
+		reason = fmt.Sprintf("%d-byte auth tag", *c.authTagRTPLen)
+	case c.gcmMasterSaltLen != 0:
+		reason = "non-standard GCM master salt length"
+	case c.conformanceTesting:
+		reason = "conformance testing"
+	case c.debugKeystream:
+		reason = "keystream debugging"
+	case c.gcmNonceFunc != nil:
+		reason = "custom GCM nonce source"
+	case c.plaintextPassthrough:
+		reason = "plaintext passthrough"
+	default:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrNotFIPSApproved, reason)
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireFIPS(t *testing.T) {
+	for _, profile := range []ProtectionProfile{
+		ProtectionProfileAes128CmHmacSha1_80,
+		ProtectionProfileAes256CmHmacSha1_32,
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileNullHmacSha1_32,
+		ProtectionProfileAeadAes128Gcm,
+		ProtectionProfileAeadAes256Gcm8,
+		ProtectionProfileDoubleAeadAes128Gcm,
+	} {
+		keyLen, err := profile.KeyLen()
+		assert.NoError(t, err)
+		saltLen, err := profile.SaltLen()
+		assert.NoError(t, err)
+
+		_, err = CreateContext(make([]byte, keyLen), make([]byte, saltLen), profile, RequireFIPS())
+		if profile == ProtectionProfileNullHmacSha1_80 || profile == ProtectionProfileNullHmacSha1_32 {
+			assert.ErrorIs(t, err, ErrNotFIPSApproved, profile)
+		} else {
+			assert.NoError(t, err, profile)
+		}
+	}
+
+	for name, opt := range map[string]ContextOption{
+		"SRTPNoEncryption":            SRTPNoEncryption(),
+		"SRTCPNoEncryption":           SRTCPNoEncryption(),
+		"SRTPAuthenticationTagLength": SRTPAuthenticationTagLength(2),
+		"UnsafeGCMMasterSaltLength":   UnsafeGCMMasterSaltLength(14),
+		"UnsafeDebugKeystream":        UnsafeDebugKeystream(),
+		"UnsafePlaintextPassthrough":  UnsafePlaintextPassthrough(),
+		"UnsafeConformanceTesting":    UnsafeConformanceTesting(),
+		"UnsafeGCMNonceSource":        UnsafeGCMNonceSource(DefaultGCMNonce),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildTestContext(profileCTR, opt)
+			assert.NoError(t, err)
+			_, err = buildTestContext(profileCTR, opt, RequireFIPS())
+			assert.ErrorIs(t, err, ErrNotFIPSApproved)
+		})
+	}
+
+	t.Run("KeySelector", func(t *testing.T) {
+		ctx, err := buildTestContext(profileCTR, RequireFIPS(),
+			SRTPKeySelector(func(*rtp.Header) (SessionKeys, ProtectionProfile, error) {
+				return SessionKeys{RemoteMasterKey: make([]byte, 16), RemoteMasterSalt: make([]byte, 14)},
+					ProtectionProfileNullHmacSha1_80, nil
+			}))
+		assert.NoError(t, err)
+
+		pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: 1}, Payload: make([]byte, 20)}
+		raw, err := pkt.Marshal()
+		assert.NoError(t, err)
+		_, err = ctx.DecryptRTP(nil, raw, nil)
+		assert.ErrorIs(t, err, ErrNotFIPSApproved)
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		encryptCtx, err := buildTestContext(profileCTR, RequireFIPS(), SRTPEncryptedHeaderExtensions(1))
+		assert.NoError(t, err)
+		decryptCtx, err := buildTestContext(profileCTR, SRTPEncryptedHeaderExtensions(1))
+		assert.NoError(t, err)
+
+		pkt := &rtp.Packet{Header: rtp.Header{SSRC: 1, SequenceNumber: 1}, Payload: []byte{0x00, 0x01}}
+		assert.NoError(t, pkt.Header.SetExtension(1, []byte{0x02, 0x03}))
+		raw, err := pkt.Marshal()
+		assert.NoError(t, err)
+		encrypted, err := encryptCtx.EncryptRTP(nil, raw, nil)
+		assert.NoError(t, err)
+		decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, decrypted)
+
+		rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+		encrypted, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+		assert.NoError(t, err)
+		decrypted, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, rtcpPacket, decrypted)
+	})
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// Framing is framing of packets sent over connection of a session.
+type Framing int
+
+const (
+	// FramingNone is used for packet-oriented connections, e.g. UDP, where each Read and Write
+	// carries a single packet.
+	FramingNone Framing = iota
+	// FramingRFC4571 is used for stream-oriented connections, e.g. TCP or TURN over TCP, where each
+	// packet is prefixed with its 16-bit length, as defined by RFC 4571.
+	FramingRFC4571
+)
+
+const (
+	rfc4571LengthSize   = 2
+	rfc4571MaxFrameSize = 0xffff
+	// rfc4571ReadBufferSize is size of buffer used for reading from the connection, so short frames
+	// do not need many reads.
+	rfc4571ReadBufferSize = 4096
+)
+
+// framedConn returns conn which frames packets as set by Framing.
+func (c *Config) framedConn(conn net.Conn) net.Conn {
+	if c.Framing == FramingRFC4571 {
+		return newRFC4571Conn(conn)
+	}
+
+	return conn
+}
+
+// rfc4571Conn is net.Conn which reads and writes packets over stream-oriented connection framed
+// as defined by RFC 4571.
+type rfc4571Conn struct {
+	net.Conn
+	reader *bufio.Reader
+	header [rfc4571LengthSize]byte
+
+	// Frame is written by a single Write of the connection, so frames written concurrently are not mixed.
+	writeLock sync.Mutex
+	writeBuf  []byte
+}
+
+func newRFC4571Conn(conn net.Conn) *rfc4571Conn {
+	return &rfc4571Conn{Conn: conn, reader: bufio.NewReaderSize(conn, rfc4571ReadBufferSize)}
+}
+
+// Read reads a single frame into b and returns its length. Frames longer than b are truncated, like
+// datagrams read from packet-oriented connections. Empty frames are skipped.
+func (c *rfc4571Conn) Read(b []byte) (int, error) {
+	for {
+		if _, err := io.ReadFull(c.reader, c.header[:]); err != nil {
+			return 0, err
+		}
+		frameLen := int(binary.BigEndian.Uint16(c.header[:]))
+		if frameLen == 0 {
+			continue
+		}
+
+		n := min(frameLen, len(b))
+		if _, err := io.ReadFull(c.reader, b[:n]); err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		if _, err := c.reader.Discard(frameLen - n); err != nil {
+			return 0, unexpectedEOF(err)
+		}
+
+		return n, nil
+	}
+}
+
+// Write writes b as a single frame. It returns errFrameTooLarge when b does not fit in the frame.
+func (c *rfc4571Conn) Write(b []byte) (int, error) {
+	if len(b) > rfc4571MaxFrameSize {
+		return 0, errFrameTooLarge
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.writeBuf = growBufferSize(c.writeBuf, rfc4571LengthSize+len(b))
+	binary.BigEndian.PutUint16(c.writeBuf, uint16(len(b))) //nolint:gosec // G115, checked above
+	copy(c.writeBuf[rfc4571LengthSize:], b)
+
+	n, err := c.Conn.Write(c.writeBuf)
+
+	return max(n-rfc4571LengthSize, 0), err
+}
+
+// unexpectedEOF converts io.EOF returned in the middle of a frame to io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF { //nolint:errorlint // io.EOF is returned unwrapped by readers
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRFC4571Conn(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	aPipe, bPipe := net.Pipe()
+	conn := (&Config{Framing: FramingRFC4571}).framedConn(aPipe)
+
+	// Frames are written one byte at a time, so they have to be reassembled.
+	stream := []byte{
+		0x00, 0x00, // empty frame
+		0x00, 0x03, 0x01, 0x02, 0x03,
+		0x00, 0x05, 0x01, 0x02, 0x03, 0x04, 0x05,
+		0x00, 0x04, 0x01, 0x02, // incomplete frame
+	}
+	go func() {
+		for i := range stream {
+			_, _ = bPipe.Write(stream[i : i+1])
+		}
+		_ = bPipe.Close()
+	}()
+
+	buf := make([]byte, 4)
+	n, err := conn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, buf[:n])
+	// Frame longer than the buffer is truncated.
+	n, err = conn.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, buf[:n])
+	_, err = conn.Read(buf)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	_, err = conn.Read(buf)
+	assert.ErrorIs(t, err, io.EOF)
+
+	aPipe, bPipe = net.Pipe()
+	conn = (&Config{Framing: FramingRFC4571}).framedConn(aPipe)
+	go func() {
+		n, err := conn.Write([]byte{0x01, 0x02, 0x03})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+	}()
+	buf = make([]byte, 10)
+	n, err = io.ReadAtLeast(bPipe, buf, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x03, 0x01, 0x02, 0x03}, buf[:n])
+
+	_, err = conn.Write(make([]byte, 0x10000))
+	assert.ErrorIs(t, err, errFrameTooLarge)
+
+	assert.NoError(t, aPipe.Close())
+	assert.NoError(t, bPipe.Close())
+}
+
+func TestSessionMuxRFC4571(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	session, pipe, config := buildSessionSRTP(t)
+	assert.NoError(t, session.Close())
+	assert.NoError(t, pipe.Close())
+	framedConfig := *config
+	framedConfig.Framing = FramingRFC4571
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, err := listener.Accept()
+		assert.NoError(t, err)
+		accepted <- conn
+	}()
+	aConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	bConn := <-accepted
+	assert.NoError(t, listener.Close())
+
+	aSRTP, aSRTCP, err := NewSessionMux(aConn, &framedConfig)
+	require.NoError(t, err)
+	bSRTP, bSRTCP, err := NewSessionMux(bConn, &framedConfig)
+	require.NoError(t, err)
+
+	// Packets written back to back may be received in a single TCP segment.
+	testPayload := []byte{0x00, 0x01, 0x03, 0x04}
+	rtpWriteStream, err := aSRTP.OpenWriteStream()
+	assert.NoError(t, err)
+	for seq := uint16(1); seq <= 3; seq++ {
+		_, err = rtpWriteStream.WriteRTP(&rtp.Header{Version: 2, SSRC: 5000, SequenceNumber: seq}, testPayload)
+		assert.NoError(t, err)
+	}
+	rtcpPayload, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 5000}})
+	assert.NoError(t, err)
+	rtcpWriteStream, err := aSRTCP.OpenWriteStream()
+	assert.NoError(t, err)
+	_, err = rtcpWriteStream.Write(rtcpPayload)
+	assert.NoError(t, err)
+
+	rtpReadStream, ssrc, err := bSRTP.AcceptStream()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(5000), ssrc)
+	header := &rtp.Header{}
+	readBuffer := make([]byte, 100)
+	for seq := uint16(1); seq <= 3; seq++ {
+		n, err := rtpReadStream.ReadRTPTo(readBuffer, header)
+		assert.NoError(t, err)
+		assert.Equal(t, seq, header.SequenceNumber)
+		assert.Equal(t, testPayload, readBuffer[n-len(testPayload):n])
+	}
+
+	rtcpReadStream, _, err := bSRTCP.AcceptStream()
+	assert.NoError(t, err)
+	n, err := rtcpReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpPayload, readBuffer[:n])
+
+	assert.NoError(t, aSRTP.Close())
+	assert.NoError(t, aSRTCP.Close())
+	assert.NoError(t, bSRTCP.Close())
+	assert.NoError(t, bSRTP.Close())
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	headerExtensionOneByteIDReserved = 15
+	headerExtensionTwoByteMask       = 0xfff0
+	headerExtensionTwoByteProfile    = 0x1000
+)
+
+// headerExtensionEncryption encrypts selected RTP header extensions as defined in RFC 6904.
+// Only header extensions from RFC 8285 are supported. Keystream is generated with AES-CM in the same
+// way as for the payload, but with separate header encryption key and salt. Its bytes correspond to
+// bytes of header extension data, and only data of encrypted extension elements is XORed with it.
+type headerExtensionEncryption struct {
+	block cipher.Block
+	salt  []byte
+	ids   [256]bool
+	fips  bool
+}
+
+func newHeaderExtensionEncryption(
+	profile protectionProfileWithArgs, masterKey, masterSalt []byte, ids []uint8,
+) (*headerExtensionEncryption, error) {
+	key, err := profile.deriveSessionKey(labelSRTPHeaderEncryption, masterKey, masterSalt, len(masterKey))
+	if err != nil {
+		return nil, err
+	}
+
+	encryption := &headerExtensionEncryption{fips: profile.fips}
+	if encryption.block, err = profile.newBlockCipher(key); err != nil {
+		return nil, err
+	}
+	if encryption.salt, err = profile.deriveSessionKey(
+		labelSRTPHeaderSalt, masterKey, masterSalt, len(masterSalt),
+	); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		encryption.ids[id] = true
+	}
+
+	return encryption, nil
+}
+
+// apply encrypts or decrypts selected header extensions of the packet in place.
+func (h *headerExtensionEncryption) apply(packet []byte, header *rtp.Header, headerLen int, roc uint32) error {
+	if !header.Extension {
+		return nil
+	}
+
+	extHeaderPos := 12 + 4*len(header.CSRC)
+	if extHeaderPos+4 > headerLen {
+		return errHeaderLengthMismatch
+	}
+	start := extHeaderPos + 4
+	end := start + 4*int(binary.BigEndian.Uint16(packet[extHeaderPos+2:]))
+	if end > headerLen {
+		return errHeaderLengthMismatch
+	}
+	data := packet[start:end]
+
+	var oneByte bool
+	switch {
+	case header.ExtensionProfile == rtp.ExtensionProfileOneByte:
+		oneByte = true
+	case header.ExtensionProfile&headerExtensionTwoByteMask == headerExtensionTwoByteProfile:
+	default:
+		// RFC 6904 applies to RFC 8285 header extensions only.
+		return nil
+	}
+
+	keystream := make([]byte, len(data))
+	counter := generateCounter(header.SequenceNumber, roc, header.SSRC, h.salt)
+	if err := xorBytesCTR(h.block, counter[:], keystream, keystream, h.fips); err != nil {
+		return err
+	}
+
+	for pos := 0; pos < len(data); {
+		if data[pos] == 0 {
+			// Padding.
+			pos++
+
+			continue
+		}
+
+		var id uint8
+		var dataPos, dataLen int
+		if oneByte {
+			id = data[pos] >> 4
+			if id == headerExtensionOneByteIDReserved {
+				break
+			}
+			dataPos, dataLen = pos+1, int(data[pos]&0x0f)+1
+		} else {
+			if pos+1 >= len(data) {
+				break
+			}
+			id = data[pos]
+			dataPos, dataLen = pos+2, int(data[pos+1])
+		}
+		if dataPos+dataLen > len(data) {
+			return errHeaderLengthMismatch
+		}
+
+		if h.ids[id] {
+			for i := dataPos; i < dataPos+dataLen; i++ {
+				data[i] ^= keystream[i]
+			}
+		}
+		pos = dataPos + dataLen
+	}
+
+	return nil
+}
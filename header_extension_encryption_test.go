@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptedHeaderExtensions(t *testing.T) {
+	for _, profile := range []uint16{rtp.ExtensionProfileOneByte, rtp.ExtensionProfileTwoByte} {
+		t.Run("", func(t *testing.T) {
+			opt := SRTPEncryptedHeaderExtensions(1, 3)
+			encryptCtx, err := buildTestContext(ProtectionProfileAes128CmHmacSha1_80, opt)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(ProtectionProfileAes128CmHmacSha1_80, opt)
+			assert.NoError(t, err)
+
+			packet := &rtp.Packet{
+				Header: rtp.Header{
+					Version:          2,
+					SequenceNumber:   1,
+					SSRC:             0xcafebabe,
+					Extension:        true,
+					ExtensionProfile: profile,
+				},
+				Payload: []byte{0x01, 0x02, 0x03, 0x04},
+			}
+			ext1 := []byte{0x11, 0x11, 0x11}
+			ext2 := []byte{0x22, 0x22}
+			assert.NoError(t, packet.SetExtension(1, ext1))
+			assert.NoError(t, packet.SetExtension(2, ext2))
+			plaintext, err := packet.Marshal()
+			assert.NoError(t, err)
+
+			encrypted, err := encryptCtx.EncryptRTP(nil, plaintext, nil)
+			assert.NoError(t, err)
+
+			encryptedHeader := &rtp.Header{}
+			_, err = encryptedHeader.Unmarshal(encrypted)
+			assert.NoError(t, err)
+			assert.Len(t, encryptedHeader.GetExtension(1), len(ext1))
+			assert.NotEqual(t, ext1, encryptedHeader.GetExtension(1))
+			assert.Equal(t, ext2, encryptedHeader.GetExtension(2))
+
+			header := &rtp.Header{}
+			decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, header)
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+			assert.Equal(t, ext1, header.GetExtension(1))
+			assert.Equal(t, ext2, header.GetExtension(2))
+
+			// Encryption in place.
+			inPlaceCtx, err := buildTestContext(ProtectionProfileAes128CmHmacSha1_80, opt)
+			assert.NoError(t, err)
+			inPlace := bytes.Clone(plaintext)
+			encrypted2, err := inPlaceCtx.EncryptRTP(inPlace, inPlace, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, encrypted, encrypted2)
+		})
+	}
+}
+
+func TestEncryptedHeaderExtensionsUnsupported(t *testing.T) {
+	opt := SRTPEncryptedHeaderExtensions(1)
+	for _, profile := range []ProtectionProfile{ProtectionProfileAeadAes128Gcm, ProtectionProfileDoubleAeadAes128Gcm} {
+		keyLen, err := profile.KeyLen()
+		assert.NoError(t, err)
+		saltLen, err := profile.SaltLen()
+		assert.NoError(t, err)
+		_, err = CreateContext(make([]byte, keyLen), make([]byte, saltLen), profile, opt)
+		assert.ErrorIs(t, err, errHeaderExtensionEncryptionNotSupported)
+	}
+
+	_, err := CreateContext(make([]byte, 16), make([]byte, 14), ProtectionProfileAes128CmHmacSha1_80,
+		opt, Cryptex(CryptexModeEnabled))
+	assert.ErrorIs(t, err, errHeaderExtensionEncryptionNotSupported)
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package aria
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from RFC 5794 appendix A.
+func TestARIA(t *testing.T) {
+	for _, test := range []struct {
+		key, plaintext, ciphertext string
+	}{
+		{
+			key:        "000102030405060708090a0b0c0d0e0f",
+			plaintext:  "00112233445566778899aabbccddeeff",
+			ciphertext: "d718fbd6ab644c739da95f3be6451778",
+		},
+		{
+			key:        "000102030405060708090a0b0c0d0e0f1011121314151617",
+			plaintext:  "00112233445566778899aabbccddeeff",
+			ciphertext: "26449c1805dbe7aa25a468ce263a9e79",
+		},
+		{
+			key:        "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			plaintext:  "00112233445566778899aabbccddeeff",
+			ciphertext: "f92bd7c79fb72e2f2b8f80c1972d24fc",
+		},
+	} {
+		key, _ := hex.DecodeString(test.key)
+		plaintext, _ := hex.DecodeString(test.plaintext)
+		ciphertext, _ := hex.DecodeString(test.ciphertext)
+
+		block, err := NewCipher(key)
+		assert.NoError(t, err)
+		assert.Equal(t, BlockSize, block.BlockSize())
+
+		out := make([]byte, BlockSize)
+		block.Encrypt(out, plaintext)
+		assert.Equal(t, ciphertext, out)
+		block.Decrypt(out, out)
+		assert.Equal(t, plaintext, out)
+	}
+}
+
+func TestARIAInvalidKeySize(t *testing.T) {
+	for _, keyLen := range []int{0, 15, 17, 64} {
+		_, err := NewCipher(make([]byte, keyLen))
+		assert.Equal(t, KeySizeError(keyLen), err)
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package seed
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from RFC 4269 appendix B.
+func TestSEED(t *testing.T) {
+	for _, test := range []struct {
+		key, plaintext, ciphertext string
+	}{
+		{
+			key:        "00000000000000000000000000000000",
+			plaintext:  "000102030405060708090a0b0c0d0e0f",
+			ciphertext: "5ebac6e0054e166819aff1cc6d346cdb",
+		},
+		{
+			key:        "000102030405060708090a0b0c0d0e0f",
+			plaintext:  "00000000000000000000000000000000",
+			ciphertext: "c11f22f20140505084483597e4370f43",
+		},
+		{
+			key:        "4706480851e61be85d74bfb3fd956185",
+			plaintext:  "83a2f8a288641fb9a4e9a5cc2f131c7d",
+			ciphertext: "ee54d13ebcae706d226bc3142cd40d4a",
+		},
+	} {
+		key, _ := hex.DecodeString(test.key)
+		plaintext, _ := hex.DecodeString(test.plaintext)
+		ciphertext, _ := hex.DecodeString(test.ciphertext)
+
+		block, err := NewCipher(key)
+		assert.NoError(t, err)
+		assert.Equal(t, BlockSize, block.BlockSize())
+
+		out := make([]byte, BlockSize)
+		block.Encrypt(out, plaintext)
+		assert.Equal(t, ciphertext, out)
+		block.Decrypt(out, out)
+		assert.Equal(t, plaintext, out)
+	}
+}
+
+func TestSEEDInvalidKeySize(t *testing.T) {
+	for _, keyLen := range []int{0, 15, 17, 24, 32} {
+		_, err := NewCipher(make([]byte, keyLen))
+		assert.Equal(t, KeySizeError(keyLen), err)
+	}
+}
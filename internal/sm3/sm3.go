@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package sm3 implements SM3 hash algorithm, as defined in GB/T 32905-2016.
+package sm3
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	// Size is the size of SM3 checksum in bytes.
+	Size = 32
+	// BlockSize is the block size of SM3 in bytes.
+	BlockSize = 64
+)
+
+// Initial value of the hash state.
+var iv = [8]uint32{ // nolint:gochecknoglobals
+	0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600, 0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e,
+}
+
+type digest struct {
+	h   [8]uint32
+	x   [BlockSize]byte
+	nx  int
+	len uint64
+}
+
+// New returns a new hash.Hash computing the SM3 checksum.
+func New() hash.Hash {
+	d := &digest{}
+	d.Reset()
+
+	return d
+}
+
+// Sum returns the SM3 checksum of the data.
+func Sum(data []byte) [Size]byte {
+	d := &digest{}
+	d.Reset()
+	_, _ = d.Write(data)
+
+	var sum [Size]byte
+	d.Sum(sum[:0])
+
+	return sum
+}
+
+func (d *digest) Reset() {
+	d.h = iv
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *digest) Size() int {
+	return Size
+}
+
+func (d *digest) BlockSize() int {
+	return BlockSize
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		copied := copy(d.x[d.nx:], p)
+		d.nx += copied
+		p = p[copied:]
+		if d.nx < BlockSize {
+			return n, nil
+		}
+		d.block(d.x[:])
+		d.nx = 0
+	}
+	for len(p) >= BlockSize {
+		d.block(p[:BlockSize])
+		p = p[BlockSize:]
+	}
+	d.nx = copy(d.x[:], p)
+
+	return n, nil
+}
+
+func (d *digest) Sum(in []byte) []byte {
+	// Make a copy, so the caller can keep writing and summing.
+	d0 := *d
+
+	// Padding: 0x80, zeros, and 64-bit message length in bits.
+	var tmp [BlockSize + 8]byte
+	tmp[0] = 0x80
+	padLen := (BlockSize - 8 - 1 - int(d0.len%BlockSize) + BlockSize) % BlockSize
+	binary.BigEndian.PutUint64(tmp[1+padLen:], d0.len<<3)
+	_, _ = d0.Write(tmp[:1+padLen+8])
+
+	var sum [Size]byte
+	for i, h := range d0.h {
+		binary.BigEndian.PutUint32(sum[4*i:], h)
+	}
+
+	return append(in, sum[:]...)
+}
+
+func p0(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17)
+}
+
+func p1(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23)
+}
+
+// block applies compression function to one message block.
+func (d *digest) block(p []byte) {
+	var w [68]uint32
+	for j := range 16 {
+		w[j] = binary.BigEndian.Uint32(p[4*j:])
+	}
+	for j := 16; j < 68; j++ {
+		w[j] = p1(w[j-16]^w[j-9]^bits.RotateLeft32(w[j-3], 15)) ^ bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+	}
+
+	a, b, c, dd, e, f, g, h := d.h[0], d.h[1], d.h[2], d.h[3], d.h[4], d.h[5], d.h[6], d.h[7]
+	for j := range 64 {
+		var t, ff, gg uint32
+		if j < 16 {
+			t = 0x79cc4519
+			ff = a ^ b ^ c
+			gg = e ^ f ^ g
+		} else {
+			t = 0x7a879d8a
+			ff = (a & b) | (a & c) | (b & c)
+			gg = (e & f) | (^e & g)
+		}
+		ss1 := bits.RotateLeft32(bits.RotateLeft32(a, 12)+e+bits.RotateLeft32(t, j), 7)
+		ss2 := ss1 ^ bits.RotateLeft32(a, 12)
+		tt1 := ff + dd + ss2 + (w[j] ^ w[j+4])
+		tt2 := gg + h + ss1 + w[j]
+		dd, c, b, a = c, bits.RotateLeft32(b, 9), a, tt1
+		h, g, f, e = g, bits.RotateLeft32(f, 19), e, p0(tt2)
+	}
+
+	d.h[0] ^= a
+	d.h[1] ^= b
+	d.h[2] ^= c
+	d.h[3] ^= dd
+	d.h[4] ^= e
+	d.h[5] ^= f
+	d.h[6] ^= g
+	d.h[7] ^= h
+}
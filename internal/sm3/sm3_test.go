@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package sm3
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from GB/T 32905-2016 appendix A.
+func TestSM3(t *testing.T) {
+	for _, test := range []struct {
+		message, sum string
+	}{
+		{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+	} {
+		expected, _ := hex.DecodeString(test.sum)
+		sum := Sum([]byte(test.message))
+		assert.Equal(t, expected, sum[:])
+
+		// Data written in parts.
+		h := New()
+		for i := range len(test.message) {
+			_, err := h.Write([]byte{test.message[i]})
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, expected, h.Sum(nil))
+		assert.Equal(t, expected, h.Sum(nil))
+
+		h.Reset()
+		_, err := h.Write([]byte(test.message))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, h.Sum(nil))
+	}
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package sm4
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from GB/T 32907-2016 appendix A.
+func TestSM4(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	ciphertext, _ := hex.DecodeString("681edf34d206965e86b3e94f536e4246")
+
+	block, err := NewCipher(key)
+	assert.NoError(t, err)
+	assert.Equal(t, BlockSize, block.BlockSize())
+
+	out := make([]byte, BlockSize)
+	block.Encrypt(out, key)
+	assert.Equal(t, ciphertext, out)
+	block.Decrypt(out, out)
+	assert.Equal(t, key, out)
+
+	// Plaintext encrypted 1000000 times.
+	ciphertext, _ = hex.DecodeString("595298c7c6fd271f0402f804c33d3f66")
+	copy(out, key)
+	for range 1000000 {
+		block.Encrypt(out, out)
+	}
+	assert.Equal(t, ciphertext, out)
+}
+
+func TestSM4InvalidKeySize(t *testing.T) {
+	for _, keyLen := range []int{0, 15, 17, 32} {
+		_, err := NewCipher(make([]byte, keyLen))
+		assert.Equal(t, KeySizeError(keyLen), err)
+	}
+}
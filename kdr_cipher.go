@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import "github.com/pion/rtp"
+
+// kdrCipherCacheSize limits number of ciphers with re-derived session keys kept by kdrCipher.
+// Usually only ciphers for current "index DIV kdr" values of few streams are needed.
+const kdrCipherCacheSize = 8
+
+// kdrCipher re-derives session keys every kdr packets, as described in RFC 3711 section 4.3.1.
+// SRTP packets use keys derived for their packet index, and SRTCP packets for their SRTCP index.
+// Ciphers for "index DIV kdr" values are created on demand, and recently used ones are cached.
+type kdrCipher struct {
+	// srtpCipher is the cipher for zero "index DIV kdr". It is also used for operations which do not
+	// depend on the session keys.
+	srtpCipher
+	kdr       uint64
+	newCipher func(indexOverKdr uint64) (srtpCipher, error)
+	ciphers   map[uint64]srtpCipher
+}
+
+func newKDRCipher(
+	cipher srtpCipher, kdr uint64, newCipher func(indexOverKdr uint64) (srtpCipher, error),
+) *kdrCipher {
+	return &kdrCipher{
+		srtpCipher: cipher,
+		kdr:        kdr,
+		newCipher:  newCipher,
+		ciphers:    map[uint64]srtpCipher{},
+	}
+}
+
+// cipherForIndex returns cipher with session keys derived for SRTP or SRTCP packet with given index.
+func (k *kdrCipher) cipherForIndex(index uint64) (srtpCipher, error) {
+	indexOverKdr := index / k.kdr
+	if indexOverKdr == 0 {
+		return k.srtpCipher, nil
+	}
+	if cipher, ok := k.ciphers[indexOverKdr]; ok {
+		return cipher, nil
+	}
+
+	cipher, err := k.newCipher(indexOverKdr)
+	if err != nil {
+		return nil, err
+	}
+	if len(k.ciphers) >= kdrCipherCacheSize {
+		clear(k.ciphers)
+	}
+	k.ciphers[indexOverKdr] = cipher
+
+	return cipher, nil
+}
+
+func (k *kdrCipher) encryptRTP(
+	dst []byte, header *rtp.Header, headerLen int, plaintext []byte, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	cipher, err := k.cipherForIndex(uint64(roc)<<16 | uint64(header.SequenceNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.encryptRTP(dst, header, headerLen, plaintext, roc, rocInAuthTag)
+}
+
+func (k *kdrCipher) decryptRTP(
+	dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	cipher, err := k.cipherForIndex(uint64(roc)<<16 | uint64(header.SequenceNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.decryptRTP(dst, ciphertext, header, headerLen, roc, rocInAuthTag)
+}
+
+func (k *kdrCipher) encryptRTCP(dst, decrypted []byte, srtcpIndex uint32, ssrc uint32) ([]byte, error) {
+	cipher, err := k.cipherForIndex(uint64(srtcpIndex))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.encryptRTCP(dst, decrypted, srtcpIndex, ssrc)
+}
+
+func (k *kdrCipher) decryptRTCP(dst, encrypted []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	cipher, err := k.cipherForIndex(uint64(srtcpIndex))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.decryptRTCP(dst, encrypted, srtcpIndex, ssrc)
+}
+
+func (k *kdrCipher) resignRTP(
+	packet []byte, header *rtp.Header, headerLen int, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	cipher, err := k.cipherForIndex(uint64(roc)<<16 | uint64(header.SequenceNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.resignRTP(packet, header, headerLen, roc, rocInAuthTag)
+}
+
+func (k *kdrCipher) keystreamRTP(header *rtp.Header, payloadLen int, roc uint32) ([]byte, error) {
+	cipher, err := k.cipherForIndex(uint64(roc)<<16 | uint64(header.SequenceNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.keystreamRTP(header, payloadLen, roc)
+}
+
+func (k *kdrCipher) clone() srtpCipher {
+	return newKDRCipher(k.srtpCipher.clone(), k.kdr, k.newCipher)
+}
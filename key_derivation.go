@@ -5,15 +5,56 @@ package srtp
 
 import (
 	"crypto/aes"
+	"crypto/cipher"
 	"encoding/binary"
+
+	"github.com/pion/rtp"
 )
 
-func aesCmKeyDerivation(label byte, masterKey, masterSalt []byte, indexOverKdr int, outLen int) ([]byte, error) {
-	if indexOverKdr != 0 {
-		// 24-bit "index DIV kdr" must be xored to prf input.
-		return nil, errNonZeroKDRNotSupported
+// KeyDerivationFunc derives SRTP session keys from a master key which is held outside of the Context.
+// See ExternalKeyDerivation option.
+type KeyDerivationFunc interface {
+	// DeriveKey returns outLen bytes of session key material with given label, as defined by
+	// RFC 3711 section 4.3.1: 0x00-0x02 for SRTP encryption key, authentication key and salt,
+	// 0x03-0x05 for SRTCP ones, and 0x06-0x07 for header encryption key and salt from RFC 6904.
+	// indexOverKdr is "index DIV kdr" value, which is zero unless KeyDerivationRate option is used.
+	// It is called from the goroutine which creates the Context or encrypts and decrypts packets.
+	DeriveKey(label byte, indexOverKdr uint64, outLen int) ([]byte, error)
+}
+
+// aesCMKeyDerivationFunc is KeyDerivationFunc using AES-CM PRF from RFC 3711.
+type aesCMKeyDerivationFunc struct {
+	masterKey, masterSalt []byte
+}
+
+// NewAESCMKeyDerivationFunc returns KeyDerivationFunc which derives session keys from the master key
+// and salt with AES-CM PRF from RFC 3711, like the Context does by default. It may be used as a reference
+// implementation or as a fallback.
+func NewAESCMKeyDerivationFunc(masterKey, masterSalt []byte) KeyDerivationFunc {
+	return &aesCMKeyDerivationFunc{
+		masterKey:  append([]byte{}, masterKey...),
+		masterSalt: append([]byte{}, masterSalt...),
+	}
+}
+
+func (a *aesCMKeyDerivationFunc) DeriveKey(label byte, indexOverKdr uint64, outLen int) ([]byte, error) {
+	return aesCmKeyDerivation(label, a.masterKey, a.masterSalt, indexOverKdr, outLen)
+}
+
+func aesCmKeyDerivation(label byte, masterKey, masterSalt []byte, indexOverKdr uint64, outLen int) ([]byte, error) {
+	// The resulting value is then AES encrypted using the master key to get the cipher key.
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
 	}
 
+	return ctrKeyDerivation(block, label, masterSalt, indexOverKdr, outLen), nil
+}
+
+// ctrKeyDerivation derives session key with PRF which encrypts the input block with block cipher
+// keyed with the master key in counter mode. It is AES_CM PRF from RFC 3711 for AES block cipher,
+// and ARIA_*_CTR_PRF from RFC 8269 for ARIA.
+func ctrKeyDerivation(block cipher.Block, label byte, masterSalt []byte, indexOverKdr uint64, outLen int) []byte {
 	// https://tools.ietf.org/html/rfc3711#appendix-B.3
 	// The input block for AES-CM is generated by exclusive-oring the master salt with the
 	// concatenation of the encryption key label 0x00 with (index DIV kdr),
@@ -25,11 +66,9 @@ func aesCmKeyDerivation(label byte, masterKey, masterSalt []byte, indexOverKdr i
 	copy(prfIn[:nMasterSalt], masterSalt)
 
 	prfIn[7] ^= label
-
-	// The resulting value is then AES encrypted using the master key to get the cipher key.
-	block, err := aes.NewCipher(masterKey)
-	if err != nil {
-		return nil, err
+	// 48-bit "index DIV kdr" follows the label, see RFC 3711 section 4.3.1.
+	for i := 0; i < 6; i++ {
+		prfIn[13-i] ^= byte(indexOverKdr >> (8 * i)) //nolint:gosec // G115
 	}
 
 	nBlockSize := block.BlockSize()
@@ -41,7 +80,7 @@ func aesCmKeyDerivation(label byte, masterKey, masterSalt []byte, indexOverKdr i
 		i++
 	}
 
-	return out[:outLen], nil
+	return out[:outLen]
 }
 
 // Generate IV https://tools.ietf.org/html/rfc3711#section-4.1.1
@@ -72,3 +111,43 @@ func generateCounter(
 
 	return counter
 }
+
+// newF8IVBlock creates block cipher which encrypts f8 IVs, keyed with the session key XORed with
+// the masking key m = k_s || 0x555..5, see RFC 3711 section 4.1.2.1.
+func newF8IVBlock(profile protectionProfileWithArgs, sessionKey, sessionSalt []byte) (cipher.Block, error) {
+	maskedKey := make([]byte, len(sessionKey))
+	for i := range maskedKey {
+		mask := byte(0x55)
+		if i < len(sessionSalt) {
+			mask = sessionSalt[i]
+		}
+		maskedKey[i] = sessionKey[i] ^ mask
+	}
+
+	return profile.newBlockCipher(maskedKey)
+}
+
+// f8RTPIV returns f8 IV for SRTP packet, see RFC 3711 section 4.1.2.2:
+// IV = 0x00 || M || PT || SEQ || TS || SSRC || ROC.
+func f8RTPIV(header *rtp.Header, roc uint32) (iv [16]byte) {
+	iv[1] = header.PayloadType & 0x7f
+	if header.Marker {
+		iv[1] |= 0x80
+	}
+	binary.BigEndian.PutUint16(iv[2:], header.SequenceNumber)
+	binary.BigEndian.PutUint32(iv[4:], header.Timestamp)
+	binary.BigEndian.PutUint32(iv[8:], header.SSRC)
+	binary.BigEndian.PutUint32(iv[12:], roc)
+
+	return iv
+}
+
+// f8RTCPIV returns f8 IV for encrypted SRTCP packet, see RFC 3711 section 4.1.2.3:
+// IV = 0..0 || E || SRTCP index || V || P || RC || PT || length || SSRC.
+func f8RTCPIV(rtcpHeader []byte, srtcpIndex uint32) (iv [16]byte) {
+	binary.BigEndian.PutUint32(iv[4:], srtcpIndex)
+	iv[4] |= srtcpEncryptionFlag
+	copy(iv[8:], rtcpHeader[:srtcpHeaderSize])
+
+	return iv
+}
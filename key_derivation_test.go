@@ -4,8 +4,10 @@
 package srtp
 
 import (
+	"crypto/cipher"
 	"testing"
 
+	"github.com/pion/srtp/v3/internal/aria"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -77,11 +79,118 @@ func TestValidSessionKeys_AesCm256(t *testing.T) {
 		"Session Auth Tag % 02x does not match expected % 02x", sessionAuthTag, expectedSessionAuthTag)
 }
 
-// This test asserts that calling aesCmKeyDerivation with a non-zero indexOverKdr fails
-// Currently this isn't supported, but the API makes sure we can add this in the future.
+func TestValidSessionKeys_OtherCiphers(t *testing.T) {
+	masterKey := []byte{
+		0xE1, 0xF9, 0x7A, 0x0D, 0x3E, 0x01, 0x8B, 0xE0, 0xD6, 0x4F, 0xA3, 0x2C, 0x06, 0xDE, 0x41, 0x39,
+		0x0E, 0xC6, 0x75, 0xAD, 0x49, 0x8A, 0xFE, 0xEB, 0xB6, 0x96, 0x0B, 0x3A, 0xAB, 0xE6, 0xC1, 0x73,
+	}
+	masterSalt := []byte{0x0E, 0xC6, 0x75, 0xAD, 0x49, 0x8A, 0xFE, 0xEB, 0xB6, 0x96, 0x0B, 0x3A, 0xAB, 0xE6}
+
+	// ARIA_128_CTR_PRF and ARIA_256_CTR_PRF from RFC 8269 use ARIA instead of AES in AES_CM PRF.
+	profile := protectionProfileWithArgs{ProtectionProfile: ProtectionProfileAria128CtrHmacSha1_80}
+	for _, test := range []struct {
+		label    byte
+		expected []byte
+	}{
+		{labelSRTPEncryption, []byte{
+			0xdb, 0xd8, 0x5a, 0x3c, 0x4d, 0x92, 0x19, 0xb3, 0xe8, 0x1f, 0x7d, 0x94, 0x2e, 0x29, 0x9d, 0xe4,
+		}},
+		{labelSRTPAuthenticationTag, []byte{
+			0xd0, 0x21, 0x87, 0x7b, 0xd3, 0xea, 0xf9, 0x2d, 0x58, 0x1e,
+			0xd7, 0x0d, 0xdc, 0x05, 0x0e, 0x03, 0xf1, 0x12, 0x57, 0x03,
+		}},
+		{labelSRTPSalt, []byte{0x97, 0x00, 0x65, 0x7f, 0x5f, 0x34, 0x16, 0x18, 0x30, 0xd7, 0xd8, 0x5f, 0x5d, 0xc8}},
+	} {
+		sessionKey, err := profile.deriveSessionKey(test.label, masterKey[:16], masterSalt, len(test.expected))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, sessionKey)
+	}
+
+	profile = protectionProfileWithArgs{ProtectionProfile: ProtectionProfileAria256CtrHmacSha1_80}
+	sessionKey, err := profile.deriveSessionKey(labelSRTPEncryption, masterKey, masterSalt, len(masterKey))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x60, 0xb9, 0xb1, 0x4c, 0xab, 0x15, 0xeb, 0x47, 0xd7, 0xd8, 0xc1, 0xc4, 0x4c, 0xe3, 0x65, 0xe8,
+		0x32, 0x7a, 0x6d, 0x86, 0xc6, 0xea, 0xeb, 0x00, 0xbc, 0x97, 0x2e, 0x12, 0x5a, 0x5d, 0x4a, 0x3c,
+	}, sessionKey)
+
+	// SM4 profiles use SM4 in counter mode.
+	profile = protectionProfileWithArgs{ProtectionProfile: ProtectionProfileSm4CtrHmacSm3_80}
+	sessionKey, err = profile.deriveSessionKey(labelSRTPEncryption, masterKey[:16], masterSalt, 16)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xbf, 0x08, 0x04, 0x17, 0x33, 0xf3, 0x55, 0x89, 0xa4, 0x2d, 0xdb, 0x3b, 0xc8, 0x67, 0xcf, 0xd2,
+	}, sessionKey)
+	sessionKey, err = profile.deriveSessionKey(labelSRTPAuthenticationTag, masterKey[:16], masterSalt, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0xc5, 0x02, 0xb7, 0xcd, 0x72, 0x59, 0x25, 0xbe, 0x34, 0xed, 0xb6, 0x68, 0xf7, 0xe1, 0x57, 0x7e,
+		0x3e, 0xd6, 0x71, 0x98, 0x22, 0x4e, 0xd0, 0x02, 0x24, 0x28, 0x04, 0x80, 0x78, 0xe5, 0x2a, 0x10,
+	}, sessionKey)
+
+	// SEED profiles use AES_CM PRF.
+	profile = protectionProfileWithArgs{ProtectionProfile: ProtectionProfileSeedCtrHmacSha1_80}
+	sessionKey, err = profile.deriveSessionKey(labelSRTPEncryption, masterKey[:16], masterSalt, 16)
+	assert.NoError(t, err)
+	expected, err := aesCmKeyDerivation(labelSRTPEncryption, masterKey[:16], masterSalt, 0, 16)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, sessionKey)
+}
+
+// ARIA_128_CTR_PRF and ARIA_256_CTR_PRF are defined in RFC 8269 as the keystream of ARIA
+// in counter mode, keyed with the master key, for the IV built like for AES_CM PRF in RFC 3711 section 4.3.3.
+func TestAriaCtrPRF(t *testing.T) {
+	masterKey := []byte{
+		0xE1, 0xF9, 0x7A, 0x0D, 0x3E, 0x01, 0x8B, 0xE0, 0xD6, 0x4F, 0xA3, 0x2C, 0x06, 0xDE, 0x41, 0x39,
+		0x0E, 0xC6, 0x75, 0xAD, 0x49, 0x8A, 0xFE, 0xEB, 0xB6, 0x96, 0x0B, 0x3A, 0xAB, 0xE6, 0xC1, 0x73,
+	}
+	masterSalt := []byte{0x0E, 0xC6, 0x75, 0xAD, 0x49, 0x8A, 0xFE, 0xEB, 0xB6, 0x96, 0x0B, 0x3A, 0xAB, 0xE6}
+
+	for _, profile := range []ProtectionProfile{
+		ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_80,
+		ProtectionProfileAria256CtrHmacSha1_80, ProtectionProfileAeadAria256Gcm,
+	} {
+		keyLen, err := profile.KeyLen()
+		assert.NoError(t, err)
+		block, err := aria.NewCipher(masterKey[:keyLen])
+		assert.NoError(t, err)
+
+		for _, label := range []byte{labelSRTPEncryption, labelSRTPAuthenticationTag, labelSRTCPSalt} {
+			iv := make([]byte, aria.BlockSize)
+			copy(iv, masterSalt)
+			iv[7] ^= label
+			expected := make([]byte, 32)
+			cipher.NewCTR(block, iv).XORKeyStream(expected, expected)
+
+			sessionKey, err := protectionProfileWithArgs{ProtectionProfile: profile}.deriveSessionKey(
+				label, masterKey[:keyLen], masterSalt, len(expected))
+			assert.NoError(t, err)
+			assert.Equal(t, expected, sessionKey, "%s label %d", profile, label)
+		}
+	}
+}
+
+// "index DIV kdr" is xored with the 48 least significant bits of the 112-bit master salt, see RFC 3711 section 4.3.1.
 func TestIndexOverKDR(t *testing.T) {
-	_, err := aesCmKeyDerivation(labelSRTPAuthenticationTag, []byte{}, []byte{}, 1, 0)
-	assert.Error(t, err)
+	masterKey := []byte{0xE1, 0xF9, 0x7A, 0x0D, 0x3E, 0x01, 0x8B, 0xE0, 0xD6, 0x4F, 0xA3, 0x2C, 0x06, 0xDE, 0x41, 0x39}
+	masterSalt := []byte{0x0E, 0xC6, 0x75, 0xAD, 0x49, 0x8A, 0xFE, 0xEB, 0xB6, 0x96, 0x0B, 0x3A, 0xAB, 0xE6}
+	indexOverKdr := uint64(0x010203040506)
+	xoredSalt := append([]byte{}, masterSalt...)
+	for i, b := range []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06} {
+		xoredSalt[8+i] ^= b
+	}
+
+	for _, label := range []byte{labelSRTPEncryption, labelSRTPSalt, labelSRTCPAuthenticationTag} {
+		key, err := aesCmKeyDerivation(label, masterKey, masterSalt, indexOverKdr, 16)
+		assert.NoError(t, err)
+		expected, err := aesCmKeyDerivation(label, masterKey, xoredSalt, 0, 16)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, key)
+
+		keyWithoutKDR, err := aesCmKeyDerivation(label, masterKey, masterSalt, 0, 16)
+		assert.NoError(t, err)
+		assert.NotEqual(t, keyWithoutKDR, key)
+	}
 }
 
 func BenchmarkGenerateCounter(b *testing.B) {
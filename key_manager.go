@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+// KeyEventType is type of KeyEvent.
+type KeyEventType int
+
+const (
+	// KeyReady is emitted when new master keys are available, e.g. when they are extracted from DTLS
+	// or SDES, or received in EKT Field.
+	KeyReady KeyEventType = iota + 1
+	// KeyExpired is emitted when a master key is not used anymore, e.g. when receive key added with
+	// limited lifetime expires.
+	KeyExpired
+	// RekeyRequired is emitted when the send master key should be replaced, before encryption with it
+	// fails because of packet limits from RFC 3711 section 9.2.
+	RekeyRequired
+)
+
+// KeySource identifies the key management protocol which the keys of KeyEvent come from, as
+// classified by RFC 7201.
+type KeySource int
+
+const (
+	// KeySourceContext is used for events emitted by Context, e.g. for keys set by the application.
+	KeySourceContext KeySource = iota
+	// KeySourceDTLS is used for keys extracted from DTLS, as defined by RFC 5764.
+	KeySourceDTLS
+	// KeySourceSDES is used for keys exchanged in SDP, as defined by RFC 4568.
+	KeySourceSDES
+	// KeySourceEKT is used for keys received in EKT Fields, as defined by RFC 8870.
+	KeySourceEKT
+)
+
+// KeyEvent describes change of key lifecycle passed to KeyManager.
+type KeyEvent struct {
+	Type   KeyEventType
+	Source KeySource
+	// Profile and Keys are set for KeyReady events. Keys received in EKT Field are passed as
+	// RemoteMasterKey, without salt.
+	Profile ProtectionProfile
+	Keys    SessionKeys
+	// MKI identifies the key, when MKI is enabled.
+	MKI []byte
+	// SSRC is the SSRC the event applies to, when HasSSRC is set.
+	HasSSRC bool
+	SSRC    uint32
+}
+
+// KeyManager receives key lifecycle events from all keying mechanisms, i.e. from
+// Config.ExtractSessionKeysFromDTLS, Config.ExtractSessionKeysFromSDES and Context with KeyEvents
+// option. It allows applications to handle keys in a single place, regardless of how they are exchanged,
+// e.g. to apply new keys with UpdateMasterKeys, or to start re-keying by the key management protocol.
+//
+// Events from Context are emitted synchronously from the encrypting or decrypting call, so HandleKeyEvent
+// should return quickly.
+type KeyManager interface {
+	HandleKeyEvent(event KeyEvent)
+}
+
+// emitKeyEvent passes event to KeyManager set by KeyEvents option.
+func (c *Context) emitKeyEvent(event KeyEvent) {
+	if c.keyManager != nil {
+		c.keyManager.HandleKeyEvent(event)
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+type keyEventRecorder struct {
+	events []KeyEvent
+}
+
+func (r *keyEventRecorder) HandleKeyEvent(event KeyEvent) {
+	r.events = append(r.events, event)
+}
+
+func TestKeyManagerConfig(t *testing.T) {
+	recorder := &keyEventRecorder{}
+	config := &Config{Profile: ProtectionProfileAes128CmHmacSha1_80, KeyManager: recorder}
+	assert.NoError(t, config.ExtractSessionKeysFromDTLS(&mockKeyingMaterialExporter{}, true))
+	if assert.Len(t, recorder.events, 1) {
+		assert.Equal(t, KeyEvent{
+			Type: KeyReady, Source: KeySourceDTLS, Profile: config.Profile, Keys: config.Keys,
+		}, recorder.events[0])
+	}
+
+	attr, err := NewCryptoAttribute(1, ProtectionProfileAeadAes128Gcm)
+	assert.NoError(t, err)
+	assert.NoError(t, config.ExtractSessionKeysFromSDES(attr, attr))
+	if assert.Len(t, recorder.events, 2) {
+		assert.Equal(t, KeyEvent{
+			Type: KeyReady, Source: KeySourceSDES, Profile: ProtectionProfileAeadAes128Gcm, Keys: config.Keys,
+		}, recorder.events[1])
+	}
+}
+
+func TestKeyManagerContext(t *testing.T) {
+	recorder := &keyEventRecorder{}
+	key, salt := make([]byte, 16), make([]byte, 14)
+	encryptCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{1}), KeyEvents(recorder, 2),
+		OnSRTCPIndexWarning(maxSRTCPIndex-2, func(uint32, uint32) {}))
+	assert.NoError(t, err)
+
+	rtpPacket, err := (&rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 1}}).Marshal()
+	assert.NoError(t, err)
+	_, err = encryptCtx.EncryptRTP(nil, rtpPacket, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, recorder.events)
+	// The second packet reaches the threshold, and SRTCP index 2 reaches the warning.
+	_, err = encryptCtx.EncryptRTCP(nil, []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, nil)
+	assert.NoError(t, err)
+	_, err = encryptCtx.EncryptRTCP(nil, []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []KeyEvent{
+		{Type: RekeyRequired, MKI: []byte{1}},
+		{Type: RekeyRequired, MKI: []byte{1}, HasSSRC: true, SSRC: 1},
+	}, recorder.events)
+
+	// Receive key expires when all streams pass its lifetime.
+	recorder.events = nil
+	decryptCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{1}), KeyEvents(recorder, 0))
+	assert.NoError(t, err)
+	assert.NoError(t, decryptCtx.AddReceiveKey(ReceiveKey{MKI: []byte{2}, MasterKey: key, MasterSalt: salt, To: 1}))
+	rtpPacket, err = (&rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 2}}).Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, rtpPacket, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []KeyEvent{{Type: KeyExpired, MKI: []byte{2}}}, recorder.events)
+}
+
+func TestKeyManagerEKT(t *testing.T) {
+	recorder := &keyEventRecorder{}
+	senderKey, salt := make([]byte, 16), make([]byte, 14)
+	for i := range senderKey {
+		senderKey[i] = byte(i)
+	}
+	ektKey := EKTKey{SPI: 1, Key: make([]byte, 16), MasterSalt: salt}
+
+	encryptCtx, err := CreateContext(senderKey, salt, profileCTR, EncryptedKeyTransport(ektKey, 2))
+	assert.NoError(t, err)
+	decryptCtx, err := CreateContext(make([]byte, 16), salt, profileCTR,
+		EncryptedKeyTransport(ektKey, 2), KeyEvents(recorder, 0))
+	assert.NoError(t, err)
+
+	for seq := range uint16(3) {
+		rtpPacket, errMarshal := (&rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: seq}}).Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, rtpPacket, nil)
+		assert.NoError(t, errEncrypt)
+		_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, err)
+	}
+
+	// The event is emitted once, for the first packet with the new key.
+	assert.Equal(t, []KeyEvent{{
+		Type: KeyReady, Source: KeySourceEKT, Profile: profileCTR,
+		Keys: SessionKeys{RemoteMasterKey: senderKey}, HasSSRC: true, SSRC: 1,
+	}}, recorder.events)
+}
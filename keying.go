@@ -13,6 +13,7 @@ type KeyingMaterialExporter interface {
 // ExtractSessionKeysFromDTLS allows setting the Config SessionKeys by
 // extracting them from DTLS. This behavior is defined in RFC5764:
 // https://tools.ietf.org/html/rfc5764
+// KeyReady event is passed to Config.KeyManager, when it is set.
 func (c *Config) ExtractSessionKeysFromDTLS(exporter KeyingMaterialExporter, isClient bool) error {
 	keyLen, err := c.Profile.KeyLen()
 	if err != nil {
@@ -46,14 +47,20 @@ func (c *Config) ExtractSessionKeysFromDTLS(exporter KeyingMaterialExporter, isC
 		c.Keys.LocalMasterSalt = clientWriteKey[keyLen:]
 		c.Keys.RemoteMasterKey = serverWriteKey[0:keyLen]
 		c.Keys.RemoteMasterSalt = serverWriteKey[keyLen:]
-
-		return nil
+	} else {
+		c.Keys.LocalMasterKey = serverWriteKey[0:keyLen]
+		c.Keys.LocalMasterSalt = serverWriteKey[keyLen:]
+		c.Keys.RemoteMasterKey = clientWriteKey[0:keyLen]
+		c.Keys.RemoteMasterSalt = clientWriteKey[keyLen:]
 	}
-
-	c.Keys.LocalMasterKey = serverWriteKey[0:keyLen]
-	c.Keys.LocalMasterSalt = serverWriteKey[keyLen:]
-	c.Keys.RemoteMasterKey = clientWriteKey[0:keyLen]
-	c.Keys.RemoteMasterSalt = clientWriteKey[keyLen:]
+	c.emitKeyReady(KeySourceDTLS)
 
 	return nil
 }
+
+// emitKeyReady passes KeyReady event with keys of the Config to Config.KeyManager.
+func (c *Config) emitKeyReady(source KeySource) {
+	if c.KeyManager != nil {
+		c.KeyManager.HandleKeyEvent(KeyEvent{Type: KeyReady, Source: source, Profile: c.Profile, Keys: c.Keys})
+	}
+}
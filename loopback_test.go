@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"io"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoopbackSession(t *testing.T) {
+	send, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	recv, err := buildTestContext(profileCTR, SRTPReplayProtection(64), SRTCPReplayProtection(64))
+	assert.NoError(t, err)
+
+	writer, reader := NewLoopbackSession(send, recv)
+	buf := make([]byte, 1500)
+
+	for seq := range uint16(3) {
+		pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: 1}, Payload: rtpTestCaseDecrypted()}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+
+		n, errWrite := writer.WriteRTP(pktRaw)
+		assert.NoError(t, errWrite)
+		assert.Equal(t, len(pktRaw), n)
+
+		n, header, errRead := reader.ReadRTP(buf)
+		assert.NoError(t, errRead)
+		assert.Equal(t, pktRaw, buf[:n])
+		assert.Equal(t, seq, header.SequenceNumber)
+	}
+
+	rtcpPkt := &rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2}
+	rtcpRaw, err := rtcpPkt.Marshal()
+	assert.NoError(t, err)
+	_, err = writer.WriteRTCP(rtcpRaw)
+	assert.NoError(t, err)
+
+	n, header, err := reader.ReadRTCP(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpRaw, buf[:n])
+	assert.Equal(t, rtcp.TypePayloadSpecificFeedback, header.Type)
+
+	assert.NoError(t, writer.Close())
+	_, _, err = reader.ReadRTP(buf)
+	assert.ErrorIs(t, err, io.EOF)
+	_, _, err = reader.ReadRTCP(buf)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.NoError(t, reader.Close())
+}
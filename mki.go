@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// ReceiveKey describes master key used for decrypting packets, identified by its MKI.
+type ReceiveKey struct {
+	// MKI identifies the key. It may have different length than MKI used for encrypting packets,
+	// e.g. when packets from multiple peers are decrypted by one Context.
+	MKI        []byte
+	MasterKey  []byte
+	MasterSalt []byte
+	// Profile is protection profile used with the key. Zero value means profile of the Context.
+	Profile ProtectionProfile
+	// From and To set the key lifetime as range of SRTP packet indexes (ROC << 16 | SEQ) for which
+	// the key may be used, see RFC 3711 section 8.1. Zero To means no upper limit. Key lifetime
+	// applies to SRTP packets only.
+	From, To uint64
+}
+
+// mkiLifetime is <From, To> key lifetime of a receive key.
+type mkiLifetime struct {
+	from, to uint64
+}
+
+// AddReceiveKey adds new key for decrypting packets. Context must be created with MasterKeyIndicator
+// option to enable MKI support. Unlike AddCipherForMKI, MKI of the key may have any non-zero length.
+// MKI is found at the end of packets, so MKI which is a suffix of MKI of another key, or which ends
+// with MKI of another key, is ambiguous and it is rejected.
+// Packets with index outside of key lifetime are rejected with ErrKeyLifetimeExceeded. Keys with
+// limited lifetime expire, and are removed automatically, once index of all SRTP streams
+// received by the Context passes the end of their lifetime. Key used for encrypting packets is
+// never removed. Use RemoveMKI to remove the key earlier.
+// Operation is not thread-safe, you need to provide synchronization with decrypting packets.
+func (c *Context) AddReceiveKey(key ReceiveKey) error {
+	profile := key.Profile
+	if profile == 0 {
+		profile = c.profile
+	}
+	if !profile.isSupported() {
+		return fmt.Errorf("%w: %#v", ErrUnsupportedProfile, profile)
+	}
+	if profile != c.profile && c.rccMode != RCCModeNone {
+		return errUnsupportedRccMode
+	}
+	if len(c.mkis) == 0 {
+		return errMKIIsNotEnabled
+	}
+	if len(key.MKI) == 0 {
+		return errInvalidMKILength
+	}
+	if _, ok := c.mkis[string(key.MKI)]; ok {
+		return errMKIAlreadyInUse
+	}
+	for mki := range c.mkis {
+		if bytes.HasSuffix([]byte(mki), key.MKI) || bytes.HasSuffix(key.MKI, []byte(mki)) {
+			return fmt.Errorf("%w: %x and %x", errMKIAmbiguous, key.MKI, mki)
+		}
+	}
+	if key.To != 0 && key.To < key.From {
+		return errInvalidKeyLifetime
+	}
+
+	mki := append([]byte{}, key.MKI...)
+	cipher, err := c.createCipher(profile, mki, key.MasterKey, key.MasterSalt, c.encryptSRTP, c.encryptSRTCP)
+	if err != nil {
+		return err
+	}
+	c.setMKICipher(string(mki), cipher)
+	c.recordMasterKey(profile, mki, key.MasterKey, key.MasterSalt)
+	if key.From != 0 || key.To != 0 {
+		if c.mkiLifetimes == nil {
+			c.mkiLifetimes = map[string]mkiLifetime{}
+		}
+		c.mkiLifetimes[string(mki)] = mkiLifetime{from: key.From, to: key.To}
+		c.keyExpiryBlocker = nil
+	}
+
+	return nil
+}
+
+// checkKeyLifetime checks if SRTP packet with given index may be decrypted with key identified by MKI.
+func (c *Context) checkKeyLifetime(mki []byte, index uint64) error {
+	lifetime, ok := c.mkiLifetimes[string(mki)]
+	if !ok {
+		return nil
+	}
+	if index < lifetime.from || (lifetime.to != 0 && index > lifetime.to) {
+		return fmt.Errorf("%w: mki=%x index=%d", ErrKeyLifetimeExceeded, mki, index)
+	}
+
+	return nil
+}
+
+// expireReceiveKeys removes receive keys whose lifetime ended for all SRTP streams. Streams are not
+// scanned for every packet: a stream whose index has not passed the earliest end of key lifetime
+// is remembered, and no key can expire until it passes it.
+func (c *Context) expireReceiveKeys() {
+	if blocker := c.keyExpiryBlocker; blocker != nil &&
+		c.srtpSSRCStates[blocker.ssrc] == blocker && blocker.index <= c.keyExpiryBlockedTo {
+		return
+	}
+	c.keyExpiryBlocker = nil
+
+	for mki, lifetime := range c.mkiLifetimes {
+		if lifetime.to == 0 || mki == string(c.sendMKI) {
+			continue
+		}
+		expired := true
+		for _, state := range c.srtpSSRCStates {
+			if state.index <= lifetime.to {
+				expired = false
+
+				break
+			}
+		}
+		if expired {
+			c.deleteMKICipher(mki)
+			delete(c.mkiLifetimes, mki)
+			delete(c.persistedKeys, mki)
+			c.emitKeyEvent(KeyEvent{Type: KeyExpired, MKI: []byte(mki)})
+		}
+	}
+
+	var minTo uint64
+	for mki, lifetime := range c.mkiLifetimes {
+		if lifetime.to != 0 && mki != string(c.sendMKI) && (minTo == 0 || lifetime.to < minTo) {
+			minTo = lifetime.to
+		}
+	}
+	if minTo == 0 {
+		return
+	}
+	for _, state := range c.srtpSSRCStates {
+		if state.index <= minTo {
+			c.keyExpiryBlocker, c.keyExpiryBlockedTo = state, minTo
+
+			return
+		}
+	}
+}
+
+// EncryptRTPWithMKI encrypts a RTP packet like EncryptRTP, but with the key identified by mki instead of
+// the current send key, e.g. to implement key changes scheduled per packet. The key must be added with
+// AddCipherForMKI; ErrMKINotFound is returned otherwise. The current send key set by SetSendMKI is not
+// changed, and packets encrypted with other keys are not counted by OnKeyExpired option.
+func (c *Context) EncryptRTPWithMKI(dst, plaintext []byte, header *rtp.Header, mki []byte) ([]byte, error) {
+	return c.withSendMKI(mki, func() ([]byte, error) {
+		return c.EncryptRTP(dst, plaintext, header)
+	})
+}
+
+// EncryptRTCPWithMKI encrypts a RTCP packet like EncryptRTCP, but with the key identified by mki, like
+// EncryptRTPWithMKI.
+func (c *Context) EncryptRTCPWithMKI(dst, decrypted []byte, header *rtcp.Header, mki []byte) ([]byte, error) {
+	return c.withSendMKI(mki, func() ([]byte, error) {
+		return c.EncryptRTCP(dst, decrypted, header)
+	})
+}
+
+// withSendMKI calls fn with the key identified by mki temporarily used as the send key.
+func (c *Context) withSendMKI(mki []byte, fn func() ([]byte, error)) ([]byte, error) {
+	if bytes.Equal(mki, c.sendMKI) {
+		return fn()
+	}
+	cipher, ok := c.mkis[string(mki)]
+	if !ok {
+		return nil, ErrMKINotFound
+	}
+
+	prevCipher, prevMKI := c.cipher, c.sendMKI
+	c.cipher, c.sendMKI, c.sendKeyOverridden = cipher, mki, true
+	defer func() {
+		c.cipher, c.sendMKI, c.sendKeyOverridden = prevCipher, prevMKI, false
+	}()
+
+	return fn()
+}
@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptTestRTP(t *testing.T, ctx *Context, seq uint16) []byte {
+	t.Helper()
+
+	packet := &rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: seq},
+		Payload: []byte{0x00, 0x01, 0x02, 0x03},
+	}
+	raw, err := packet.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := ctx.EncryptRTP(nil, raw, nil)
+	assert.NoError(t, err)
+
+	return encrypted
+}
+
+func TestAddReceiveKey(t *testing.T) {
+	key := make([]byte, 16)
+	salt := make([]byte, 14)
+
+	ctx, err := CreateContext(key, salt, profileCTR)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, ctx.AddReceiveKey(ReceiveKey{MKI: []byte{1}, MasterKey: key, MasterSalt: salt}),
+		errMKIIsNotEnabled)
+
+	ctx, err = CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{1}))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, ctx.AddReceiveKey(ReceiveKey{MasterKey: key, MasterSalt: salt}), errInvalidMKILength)
+	assert.ErrorIs(t, ctx.AddReceiveKey(ReceiveKey{MKI: []byte{1}, MasterKey: key, MasterSalt: salt}),
+		errMKIAlreadyInUse)
+	assert.ErrorIs(t, ctx.AddReceiveKey(ReceiveKey{MKI: []byte{2}, MasterKey: key, MasterSalt: salt, From: 10, To: 5}),
+		errInvalidKeyLifetime)
+	assert.ErrorIs(t, ctx.AddReceiveKey(ReceiveKey{MKI: []byte{2}, MasterKey: key, MasterSalt: salt, Profile: 0xFFFF}),
+		ErrUnsupportedProfile)
+	assert.NoError(t, ctx.AddReceiveKey(ReceiveKey{MKI: []byte{2, 3, 4}, MasterKey: key, MasterSalt: salt}))
+	assert.NoError(t, ctx.AddReceiveKey(ReceiveKey{
+		MKI: []byte{5, 6}, MasterKey: make([]byte, 16), MasterSalt: make([]byte, 12), Profile: profileGCM,
+	}))
+	// MKIs which are suffixes of each other cannot be told apart.
+	for _, mki := range [][]byte{{3, 4}, {0, 2, 3, 4}, {0, 1}} {
+		assert.ErrorIs(t, ctx.AddReceiveKey(ReceiveKey{MKI: mki, MasterKey: key, MasterSalt: salt}), errMKIAmbiguous)
+	}
+	assert.NoError(t, ctx.RemoveMKI([]byte{2, 3, 4}))
+}
+
+func TestReceiveKeysWithDifferentMKILengths(t *testing.T) {
+	key := []byte{
+		0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f,
+		0x01, 0x7f, 0x69, 0x94, 0x40, 0x1e, 0x28, 0x89,
+	}
+	salt := []byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c}
+	otherKey := make([]byte, 16)
+	otherSalt := make([]byte, 12)
+
+	decryptCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{1}))
+	assert.NoError(t, err)
+	assert.NoError(t, decryptCtx.AddReceiveKey(ReceiveKey{MKI: []byte{2, 2, 2}, MasterKey: key, MasterSalt: salt}))
+	assert.NoError(t, decryptCtx.AddReceiveKey(ReceiveKey{
+		MKI: []byte{3, 3, 3, 3, 3}, MasterKey: otherKey, MasterSalt: otherSalt, Profile: profileGCM,
+	}))
+
+	for _, sender := range []struct {
+		name       string
+		mki        []byte
+		key, salt  []byte
+		profile    ProtectionProfile
+		sequenceNo uint16
+	}{
+		{"SendMKI", []byte{1}, key, salt, profileCTR, 1},
+		{"ThreeBytes", []byte{2, 2, 2}, key, salt, profileCTR, 2},
+		{"FiveBytesGCM", []byte{3, 3, 3, 3, 3}, otherKey, otherSalt, profileGCM, 3},
+	} {
+		t.Run(sender.name, func(t *testing.T) {
+			encryptCtx, errCtx := CreateContext(sender.key, sender.salt, sender.profile, MasterKeyIndicator(sender.mki))
+			assert.NoError(t, errCtx)
+
+			decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encryptTestRTP(t, encryptCtx, sender.sequenceNo), nil)
+			assert.NoError(t, errDecrypt)
+			assert.Equal(t, []byte{0x00, 0x01, 0x02, 0x03}, decrypted[len(decrypted)-4:])
+		})
+	}
+
+	encryptCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{4, 4}))
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, encryptCtx, 4), nil)
+	assert.ErrorIs(t, err, ErrMKINotFound)
+}
+
+func TestFindCipherForMKIMixedTagLengths(t *testing.T) {
+	ctx, err := CreateContext(make([]byte, 16), make([]byte, 14), profileCTR, MasterKeyIndicator([]byte{1}))
+	assert.NoError(t, err)
+	assert.NoError(t, ctx.AddCipherForMKIWithProfile(
+		[]byte{2}, make([]byte, 16), make([]byte, 12), profileGCM))
+	assert.NoError(t, ctx.AddCipherForMKIWithProfile(
+		[]byte{3}, make([]byte, 16), make([]byte, 14), ProtectionProfileAes128CmHmacSha1_32))
+
+	// MKI 3 followed by 4-byte tag, and MKI 2 without HMAC tag both match the packet. Shorter tag is
+	// always tried first.
+	packet := make([]byte, 32)
+	packet[len(packet)-5] = 3
+	packet[len(packet)-1] = 2
+	for range 10 {
+		cipher, mki, errFind := ctx.findCipherForMKI(packet, 12, false, nil)
+		assert.NoError(t, errFind)
+		assert.Equal(t, []byte{2}, mki)
+		assert.Equal(t, ctx.mkis["\x02"], cipher)
+	}
+
+	// MKI of the current cipher is preferred.
+	packet[len(packet)-11] = 1
+	_, mki, err := ctx.findCipherForMKI(packet, 12, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, mki)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		_, _, _ = ctx.findCipherForMKI(packet, 12, false, nil)
+	})
+	assert.Zero(t, allocs)
+
+	// Lookup is updated when MKIs are changed.
+	assert.NoError(t, ctx.RemoveMKI([]byte{2}))
+	packet[len(packet)-11] = 0
+	_, mki, err = ctx.findCipherForMKI(packet, 12, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3}, mki)
+}
+
+func TestReceiveKeyLifetime(t *testing.T) {
+	key := make([]byte, 16)
+	salt := make([]byte, 14)
+	oldMKI := []byte{1, 1}
+	newMKI := []byte{2, 2}
+
+	decryptCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{0, 0}))
+	assert.NoError(t, err)
+	assert.NoError(t, decryptCtx.AddReceiveKey(ReceiveKey{MKI: oldMKI, MasterKey: key, MasterSalt: salt, To: 10}))
+	assert.NoError(t, decryptCtx.AddReceiveKey(ReceiveKey{MKI: newMKI, MasterKey: key, MasterSalt: salt, From: 11}))
+
+	oldCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator(oldMKI))
+	assert.NoError(t, err)
+	newCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator(newMKI))
+	assert.NoError(t, err)
+
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, oldCtx, 10), nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, newCtx, 9), nil)
+	assert.ErrorIs(t, err, ErrKeyLifetimeExceeded)
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, oldCtx, 11), nil)
+	assert.ErrorIs(t, err, ErrKeyLifetimeExceeded)
+
+	// Old key expires once the stream passes the end of its lifetime.
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, newCtx, 12), nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, oldCtx, 8), nil)
+	assert.ErrorIs(t, err, ErrMKINotFound)
+	assert.ErrorIs(t, decryptCtx.RemoveMKI(oldMKI), ErrMKINotFound)
+	assert.NoError(t, decryptCtx.RemoveMKI(newMKI))
+}
+
+func TestReceiveKeyLifetimeMultipleStreams(t *testing.T) {
+	key := make([]byte, 16)
+	salt := make([]byte, 14)
+	oldMKI := []byte{1, 1}
+
+	decryptCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{0, 0}))
+	assert.NoError(t, err)
+	assert.NoError(t, decryptCtx.AddReceiveKey(ReceiveKey{MKI: oldMKI, MasterKey: key, MasterSalt: salt, To: 10}))
+	sendCtx, err := CreateContext(key, salt, profileCTR, MasterKeyIndicator([]byte{0, 0}))
+	assert.NoError(t, err)
+
+	decrypt := func(ssrc uint32, seq uint16) {
+		_, errDecrypt := decryptCtx.DecryptRTP(nil, encryptTestRTPForSSRC(t, sendCtx, ssrc, seq), nil)
+		assert.NoError(t, errDecrypt)
+	}
+
+	// The key is kept while any stream has not passed the end of its lifetime.
+	decrypt(1, 5)
+	decrypt(2, 20)
+	decrypt(2, 21)
+	assert.Same(t, decryptCtx.srtpSSRCStates[1], decryptCtx.keyExpiryBlocker)
+	assert.Contains(t, decryptCtx.mkis, string(oldMKI))
+
+	decrypt(1, 11)
+	assert.NotContains(t, decryptCtx.mkis, string(oldMKI))
+	assert.Nil(t, decryptCtx.keyExpiryBlocker)
+}
+
+func TestEncryptWithMKI(t *testing.T) {
+	mki1, mki2 := []byte{1}, []byte{2}
+	key2 := []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	salt2 := make([]byte, 14)
+	rtcpPacket := []byte{0x81, 0xc8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+
+	var expired [][]byte
+	encryptCtx, err := buildTestContext(profileCTR, MasterKeyIndicator(mki1), OnKeyExpired(2, func(mki []byte) {
+		expired = append(expired, mki)
+	}))
+	assert.NoError(t, err)
+	assert.NoError(t, encryptCtx.AddCipherForMKI(mki2, key2, salt2))
+	decryptCtx, err := buildTestContext(profileCTR, MasterKeyIndicator(mki1))
+	assert.NoError(t, err)
+	assert.NoError(t, decryptCtx.AddCipherForMKI(mki2, key2, salt2))
+	key2OnlyCtx, err := CreateContext(key2, salt2, profileCTR, MasterKeyIndicator(mki2))
+	assert.NoError(t, err)
+
+	raw, err := (&rtp.Packet{
+		Header: rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: 1}, Payload: []byte{0x00},
+	}).Marshal()
+	assert.NoError(t, err)
+
+	_, err = encryptCtx.EncryptRTPWithMKI(nil, raw, nil, []byte{3})
+	assert.ErrorIs(t, err, ErrMKINotFound)
+
+	encrypted, err := encryptCtx.EncryptRTPWithMKI(nil, raw, nil, mki2)
+	assert.NoError(t, err)
+	assert.Equal(t, mki2, encrypted[len(encrypted)-10-1:len(encrypted)-10])
+	_, err = key2OnlyCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+
+	encrypted, err = encryptCtx.EncryptRTCPWithMKI(nil, rtcpPacket, nil, mki2)
+	assert.NoError(t, err)
+	decrypted, err := key2OnlyCtx.DecryptRTCP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpPacket, decrypted)
+
+	// The send key is not changed, and packets encrypted with other keys are not counted.
+	assert.Empty(t, expired)
+	encrypted = encryptTestRTP(t, encryptCtx, 2)
+	assert.Equal(t, mki1, encrypted[len(encrypted)-10-1:len(encrypted)-10])
+	assert.Empty(t, expired)
+	_, err = encryptCtx.EncryptRTPWithMKI(nil, raw, nil, mki1)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{mki1}, expired)
+}
@@ -4,38 +4,73 @@
 package srtp
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/pion/rtp"
 	"github.com/pion/transport/v4/replaydetector"
 )
 
 // ContextOption represents option of Context using the functional options pattern.
 type ContextOption func(*Context) error
 
-// SRTPReplayProtection sets SRTP replay protection window size.
+// maxReplayWindowSize is the largest replay protection window size. Larger windows would make
+// sequence number of older packets ambiguous, see RFC 3711 section 3.3.1.
+const maxReplayWindowSize = 1 << 15
+
+// maxKeyDerivationRate is the maximum key derivation rate allowed by RFC 3711 section 4.3.1.
+const maxKeyDerivationRate = 1 << 24
+
+// SRTPReplayProtection sets SRTP replay protection window size, from 1 to 32768 packets. Large
+// windows (e.g. 1024 or 4096 packets) accept packets reordered on links with high jitter.
+// By default replay protection is disabled for Context created by CreateContext, and SessionSRTP
+// enables it with window size of 64 packets. Use SRTPNoReplayProtection to disable it.
 func SRTPReplayProtection(windowSize uint) ContextOption { // nolint:revive
 	return func(c *Context) error {
-		c.newSRTPReplayDetector = func() replaydetector.ReplayDetector {
-			return replaydetector.New(windowSize, maxROC<<16|maxSequenceNumber)
+		if err := validateReplayWindowSize(windowSize); err != nil {
+			return err
+		}
+		c.srtpReplayWindowSize = windowSize
+		c.newSRTPReplayDetector = func(uint32) replaydetector.ReplayDetector {
+			return newReplayWindow(windowSize, maxROC<<16|maxSequenceNumber)
 		}
 
 		return nil
 	}
 }
 
-// SRTCPReplayProtection sets SRTCP replay protection window size.
+// SRTCPReplayProtection sets SRTCP replay protection window size. It is independent of
+// the SRTP window size set by SRTPReplayProtection. RTCP packet rate is much lower than RTP one,
+// so a small window is usually enough. Window size must be from 1 to 32768 packets. By default replay
+// protection is disabled for Context created by CreateContext, and SessionSRTCP enables it with window
+// size of 64 packets. Use SRTCPNoReplayProtection to disable it.
 func SRTCPReplayProtection(windowSize uint) ContextOption {
 	return func(c *Context) error {
-		c.newSRTCPReplayDetector = func() replaydetector.ReplayDetector {
-			return replaydetector.New(windowSize, maxSRTCPIndex)
+		if err := validateReplayWindowSize(windowSize); err != nil {
+			return err
+		}
+		c.srtcpReplayWindowSize = windowSize
+		c.newSRTCPReplayDetector = func(uint32) replaydetector.ReplayDetector {
+			return newReplayWindow(windowSize, maxSRTCPIndex)
 		}
 
 		return nil
 	}
 }
 
+func validateReplayWindowSize(windowSize uint) error {
+	if windowSize == 0 || windowSize > maxReplayWindowSize {
+		return fmt.Errorf("%w: %d", errInvalidReplayWindowSize, windowSize)
+	}
+
+	return nil
+}
+
 // SRTPNoReplayProtection disables SRTP replay protection.
 func SRTPNoReplayProtection() ContextOption { // nolint:revive
 	return func(c *Context) error {
-		c.newSRTPReplayDetector = func() replaydetector.ReplayDetector {
+		c.srtpReplayWindowSize = 0
+		c.newSRTPReplayDetector = func(uint32) replaydetector.ReplayDetector {
 			return &nopReplayDetector{}
 		}
 
@@ -46,7 +81,8 @@ func SRTPNoReplayProtection() ContextOption { // nolint:revive
 // SRTCPNoReplayProtection disables SRTCP replay protection.
 func SRTCPNoReplayProtection() ContextOption {
 	return func(c *Context) error {
-		c.newSRTCPReplayDetector = func() replaydetector.ReplayDetector {
+		c.srtcpReplayWindowSize = 0
+		c.newSRTCPReplayDetector = func(uint32) replaydetector.ReplayDetector {
 			return &nopReplayDetector{}
 		}
 
@@ -56,16 +92,35 @@ func SRTCPNoReplayProtection() ContextOption {
 
 // SRTPReplayDetectorFactory sets custom SRTP replay detector.
 func SRTPReplayDetectorFactory(fn func() replaydetector.ReplayDetector) ContextOption { // nolint:revive
+	return SRTPReplayDetectorFactoryForSSRC(func(uint32) replaydetector.ReplayDetector { return fn() })
+}
+
+// SRTCPReplayDetectorFactory sets custom SRTCP replay detector.
+func SRTCPReplayDetectorFactory(fn func() replaydetector.ReplayDetector) ContextOption {
+	return SRTCPReplayDetectorFactoryForSSRC(func(uint32) replaydetector.ReplayDetector { return fn() })
+}
+
+// SRTPReplayDetectorFactoryForSSRC sets custom SRTP replay detector created for each SSRC. Unlike
+// SRTPReplayDetectorFactory, the factory gets SSRC of the stream, so the detector may keep its state
+// outside of the Context, e.g. in shared memory or a clustered store shared by multiple media servers.
+// Detectors are created when the first packet of SSRC is processed, or when Context state is restored.
+// Calls to the detector of one SSRC are serialized by the Context.
+func SRTPReplayDetectorFactoryForSSRC( // nolint:revive
+	fn func(ssrc uint32) replaydetector.ReplayDetector,
+) ContextOption {
 	return func(c *Context) error {
+		c.srtpReplayWindowSize = 0
 		c.newSRTPReplayDetector = fn
 
 		return nil
 	}
 }
 
-// SRTCPReplayDetectorFactory sets custom SRTCP replay detector.
-func SRTCPReplayDetectorFactory(fn func() replaydetector.ReplayDetector) ContextOption {
+// SRTCPReplayDetectorFactoryForSSRC sets custom SRTCP replay detector created for each SSRC.
+// It works like SRTPReplayDetectorFactoryForSSRC.
+func SRTCPReplayDetectorFactoryForSSRC(fn func(ssrc uint32) replaydetector.ReplayDetector) ContextOption {
 	return func(c *Context) error {
+		c.srtcpReplayWindowSize = 0
 		c.newSRTCPReplayDetector = fn
 
 		return nil
@@ -105,6 +160,9 @@ func SRTPEncryption() ContextOption { // nolint:revive
 // This option is useful when you want to use NullCipher for SRTP and keep authentication only.
 // It simplifies debugging and testing, but it is not recommended for production use.
 //
+// It may be also used when payload is already end-to-end encrypted, e.g. with SFrame, so only hop-by-hop
+// authentication of the header and payload is needed. See Context.RTPOverhead to fit packets into MTU.
+//
 // Note: you can also use SRTPAuthenticationTagLength(0) to disable authentication tag too.
 func SRTPNoEncryption() ContextOption { // nolint:revive
 	return func(c *Context) error {
@@ -126,6 +184,8 @@ func SRTCPEncryption() ContextOption {
 // SRTCPNoEncryption disables SRTCP encryption.
 // This option is useful when you want to use NullCipher for SRTCP and keep authentication only.
 // It simplifies debugging and testing, but it is not recommended for production use.
+// SRTCP packets are sent unencrypted with E-flag cleared, and are still authenticated, as allowed by
+// RFC 3711 section 3.4, so they can be read e.g. by monitoring middleboxes.
 func SRTCPNoEncryption() ContextOption {
 	return func(c *Context) error {
 		c.encryptSRTCP = false
@@ -159,6 +219,21 @@ func RolloverCounterCarryingTransform(mode RCCMode, rocTransmitRate uint16) Cont
 	}
 }
 
+// SRTPEncryptedHeaderExtensions enables encryption of RTP header extensions with given IDs, as
+// defined in RFC 6904. IDs are usually negotiated with "urn:ietf:params:rtp-hdrext:encrypt" URI in
+// a=extmap SDP attributes. Extensions are encrypted by EncryptRTP and decrypted by DecryptRTP.
+// Only one-byte and two-byte header extensions from RFC 8285 are encrypted.
+//
+// Encryption of header extensions is supported for AES-CM protection profiles only, and it cannot
+// be used together with Cryptex.
+func SRTPEncryptedHeaderExtensions(ids ...uint8) ContextOption { // nolint:revive
+	return func(c *Context) error {
+		c.encryptedHeaderExtensionIDs = append([]uint8{}, ids...)
+
+		return nil
+	}
+}
+
 // SRTPAuthenticationTagLength sets length of SRTP authentication tag in bytes for AES-CM protection
 // profiles. Decreasing the length of the authentication tag is not recommended for production use,
 // as it decreases integrity protection.
@@ -174,6 +249,74 @@ func SRTPAuthenticationTagLength(authTagRTPLen int) ContextOption { // nolint:re
 	}
 }
 
+// KeyDerivationRate sets key derivation rate from RFC 3711 section 4.3.1. Session keys are re-derived
+// from the master key every kdr packets: SRTP packets use keys derived for their packet index
+// (ROC << 16 | SEQ) divided by kdr, and SRTCP packets for their SRTCP index divided by kdr. kdr must
+// be zero or a power of 2 not greater than 2^24. Zero, the default, means that session keys are
+// derived only once.
+//
+// Key derivation rate is usually signaled with KDR session parameter of SDES. DTLS-SRTP always uses
+// zero key derivation rate.
+func KeyDerivationRate(kdr uint64) ContextOption {
+	return func(c *Context) error {
+		if kdr > maxKeyDerivationRate || kdr&(kdr-1) != 0 {
+			return fmt.Errorf("%w: %d", errInvalidKeyDerivationRate, kdr)
+		}
+		c.keyDerivationRate = kdr
+
+		return nil
+	}
+}
+
+// ExternalKeyDerivation sets function which derives session keys instead of AES-CM PRF, e.g. one
+// backed by an HSM, a cloud KMS or a FIPS module, so the master key does not have to be held in memory.
+// All keys of the Context must be created with empty master key and salt, e.g. by CreateContext(nil, nil,
+// profile, ExternalKeyDerivation(kdf)); an error is returned when non-empty master key or salt is passed.
+// Master keys are not known, so MarshalBinary returns an error when KeyPersistence option is set.
+// External key derivation is not supported for double AEAD profiles and with EKT.
+func ExternalKeyDerivation(kdf KeyDerivationFunc) ContextOption {
+	return func(c *Context) error {
+		c.keyDerivationFunc = kdf
+
+		return nil
+	}
+}
+
+// RequireFIPS restricts the Context to FIPS 140 approved algorithms, as required e.g. by government
+// deployments. AES-CM and AES-GCM profiles are allowed, and CreateContext fails with an error wrapping
+// ErrNotFIPSApproved when NULL profile, disabled SRTP or SRTCP encryption, authentication tag shorter
+// than 32 bits or an Unsafe* option is configured. Keys with other profiles, e.g. returned by
+// SRTPKeySelector, are rejected in the same way. AES-CM keystream is always generated by cipher.NewCTR,
+// so all AES and HMAC operations are done by the standard library.
+//
+// This option does not enable FIPS 140-3 mode of the Go Cryptographic Module. It must be enabled
+// with GODEBUG=fips140=on, see crypto/fips140 package. Note that GODEBUG=fips140=only disallows
+// GCM with nonces generated by the caller, so AES-GCM profiles cannot be used in that mode.
+func RequireFIPS() ContextOption {
+	return func(c *Context) error {
+		c.requireFIPS = true
+
+		return nil
+	}
+}
+
+// SRTPTruncatedAuthTagPolicy sets policies for SRTP authentication tags shorter than 80 bits, separately
+// for encrypted and decrypted packets. It allows e.g. to keep sending packets with 32-bit tags to legacy
+// receivers, while accepting only full-length tags. RFC 3711 section 7.5 advises against short tags when
+// forged packets may cause more harm than a short glitch, e.g. when decrypted packets are forwarded
+// further by a relay, so such receivers should reject truncated tags.
+//
+// Packets rejected by a policy fail with an error wrapping ErrTruncatedAuthTag. Policies apply to
+// AES-CM and NULL profiles only, and to all keys of the Context, including ones selected by MKI.
+func SRTPTruncatedAuthTagPolicy(encrypt, decrypt TruncatedAuthTagPolicy) ContextOption { // nolint:revive
+	return func(c *Context) error {
+		c.encryptTruncatedAuthTagPolicy = encrypt
+		c.decryptTruncatedAuthTagPolicy = decrypt
+
+		return nil
+	}
+}
+
 // Cryptex allows to enable Cryptex mechanism to completely encrypt RTP Header Extensions and Contributing
 // Sources, as defined in RFC 9335.
 func Cryptex(cryptexMode CryptexMode) ContextOption {
@@ -183,3 +326,455 @@ func Cryptex(cryptexMode CryptexMode) ContextOption {
 		return nil
 	}
 }
+
+// Clock sets function used by Context to get current time. By default time.Now is used.
+// This option is intended mainly for testing.
+func Clock(now func() time.Time) ContextOption {
+	return func(c *Context) error {
+		c.now = now
+
+		return nil
+	}
+}
+
+// SRTPAuthFailureLimit enables temporary blacklisting of SSRCs which receive too many SRTP packets
+// with invalid authentication tag. When more than maxFailures authentication failures happen within
+// the window for an already known SSRC, all packets of that SSRC are rejected with ErrSSRCBlacklisted
+// during the cooldown period, without spending CPU on authentication.
+//
+// Only SSRCs with at least one successfully authenticated packet are tracked, so forged packets
+// with random SSRCs cannot grow the internal state.
+func SRTPAuthFailureLimit(maxFailures uint, window, cooldown time.Duration) ContextOption {
+	return func(c *Context) error {
+		if window <= 0 || cooldown <= 0 {
+			return errInvalidAuthFailureLimit
+		}
+		c.authFailureLimit = maxFailures
+		c.authFailureWindow = window
+		c.authFailureCooldown = cooldown
+
+		return nil
+	}
+}
+
+// SRTPROCRecovery enables recovery from wrongly guessed SRTP ROC, e.g. when a receiver joins the stream
+// mid-stream or after a long packet loss. When a packet fails authentication, its decryption is retried
+// with ROC greater and lower by one than the guessed one. When one of them succeeds, ROC of the SSRC is
+// resynchronized to it. Recovery is not used for packets which carry ROC, e.g. with RCC.
+//
+// Every forged packet is authenticated up to three times in this mode, and packets decrypted in place
+// are copied before decryption.
+func SRTPROCRecovery() ContextOption { // nolint:revive
+	return func(c *Context) error {
+		c.rocRecovery = true
+
+		return nil
+	}
+}
+
+// SSRCStateLimit limits SRTP and SRTCP per-SSRC state kept by the Context. When maxSSRCs is positive,
+// the least recently used state is evicted before state of a new SSRC is added above the limit. When
+// idleTimeout is positive, states not used for longer than idleTimeout are evicted when state of a new
+// SSRC is added. Zero disables the respective limit. SRTP and SRTCP states are limited separately.
+//
+// Newest packet index of an evicted SSRC is remembered, so when the SSRC appears again its ROC and SRTCP
+// index continue from the old values, and with replay protection enabled packets not newer than ones
+// processed before the eviction are rejected. Indexes of up to max(maxSSRCs, 1024) evicted SSRCs are
+// remembered. After that, states are no longer evicted, and packets of new SSRCs are rejected with
+// ErrTooManySSRCs until an evicted SSRC appears again.
+func SSRCStateLimit(maxSSRCs int, idleTimeout time.Duration) ContextOption {
+	return func(c *Context) error {
+		if maxSSRCs < 0 || idleTimeout < 0 {
+			return errInvalidSSRCStateLimit
+		}
+		c.maxSSRCStates = maxSSRCs
+		c.ssrcIdleTimeout = idleTimeout
+
+		return nil
+	}
+}
+
+// SRTPKeySelector sets a function which selects keys used to decrypt SRTP packets, based on parsed
+// header fields, e.g. the MID header extension. Function is called for every decrypted packet, and
+// it must return remote master key and salt (SessionKeys.RemoteMasterKey and RemoteMasterSalt)
+// together with protection profile. Other fields of SessionKeys are ignored. Ciphers are created
+// on demand, and a limited number of them is cached by the Context, so the selector should return
+// one of few keys known to the application.
+//
+// When the selector is set, it replaces key lookup by MKI for SRTP packets. The header is not
+// authenticated yet when the selector is called. SRTCP packets are not affected.
+func SRTPKeySelector(fn func(header *rtp.Header) (SessionKeys, ProtectionProfile, error)) ContextOption {
+	return func(c *Context) error {
+		c.keySelector = fn
+
+		return nil
+	}
+}
+
+// OnNewSSRC sets a callback which is called when the Context creates SRTP or SRTCP state for a new SSRC.
+// For decrypted packets it is called after the first packet of the SSRC is successfully authenticated.
+// It is also called when state is created by encrypting packets or by SetROC/SetIndex and similar
+// functions. The callback is called synchronously, and the Context does not hold any lock while
+// calling it, so it may call Context methods.
+func OnNewSSRC(fn func(ssrc uint32, isRTCP bool)) ContextOption {
+	return func(c *Context) error {
+		c.onNewSSRC = fn
+
+		return nil
+	}
+}
+
+// OnKeyExpired sets a callback which is called when the number of SRTP and SRTCP packets encrypted with
+// the current master key reaches threshold, e.g. 2^31. It allows applications to re-key proactively,
+// before encryption fails when packet limits from RFC 3711 section 9.2 are reached. mki is the MKI of
+// the key, or nil when MKI is not enabled. The callback is called once per key, synchronously from
+// the encrypting call. The counter is reset when the key is changed by UpdateMasterKey or SetSendMKI.
+func OnKeyExpired(threshold uint64, fn func(mki []byte)) ContextOption {
+	return func(c *Context) error {
+		c.keyUsageThreshold = threshold
+		c.onKeyExpired = fn
+
+		return nil
+	}
+}
+
+// KeyEvents passes key lifecycle events of the Context to manager. RekeyRequired event is emitted when
+// the number of SRTP and SRTCP packets encrypted with the current send key reaches rekeyThreshold (zero
+// disables it), and when SRTCP index of a SSRC reaches the threshold set by OnSRTCPIndexWarning option.
+// KeyExpired event is emitted when receive key added by AddReceiveKey expires, and KeyReady event when
+// a new key is received in EKT Field. The counter of encrypted packets is reset when the key is changed
+// by UpdateMasterKey or SetSendMKI, like for OnKeyExpired option.
+func KeyEvents(manager KeyManager, rekeyThreshold uint64) ContextOption {
+	return func(c *Context) error {
+		c.keyManager = manager
+		c.rekeyThreshold = rekeyThreshold
+
+		return nil
+	}
+}
+
+// OnSRTCPIndexWarning sets a callback which is called when SRTCP index of encrypted packet gets within
+// remaining indexes of the 2^31 limit from RFC 3711 section 9.2, after which packets of the SSRC cannot
+// be encrypted with the same key. It allows applications to re-key before encryption fails with
+// ErrSRTCPIndexExhausted. The callback is called once per SSRC, synchronously from the encrypting call.
+// It may be called again after the index is changed by SetIndex or rolls over.
+func OnSRTCPIndexWarning(remaining uint32, fn func(ssrc, index uint32)) ContextOption {
+	return func(c *Context) error {
+		if remaining > maxSRTCPIndex {
+			return fmt.Errorf("%w: %d", errInvalidSRTCPIndex, remaining)
+		}
+		c.srtcpIndexWarning = remaining
+		c.onSRTCPIndexWarning = fn
+
+		return nil
+	}
+}
+
+// SRTCPIndexRolloverOnRekey allows SRTCP index of SSRC to roll over and start from 1 again after all
+// 2^31 indexes were used, but only when the send key was changed by UpdateMasterKey or SetSendMKI since
+// the current sequence of indexes was started, so index is never reused with the same key, as required
+// by RFC 3711 section 9.2. Without the option, or without the new key, encryption fails with
+// ErrSRTCPIndexExhausted.
+//
+// The option also makes receiving Context accept SRTCP indexes which started from 1 again, when they are
+// received in packets authenticated with the new key, set by UpdateMasterKey or SetSendMKI after
+// the sequence of received indexes was started. Replay protection state of the SSRC is then reset.
+func SRTCPIndexRolloverOnRekey() ContextOption {
+	return func(c *Context) error {
+		c.srtcpIndexRollover = true
+
+		return nil
+	}
+}
+
+// SRTPDecryptLatencyRecorder sets recorder which receives time spent on decryption and authentication
+// of SRTP packets. Time is measured using the clock set by Clock option.
+func SRTPDecryptLatencyRecorder(recorder LatencyRecorder) ContextOption {
+	return func(c *Context) error {
+		c.latencyRecorder = recorder
+
+		return nil
+	}
+}
+
+// PacketStatsRecorder sets recorder which is notified about every encrypted and decrypted SRTP and
+// SRTCP packet, and about packets which failed to decrypt. Use it to update external metrics, like
+// Prometheus counters, without wrapping every call. Counters are also available with Context.Stats.
+func PacketStatsRecorder(recorder StatsRecorder) ContextOption {
+	return func(c *Context) error {
+		c.statsRecorder = recorder
+
+		return nil
+	}
+}
+
+// SRTPSingleSSRC makes EncryptRTP return an error when SSRC of a packet differs from SSRC of the first
+// encrypted packet. It helps to detect accidental reuse of Context for multiple streams. By default
+// Context can encrypt packets with any number of SSRCs.
+func SRTPSingleSSRC() ContextOption {
+	return func(c *Context) error {
+		c.singleSSRC = true
+
+		return nil
+	}
+}
+
+// SRTPHeaderExtensionInserter makes Context add RTP header extension with given ID to every encrypted
+// SRTP packet. Extension value is returned by value function, which is called with the original header
+// of the packet. Extension replaces one with the same ID, if it is already present. Extension is added
+// before encryption, so it is authenticated, and encrypted too when Cryptex is used.
+//
+// One-byte header extensions are used when possible, as described for rtp.Header.SetExtension.
+// The header passed to EncryptRTP is not modified.
+func SRTPHeaderExtensionInserter(id uint8, value func(header *rtp.Header) []byte) ContextOption {
+	return func(c *Context) error {
+		c.headerExtensionID = id
+		c.headerExtensionValue = value
+
+		return nil
+	}
+}
+
+// DecryptFailureSampler sets a function which is called when decryption of SRTP or SRTCP packet fails,
+// e.g. for forensic capture of attack samples. Function receives the error and up to sampleSize
+// first bytes of the packet, as received. When sampleSize is zero or negative, 64 bytes are used.
+// Sample contains only packet data, no key material is included.
+func DecryptFailureSampler(sampleSize int, fn func(reason error, sample []byte)) ContextOption {
+	return func(c *Context) error {
+		if sampleSize <= 0 {
+			sampleSize = defaultFailureSampleSize
+		}
+		c.failureSampler = fn
+		c.failureSampleSize = sampleSize
+
+		return nil
+	}
+}
+
+// UnsafeGCMMasterSaltLength sets non-standard length of master salt for AEAD_AES_128_GCM and
+// AEAD_AES_256_GCM profiles, from 12 to 14 bytes. RFC 7714 requires 12-byte master salt, longer ones
+// are used by some broken implementations. Whole salt is used by the key derivation function, and
+// 12-byte session salts are derived from it. Longer salts are rejected, because the key derivation
+// function uses at most 14 bytes of the salt.
+//
+// This option is NOT standard compliant. It is intended for interoperability testing and debugging only.
+// It is ignored for non-AEAD profiles.
+func UnsafeGCMMasterSaltLength(saltLen int) ContextOption {
+	return func(c *Context) error {
+		if saltLen < gcmSessionSaltLen || saltLen > aesCmPRFSaltLen {
+			return fmt.Errorf("%w: %d", errInvalidGCMMasterSaltLength, saltLen)
+		}
+		c.gcmMasterSaltLen = saltLen
+
+		return nil
+	}
+}
+
+// UnsafeDebugKeystream enables Context.DebugKeystreamRTP, which exposes raw SRTP keystream.
+// It is intended for cryptographic debugging and cross-checking against reference vectors only.
+// Keystream allows to decrypt and forge packet payloads, so this option MUST NOT be used in production.
+func UnsafeDebugKeystream() ContextOption {
+	return func(c *Context) error {
+		c.debugKeystream = true
+
+		return nil
+	}
+}
+
+// UnsafeConformanceTesting enables Context.DebugSessionKeys, DebugRTPIV, DebugRTCPIV and
+// DebugKeystreamRTP, which expose intermediate values of SRTP transforms. It is intended for validation
+// against RFC test vectors and for FIPS CAVP testing only. Exposed values allow to decrypt and forge
+// packets, so this option MUST NOT be used in production.
+func UnsafeConformanceTesting() ContextOption {
+	return func(c *Context) error {
+		c.conformanceTesting = true
+		c.debugKeystream = true
+
+		return nil
+	}
+}
+
+// UnsafeGCMNonceSource replaces construction of IVs used by AEAD profiles with fn, e.g. to reproduce
+// test vectors with arbitrary IVs. DefaultGCMNonce may be used by fn to compute the standard IV with
+// some of the inputs fixed.
+//
+// Reusing IV with the same key completely breaks AES-GCM security, so this option is intended for
+// conformance testing only, and it MUST NOT be used in production. It is ignored for non-AEAD profiles.
+func UnsafeGCMNonceSource(fn GCMNonceFunc) ContextOption {
+	return func(c *Context) error {
+		c.gcmNonceFunc = fn
+
+		return nil
+	}
+}
+
+// GCMAdditionalData extends or transforms additional authenticated data (AAD) of SRTP and SRTCP packets
+// protected with AEAD profiles, both when they are encrypted and decrypted. It allows interoperability
+// with proprietary systems which authenticate extra bytes, e.g. OHB or vendor trailer, with the packet.
+// Bytes added by fn are not sent in the packet, so both endpoints must add the same ones.
+// The option is ignored for non-AEAD and Double AEAD profiles.
+func GCMAdditionalData(fn GCMAdditionalDataFunc) ContextOption {
+	return func(c *Context) error {
+		c.gcmAADFunc = fn
+
+		return nil
+	}
+}
+
+// CipherTimeout limits time spent by a custom cipher passed to CreateContextWithCipher on encryption
+// or decryption of one packet. The cipher must implement ContextCipher, it gets context which is done
+// after the timeout. When operation is aborted, ErrCipherTimeout is returned. Zero value disables the limit.
+//
+// Built-in ciphers are not affected by this option.
+func CipherTimeout(timeout time.Duration) ContextOption {
+	return func(c *Context) error {
+		c.cipherTimeout = timeout
+
+		return nil
+	}
+}
+
+// UnsafePlaintextPassthrough enables passing through plaintext RTP packets by
+// Context.DecryptRTPOrPassthrough. When a packet fails authentication, but it looks like a plaintext
+// RTP packet, it is returned as-is with a flag which marks it as plaintext. It is intended only for
+// migration of deployments where some senders do not encrypt their packets yet.
+//
+// WARNING: this option completely defeats SRTP protection of received packets. Anyone who can send
+// packets to the receiver can inject arbitrary unauthenticated media, and passed through packets are
+// not checked for replay. Callers MUST check the plaintext flag and treat such packets as untrusted.
+// DO NOT use it in production after the migration is complete.
+func UnsafePlaintextPassthrough() ContextOption {
+	return func(c *Context) error {
+		c.plaintextPassthrough = true
+
+		return nil
+	}
+}
+
+// SRTCPStrictEncryptionFlag makes DecryptRTCP reject SRTCP packets whose E-flag does not match the
+// configuration of the Context, e.g. unencrypted packets received when SRTCP encryption is enabled.
+// By default packets are decrypted according to their E-flag, which may mask misconfiguration
+// or downgrade attacks. Rejected packets fail with an error wrapping ErrSRTCPEncryptionFlagMismatch.
+func SRTCPStrictEncryptionFlag() ContextOption {
+	return func(c *Context) error {
+		c.strictSRTCPEncryptionFlag = true
+
+		return nil
+	}
+}
+
+// TraceHook sets a function which is called with TraceEvent for each SRTP and SRTCP packet encrypted
+// or decrypted by the Context, including packets which failed authentication. Events contain packet
+// indexes, IVs, and layout of the protected packet, so interoperability problems with other SRTP stacks
+// can be diagnosed without patching the library. Packets rejected before decryption, e.g. replayed
+// ones, are not traced. The function is called synchronously, and computing the IVs adds overhead,
+// so the option is intended for debugging only. IVs are derived from session keys, so events should
+// not be logged in production.
+func TraceHook(fn func(event TraceEvent)) ContextOption {
+	return func(c *Context) error {
+		c.traceHook = fn
+
+		return nil
+	}
+}
+
+// UniformDecryptFailures makes DecryptRTP, DecryptRTCP and related functions return ErrDecryptFailed
+// when authentication of a packet fails, its MKI is unknown, it is too short or its header cannot be parsed,
+// so these failures cannot be distinguished by the sender of the packet, e.g. from timing of responses or
+// from logs exposed to it. Packets rejected before authentication are authenticated with the send key
+// anyway, so all these failures take about the same time. The original error is passed to fn, when it is
+// not nil, e.g. to log it internally. Other errors, e.g. of replayed packets, are returned as they are.
+func UniformDecryptFailures(fn func(err error, isRTCP bool)) ContextOption {
+	return func(c *Context) error {
+		c.uniformDecryptFailures = true
+		c.onDecryptFailure = fn
+
+		return nil
+	}
+}
+
+// StrictParsing makes DecryptRTP, DecryptRTCP and related functions check received packets against limits,
+// before keys are looked up and any cryptographic operation is done. Packets which exceed the limits fail
+// with an error wrapping ErrPacketLimitExceeded, and packets which are too short or have wrong RTP version
+// are rejected too. It allows internet-facing endpoints to cheaply drop floods of garbage packets,
+// instead of spending CPU time on their authentication. Zero fields of limits are not checked.
+func StrictParsing(limits ParsingLimits) ContextOption {
+	return func(c *Context) error {
+		if limits.MaxPacketSize < 0 || limits.MaxCSRCCount < 0 || limits.MaxHeaderExtensionLen < 0 {
+			return errInvalidParsingLimits
+		}
+		c.strictParsing = true
+		c.parsingLimits = limits
+
+		return nil
+	}
+}
+
+// SRTCPAcceptUnencrypted sets whether DecryptRTCP accepts authenticated SRTCP packets with E-flag cleared,
+// like ones sent by a Context with SRTCPNoEncryption option. They are accepted by default. When accept
+// is false, such packets are rejected with an error wrapping ErrSRTCPNotEncrypted, regardless of
+// the SRTCP encryption setting of the Context.
+func SRTCPAcceptUnencrypted(accept bool) ContextOption {
+	return func(c *Context) error {
+		c.rejectUnencryptedSRTCP = !accept
+
+		return nil
+	}
+}
+
+// SRTCPValidateCompound makes DecryptRTCP check that decrypted SRTCP packet is a well-formed RTCP
+// compound packet, i.e. it can be parsed by pion/rtcp. Malformed packets, e.g. ones decrypted with
+// a wrong key when authentication is disabled, are rejected with an error wrapping ErrMalformedRTCP,
+// so they do not reach downstream parsers. Order of packets in the compound is not checked, so
+// reduced-size RTCP from RFC 5506 is accepted.
+func SRTCPValidateCompound() ContextOption {
+	return func(c *Context) error {
+		c.validateRTCPCompound = true
+
+		return nil
+	}
+}
+
+// EncryptedKeyTransport enables Encrypted Key Transport from RFC 8870. EncryptRTP appends EKT Field
+// to every SRTP packet: Full EKT Field with master key of the Context encrypted with the given EKT key
+// is sent in the first packet of each SSRC and then in every fullFieldInterval-th packet, and Short
+// EKT Field in others. Zero fullFieldInterval sends Full EKT Field in every packet. DecryptRTP strips
+// EKT Fields, and uses master keys and ROC received in Full EKT Fields to decrypt packets of their
+// SSRCs. More keys for received packets can be added with Context.AddEKTKey.
+// EKT cannot be used together with MKI.
+func EncryptedKeyTransport(key EKTKey, fullFieldInterval uint) ContextOption {
+	return func(c *Context) error {
+		if _, err := newEKTBlockCipher(key.Key); err != nil {
+			return err
+		}
+		c.ektSendKey = key
+		c.ektKeys = map[uint16]EKTKey{key.SPI: key}
+		c.ektFullFieldInterval = fullFieldInterval
+
+		return nil
+	}
+}
+
+// KeyPersistence makes Context.MarshalBinary include master keys with per-SSRC state of the Context
+// for warm restarts. Master keys are kept in memory by the Context when it is enabled. Serialized
+// master keys and state are sealed with sealer, or stored in plain text when it is nil.
+func KeyPersistence(sealer KeySealer) ContextOption {
+	return func(c *Context) error {
+		c.persistedKeys = map[string]persistedKey{}
+		c.keySealer = sealer
+
+		return nil
+	}
+}
+
+// SRTCPIndexAdvanceOnRestore makes Context.UnmarshalState and Context.UnmarshalBinary advance restored
+// SRTCP indexes by advance. Restoring a snapshot taken before the last SRTCP packets were sent would
+// use their indexes again, which reuses IVs. Set advance above the number of SRTCP packets which can be
+// sent per SSRC between snapshots. Restored indexes are capped at the maximum SRTCP index.
+func SRTCPIndexAdvanceOnRestore(advance uint32) ContextOption {
+	return func(c *Context) error {
+		c.srtcpIndexRestoreAdvance = advance
+
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// PacketConn is net.PacketConn which transparently protects packets sent and received through
+// underlying net.PacketConn with SRTP and SRTCP. RTP and RTCP packets are demultiplexed by payload
+// type, as described in RFC 5761 section 4, so they may share the connection (rtcp-mux).
+// It allows existing code using net.PacketConn to adopt SRTP without restructuring it around
+// sessions and streams.
+//
+// All peers use the same keys, so the connection is intended for a single remote peer, or for
+// peers which share keys.
+type PacketConn struct {
+	conn net.PacketConn
+
+	localContextMutex           sync.Mutex
+	remoteContextMutex          sync.Mutex
+	localContext, remoteContext *Context
+
+	onDecryptError func(err error, pkt []byte, isRTCP bool)
+}
+
+// NewPacketConn creates PacketConn using conn as the underlying transport. Keys, Profile, LocalOptions,
+// RemoteOptions and OnDecryptError fields of config are used, other ones are ignored. Replay protection
+// is enabled on remote context by default, like for SessionSRTP and SessionSRTCP.
+func NewPacketConn(conn net.PacketConn, config *Config) (*PacketConn, error) {
+	if config == nil {
+		return nil, errNoConfig
+	} else if conn == nil {
+		return nil, errNoConn
+	}
+
+	localContext, err := CreateContext(
+		config.Keys.LocalMasterKey, config.Keys.LocalMasterSalt, config.Profile, config.LocalOptions...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteOpts := append(
+		[]ContextOption{
+			// Default options
+			SRTPReplayProtection(defaultSessionSRTPReplayProtectionWindow),
+			SRTCPReplayProtection(defaultSessionSRTCPReplayProtectionWindow),
+		},
+		config.RemoteOptions...,
+	)
+	remoteContext, err := CreateContext(
+		config.Keys.RemoteMasterKey, config.Keys.RemoteMasterSalt, config.Profile, remoteOpts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PacketConn{
+		conn:           conn,
+		localContext:   localContext,
+		remoteContext:  remoteContext,
+		onDecryptError: config.OnDecryptError,
+	}, nil
+}
+
+// ReadFrom reads a packet from the underlying connection, and decrypts it into p. Packets which cannot
+// be decrypted or authenticated are dropped, and passed to Config.OnDecryptError when it is set.
+// p must be big enough to hold the encrypted packet.
+func (c *PacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	// Packets are decrypted in place, so a copy is kept for the decrypt error callback.
+	var received []byte
+	for {
+		n, addr, err := c.conn.ReadFrom(p)
+		if err != nil {
+			return 0, addr, err
+		}
+
+		if c.onDecryptError != nil {
+			received = append(received[:0], p[:n]...)
+		}
+		isRTCP := isRTCPPacket(p[:n])
+		decrypted, err := c.decrypt(p[:n], isRTCP)
+		if err == nil {
+			return len(decrypted), addr, nil
+		}
+		if c.onDecryptError != nil {
+			c.onDecryptError(err, received, isRTCP)
+		}
+	}
+}
+
+func (c *PacketConn) decrypt(buf []byte, isRTCP bool) ([]byte, error) {
+	c.remoteContextMutex.Lock()
+	defer c.remoteContextMutex.Unlock()
+
+	if isRTCP {
+		return c.remoteContext.DecryptRTCP(buf, buf, nil)
+	}
+
+	return c.remoteContext.DecryptRTP(buf, buf, nil)
+}
+
+// WriteTo encrypts RTP or RTCP packet p, and writes it to addr. p is not modified. On success it
+// returns length of p.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	wbuf, ok := writeBufferPool.Get().(*writeBuffer)
+	if !ok {
+		return 0, errFailedTypeAssertion
+	}
+	defer writeBufferPool.Put(wbuf)
+
+	var encrypted []byte
+	var err error
+	c.localContextMutex.Lock()
+	if isRTCPPacket(p) {
+		encrypted, err = c.localContext.EncryptRTCP(wbuf.buf, p, nil)
+	} else {
+		encrypted, err = c.localContext.EncryptRTP(wbuf.buf, p, &wbuf.header)
+	}
+	c.localContextMutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = c.conn.WriteTo(encrypted, addr); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the underlying connection.
+func (c *PacketConn) Close() error {
+	return c.conn.Close()
+}
+
+// LocalAddr returns the local network address of the underlying connection.
+func (c *PacketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// SetDeadline sets read and write deadlines of the underlying connection.
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets read deadline of the underlying connection.
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets write deadline of the underlying connection.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPacketConnErrors(t *testing.T) {
+	_, err := NewPacketConn(nil, nil)
+	assert.ErrorIs(t, err, errNoConfig)
+
+	_, err = NewPacketConn(nil, &Config{})
+	assert.ErrorIs(t, err, errNoConn)
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, conn.Close()) }()
+	_, err = NewPacketConn(conn, &Config{Profile: ProtectionProfileAes128CmHmacSha1_80})
+	assert.Error(t, err)
+}
+
+func TestPacketConn(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	session, pipe, config := buildSessionSRTP(t)
+	assert.NoError(t, session.Close())
+	assert.NoError(t, pipe.Close())
+
+	aConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	bConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	type decryptError struct {
+		err    error
+		pkt    []byte
+		isRTCP bool
+	}
+	var decryptErrors []decryptError
+	bConfig := *config
+	bConfig.OnDecryptError = func(err error, pkt []byte, isRTCP bool) {
+		decryptErrors = append(decryptErrors, decryptError{err, append([]byte{}, pkt...), isRTCP})
+	}
+
+	aPacketConn, err := NewPacketConn(aConn, config)
+	require.NoError(t, err)
+	bPacketConn, err := NewPacketConn(bConn, &bConfig)
+	require.NoError(t, err)
+
+	rtpPacket, err := (&rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: 5000, SequenceNumber: 1, PayloadType: 96},
+		Payload: []byte{0x00, 0x01, 0x02, 0x03},
+	}).Marshal()
+	require.NoError(t, err)
+	rtcpPacket, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 5000}})
+	require.NoError(t, err)
+
+	// Packet which fails authentication is dropped.
+	forged := append(append([]byte{}, rtpPacket...), make([]byte, 10)...)
+	_, err = aConn.WriteTo(forged, bConn.LocalAddr())
+	assert.NoError(t, err)
+
+	for _, pkt := range [][]byte{rtpPacket, rtcpPacket} {
+		sent := append([]byte{}, pkt...)
+		n, err := aPacketConn.WriteTo(sent, bPacketConn.LocalAddr())
+		assert.NoError(t, err)
+		assert.Equal(t, len(pkt), n)
+		assert.Equal(t, pkt, sent)
+	}
+
+	buf := make([]byte, 1500)
+	n, addr, err := bPacketConn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, rtpPacket, buf[:n])
+	assert.Equal(t, aPacketConn.LocalAddr(), addr)
+	n, _, err = bPacketConn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpPacket, buf[:n])
+
+	if assert.Len(t, decryptErrors, 1) {
+		assert.ErrorIs(t, decryptErrors[0].err, ErrFailedToVerifyAuthTag)
+		assert.Equal(t, forged, decryptErrors[0].pkt)
+		assert.False(t, decryptErrors[0].isRTCP)
+	}
+
+	assert.NoError(t, bPacketConn.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	_, _, err = bPacketConn.ReadFrom(buf)
+	var netErr net.Error
+	if assert.ErrorAs(t, err, &netErr) {
+		assert.True(t, netErr.Timeout())
+	}
+
+	assert.NoError(t, aPacketConn.Close())
+	assert.NoError(t, bPacketConn.Close())
+}
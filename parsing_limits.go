@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ParsingLimits contains limits of received packets enforced by StrictParsing option.
+// Zero value of a field disables the limit.
+type ParsingLimits struct {
+	// MaxPacketSize is the maximum size of received SRTP or SRTCP packet, including auth tag and MKI.
+	MaxPacketSize int
+	// MaxCSRCCount is the maximum number of CSRCs in SRTP packet header.
+	MaxCSRCCount int
+	// MaxHeaderExtensionLen is the maximum length of SRTP header extension, excluding its 4-byte header.
+	MaxHeaderExtensionLen int
+}
+
+// checkRTPLimits checks received SRTP packet against limits set by StrictParsing option.
+// Values are read from the packet itself, so the check does not depend on how its header was parsed.
+func (c *Context) checkRTPLimits(packet []byte) error {
+	if !c.strictParsing {
+		return nil
+	}
+
+	limits := c.parsingLimits
+	if limits.MaxPacketSize > 0 && len(packet) > limits.MaxPacketSize {
+		return &packetLimitError{Proto: "srtp", Limit: "packet size", Value: len(packet), Max: limits.MaxPacketSize}
+	}
+	if len(packet) < minSrtpHeaderSize {
+		return fmt.Errorf("%w: %d", errTooShortRTP, len(packet))
+	}
+	if version := packet[0] >> 6; version != rtpVersion {
+		return fmt.Errorf("%w: %d", errInvalidRTPVersion, version)
+	}
+
+	csrcCount := int(packet[0] & 0x0f)
+	if limits.MaxCSRCCount > 0 && csrcCount > limits.MaxCSRCCount {
+		return &packetLimitError{Proto: "srtp", Limit: "CSRC count", Value: csrcCount, Max: limits.MaxCSRCCount}
+	}
+
+	// Header extension length is in 32-bit words, and it follows 12-byte fixed header and CSRCs.
+	extOffset := 12 + csrcCount*4
+	if limits.MaxHeaderExtensionLen > 0 && packet[0]&0x10 != 0 && len(packet) >= extOffset+4 {
+		extLen := int(binary.BigEndian.Uint16(packet[extOffset+2:])) * 4
+		if extLen > limits.MaxHeaderExtensionLen {
+			return &packetLimitError{
+				Proto: "srtp", Limit: "header extension length", Value: extLen, Max: limits.MaxHeaderExtensionLen,
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRTCPLimits checks received SRTCP packet against limits set by StrictParsing option.
+func (c *Context) checkRTCPLimits(packet []byte) error {
+	if !c.strictParsing {
+		return nil
+	}
+
+	limits := c.parsingLimits
+	if limits.MaxPacketSize > 0 && len(packet) > limits.MaxPacketSize {
+		return &packetLimitError{Proto: "srtcp", Limit: "packet size", Value: len(packet), Max: limits.MaxPacketSize}
+	}
+	if len(packet) < srtcpHeaderSize {
+		return fmt.Errorf("%w: %d", errTooShortRTCP, len(packet))
+	}
+	if version := packet[0] >> 6; version != rtpVersion {
+		return fmt.Errorf("%w: %d", errInvalidRTPVersion, version)
+	}
+
+	return nil
+}
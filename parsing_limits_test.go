@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictParsing(t *testing.T) {
+	limits := ParsingLimits{MaxPacketSize: 100, MaxCSRCCount: 2, MaxHeaderExtensionLen: 8}
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, StrictParsing(limits))
+	assert.NoError(t, err)
+
+	encrypt := func(pkt *rtp.Packet) []byte {
+		raw, err := pkt.Marshal()
+		assert.NoError(t, err)
+		encrypted, err := encryptCtx.EncryptRTP(nil, raw, nil)
+		assert.NoError(t, err)
+
+		return encrypted
+	}
+	withExtension := func(extLen int) *rtp.Packet {
+		pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 4}, Payload: []byte{0x01}}
+		assert.NoError(t, pkt.SetExtension(1, make([]byte, extLen)))
+
+		return pkt
+	}
+
+	for name, testCase := range map[string]struct {
+		pkt   *rtp.Packet
+		valid bool
+	}{
+		"Valid": {
+			pkt:   &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 1, CSRC: []uint32{1, 2}}},
+			valid: true,
+		},
+		"TooLarge": {
+			pkt: &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 2}, Payload: make([]byte, 80)},
+		},
+		"TooManyCSRCs": {
+			pkt: &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 3, CSRC: []uint32{1, 2, 3}}},
+		},
+		// One-byte header extension element with 7 bytes of data is padded to 8 bytes.
+		"ValidExtension":   {pkt: withExtension(7), valid: true},
+		"TooLongExtension": {pkt: withExtension(8)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decryptCtx.DecryptRTP(nil, encrypt(testCase.pkt), nil)
+			if testCase.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrPacketLimitExceeded)
+			}
+		})
+	}
+
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	encrypted, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	encrypted, err = encryptCtx.EncryptRTCP(nil, append(rtcpPacket, make([]byte, 100)...), nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, ErrPacketLimitExceeded)
+
+	// Packets with wrong version are rejected before decryption.
+	encrypted = encrypt(&rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 5}})
+	encrypted[0] = encrypted[0]&0x3f | 0x40
+	_, err = decryptCtx.DecryptRTPWithHeaderLen(nil, encrypted, 12, 1, 5)
+	assert.ErrorIs(t, err, errInvalidRTPVersion)
+
+	_, err = buildTestContext(profileCTR, StrictParsing(ParsingLimits{MaxCSRCCount: -1}))
+	assert.ErrorIs(t, err, errInvalidParsingLimits)
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"fmt"
+	"slices"
+)
+
+// privateProtectionProfileID is the first protection profile ID of the private range, used for profiles
+// which do not have DTLS-SRTP Protection Profile ID assigned.
+const privateProtectionProfileID = 0xF000
+
+// ProfileCapabilities describes parameters of a protection profile and features which can be used
+// with it, e.g. to build SDP offers or to choose profiles for negotiation.
+type ProfileCapabilities struct {
+	Profile ProtectionProfile
+	// Name is the name returned by ProtectionProfile.String.
+	Name string
+	// SDESName is SDES crypto-suite name of the profile, or empty string when the profile cannot be
+	// negotiated by SDES.
+	SDESName string
+	// DTLSSRTP is true when the profile has DTLS-SRTP Protection Profile ID assigned, so it can be
+	// negotiated by DTLS.
+	DTLSSRTP bool
+
+	// Lengths of master key and salt, in bytes.
+	KeyLen  int
+	SaltLen int
+	// AuthKeyLen, AuthTagRTPLen and AuthTagRTCPLen are lengths of HMAC authentication key and tags
+	// of non-AEAD profiles, in bytes. AEADAuthTagLen is length of the tag of AEAD profiles.
+	AuthKeyLen     int
+	AuthTagRTPLen  int
+	AuthTagRTCPLen int
+	AEADAuthTagLen int
+
+	// AEAD is true for profiles which use AEAD cipher, and DoubleAEAD for double AEAD profiles
+	// from RFC 8723.
+	AEAD       bool
+	DoubleAEAD bool
+	// Encryption is false for null profiles, which only authenticate packets.
+	Encryption bool
+
+	// MKI is true when MKI can be used with the profile.
+	MKI bool
+	// Cryptex is true when the profile can be used with Cryptex option.
+	Cryptex bool
+	// HeaderExtensionEncryption is true when the profile can be used with SRTPEncryptedHeaderExtensions option.
+	HeaderExtensionEncryption bool
+	// FIPSApproved is true when the profile can be used with RequireFIPS option.
+	FIPSApproved bool
+}
+
+// Capabilities returns parameters and supported features of the protection profile.
+// It returns ErrUnsupportedProfile wrapped error for profiles not supported by this package.
+func (p ProtectionProfile) Capabilities() (ProfileCapabilities, error) {
+	keyLen, err := p.KeyLen()
+	if err != nil {
+		return ProfileCapabilities{}, fmt.Errorf("%w: %#v", ErrUnsupportedProfile, p)
+	}
+	// Other lengths are known for all supported profiles.
+	saltLen, _ := p.SaltLen()
+	authKeyLen, _ := p.AuthKeyLen()
+	authTagRTPLen, _ := p.AuthTagRTPLen()
+	authTagRTCPLen, _ := p.AuthTagRTCPLen()
+	aeadAuthTagLen, _ := p.AEADAuthTagLen()
+	sdesName, _ := p.sdesName()
+	encryption := p != ProtectionProfileNullHmacSha1_80 && p != ProtectionProfileNullHmacSha1_32
+
+	return ProfileCapabilities{
+		Profile:                   p,
+		Name:                      p.String(),
+		SDESName:                  sdesName,
+		DTLSSRTP:                  p < privateProtectionProfileID,
+		KeyLen:                    keyLen,
+		SaltLen:                   saltLen,
+		AuthKeyLen:                authKeyLen,
+		AuthTagRTPLen:             authTagRTPLen,
+		AuthTagRTCPLen:            authTagRTCPLen,
+		AEADAuthTagLen:            aeadAuthTagLen,
+		AEAD:                      p.isAEAD(),
+		DoubleAEAD:                p.isDoubleAEAD(),
+		Encryption:                encryption,
+		MKI:                       true,
+		Cryptex:                   encryption && !p.isDoubleAEAD(),
+		HeaderExtensionEncryption: encryption && !p.isAEAD() && !p.isF8(),
+		FIPSApproved:              p.isFIPSApproved(),
+	}, nil
+}
+
+// SupportedProfiles returns all protection profiles supported by this package.
+func SupportedProfiles() []ProtectionProfile {
+	return slices.Clone(supportedProtectionProfiles)
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileCapabilities(t *testing.T) {
+	caps, err := ProtectionProfileAeadAes128Gcm.Capabilities()
+	assert.NoError(t, err)
+	assert.Equal(t, ProfileCapabilities{
+		Profile:        ProtectionProfileAeadAes128Gcm,
+		Name:           "SRTP_AEAD_AES_128_GCM",
+		SDESName:       "AEAD_AES_128_GCM",
+		DTLSSRTP:       true,
+		KeyLen:         16,
+		SaltLen:        12,
+		AEADAuthTagLen: 16,
+		AEAD:           true,
+		Encryption:     true,
+		MKI:            true,
+		Cryptex:        true,
+		FIPSApproved:   true,
+	}, caps)
+
+	caps, err = ProtectionProfileAes192CmHmacSha1_32.Capabilities()
+	assert.NoError(t, err)
+	assert.Equal(t, ProfileCapabilities{
+		Profile:                   ProtectionProfileAes192CmHmacSha1_32,
+		Name:                      "SRTP_AES192_CM_HMAC_SHA1_32",
+		SDESName:                  "AES_192_CM_HMAC_SHA1_32",
+		KeyLen:                    24,
+		SaltLen:                   14,
+		AuthKeyLen:                20,
+		AuthTagRTPLen:             4,
+		AuthTagRTCPLen:            10,
+		Encryption:                true,
+		MKI:                       true,
+		Cryptex:                   true,
+		HeaderExtensionEncryption: true,
+		FIPSApproved:              true,
+	}, caps)
+
+	// GCM profiles with 8-byte tags have RFC 7714 SDES names, and no DTLS-SRTP IDs.
+	caps, err = ProtectionProfileAeadAes256Gcm8.Capabilities()
+	assert.NoError(t, err)
+	assert.Equal(t, "AEAD_AES_256_GCM_8", caps.SDESName)
+	assert.False(t, caps.DTLSSRTP)
+
+	_, err = ProtectionProfile(0x1234).Capabilities()
+	assert.ErrorIs(t, err, ErrUnsupportedProfile)
+
+	// SupportedProfiles returns a copy.
+	profiles := SupportedProfiles()
+	profiles[0] = 0
+	assert.Equal(t, supportedProtectionProfiles, SupportedProfiles())
+}
+
+// TestProfileCapabilitiesMatchContext checks that capabilities of all profiles match the behavior of Context.
+func TestProfileCapabilitiesMatchContext(t *testing.T) {
+	for _, profile := range SupportedProfiles() {
+		t.Run(profile.String(), func(t *testing.T) {
+			caps, err := profile.Capabilities()
+			assert.NoError(t, err)
+			assert.Equal(t, profile, caps.Profile)
+			assert.Equal(t, caps.AEADAuthTagLen > 0, caps.AEAD)
+			if !caps.DoubleAEAD {
+				assert.Equal(t, profile.RTPOverhead(0), caps.AuthTagRTPLen+caps.AEADAuthTagLen)
+			}
+
+			if caps.SDESName != "" {
+				parsed, err := ParseProtectionProfile(caps.SDESName)
+				assert.NoError(t, err)
+				assert.Equal(t, profile, parsed)
+			}
+
+			key := make([]byte, caps.KeyLen)
+			salt := make([]byte, caps.SaltLen)
+			createContext := func(opts ...ContextOption) error {
+				_, err := CreateContext(key, salt, profile, opts...)
+
+				return err
+			}
+			assert.NoError(t, createContext())
+			assert.Equal(t, caps.MKI, createContext(MasterKeyIndicator([]byte{1})) == nil)
+			assert.Equal(t, caps.FIPSApproved, createContext(RequireFIPS()) == nil)
+			if caps.Encryption {
+				assert.Equal(t, caps.Cryptex, createContext(Cryptex(CryptexModeEnabled)) == nil)
+				assert.Equal(t, caps.HeaderExtensionEncryption, createContext(SRTPEncryptedHeaderExtensions(1)) == nil)
+			}
+		})
+	}
+}
@@ -3,7 +3,22 @@
 
 package srtp
 
-import "fmt"
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1" //nolint:gosec
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/pion/srtp/v3/internal/aria"
+	"github.com/pion/srtp/v3/internal/seed"
+	"github.com/pion/srtp/v3/internal/sm3"
+	"github.com/pion/srtp/v3/internal/sm4"
+)
+
+// maxAuthDigestSize is the largest size of HMAC digest used by non-AEAD profiles (HMAC-SM3).
+const maxAuthDigestSize = sm3.Size
 
 // ProtectionProfile specifies Cipher and AuthTag details, similar to TLS cipher suite.
 type ProtectionProfile uint16
@@ -23,16 +38,72 @@ type ProtectionProfile uint16
 // Use of them is equivalent to using ProtectionProfileAes128CmHmacSha1_NN
 // profile with SRTPNoEncryption and SRTCPNoEncryption options.
 //
+// AEAD_AES_128_GCM_8 and AEAD_AES_256_GCM_8 profiles from RFC 7714 use 8-byte authentication tag
+// for both SRTP and SRTCP. They are defined for SDES only, and do not have DTLS-SRTP Protection
+// Profile IDs assigned, so IDs from private range are used for them.
+//
+// IDs from 0xF000 up are private to this package: they identify profiles which have no IANA
+// DTLS-SRTP Protection Profile ID, and are taken from the top of the 16-bit range, far from assigned
+// IDs, so they do not collide with future assignments. They must never be sent in DTLS use_srtp
+// extension; ProfileCapabilities.DTLSSRTP is false for them. These profiles are negotiated by their
+// SDES names, or configured out of band.
+//
+// AES192_CM_HMAC_SHA1_80 and AES192_CM_HMAC_SHA1_32 profiles from RFC 6188 are also defined for SDES
+// only. AEAD_AES_192_GCM is not standardized for SRTP, it is provided for interoperability with
+// implementations which support it. Private range IDs are used for these profiles too.
+//
+// Double AEAD profiles from RFC 8723 are used in PERC (Privacy Enhanced RTP Conferencing).
+// Their master key and salt are concatenations of inner (end-to-end) and outer (hop-by-hop) ones.
+//
+// ARIA profiles from RFC 8269 use ARIA block cipher instead of AES, also in the key derivation
+// function (ARIA_*_CTR_PRF). RFC 8269 registers DTLS-SRTP IDs 0x000B-0x0010 for the 128-bit
+// and 256-bit profiles only. ARIA_192_CTR_HMAC_SHA1_80 and ARIA_192_CTR_HMAC_SHA1_32 are registered
+// as SDES crypto-suites and MIKEY parameters only, so private IDs 0xF006 and 0xF007 are used for them.
+//
+// SEED profiles from RFC 5669 use SEED block cipher for encryption, and AES_CM PRF for the key
+// derivation. RFC 5669 registers them as SDES crypto-suites and MIKEY parameters only, so private
+// IDs 0xF008 and 0xF009 are used for SEED_CTR_128_HMAC_SHA1_80 and SEED_128_GCM_96. SEED_128_CCM_80
+// is not supported.
+//
+// SM4 profiles are provided for deployments which must use Chinese ShangMi algorithms. They are
+// non-standard: no RFC defines SM4 for SRTP, so IDs from private range are used for them, and they are
+// used only when selected explicitly, e.g. with both peers configured out of band. SM4_CTR_HMAC_SM3_80 uses SM4
+// block cipher in counter mode with 32-byte HMAC-SM3 authentication key and 80-bit tag, and AEAD_SM4_GCM
+// uses SM4 in Galois/Counter mode like AEAD_AES_128_GCM. Both use SM4 in counter mode for the key derivation.
+//
+// AES_F8_128_HMAC_SHA1_80 uses AES in f8 mode from RFC 3711 section 4.1.2, which is still negotiated
+// by some 3GPP/IMS equipment. It is defined for SDES only, so private range ID is used for it.
+//
 //nolint:lll
 const (
-	ProtectionProfileAes128CmHmacSha1_80 ProtectionProfile = 0x0001
-	ProtectionProfileAes128CmHmacSha1_32 ProtectionProfile = 0x0002
-	ProtectionProfileAes256CmHmacSha1_80 ProtectionProfile = 0x0003
-	ProtectionProfileAes256CmHmacSha1_32 ProtectionProfile = 0x0004
-	ProtectionProfileNullHmacSha1_80     ProtectionProfile = 0x0005
-	ProtectionProfileNullHmacSha1_32     ProtectionProfile = 0x0006
-	ProtectionProfileAeadAes128Gcm       ProtectionProfile = 0x0007
-	ProtectionProfileAeadAes256Gcm       ProtectionProfile = 0x0008
+	ProtectionProfileAes128CmHmacSha1_80   ProtectionProfile = 0x0001
+	ProtectionProfileAes128CmHmacSha1_32   ProtectionProfile = 0x0002
+	ProtectionProfileAes256CmHmacSha1_80   ProtectionProfile = 0x0003
+	ProtectionProfileAes256CmHmacSha1_32   ProtectionProfile = 0x0004
+	ProtectionProfileNullHmacSha1_80       ProtectionProfile = 0x0005
+	ProtectionProfileNullHmacSha1_32       ProtectionProfile = 0x0006
+	ProtectionProfileAeadAes128Gcm         ProtectionProfile = 0x0007
+	ProtectionProfileAeadAes256Gcm         ProtectionProfile = 0x0008
+	ProtectionProfileDoubleAeadAes128Gcm   ProtectionProfile = 0x0009
+	ProtectionProfileDoubleAeadAes256Gcm   ProtectionProfile = 0x000A
+	ProtectionProfileAeadAes128Gcm8        ProtectionProfile = 0xF001
+	ProtectionProfileAeadAes256Gcm8        ProtectionProfile = 0xF002
+	ProtectionProfileAes192CmHmacSha1_80   ProtectionProfile = 0xF003
+	ProtectionProfileAes192CmHmacSha1_32   ProtectionProfile = 0xF004
+	ProtectionProfileAeadAes192Gcm         ProtectionProfile = 0xF005
+	ProtectionProfileAria128CtrHmacSha1_80 ProtectionProfile = 0x000B
+	ProtectionProfileAria128CtrHmacSha1_32 ProtectionProfile = 0x000C
+	ProtectionProfileAria256CtrHmacSha1_80 ProtectionProfile = 0x000D
+	ProtectionProfileAria256CtrHmacSha1_32 ProtectionProfile = 0x000E
+	ProtectionProfileAeadAria128Gcm        ProtectionProfile = 0x000F
+	ProtectionProfileAeadAria256Gcm        ProtectionProfile = 0x0010
+	ProtectionProfileAria192CtrHmacSha1_80 ProtectionProfile = 0xF006
+	ProtectionProfileAria192CtrHmacSha1_32 ProtectionProfile = 0xF007
+	ProtectionProfileSeedCtrHmacSha1_80    ProtectionProfile = 0xF008
+	ProtectionProfileSeed128Gcm96          ProtectionProfile = 0xF009
+	ProtectionProfileSm4CtrHmacSm3_80      ProtectionProfile = 0xF00A
+	ProtectionProfileAeadSm4Gcm            ProtectionProfile = 0xF00B
+	ProtectionProfileAes128F8HmacSha1_80   ProtectionProfile = 0xF00C
 )
 
 // KeyLen returns length of encryption key in bytes.
@@ -43,11 +114,27 @@ func (p ProtectionProfile) KeyLen() (int, error) {
 	case ProtectionProfileAes128CmHmacSha1_32,
 		ProtectionProfileAes128CmHmacSha1_80,
 		ProtectionProfileAeadAes128Gcm,
+		ProtectionProfileAeadAes128Gcm8,
 		ProtectionProfileNullHmacSha1_32,
-		ProtectionProfileNullHmacSha1_80:
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_32,
+		ProtectionProfileAeadAria128Gcm,
+		ProtectionProfileSeedCtrHmacSha1_80,
+		ProtectionProfileSeed128Gcm96,
+		ProtectionProfileSm4CtrHmacSm3_80,
+		ProtectionProfileAeadSm4Gcm,
+		ProtectionProfileAes128F8HmacSha1_80:
 		return 16, nil
-	case ProtectionProfileAeadAes256Gcm, ProtectionProfileAes256CmHmacSha1_32, ProtectionProfileAes256CmHmacSha1_80:
+	case ProtectionProfileAes192CmHmacSha1_32, ProtectionProfileAes192CmHmacSha1_80, ProtectionProfileAeadAes192Gcm,
+		ProtectionProfileAria192CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_32:
+		return 24, nil
+	case ProtectionProfileAeadAes256Gcm, ProtectionProfileAes256CmHmacSha1_32, ProtectionProfileAes256CmHmacSha1_80,
+		ProtectionProfileAeadAes256Gcm8, ProtectionProfileDoubleAeadAes128Gcm,
+		ProtectionProfileAria256CtrHmacSha1_80, ProtectionProfileAria256CtrHmacSha1_32, ProtectionProfileAeadAria256Gcm:
 		return 32, nil
+	case ProtectionProfileDoubleAeadAes256Gcm:
+		return 64, nil
 	default:
 		return 0, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, p)
 	}
@@ -60,13 +147,22 @@ func (p ProtectionProfile) SaltLen() (int, error) {
 	switch p {
 	case ProtectionProfileAes128CmHmacSha1_32,
 		ProtectionProfileAes128CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_32,
 		ProtectionProfileAes256CmHmacSha1_80,
 		ProtectionProfileNullHmacSha1_32,
-		ProtectionProfileNullHmacSha1_80:
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_80, ProtectionProfileAria256CtrHmacSha1_80,
+		ProtectionProfileSeedCtrHmacSha1_80, ProtectionProfileSm4CtrHmacSm3_80, ProtectionProfileAes128F8HmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_32, ProtectionProfileAria192CtrHmacSha1_32, ProtectionProfileAria256CtrHmacSha1_32:
 		return 14, nil
-	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm:
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes192Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm, ProtectionProfileSeed128Gcm96, ProtectionProfileAeadSm4Gcm:
 		return 12, nil
+	case ProtectionProfileDoubleAeadAes128Gcm, ProtectionProfileDoubleAeadAes256Gcm:
+		return 24, nil
 	default:
 		return 0, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, p)
 	}
@@ -76,11 +172,19 @@ func (p ProtectionProfile) SaltLen() (int, error) {
 // For AEAD ones it returns zero.
 func (p ProtectionProfile) AuthTagRTPLen() (int, error) {
 	switch p {
-	case ProtectionProfileAes128CmHmacSha1_80, ProtectionProfileAes256CmHmacSha1_80, ProtectionProfileNullHmacSha1_80:
+	case ProtectionProfileAes128CmHmacSha1_80, ProtectionProfileAes192CmHmacSha1_80, ProtectionProfileAes256CmHmacSha1_80,
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_80, ProtectionProfileAria256CtrHmacSha1_80,
+		ProtectionProfileSeedCtrHmacSha1_80, ProtectionProfileSm4CtrHmacSm3_80, ProtectionProfileAes128F8HmacSha1_80:
 		return 10, nil
-	case ProtectionProfileAes128CmHmacSha1_32, ProtectionProfileAes256CmHmacSha1_32, ProtectionProfileNullHmacSha1_32:
+	case ProtectionProfileAes128CmHmacSha1_32, ProtectionProfileAes192CmHmacSha1_32, ProtectionProfileAes256CmHmacSha1_32,
+		ProtectionProfileNullHmacSha1_32,
+		ProtectionProfileAria128CtrHmacSha1_32, ProtectionProfileAria192CtrHmacSha1_32, ProtectionProfileAria256CtrHmacSha1_32:
 		return 4, nil
-	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm:
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes192Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8,
+		ProtectionProfileDoubleAeadAes128Gcm, ProtectionProfileDoubleAeadAes256Gcm,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm, ProtectionProfileSeed128Gcm96, ProtectionProfileAeadSm4Gcm:
 		return 0, nil
 	default:
 		return 0, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, p)
@@ -94,12 +198,20 @@ func (p ProtectionProfile) AuthTagRTCPLen() (int, error) {
 	switch p {
 	case ProtectionProfileAes128CmHmacSha1_32,
 		ProtectionProfileAes128CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_32,
 		ProtectionProfileAes256CmHmacSha1_80,
 		ProtectionProfileNullHmacSha1_32,
-		ProtectionProfileNullHmacSha1_80:
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_80, ProtectionProfileAria256CtrHmacSha1_80,
+		ProtectionProfileSeedCtrHmacSha1_80, ProtectionProfileSm4CtrHmacSm3_80, ProtectionProfileAes128F8HmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_32, ProtectionProfileAria192CtrHmacSha1_32, ProtectionProfileAria256CtrHmacSha1_32:
 		return 10, nil
-	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm:
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes192Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8,
+		ProtectionProfileDoubleAeadAes128Gcm, ProtectionProfileDoubleAeadAes256Gcm,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm, ProtectionProfileSeed128Gcm96, ProtectionProfileAeadSm4Gcm:
 		return 0, nil
 	default:
 		return 0, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, p)
@@ -107,18 +219,29 @@ func (p ProtectionProfile) AuthTagRTCPLen() (int, error) {
 }
 
 // AEADAuthTagLen returns length of authentication tag in bytes for AEAD protection profiles.
-// For AES ones it returns zero.
+// For AES ones it returns zero. For double AEAD profiles it returns length of the outer tag.
 func (p ProtectionProfile) AEADAuthTagLen() (int, error) {
 	switch p {
 	case ProtectionProfileAes128CmHmacSha1_32,
 		ProtectionProfileAes128CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_32,
 		ProtectionProfileAes256CmHmacSha1_80,
 		ProtectionProfileNullHmacSha1_32,
-		ProtectionProfileNullHmacSha1_80:
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_80, ProtectionProfileAria256CtrHmacSha1_80,
+		ProtectionProfileSeedCtrHmacSha1_80, ProtectionProfileSm4CtrHmacSm3_80, ProtectionProfileAes128F8HmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_32, ProtectionProfileAria192CtrHmacSha1_32, ProtectionProfileAria256CtrHmacSha1_32:
 		return 0, nil
-	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm:
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes192Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileDoubleAeadAes128Gcm, ProtectionProfileDoubleAeadAes256Gcm,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm, ProtectionProfileAeadSm4Gcm:
 		return 16, nil
+	case ProtectionProfileSeed128Gcm96:
+		return 12, nil
+	case ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8:
+		return 8, nil
 	default:
 		return 0, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, p)
 	}
@@ -130,18 +253,149 @@ func (p ProtectionProfile) AuthKeyLen() (int, error) {
 	switch p {
 	case ProtectionProfileAes128CmHmacSha1_32,
 		ProtectionProfileAes128CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_32,
 		ProtectionProfileAes256CmHmacSha1_80,
 		ProtectionProfileNullHmacSha1_32,
-		ProtectionProfileNullHmacSha1_80:
+		ProtectionProfileNullHmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_80, ProtectionProfileAria256CtrHmacSha1_80,
+		ProtectionProfileSeedCtrHmacSha1_80, ProtectionProfileAes128F8HmacSha1_80,
+		ProtectionProfileAria128CtrHmacSha1_32, ProtectionProfileAria192CtrHmacSha1_32, ProtectionProfileAria256CtrHmacSha1_32:
 		return 20, nil
-	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm:
+	case ProtectionProfileSm4CtrHmacSm3_80:
+		return sm3.Size, nil
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes192Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8,
+		ProtectionProfileDoubleAeadAes128Gcm, ProtectionProfileDoubleAeadAes256Gcm,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm, ProtectionProfileSeed128Gcm96, ProtectionProfileAeadSm4Gcm:
 		return 0, nil
 	default:
 		return 0, fmt.Errorf("%w: %#v", errNoSuchSRTPProfile, p)
 	}
 }
 
+// RTPOverhead returns number of bytes added to RTP packet by SRTP protection: authentication tag
+// or AEAD authentication tag, and MKI of given length. For double AEAD profiles it also includes
+// inner authentication tag and minimal Original Header Block. It returns zero for unsupported profiles.
+func (p ProtectionProfile) RTPOverhead(mkiLen int) int {
+	if !p.isSupported() {
+		return 0
+	}
+	authTagLen, _ := p.AuthTagRTPLen()
+	aeadAuthTagLen, _ := p.AEADAuthTagLen()
+	if p.isDoubleAEAD() {
+		aeadAuthTagLen += aeadAuthTagLen + ohbConfigLen
+	}
+
+	return authTagLen + aeadAuthTagLen + mkiLen
+}
+
+// RTCPOverhead returns number of bytes added to RTCP packet by SRTCP protection: authentication tag
+// or AEAD authentication tag, SRTCP index word and MKI of given length. It returns zero for unsupported profiles.
+func (p ProtectionProfile) RTCPOverhead(mkiLen int) int {
+	if !p.isSupported() {
+		return 0
+	}
+	authTagLen, _ := p.AuthTagRTCPLen()
+	aeadAuthTagLen, _ := p.AEADAuthTagLen()
+
+	return authTagLen + aeadAuthTagLen + srtcpIndexSize + mkiLen
+}
+
+// isSupported checks if protection profile is known to this package.
+func (p ProtectionProfile) isSupported() bool {
+	_, err := p.KeyLen()
+
+	return err == nil
+}
+
+// isAEAD checks if protection profile uses AEAD cipher.
+func (p ProtectionProfile) isAEAD() bool {
+	aeadAuthTagLen, err := p.AEADAuthTagLen()
+
+	return err == nil && aeadAuthTagLen > 0
+}
+
+// isARIA checks if protection profile uses ARIA block cipher from RFC 8269.
+func (p ProtectionProfile) isARIA() bool {
+	switch p {
+	case ProtectionProfileAria128CtrHmacSha1_80, ProtectionProfileAria128CtrHmacSha1_32,
+		ProtectionProfileAria192CtrHmacSha1_80, ProtectionProfileAria192CtrHmacSha1_32,
+		ProtectionProfileAria256CtrHmacSha1_80, ProtectionProfileAria256CtrHmacSha1_32,
+		ProtectionProfileAeadAria128Gcm, ProtectionProfileAeadAria256Gcm:
+		return true
+	default:
+		return false
+	}
+}
+
+// isSEED checks if protection profile uses SEED block cipher from RFC 5669.
+func (p ProtectionProfile) isSEED() bool {
+	return p == ProtectionProfileSeedCtrHmacSha1_80 || p == ProtectionProfileSeed128Gcm96
+}
+
+// isSM4 checks if protection profile uses SM4 block cipher.
+func (p ProtectionProfile) isSM4() bool {
+	return p == ProtectionProfileSm4CtrHmacSm3_80 || p == ProtectionProfileAeadSm4Gcm
+}
+
+// isF8 checks if protection profile uses AES in f8 mode from RFC 3711.
+func (p ProtectionProfile) isF8() bool {
+	return p == ProtectionProfileAes128F8HmacSha1_80
+}
+
+// newBlockCipher creates block cipher used by the profile for encryption with given session key.
+func (p ProtectionProfile) newBlockCipher(key []byte) (cipher.Block, error) {
+	switch {
+	case p.isARIA():
+		return aria.NewCipher(key)
+	case p.isSEED():
+		return seed.NewCipher(key)
+	case p.isSM4():
+		return sm4.NewCipher(key)
+	default:
+		return aes.NewCipher(key)
+	}
+}
+
+// newPRFBlockCipher creates block cipher used by the key derivation function of the profile.
+// ARIA profiles use ARIA_*_CTR_PRF from RFC 8269, SM4 profiles use the same PRF with SM4,
+// and other ones use AES_CM PRF from RFC 3711.
+func (p ProtectionProfile) newPRFBlockCipher(masterKey []byte) (cipher.Block, error) {
+	switch {
+	case p.isARIA():
+		return aria.NewCipher(masterKey)
+	case p.isSM4():
+		return sm4.NewCipher(masterKey)
+	default:
+		return aes.NewCipher(masterKey)
+	}
+}
+
+// authHashFunc returns hash function used by HMAC authentication of non-AEAD profiles.
+func (p ProtectionProfile) authHashFunc() func() hash.Hash {
+	if p == ProtectionProfileSm4CtrHmacSm3_80 {
+		return sm3.New
+	}
+
+	return sha1.New
+}
+
+// isDoubleAEAD checks if protection profile uses double AEAD transform from RFC 8723.
+func (p ProtectionProfile) isDoubleAEAD() bool {
+	return p == ProtectionProfileDoubleAeadAes128Gcm || p == ProtectionProfileDoubleAeadAes256Gcm
+}
+
+// singleAEADProfile returns AEAD profile used by inner and outer transforms of double AEAD profile.
+func (p ProtectionProfile) singleAEADProfile() ProtectionProfile {
+	if p == ProtectionProfileDoubleAeadAes256Gcm {
+		return ProtectionProfileAeadAes256Gcm
+	}
+
+	return ProtectionProfileAeadAes128Gcm
+}
+
 // String returns the name of the protection profile.
 func (p ProtectionProfile) String() string {
 	switch p {
@@ -149,19 +403,135 @@ func (p ProtectionProfile) String() string {
 		return "SRTP_AES128_CM_HMAC_SHA1_80"
 	case ProtectionProfileAes128CmHmacSha1_32:
 		return "SRTP_AES128_CM_HMAC_SHA1_32"
+	case ProtectionProfileAes192CmHmacSha1_80:
+		return "SRTP_AES192_CM_HMAC_SHA1_80"
+	case ProtectionProfileAes192CmHmacSha1_32:
+		return "SRTP_AES192_CM_HMAC_SHA1_32"
 	case ProtectionProfileAes256CmHmacSha1_80:
 		return "SRTP_AES256_CM_HMAC_SHA1_80"
 	case ProtectionProfileAes256CmHmacSha1_32:
 		return "SRTP_AES256_CM_HMAC_SHA1_32"
 	case ProtectionProfileAeadAes128Gcm:
 		return "SRTP_AEAD_AES_128_GCM"
+	case ProtectionProfileAeadAes192Gcm:
+		return "SRTP_AEAD_AES_192_GCM"
 	case ProtectionProfileAeadAes256Gcm:
 		return "SRTP_AEAD_AES_256_GCM"
+	case ProtectionProfileAeadAes128Gcm8:
+		return "SRTP_AEAD_AES_128_GCM_8"
+	case ProtectionProfileAeadAes256Gcm8:
+		return "SRTP_AEAD_AES_256_GCM_8"
+	case ProtectionProfileDoubleAeadAes128Gcm:
+		return "DOUBLE_AEAD_AES_128_GCM_AEAD_AES_128_GCM"
+	case ProtectionProfileDoubleAeadAes256Gcm:
+		return "DOUBLE_AEAD_AES_256_GCM_AEAD_AES_256_GCM"
 	case ProtectionProfileNullHmacSha1_80:
 		return "SRTP_NULL_HMAC_SHA1_80"
 	case ProtectionProfileNullHmacSha1_32:
 		return "SRTP_NULL_HMAC_SHA1_32"
+	case ProtectionProfileAria128CtrHmacSha1_80:
+		return "SRTP_ARIA_128_CTR_HMAC_SHA1_80"
+	case ProtectionProfileAria128CtrHmacSha1_32:
+		return "SRTP_ARIA_128_CTR_HMAC_SHA1_32"
+	case ProtectionProfileAria192CtrHmacSha1_80:
+		return "SRTP_ARIA_192_CTR_HMAC_SHA1_80"
+	case ProtectionProfileAria192CtrHmacSha1_32:
+		return "SRTP_ARIA_192_CTR_HMAC_SHA1_32"
+	case ProtectionProfileAria256CtrHmacSha1_80:
+		return "SRTP_ARIA_256_CTR_HMAC_SHA1_80"
+	case ProtectionProfileAria256CtrHmacSha1_32:
+		return "SRTP_ARIA_256_CTR_HMAC_SHA1_32"
+	case ProtectionProfileAeadAria128Gcm:
+		return "SRTP_AEAD_ARIA_128_GCM"
+	case ProtectionProfileAeadAria256Gcm:
+		return "SRTP_AEAD_ARIA_256_GCM"
+	case ProtectionProfileSeedCtrHmacSha1_80:
+		return "SRTP_SEED_CTR_128_HMAC_SHA1_80"
+	case ProtectionProfileSeed128Gcm96:
+		return "SRTP_SEED_128_GCM_96"
+	case ProtectionProfileSm4CtrHmacSm3_80:
+		return "SRTP_SM4_CTR_HMAC_SM3_80"
+	case ProtectionProfileAeadSm4Gcm:
+		return "SRTP_AEAD_SM4_GCM"
+	case ProtectionProfileAes128F8HmacSha1_80:
+		return "SRTP_AES_F8_128_HMAC_SHA1_80"
 	default:
 		return fmt.Sprintf("Unknown SRTP profile: %#v", p)
 	}
 }
+
+// supportedProtectionProfiles lists all protection profiles supported by this package.
+var supportedProtectionProfiles = []ProtectionProfile{ // nolint:gochecknoglobals
+	ProtectionProfileAes128CmHmacSha1_80,
+	ProtectionProfileAes128CmHmacSha1_32,
+	ProtectionProfileAes192CmHmacSha1_80,
+	ProtectionProfileAes192CmHmacSha1_32,
+	ProtectionProfileAes256CmHmacSha1_80,
+	ProtectionProfileAes256CmHmacSha1_32,
+	ProtectionProfileNullHmacSha1_80,
+	ProtectionProfileNullHmacSha1_32,
+	ProtectionProfileAeadAes128Gcm,
+	ProtectionProfileAeadAes192Gcm,
+	ProtectionProfileAeadAes256Gcm,
+	ProtectionProfileAeadAes128Gcm8,
+	ProtectionProfileAeadAes256Gcm8,
+	ProtectionProfileDoubleAeadAes128Gcm,
+	ProtectionProfileDoubleAeadAes256Gcm,
+	ProtectionProfileAria128CtrHmacSha1_80,
+	ProtectionProfileAria128CtrHmacSha1_32,
+	ProtectionProfileAria192CtrHmacSha1_80,
+	ProtectionProfileAria192CtrHmacSha1_32,
+	ProtectionProfileAria256CtrHmacSha1_80,
+	ProtectionProfileAria256CtrHmacSha1_32,
+	ProtectionProfileAeadAria128Gcm,
+	ProtectionProfileAeadAria256Gcm,
+	ProtectionProfileSeedCtrHmacSha1_80,
+	ProtectionProfileSeed128Gcm96,
+	ProtectionProfileSm4CtrHmacSm3_80,
+	ProtectionProfileAeadSm4Gcm,
+	ProtectionProfileAes128F8HmacSha1_80,
+}
+
+// sdesProtectionProfileNames maps SDES crypto-suite names (RFC 4568, RFC 6188, RFC 8269 and RFC 5669)
+// to protection profiles. AES-GCM names from RFC 7714 are the same as DTLS-SRTP names without
+// "SRTP_" prefix, so they are not listed here.
+var sdesProtectionProfileNames = map[string]ProtectionProfile{ // nolint:gochecknoglobals
+	"AES_CM_128_HMAC_SHA1_80":   ProtectionProfileAes128CmHmacSha1_80,
+	"AES_CM_128_HMAC_SHA1_32":   ProtectionProfileAes128CmHmacSha1_32,
+	"AES_192_CM_HMAC_SHA1_80":   ProtectionProfileAes192CmHmacSha1_80,
+	"AES_192_CM_HMAC_SHA1_32":   ProtectionProfileAes192CmHmacSha1_32,
+	"AES_256_CM_HMAC_SHA1_80":   ProtectionProfileAes256CmHmacSha1_80,
+	"AES_256_CM_HMAC_SHA1_32":   ProtectionProfileAes256CmHmacSha1_32,
+	"NULL_HMAC_SHA1_80":         ProtectionProfileNullHmacSha1_80,
+	"NULL_HMAC_SHA1_32":         ProtectionProfileNullHmacSha1_32,
+	"ARIA_128_CTR_HMAC_SHA1_80": ProtectionProfileAria128CtrHmacSha1_80,
+	"ARIA_128_CTR_HMAC_SHA1_32": ProtectionProfileAria128CtrHmacSha1_32,
+	"ARIA_192_CTR_HMAC_SHA1_80": ProtectionProfileAria192CtrHmacSha1_80,
+	"ARIA_192_CTR_HMAC_SHA1_32": ProtectionProfileAria192CtrHmacSha1_32,
+	"ARIA_256_CTR_HMAC_SHA1_80": ProtectionProfileAria256CtrHmacSha1_80,
+	"ARIA_256_CTR_HMAC_SHA1_32": ProtectionProfileAria256CtrHmacSha1_32,
+	"AEAD_ARIA_128_GCM":         ProtectionProfileAeadAria128Gcm,
+	"AEAD_ARIA_256_GCM":         ProtectionProfileAeadAria256Gcm,
+	"SEED_CTR_128_HMAC_SHA1_80": ProtectionProfileSeedCtrHmacSha1_80,
+	"SEED_128_GCM_96":           ProtectionProfileSeed128Gcm96,
+	"F8_128_HMAC_SHA1_80":       ProtectionProfileAes128F8HmacSha1_80,
+}
+
+// ParseProtectionProfile returns protection profile with given name. It accepts names returned by
+// ProtectionProfile.String (DTLS-SRTP names from RFC 5764 and RFC 7714, e.g. "SRTP_AEAD_AES_128_GCM"),
+// the same names without "SRTP_" prefix (e.g. "AEAD_AES_128_GCM"), and SDES crypto-suite names
+// (e.g. "AES_CM_128_HMAC_SHA1_80"). Names are case-insensitive.
+func ParseProtectionProfile(name string) (ProtectionProfile, error) {
+	upperName := strings.ToUpper(name)
+	if profile, ok := sdesProtectionProfileNames[upperName]; ok {
+		return profile, nil
+	}
+	for _, profile := range supportedProtectionProfiles {
+		profileName := profile.String()
+		if upperName == profileName || "SRTP_"+upperName == profileName {
+			return profile, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %q", ErrUnsupportedProfile, name)
+}
@@ -4,6 +4,8 @@
 package srtp
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,3 +20,209 @@ func TestInvalidProtectionProfile(t *testing.T) {
 	_, err = invalidProtectionProfile.SaltLen()
 	assert.Error(t, err)
 }
+
+func TestCreateContextWithUnsupportedProfile(t *testing.T) {
+	for _, profile := range []ProtectionProfile{0, 0x1234, 0xFFFF} {
+		_, err := CreateContext(make([]byte, 16), make([]byte, 14), profile)
+		assert.ErrorIs(t, err, ErrUnsupportedProfile)
+		assert.Contains(t, err.Error(), fmt.Sprintf("%#v", profile))
+
+		_, err = NewSharedCipher(make([]byte, 16), make([]byte, 14), profile)
+		assert.ErrorIs(t, err, ErrUnsupportedProfile)
+	}
+}
+
+func TestProtectionProfileOverhead(t *testing.T) {
+	for _, test := range []struct {
+		profile      ProtectionProfile
+		rtpOverhead  int
+		rtcpOverhead int
+	}{
+		{ProtectionProfileAes128CmHmacSha1_80, 10, 14},
+		{ProtectionProfileAes128CmHmacSha1_32, 4, 14},
+		{ProtectionProfileAes192CmHmacSha1_80, 10, 14},
+		{ProtectionProfileAes192CmHmacSha1_32, 4, 14},
+		{ProtectionProfileAes256CmHmacSha1_80, 10, 14},
+		{ProtectionProfileAes256CmHmacSha1_32, 4, 14},
+		{ProtectionProfileNullHmacSha1_80, 10, 14},
+		{ProtectionProfileNullHmacSha1_32, 4, 14},
+		{ProtectionProfileAeadAes128Gcm, 16, 20},
+		{ProtectionProfileAeadAes192Gcm, 16, 20},
+		{ProtectionProfileAeadAes256Gcm, 16, 20},
+		{ProtectionProfileAeadAes128Gcm8, 8, 12},
+		{ProtectionProfileAeadAes256Gcm8, 8, 12},
+		{ProtectionProfileDoubleAeadAes128Gcm, 33, 20},
+		{ProtectionProfileDoubleAeadAes256Gcm, 33, 20},
+		{ProtectionProfileAria128CtrHmacSha1_80, 10, 14},
+		{ProtectionProfileAria128CtrHmacSha1_32, 4, 14},
+		{ProtectionProfileAria192CtrHmacSha1_80, 10, 14},
+		{ProtectionProfileAria192CtrHmacSha1_32, 4, 14},
+		{ProtectionProfileAria256CtrHmacSha1_80, 10, 14},
+		{ProtectionProfileAria256CtrHmacSha1_32, 4, 14},
+		{ProtectionProfileAeadAria128Gcm, 16, 20},
+		{ProtectionProfileAeadAria256Gcm, 16, 20},
+		{ProtectionProfileSeedCtrHmacSha1_80, 10, 14},
+		{ProtectionProfileSeed128Gcm96, 12, 16},
+		{ProtectionProfileSm4CtrHmacSm3_80, 10, 14},
+		{ProtectionProfileAes128F8HmacSha1_80, 10, 14},
+		{ProtectionProfileAeadSm4Gcm, 16, 20},
+		{0, 0, 0},
+	} {
+		t.Run(test.profile.String(), func(t *testing.T) {
+			assert.Equal(t, test.rtpOverhead, test.profile.RTPOverhead(0))
+			assert.Equal(t, test.rtcpOverhead, test.profile.RTCPOverhead(0))
+			if test.rtpOverhead == 0 {
+				return
+			}
+			assert.Equal(t, test.rtpOverhead+4, test.profile.RTPOverhead(4))
+			assert.Equal(t, test.rtcpOverhead+4, test.profile.RTCPOverhead(4))
+
+			// Verify against real packets.
+			keyLen, err := test.profile.KeyLen()
+			assert.NoError(t, err)
+			saltLen, err := test.profile.SaltLen()
+			assert.NoError(t, err)
+			ctx, err := CreateContext(make([]byte, keyLen), make([]byte, saltLen), test.profile,
+				MasterKeyIndicator([]byte{1, 2, 3, 4}))
+			assert.NoError(t, err)
+
+			rtpPacket := make([]byte, 12+100)
+			rtpPacket[0] = 0x80
+			encrypted, err := ctx.EncryptRTP(nil, rtpPacket, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.profile.RTPOverhead(4), len(encrypted)-len(rtpPacket))
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			encrypted, err = ctx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.profile.RTCPOverhead(4), len(encrypted)-len(rtcpPacket))
+		})
+	}
+}
+
+func TestParseProtectionProfile(t *testing.T) {
+	for _, profile := range supportedProtectionProfiles {
+		t.Run(profile.String(), func(t *testing.T) {
+			parsed, err := ParseProtectionProfile(profile.String())
+			assert.NoError(t, err)
+			assert.Equal(t, profile, parsed)
+
+			parsed, err = ParseProtectionProfile(strings.TrimPrefix(profile.String(), "SRTP_"))
+			assert.NoError(t, err)
+			assert.Equal(t, profile, parsed)
+
+			parsed, err = ParseProtectionProfile(strings.ToLower(profile.String()))
+			assert.NoError(t, err)
+			assert.Equal(t, profile, parsed)
+		})
+	}
+
+	parsed, err := ParseProtectionProfile("AEAD_AES_128_GCM")
+	assert.NoError(t, err)
+	assert.Equal(t, ProtectionProfileAeadAes128Gcm, parsed)
+
+	parsed, err = ParseProtectionProfile("AES_CM_128_HMAC_SHA1_80")
+	assert.NoError(t, err)
+	assert.Equal(t, ProtectionProfileAes128CmHmacSha1_80, parsed)
+
+	parsed, err = ParseProtectionProfile("ARIA_128_CTR_HMAC_SHA1_80")
+	assert.NoError(t, err)
+	assert.Equal(t, ProtectionProfileAria128CtrHmacSha1_80, parsed)
+
+	parsed, err = ParseProtectionProfile("F8_128_HMAC_SHA1_80")
+	assert.NoError(t, err)
+	assert.Equal(t, ProtectionProfileAes128F8HmacSha1_80, parsed)
+
+	for _, name := range []string{"", "SRTP_", "AES_CM_192_HMAC_SHA1_80", ProtectionProfile(0x1234).String()} {
+		_, err = ParseProtectionProfile(name)
+		assert.ErrorIs(t, err, ErrUnsupportedProfile)
+	}
+}
+
+func TestNonAESProfiles(t *testing.T) {
+	for _, profile := range []ProtectionProfile{
+		ProtectionProfileAria128CtrHmacSha1_80,
+		ProtectionProfileAria192CtrHmacSha1_32,
+		ProtectionProfileAria256CtrHmacSha1_80,
+		ProtectionProfileAeadAria128Gcm,
+		ProtectionProfileAeadAria256Gcm,
+		ProtectionProfileSeedCtrHmacSha1_80,
+		ProtectionProfileSeed128Gcm96,
+		ProtectionProfileSm4CtrHmacSm3_80,
+		ProtectionProfileAeadSm4Gcm,
+	} {
+		t.Run(profile.String(), func(t *testing.T) {
+			keyLen, err := profile.KeyLen()
+			assert.NoError(t, err)
+			saltLen, err := profile.SaltLen()
+			assert.NoError(t, err)
+			masterKey, masterSalt := make([]byte, keyLen), make([]byte, saltLen)
+			for i := range masterKey {
+				masterKey[i] = byte(i)
+			}
+
+			encryptCtx, err := CreateContext(masterKey, masterSalt, profile)
+			assert.NoError(t, err)
+			decryptCtx, err := CreateContext(masterKey, masterSalt, profile)
+			assert.NoError(t, err)
+
+			// Payload long enough to be processed by cipher.NewCTR.
+			rtpPacket := make([]byte, 12+200)
+			rtpPacket[0] = 0x80
+			encrypted, err := encryptCtx.EncryptRTP(nil, rtpPacket, nil)
+			assert.NoError(t, err)
+			assert.NotEqual(t, rtpPacket[12:], encrypted[12:len(rtpPacket)])
+			decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtpPacket, decrypted)
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			encrypted, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			decrypted, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+
+			_, err = CreateContext(masterKey, masterSalt, profile, RequireFIPS())
+			assert.ErrorIs(t, err, ErrNotFIPSApproved)
+		})
+	}
+}
+
+func TestF8Profile(t *testing.T) {
+	masterKey := make([]byte, 16)
+	masterSalt := make([]byte, 14)
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+
+	encryptCtx, err := CreateContext(masterKey, masterSalt, ProtectionProfileAes128F8HmacSha1_80)
+	assert.NoError(t, err)
+	decryptCtx, err := CreateContext(masterKey, masterSalt, ProtectionProfileAes128F8HmacSha1_80)
+	assert.NoError(t, err)
+	cmCtx, err := CreateContext(masterKey, masterSalt, ProtectionProfileAes128CmHmacSha1_80)
+	assert.NoError(t, err)
+
+	rtpPacket := append([]byte{0x80, 0xe0, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03},
+		make([]byte, 50)...)
+	encrypted, err := encryptCtx.EncryptRTP(nil, rtpPacket, nil)
+	assert.NoError(t, err)
+	cmEncrypted, err := cmCtx.EncryptRTP(nil, rtpPacket, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, cmEncrypted[12:len(rtpPacket)], encrypted[12:len(rtpPacket)])
+	decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtpPacket, decrypted)
+
+	rtcpPacket := append([]byte{0x81, 0xc9, 0x00, 0x07, 0x00, 0x00, 0x00, 0x01}, make([]byte, 24)...)
+	encrypted, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, rtcpPacket[8:], encrypted[8:len(rtcpPacket)])
+	assert.Equal(t, byte(srtcpEncryptionFlag), encrypted[len(rtcpPacket)]&srtcpEncryptionFlag)
+	decrypted, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpPacket, decrypted)
+
+	_, err = CreateContext(masterKey, masterSalt, ProtectionProfileAes128F8HmacSha1_80, SRTPEncryptedHeaderExtensions(1))
+	assert.ErrorIs(t, err, errHeaderExtensionEncryptionNotSupported)
+}
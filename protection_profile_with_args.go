@@ -3,11 +3,23 @@
 
 package srtp
 
+import "fmt"
+
 // protectionProfileWithArgs is a wrapper around ProtectionProfile that allows to
 // specify additional arguments for the profile.
 type protectionProfileWithArgs struct {
 	ProtectionProfile
 	authTagRTPLen *int
+	// indexOverKdr is "index DIV kdr" value used to derive session keys, see KeyDerivationRate option.
+	indexOverKdr uint64
+	// kdf derives session keys instead of AES-CM PRF when it is set, see ExternalKeyDerivation option.
+	kdf KeyDerivationFunc
+	// fips is set by RequireFIPS option.
+	fips bool
+	// gcmNonceFunc is set by UnsafeGCMNonceSource option.
+	gcmNonceFunc GCMNonceFunc
+	// gcmAADFunc is set by GCMAdditionalData option.
+	gcmAADFunc GCMAdditionalDataFunc
 }
 
 // AuthTagRTPLen returns length of RTP authentication tag in bytes for AES protection profiles.
@@ -19,3 +31,26 @@ func (p protectionProfileWithArgs) AuthTagRTPLen() (int, error) {
 
 	return p.ProtectionProfile.AuthTagRTPLen()
 }
+
+// deriveSessionKey derives session key or salt with given label from the master key and salt,
+// or with external key derivation function when it is set.
+func (p protectionProfileWithArgs) deriveSessionKey(
+	label byte, masterKey, masterSalt []byte, outLen int,
+) ([]byte, error) {
+	if p.kdf != nil {
+		key, err := p.kdf.DeriveKey(label, p.indexOverKdr, outLen)
+		if err == nil && len(key) != outLen {
+			return nil, fmt.Errorf("%w: label %d, expected(%d) actual(%d)",
+				errInvalidDerivedKeyLength, label, outLen, len(key))
+		}
+
+		return key, err
+	}
+
+	block, err := p.newPRFBlockCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctrKeyDerivation(block, label, masterSalt, p.indexOverKdr, outLen), nil
+}
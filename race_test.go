@@ -0,0 +1,10 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build race
+
+package srtp
+
+// raceEnabled is set when tests are run with the race detector, which makes sync.Pool drop items,
+// so allocation counts are not reliable.
+const raceEnabled = true
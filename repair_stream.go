@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// StreamKind is kind of RTP stream received by SessionSRTP.
+type StreamKind int
+
+const (
+	// StreamKindMedia is a primary media stream.
+	StreamKindMedia StreamKind = iota
+	// StreamKindRTX is a retransmission stream, as defined by RFC 4588.
+	StreamKindRTX
+	// StreamKindFEC is a forward error correction stream, e.g. FlexFEC defined by RFC 8627.
+	StreamKindFEC
+)
+
+func (k StreamKind) String() string {
+	switch k {
+	case StreamKindMedia:
+		return "media"
+	case StreamKindRTX:
+		return "rtx"
+	case StreamKindFEC:
+		return "fec"
+	default:
+		return fmt.Sprintf("StreamKind(%d)", int(k))
+	}
+}
+
+// repairStream is association of RTX or FEC SSRC with its primary SSRC. Packets and bytes count
+// packets of the repair SSRC delivered to its read stream.
+type repairStream struct {
+	primarySSRC uint32
+	kind        StreamKind
+
+	packets, bytes atomic.Uint64
+}
+
+// ReadStreamStats contains counters of packets delivered to ReadStreamSRTP.
+type ReadStreamStats struct {
+	Packets uint64
+	Bytes   uint64
+	// RepairPackets and RepairBytes count packets of RTX and FEC streams associated with the stream
+	// by SessionSRTP.AssociateRepairStream.
+	RepairPackets uint64
+	RepairBytes   uint64
+}
+
+// AssociateRepairStream associates RTX or FEC stream with SSRC repairSSRC with its primary media stream
+// with SSRC primarySSRC, e.g. as signaled by SDP "ssrc-group" attribute. Packets of the repair stream
+// inherit acceptance decision of the primary stream: they are accepted when the primary stream has
+// a read stream, and dropped otherwise, without calling Config.AcceptStreamFunc. Read stream of the repair
+// SSRC reports the association with ReadStreamSRTP.Kind and ReadStreamSRTP.PrimarySSRC, so the stream
+// returned by AcceptStream can be labeled by the application, and its packets are counted in stats of
+// the primary stream. When the primary read stream is closed, read streams of its repair streams are
+// closed too, and the associations are removed. The association should be added before packets
+// of the repair stream are received.
+func (s *SessionSRTP) AssociateRepairStream(repairSSRC, primarySSRC uint32, kind StreamKind) error {
+	if kind != StreamKindRTX && kind != StreamKindFEC {
+		return fmt.Errorf("%w: stream kind %v", errInvalidRepairStream, kind)
+	}
+	if repairSSRC == primarySSRC {
+		return fmt.Errorf("%w: SSRC %d cannot repair itself", errInvalidRepairStream, repairSSRC)
+	}
+
+	s.repairStreamsLock.Lock()
+	defer s.repairStreamsLock.Unlock()
+
+	if _, ok := s.repairStreams[primarySSRC]; ok {
+		return fmt.Errorf("%w: primary SSRC %d is a repair stream", errInvalidRepairStream, primarySSRC)
+	}
+	for _, repair := range s.repairStreams {
+		if repair.primarySSRC == repairSSRC {
+			return fmt.Errorf("%w: repair SSRC %d is a primary stream", errInvalidRepairStream, repairSSRC)
+		}
+	}
+	if s.repairStreams == nil {
+		s.repairStreams = map[uint32]*repairStream{}
+	}
+	s.repairStreams[repairSSRC] = &repairStream{primarySSRC: primarySSRC, kind: kind}
+
+	return nil
+}
+
+// repairStream returns association of the repair SSRC, or nil for other SSRCs.
+func (s *SessionSRTP) repairStream(ssrc uint32) *repairStream {
+	s.repairStreamsLock.Lock()
+	defer s.repairStreamsLock.Unlock()
+
+	return s.repairStreams[ssrc]
+}
+
+// repairStats returns counters of packets of repair streams associated with the primary SSRC.
+func (s *SessionSRTP) repairStats(primarySSRC uint32) (packets, bytes uint64) {
+	s.repairStreamsLock.Lock()
+	defer s.repairStreamsLock.Unlock()
+
+	for _, repair := range s.repairStreams {
+		if repair.primarySSRC == primarySSRC {
+			packets += repair.packets.Load()
+			bytes += repair.bytes.Load()
+		}
+	}
+
+	return packets, bytes
+}
+
+// closeRepairStreams removes associations of repair streams of the primary SSRC, and closes their
+// read streams.
+func (s *SessionSRTP) closeRepairStreams(primarySSRC uint32) {
+	var repairSSRCs []uint32
+	s.repairStreamsLock.Lock()
+	for ssrc, repair := range s.repairStreams {
+		if repair.primarySSRC == primarySSRC {
+			repairSSRCs = append(repairSSRCs, ssrc)
+			delete(s.repairStreams, ssrc)
+		}
+	}
+	s.repairStreamsLock.Unlock()
+
+	for _, ssrc := range repairSSRCs {
+		s.session.readStreamsLock.Lock()
+		r, ok := s.session.readStreams[ssrc].(*ReadStreamSRTP)
+		s.session.readStreamsLock.Unlock()
+		if ok {
+			_ = r.Close()
+		}
+	}
+}
+
+// Kind returns kind of the stream, as set by SessionSRTP.AssociateRepairStream.
+func (r *ReadStreamSRTP) Kind() StreamKind {
+	if repair := r.session.repairStream(r.ssrc); repair != nil {
+		return repair.kind
+	}
+
+	return StreamKindMedia
+}
+
+// PrimarySSRC returns SSRC of the primary stream of RTX or FEC stream associated with it by
+// SessionSRTP.AssociateRepairStream. It returns false for primary media streams.
+func (r *ReadStreamSRTP) PrimarySSRC() (uint32, bool) {
+	if repair := r.session.repairStream(r.ssrc); repair != nil {
+		return repair.primarySSRC, true
+	}
+
+	return 0, false
+}
+
+// Stats returns counters of packets delivered to the stream, and to RTX and FEC streams associated
+// with it.
+func (r *ReadStreamSRTP) Stats() ReadStreamStats {
+	stats := ReadStreamStats{Packets: r.packets.Load(), Bytes: r.bytes.Load()}
+	stats.RepairPackets, stats.RepairBytes = r.session.repairStats(r.ssrc)
+
+	return stats
+}
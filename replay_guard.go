@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"sync"
+
+	"github.com/pion/transport/v4/replaydetector"
+)
+
+// replayGuard wraps a replay detector and splits the replay check into two short critical sections,
+// so the expensive authentication and decryption is done without holding any lock, and packets which
+// fail authentication do not mark their indexes as seen:
+//
+//  1. reserve checks the index against the detector and marks it as pending. Concurrent attempts to
+//     reserve the same index are rejected until the reservation is released.
+//  2. After authentication the reservation is either committed, which marks the index as seen
+//     in the detector, or released, e.g. when authentication failed.
+//
+// replayGuard also keeps summary of recently seen indexes and the replay floor. It is safe for
+// concurrent use.
+type replayGuard struct {
+	mu       sync.Mutex
+	detector replaydetector.ReplayDetector
+	pending  map[uint64]struct{}
+
+	// Summary of the last replaySummaryBits indexes marked as seen, used for compact state.
+	// Bit i of seen is set when index seenTop-i was marked as seen.
+	hasSeen bool
+	seenTop uint64
+	seen    uint64
+
+	// Indexes lower than or equal to floor are rejected, when hasFloor is set.
+	hasFloor bool
+	floor    uint64
+}
+
+// replayToken is a provisional reservation of a packet index returned by replayGuard.reserve.
+type replayToken struct {
+	guard *replayGuard
+	index uint64
+	// accept is returned by Check of detectors which do not implement indexReplayDetector.
+	// It is called on commit, so Check is called only once for every packet.
+	accept func() bool
+	done   bool
+}
+
+// replaySummaryBits is the number of recent indexes tracked by replayGuard summary.
+const replaySummaryBits = 64
+
+func newReplayGuard(detector replaydetector.ReplayDetector) *replayGuard {
+	return &replayGuard{detector: detector}
+}
+
+// markSeen marks the index as seen if it is acceptable. It is used to restore the replay state.
+func (g *replayGuard) markSeen(index uint64) {
+	if token, ok := g.reserve(index); ok {
+		token.commit()
+	}
+}
+
+// setFloor rejects indexes lower than or equal to floor, e.g. ones which could be seen by a state
+// which was evicted. It does nothing when replay protection is disabled.
+func (g *replayGuard) setFloor(floor uint64) {
+	if _, ok := g.detector.(*nopReplayDetector); ok {
+		return
+	}
+
+	g.mu.Lock()
+	g.hasFloor = true
+	g.floor = floor
+	g.mu.Unlock()
+}
+
+// reserve reserves the index. It returns false if the index was already seen, it is outside
+// the replay window, it is not newer than the floor, or it is reserved by another packet being
+// processed. The index is not marked as seen until the returned token is committed.
+func (g *replayGuard) reserve(index uint64) (replayToken, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.pending[index]; ok {
+		return replayToken{}, false
+	}
+	if g.hasFloor && index <= g.floor {
+		return replayToken{}, false
+	}
+
+	var accept func() bool
+	if detector, ok := g.detector.(indexReplayDetector); ok {
+		if !detector.check(index) {
+			return replayToken{}, false
+		}
+	} else {
+		if accept, ok = g.detector.Check(index); !ok {
+			return replayToken{}, false
+		}
+	}
+
+	if g.pending == nil {
+		g.pending = map[uint64]struct{}{}
+	}
+	g.pending[index] = struct{}{}
+
+	return replayToken{guard: g, index: index, accept: accept}, true
+}
+
+// commit marks the reserved index as seen. It returns false if the token was already committed
+// or released, or the index is no longer acceptable, because other packets moved the replay window
+// while this one was processed.
+func (t *replayToken) commit() bool {
+	if t.done {
+		return false
+	}
+	t.done = true
+
+	g := t.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.pending, t.index)
+	if g.hasFloor && t.index <= g.floor {
+		return false
+	}
+	if t.accept != nil {
+		t.accept()
+	} else {
+		detector, _ := g.detector.(indexReplayDetector)
+		if !detector.check(t.index) {
+			return false
+		}
+		detector.accept(t.index)
+	}
+	g.track(t.index)
+
+	return true
+}
+
+// release drops the reservation without marking the index as seen. It does nothing
+// if the token was already committed or released.
+func (t *replayToken) release() {
+	if t.done {
+		return
+	}
+	t.done = true
+
+	g := t.guard
+	g.mu.Lock()
+	delete(g.pending, t.index)
+	g.mu.Unlock()
+}
+
+// track updates the summary of recently seen indexes. It must be called with mu held.
+func (g *replayGuard) track(index uint64) {
+	switch {
+	case !g.hasSeen:
+		g.hasSeen = true
+		g.seenTop = index
+		g.seen = 1
+	case index > g.seenTop:
+		if shift := index - g.seenTop; shift < replaySummaryBits {
+			g.seen = g.seen<<shift | 1
+		} else {
+			g.seen = 1
+		}
+		g.seenTop = index
+	case g.seenTop-index < replaySummaryBits:
+		g.seen |= 1 << (g.seenTop - index)
+	}
+}
+
+// summary returns bitmap of recently seen indexes, relative to top: bit i is set when index top-i
+// was marked as seen. Only the last replaySummaryBits seen indexes are included.
+func (g *replayGuard) summary(top uint64) uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case !g.hasSeen:
+		return 0
+	case top >= g.seenTop && top-g.seenTop < replaySummaryBits:
+		return g.seen << (top - g.seenTop)
+	case top < g.seenTop && g.seenTop-top < replaySummaryBits:
+		return g.seen >> (g.seenTop - top)
+	default:
+		return 0
+	}
+}
+
+// top returns the newest index marked as seen. It returns false when no index was seen yet.
+func (g *replayGuard) top() (uint64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.seenTop, g.hasSeen
+}
@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/replaydetector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayGuard(t *testing.T) {
+	guard := newReplayGuard(replaydetector.New(64, maxSequenceNumber))
+
+	token, ok := guard.reserve(10)
+	assert.True(t, ok)
+
+	// Index is pending, so it cannot be reserved again.
+	_, ok = guard.reserve(10)
+	assert.False(t, ok)
+
+	// Released index can be reserved again.
+	token.release()
+	token.release()
+	token, ok = guard.reserve(10)
+	assert.True(t, ok)
+	assert.True(t, token.commit())
+	assert.False(t, token.commit())
+
+	_, ok = guard.reserve(10)
+	assert.False(t, ok)
+
+	// Window moved forward while the packet was processed.
+	guard = newReplayGuard(newReplayWindow(64, maxSequenceNumber))
+	token, ok = guard.reserve(11)
+	assert.True(t, ok)
+	token2, ok := guard.reserve(200)
+	assert.True(t, ok)
+	assert.True(t, token2.commit())
+	assert.False(t, token.commit())
+
+	// Indexes not newer than the floor are rejected.
+	guard.setFloor(300)
+	_, ok = guard.reserve(300)
+	assert.False(t, ok)
+	token, ok = guard.reserve(301)
+	assert.True(t, ok)
+	assert.True(t, token.commit())
+}
+
+// countingReplayDetector counts calls of Check of the wrapped detector.
+type countingReplayDetector struct {
+	replaydetector.ReplayDetector
+	checks int
+}
+
+func (d *countingReplayDetector) Check(index uint64) (func() bool, bool) {
+	d.checks++
+
+	return d.ReplayDetector.Check(index)
+}
+
+func TestReplayGuardCustomDetectorSingleCheck(t *testing.T) {
+	detector := &countingReplayDetector{ReplayDetector: replaydetector.New(64, maxSequenceNumber)}
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(64),
+		SRTPReplayDetectorFactory(func() replaydetector.ReplayDetector { return detector }))
+	assert.NoError(t, err)
+
+	packet := encryptTestRTPForSSRC(t, encryptCtx, defaultSsrc, 10)
+	_, err = decryptCtx.DecryptRTP(nil, packet, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, detector.checks)
+
+	_, err = decryptCtx.DecryptRTP(nil, packet, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+	assert.Equal(t, 2, detector.checks)
+}
+
+func TestDecryptRTPReplayAfterAuthFailure(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(64))
+	assert.NoError(t, err)
+
+	decrypt := func(packet []byte) error {
+		_, errDecrypt := decryptCtx.DecryptRTP(nil, packet, nil)
+
+		return errDecrypt
+	}
+
+	packet := encryptTestRTPForSSRC(t, encryptCtx, defaultSsrc, 10)
+	forged := append([]byte{}, packet...)
+	forged[len(forged)-1] ^= 0xff
+
+	// Index of packet which failed authentication is not marked as seen.
+	assert.ErrorIs(t, decrypt(forged), ErrFailedToVerifyAuthTag)
+	assert.NoError(t, decrypt(packet))
+	assert.ErrorIs(t, decrypt(packet), errDuplicated)
+	assert.ErrorIs(t, decrypt(forged), errDuplicated)
+
+	// Packet outside the replay window is rejected.
+	assert.NoError(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, defaultSsrc, 200)))
+	assert.ErrorIs(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, defaultSsrc, 11)), errDuplicated)
+}
+
+func TestReplayGuardConcurrent(t *testing.T) {
+	const (
+		numIndexes    = 1024
+		numGoroutines = 8
+	)
+
+	guard := newReplayGuard(replaydetector.New(numIndexes, maxSequenceNumber))
+	var accepted [numIndexes]atomic.Int32
+
+	var wg sync.WaitGroup
+	for g := range numGoroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range numIndexes {
+				index := uint64(i)
+				token, ok := guard.reserve(index)
+				if !ok {
+					continue
+				}
+				// Simulate authentication failure for some packets.
+				if (i+g)%3 == 0 {
+					token.release()
+
+					continue
+				}
+				if token.commit() {
+					accepted[index].Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range accepted {
+		assert.LessOrEqual(t, accepted[i].Load(), int32(1), "index %d accepted more than once", i)
+	}
+}
+
+func TestDecryptRTPConcurrentReplay(t *testing.T) {
+	const numGoroutines = 8
+
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(64))
+	assert.NoError(t, err)
+
+	// Create SSRC state, so concurrent decryption below does not modify the state map.
+	decryptCtx.SetROC(defaultSsrc, 0)
+	decryptState := decryptCtx.srtpSSRCStates[defaultSsrc]
+
+	pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: 0x1234, SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+
+	// Run only the replay protection part concurrently, Context itself is not safe for concurrent use.
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for range numGoroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, ok := decryptState.replayGuard.reserve(0x1234)
+			if !ok {
+				return
+			}
+			defer token.release()
+			if token.commit() {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), accepted.Load())
+
+	// Packet was already accepted by one of goroutines.
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+}
+
+func BenchmarkReplayGuardParallel(b *testing.B) {
+	guard := newReplayGuard(replaydetector.New(1024, maxROC<<16|maxSequenceNumber))
+	var next atomic.Uint64
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			token, ok := guard.reserve(next.Add(1))
+			if ok {
+				token.commit()
+			}
+		}
+	})
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+// replayWindow is a sliding window replay detector used by SRTPReplayProtection and
+// SRTCPReplayProtection. It works like replaydetector.New, but in addition to the ReplayDetector
+// interface it implements indexReplayDetector, which replayGuard uses to check indexes without
+// allocating a closure for every packet.
+type replayWindow struct {
+	latest     uint64
+	maxIndex   uint64
+	windowSize uint
+	// Bit i of mask is set when index latest-i was accepted.
+	mask []uint64
+}
+
+// indexReplayDetector is implemented by replay detectors which can check and accept indexes
+// separately, without allocations.
+type indexReplayDetector interface {
+	check(index uint64) bool
+	accept(index uint64) bool
+}
+
+func newReplayWindow(windowSize uint, maxIndex uint64) *replayWindow {
+	return &replayWindow{
+		maxIndex:   maxIndex,
+		windowSize: windowSize,
+		mask:       make([]uint64, max((windowSize+63)/64, 1)),
+	}
+}
+
+// Check implements replaydetector.ReplayDetector.
+func (w *replayWindow) Check(index uint64) (func() bool, bool) {
+	if !w.check(index) {
+		return func() bool { return false }, false
+	}
+
+	return func() bool { return w.accept(index) }, true
+}
+
+// check returns true if the index is not replayed and is within the window.
+func (w *replayWindow) check(index uint64) bool {
+	if index > w.maxIndex {
+		return false
+	}
+	if index <= w.latest {
+		if w.latest >= uint64(w.windowSize)+index {
+			return false
+		}
+		if w.bit(w.latest - index) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// accept marks index checked by check as received. It returns true when the index is the latest one.
+func (w *replayWindow) accept(index uint64) bool {
+	latest := index == 0
+	if index > w.latest {
+		w.shift(index - w.latest)
+		w.latest = index
+		latest = true
+	}
+	w.setBit(w.latest - index)
+
+	return latest
+}
+
+func (w *replayWindow) bit(i uint64) bool {
+	if i >= uint64(w.windowSize) {
+		return false
+	}
+
+	return w.mask[i/64]&(1<<(i%64)) != 0
+}
+
+func (w *replayWindow) setBit(i uint64) {
+	if i >= uint64(w.windowSize) {
+		return
+	}
+	w.mask[i/64] |= 1 << (i % 64)
+}
+
+// shift moves the window forward by n indexes.
+func (w *replayWindow) shift(n uint64) {
+	if n >= uint64(w.windowSize) {
+		clear(w.mask)
+
+		return
+	}
+
+	words, bits := int(n/64), n%64 //nolint:gosec // G115, n is less than window size
+	for i := len(w.mask) - 1; i >= 0; i-- {
+		var word uint64
+		if i-words >= 0 {
+			word = w.mask[i-words] << bits
+			if bits != 0 && i-words-1 >= 0 {
+				word |= w.mask[i-words-1] >> (64 - bits)
+			}
+		}
+		w.mask[i] = word
+	}
+	if rem := w.windowSize % 64; rem != 0 {
+		w.mask[len(w.mask)-1] &= 1<<rem - 1
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/pion/transport/v4/replaydetector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayWindowMatchesReplayDetector(t *testing.T) {
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec
+	window := newReplayWindow(64, 0xFFFF)
+	detector := replaydetector.New(64, 0xFFFF)
+
+	index := uint64(100)
+	for i := 0; i < 10000; i++ {
+		index = uint64(max(int(index)+rng.Intn(100)-60, 0)) //nolint:gosec // G115
+		windowAccept, windowOK := window.Check(index)
+		detectorAccept, detectorOK := detector.Check(index)
+		assert.Equal(t, detectorOK, windowOK, "index %d", index)
+		if windowOK && detectorOK && rng.Intn(4) != 0 {
+			assert.Equal(t, detectorAccept(), windowAccept(), "index %d", index)
+		}
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	window := newReplayWindow(100, 1000)
+
+	for _, index := range []uint64{0, 1, 5, 70, 150} {
+		assert.True(t, window.check(index), "index %d", index)
+		assert.True(t, window.accept(index), "index %d", index)
+		assert.False(t, window.check(index), "index %d", index)
+	}
+	assert.False(t, window.check(50), "index outside of window")
+	assert.True(t, window.check(51))
+	assert.True(t, window.check(149))
+	assert.False(t, window.accept(149))
+	assert.False(t, window.check(149))
+	assert.False(t, window.check(70))
+	assert.False(t, window.check(1001), "index above maximum")
+
+	// Window shifted by more than two words keeps only the newest indexes.
+	assert.True(t, window.accept(280))
+	assert.False(t, window.check(180))
+	assert.True(t, window.check(181))
+	assert.True(t, window.check(200))
+	assert.False(t, window.check(150))
+	assert.False(t, window.check(280))
+}
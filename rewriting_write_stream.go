@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RewritingWriteStreamSRTP is write stream of SessionSRTP which sends packets of many source streams
+// as target streams with SSRCs chosen by the caller, e.g. by SFU which forwards many tracks to a single
+// subscriber. SSRC, sequence number and timestamp of written packets are rewritten, and each target
+// stream has independent sequence numbers, timestamps and ROC. When source stream of a target changes,
+// e.g. when SFU switches simulcast layers, sequence numbers and timestamps of the target continue from
+// the last written ones. Reordering and gaps of packets of a source stream are preserved.
+type RewritingWriteStreamSRTP struct {
+	session *SessionSRTP
+
+	mu      sync.Mutex
+	targets map[uint32]*rewriteTarget
+}
+
+// rewriteTarget keeps state of a target stream of RewritingWriteStreamSRTP. Sequence numbers are tracked
+// as extended indexes, which do not wrap around.
+type rewriteTarget struct {
+	sourceSSRC    uint32
+	rewrite       RTPHeaderRewrite
+	lastIndex     int64
+	lastTimestamp uint32
+	// floorIndex is the highest index sent before the last source switch. Packets of the current source
+	// rewritten at or below it would reuse SRTP packet index, and so keystream or nonce, so they are dropped.
+	floorIndex int64
+}
+
+// OpenRewritingWriteStream returns a new write stream which rewrites headers of written packets.
+// See RewritingWriteStreamSRTP for details.
+func (s *SessionSRTP) OpenRewritingWriteStream() (*RewritingWriteStreamSRTP, error) {
+	return &RewritingWriteStreamSRTP{session: s, targets: map[uint32]*rewriteTarget{}}, nil
+}
+
+// Write rewrites header of a full RTP packet b to send it in target stream with targetSSRC, encrypts it
+// and writes it to the connection. b is not modified.
+func (w *RewritingWriteStreamSRTP) Write(targetSSRC uint32, b []byte) (int, error) {
+	if _, ok := <-w.session.session.started; ok {
+		return 0, errStartedChannelUsedIncorrectly
+	}
+
+	wbuf, ok := writeBufferPool.Get().(*writeBuffer)
+	if !ok {
+		return 0, errFailedTypeAssertion
+	}
+
+	headerLen, err := wbuf.header.Unmarshal(b)
+	if err != nil {
+		writeBufferPool.Put(wbuf)
+
+		return 0, err
+	}
+
+	// Packet is rewritten and encrypted in place in the pooled buffer, so b is not modified.
+	buf := wbuf.grow(len(b))
+	copy(buf, b)
+
+	header := &wbuf.header
+	rewrite, err := w.rewrite(targetSSRC, header.SSRC, header.SequenceNumber, header.Timestamp)
+	if err != nil {
+		writeBufferPool.Put(wbuf)
+
+		return 0, err
+	}
+	header.SSRC = rewrite.SSRC
+	header.SequenceNumber += rewrite.SequenceNumberOffset
+	header.Timestamp += rewrite.TimestampOffset
+	binary.BigEndian.PutUint16(buf[2:], header.SequenceNumber)
+	binary.BigEndian.PutUint32(buf[4:], header.Timestamp)
+	binary.BigEndian.PutUint32(buf[8:], header.SSRC)
+
+	return w.session.send(wbuf, header, headerLen, len(b))
+}
+
+// rewrite returns rewrite of packet of source stream sent in the target stream, and updates state
+// of the target stream. It returns errRewrittenIndexUsed when the rewritten packet would reuse
+// sequence number already sent before the last source switch.
+func (w *RewritingWriteStreamSRTP) rewrite(
+	targetSSRC, sourceSSRC uint32, seq uint16, timestamp uint32,
+) (RTPHeaderRewrite, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	target, ok := w.targets[targetSSRC]
+	switch {
+	case !ok:
+		target = &rewriteTarget{
+			sourceSSRC:    sourceSSRC,
+			rewrite:       RTPHeaderRewrite{HasSSRC: true, SSRC: targetSSRC},
+			lastIndex:     int64(seq),
+			lastTimestamp: timestamp,
+			floorIndex:    -1,
+		}
+		// Packets of the target stream may have been sent already, e.g. before RemoveTarget, so
+		// sequence numbers continue from the last one encrypted by the session.
+		if lastIndex, sent := w.session.lastSentIndex(targetSSRC); sent {
+			target.lastIndex = int64(lastIndex) //nolint:gosec // G115, index has 48 bits
+			target.floorIndex = target.lastIndex
+			target.rewrite.SequenceNumberOffset = uint16(lastIndex) + 1 - seq //nolint:gosec // G115
+		}
+		w.targets[targetSSRC] = target
+	case target.sourceSSRC != sourceSSRC:
+		target.sourceSSRC = sourceSSRC
+		target.floorIndex = target.lastIndex
+		target.rewrite.SequenceNumberOffset = uint16(target.lastIndex) + 1 - seq //nolint:gosec // G115
+		target.rewrite.TimestampOffset = target.lastTimestamp + 1 - timestamp
+	}
+
+	newSeq := seq + target.rewrite.SequenceNumberOffset
+	index := target.lastIndex + int64(int16(newSeq-uint16(target.lastIndex))) //nolint:gosec // G115
+	if index <= target.floorIndex {
+		return RTPHeaderRewrite{}, fmt.Errorf("%w: ssrc=%d seq=%d", errRewrittenIndexUsed, targetSSRC, newSeq)
+	}
+
+	// Only the highest sequence number and timestamp are kept, so late packets do not move them back.
+	target.lastIndex = max(target.lastIndex, index)
+	newTimestamp := timestamp + target.rewrite.TimestampOffset
+	if int32(newTimestamp-target.lastTimestamp) > 0 { //nolint:gosec // G115
+		target.lastTimestamp = newTimestamp
+	}
+
+	return target.rewrite, nil
+}
+
+// RemoveTarget removes state of the target stream with given SSRC, e.g. when the forwarded track is
+// removed. Packets written to it later start a new target stream with timestamps of their source, and
+// sequence numbers continuing from the last packet of the SSRC encrypted by the session, so SRTP packet
+// indexes are not reused. SRTP state of the SSRC, including ROC, is kept by the local Context.
+func (w *RewritingWriteStreamSRTP) RemoveTarget(targetSSRC uint32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.targets, targetSSRC)
+}
+
+// SetWriteDeadline sets the deadline for the Write operation.
+// Setting to zero means no deadline.
+func (w *RewritingWriteStreamSRTP) SetWriteDeadline(t time.Time) error {
+	return w.session.setWriteDeadline(t)
+}
@@ -0,0 +1,384 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+const (
+	cryptoAttributePrefix = "a=crypto:"
+	inlineKeyMethod       = "inline:"
+	maxSDESMKILength      = 128
+)
+
+// CryptoAttribute is SDP Security Description (SDES) crypto attribute, as defined in RFC 4568.
+type CryptoAttribute struct {
+	// Tag identifies the attribute in offer/answer exchange.
+	Tag uint32
+	// Profile is the protection profile selected by crypto-suite name.
+	Profile ProtectionProfile
+	// Keys are master keys specified with "inline" key method. There is at least one key.
+	Keys []CryptoKey
+	// SessionParams are optional session parameters (e.g. "KDR=1" or "UNENCRYPTED_SRTCP").
+	// KDR, UNENCRYPTED_SRTP and UNENCRYPTED_SRTCP are applied by CreateContext and
+	// Config.ExtractSessionKeysFromSDES, and they reject UNAUTHENTICATED_SRTP, which is not supported.
+	// Other parameters are not interpreted.
+	SessionParams []string
+}
+
+// CryptoKey is a master key from the "inline" key parameter of SDES crypto attribute.
+type CryptoKey struct {
+	MasterKey  []byte
+	MasterSalt []byte
+	// Lifetime is the maximum number of packets protected by the key. Zero means default lifetime.
+	Lifetime uint64
+	// MKI is the Master Key Identifier of the key, with length from the attribute. Nil means MKI is
+	// not used.
+	MKI []byte
+}
+
+// NewCryptoAttribute creates SDES crypto attribute with given tag and protection profile, and with
+// a random master key and salt, e.g. for an SDP offer.
+func NewCryptoAttribute(tag uint32, profile ProtectionProfile) (*CryptoAttribute, error) {
+	if _, err := profile.sdesName(); err != nil {
+		return nil, err
+	}
+	keyLen, err := profile.KeyLen()
+	if err != nil {
+		return nil, err
+	}
+	saltLen, err := profile.SaltLen()
+	if err != nil {
+		return nil, err
+	}
+
+	keySalt := make([]byte, keyLen+saltLen)
+	if _, err = rand.Read(keySalt); err != nil {
+		return nil, err
+	}
+
+	return &CryptoAttribute{
+		Tag:     tag,
+		Profile: profile,
+		Keys:    []CryptoKey{{MasterKey: keySalt[:keyLen], MasterSalt: keySalt[keyLen:]}},
+	}, nil
+}
+
+// ParseCryptoAttribute parses SDES crypto attribute. It accepts full SDP line
+// (e.g. "a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:..."), or the attribute value only.
+func ParseCryptoAttribute(line string) (*CryptoAttribute, error) {
+	value := strings.TrimSpace(line)
+	value = strings.TrimPrefix(value, cryptoAttributePrefix)
+
+	fields := strings.Fields(value)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("%w: %q", errInvalidCryptoAttribute, line)
+	}
+
+	tag, err := strconv.ParseUint(fields[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid tag %q", errInvalidCryptoAttribute, fields[0])
+	}
+
+	profile, err := ParseProtectionProfile(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	keyLen, err := profile.KeyLen()
+	if err != nil {
+		return nil, err
+	}
+	saltLen, err := profile.SaltLen()
+	if err != nil {
+		return nil, err
+	}
+
+	attr := &CryptoAttribute{
+		Tag:     uint32(tag),
+		Profile: profile,
+	}
+	for _, param := range strings.Split(fields[2], ";") {
+		key, errKey := parseCryptoKey(param, keyLen, saltLen)
+		if errKey != nil {
+			return nil, errKey
+		}
+		attr.Keys = append(attr.Keys, key)
+	}
+	if len(fields) > 3 {
+		attr.SessionParams = fields[3:]
+	}
+
+	return attr, nil
+}
+
+// parseCryptoKey parses single "inline:<key||salt>[|lifetime][|MKI:length]" key parameter.
+func parseCryptoKey(param string, keyLen, saltLen int) (CryptoKey, error) {
+	keyInfo, ok := strings.CutPrefix(param, inlineKeyMethod)
+	if !ok {
+		return CryptoKey{}, fmt.Errorf("%w: unsupported key method in %q", errInvalidCryptoAttribute, param)
+	}
+
+	parts := strings.Split(keyInfo, "|")
+	if len(parts) > 3 {
+		return CryptoKey{}, fmt.Errorf("%w: invalid key info %q", errInvalidCryptoAttribute, keyInfo)
+	}
+
+	keySalt, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		keySalt, err = base64.RawStdEncoding.DecodeString(parts[0])
+	}
+	if err != nil || len(keySalt) != keyLen+saltLen {
+		return CryptoKey{}, fmt.Errorf("%w: invalid key and salt %q", errInvalidCryptoAttribute, parts[0])
+	}
+	key := CryptoKey{MasterKey: keySalt[:keyLen], MasterSalt: keySalt[keyLen:]}
+
+	for _, part := range parts[1:] {
+		// MKI contains colon, lifetime does not.
+		if mkiValue, mkiLen, isMKI := strings.Cut(part, ":"); isMKI {
+			if key.MKI != nil {
+				return CryptoKey{}, fmt.Errorf("%w: duplicated MKI in %q", errInvalidCryptoAttribute, keyInfo)
+			}
+			if key.MKI, err = parseSDESMKI(mkiValue, mkiLen); err != nil {
+				return CryptoKey{}, err
+			}
+
+			continue
+		}
+
+		if key.Lifetime != 0 || key.MKI != nil {
+			return CryptoKey{}, fmt.Errorf("%w: invalid key info %q", errInvalidCryptoAttribute, keyInfo)
+		}
+		if key.Lifetime, err = parseSDESLifetime(part); err != nil {
+			return CryptoKey{}, err
+		}
+	}
+
+	return key, nil
+}
+
+// parseSDESLifetime parses key lifetime, which may be a decimal number or a power of 2 (e.g. "2^31").
+func parseSDESLifetime(lifetime string) (uint64, error) {
+	if exp, isPower := strings.CutPrefix(lifetime, "2^"); isPower {
+		n, err := strconv.ParseUint(exp, 10, 6)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("%w: invalid lifetime %q", errInvalidCryptoAttribute, lifetime)
+		}
+
+		return 1 << n, nil
+	}
+
+	n, err := strconv.ParseUint(lifetime, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("%w: invalid lifetime %q", errInvalidCryptoAttribute, lifetime)
+	}
+
+	return n, nil
+}
+
+// sessionOptions returns Context options for session parameters of the attribute: KeyDerivationRate
+// for "KDR=n", SRTPNoEncryption for UNENCRYPTED_SRTP and SRTCPNoEncryption for UNENCRYPTED_SRTCP.
+// UNAUTHENTICATED_SRTP is rejected, because packets are always authenticated.
+func (a *CryptoAttribute) sessionOptions() ([]ContextOption, error) {
+	var opts []ContextOption
+	for _, param := range a.SessionParams {
+		switch param {
+		case "UNENCRYPTED_SRTP":
+			opts = append(opts, SRTPNoEncryption())
+		case "UNENCRYPTED_SRTCP":
+			opts = append(opts, SRTCPNoEncryption())
+		case "UNAUTHENTICATED_SRTP":
+			return nil, fmt.Errorf("%w: %s", errUnsupportedSessionParam, param)
+		}
+
+		value, ok := strings.CutPrefix(param, "KDR=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 8)
+		if err != nil || n > 24 {
+			return nil, fmt.Errorf("%w: invalid KDR %q", errInvalidCryptoAttribute, value)
+		}
+		opts = append(opts, KeyDerivationRate(1<<n))
+	}
+
+	return opts, nil
+}
+
+// parseSDESMKI parses decimal MKI value and its length in bytes.
+func parseSDESMKI(value, length string) ([]byte, error) {
+	mkiLen, err := strconv.Atoi(length)
+	if err != nil || mkiLen < 1 || mkiLen > maxSDESMKILength {
+		return nil, fmt.Errorf("%w: invalid MKI length %q", errInvalidCryptoAttribute, length)
+	}
+
+	mki, ok := new(big.Int).SetString(value, 10)
+	if !ok || mki.Sign() < 0 || mki.BitLen() > mkiLen*8 {
+		return nil, fmt.Errorf("%w: invalid MKI value %q", errInvalidCryptoAttribute, value)
+	}
+
+	return mki.FillBytes(make([]byte, mkiLen)), nil
+}
+
+// String returns the attribute as SDP line, e.g. "a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:...".
+// Use Value to get the attribute value only. It returns empty string if profile is not supported
+// by SDES.
+func (a *CryptoAttribute) String() string {
+	value, err := a.Value()
+	if err != nil {
+		return ""
+	}
+
+	return cryptoAttributePrefix + value
+}
+
+// Value returns value of the attribute, without "a=crypto:" prefix.
+func (a *CryptoAttribute) Value() (string, error) {
+	name, err := a.Profile.sdesName()
+	if err != nil {
+		return "", err
+	}
+	if len(a.Keys) == 0 {
+		return "", fmt.Errorf("%w: no keys", errInvalidCryptoAttribute)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatUint(uint64(a.Tag), 10))
+	sb.WriteByte(' ')
+	sb.WriteString(name)
+	sb.WriteByte(' ')
+	for i, key := range a.Keys {
+		if i > 0 {
+			sb.WriteByte(';')
+		}
+		sb.WriteString(inlineKeyMethod)
+		sb.WriteString(base64.StdEncoding.EncodeToString(append(append([]byte{}, key.MasterKey...), key.MasterSalt...)))
+		if key.Lifetime != 0 {
+			sb.WriteByte('|')
+			sb.WriteString(formatSDESLifetime(key.Lifetime))
+		}
+		if len(key.MKI) != 0 {
+			sb.WriteByte('|')
+			sb.WriteString(new(big.Int).SetBytes(key.MKI).String())
+			sb.WriteByte(':')
+			sb.WriteString(strconv.Itoa(len(key.MKI)))
+		}
+	}
+	for _, param := range a.SessionParams {
+		sb.WriteByte(' ')
+		sb.WriteString(param)
+	}
+
+	return sb.String(), nil
+}
+
+// formatSDESLifetime formats key lifetime, using power of 2 form when possible.
+func formatSDESLifetime(lifetime uint64) string {
+	if lifetime&(lifetime-1) == 0 {
+		return "2^" + strconv.Itoa(bits.TrailingZeros64(lifetime))
+	}
+
+	return strconv.FormatUint(lifetime, 10)
+}
+
+// CreateContext creates Context using the protection profile and keys of the attribute. The first key
+// is used for encrypting packets; when it has MKI, all keys are added as receive keys identified by
+// their MKIs. Options for session parameters are added, see CryptoAttribute.SessionParams. Key lifetimes
+// are not enforced.
+func (a *CryptoAttribute) CreateContext(opts ...ContextOption) (*Context, error) {
+	if len(a.Keys) == 0 {
+		return nil, fmt.Errorf("%w: no keys", errInvalidCryptoAttribute)
+	}
+	first := a.Keys[0]
+	if len(first.MKI) != 0 {
+		opts = append(opts, MasterKeyIndicator(first.MKI))
+	} else if len(a.Keys) > 1 {
+		return nil, fmt.Errorf("%w: multiple keys without MKI", errInvalidCryptoAttribute)
+	}
+	sessionOpts, err := a.sessionOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, sessionOpts...)
+
+	ctx, err := CreateContext(first.MasterKey, first.MasterSalt, a.Profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range a.Keys[1:] {
+		err = ctx.AddReceiveKey(ReceiveKey{MKI: key.MKI, MasterKey: key.MasterKey, MasterSalt: key.MasterSalt})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx, nil
+}
+
+// ExtractSessionKeysFromSDES sets the Config Profile and SessionKeys using SDES crypto attributes
+// sent to the peer (local) and received from it (remote), as negotiated with RFC 4568 offer/answer.
+// Both attributes must use the same protection profile. Only the first key of each attribute is used;
+// when it has MKI, MasterKeyIndicator option is added to the local or remote options, like options for
+// session parameters of each attribute. KeyReady event
+// is passed to Config.KeyManager, when it is set.
+func (c *Config) ExtractSessionKeysFromSDES(local, remote *CryptoAttribute) error {
+	if local.Profile != remote.Profile {
+		return fmt.Errorf("%w: %s and %s", errSDESProfileMismatch, local.Profile, remote.Profile)
+	}
+	if len(local.Keys) == 0 || len(remote.Keys) == 0 {
+		return fmt.Errorf("%w: no keys", errInvalidCryptoAttribute)
+	}
+
+	localOpts, err := local.sessionOptions()
+	if err != nil {
+		return err
+	}
+	remoteOpts, err := remote.sessionOptions()
+	if err != nil {
+		return err
+	}
+
+	localKey, remoteKey := local.Keys[0], remote.Keys[0]
+	c.Profile = local.Profile
+	c.Keys = SessionKeys{
+		LocalMasterKey:   localKey.MasterKey,
+		LocalMasterSalt:  localKey.MasterSalt,
+		RemoteMasterKey:  remoteKey.MasterKey,
+		RemoteMasterSalt: remoteKey.MasterSalt,
+	}
+	if len(localKey.MKI) != 0 {
+		c.LocalOptions = append(c.LocalOptions, MasterKeyIndicator(localKey.MKI))
+	}
+	if len(remoteKey.MKI) != 0 {
+		c.RemoteOptions = append(c.RemoteOptions, MasterKeyIndicator(remoteKey.MKI))
+	}
+	c.LocalOptions = append(c.LocalOptions, localOpts...)
+	c.RemoteOptions = append(c.RemoteOptions, remoteOpts...)
+	c.emitKeyReady(KeySourceSDES)
+
+	return nil
+}
+
+// sdesName returns SDES crypto-suite name of the profile. AEAD_AES_128_GCM, AEAD_AES_256_GCM,
+// AEAD_AES_128_GCM_8 and AEAD_AES_256_GCM_8 names are defined by RFC 7714.
+func (p ProtectionProfile) sdesName() (string, error) {
+	for name, profile := range sdesProtectionProfileNames {
+		if profile == p {
+			return name, nil
+		}
+	}
+	switch p {
+	case ProtectionProfileAeadAes128Gcm, ProtectionProfileAeadAes256Gcm,
+		ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8:
+		return strings.TrimPrefix(p.String(), "SRTP_"), nil
+	default:
+		return "", fmt.Errorf("%w: %s is not supported by SDES", ErrUnsupportedProfile, p)
+	}
+}
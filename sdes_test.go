@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCryptoAttribute(t *testing.T) {
+	// Example from RFC 4568 section 4.
+	line := "a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:PS1uQCVeeCFCanVmcjkpPywjNWhcYD0mXXtxaVBR|2^20|1:4 KDR=1"
+	attr, err := ParseCryptoAttribute(line)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), attr.Tag)
+	assert.Equal(t, ProtectionProfileAes128CmHmacSha1_80, attr.Profile)
+	assert.Len(t, attr.Keys, 1)
+	assert.Len(t, attr.Keys[0].MasterKey, 16)
+	assert.Len(t, attr.Keys[0].MasterSalt, 14)
+	assert.Equal(t, uint64(1<<20), attr.Keys[0].Lifetime)
+	assert.Equal(t, []byte{0, 0, 0, 1}, attr.Keys[0].MKI)
+	assert.Equal(t, []string{"KDR=1"}, attr.SessionParams)
+	assert.Equal(t, line, attr.String())
+
+	value := "2 AEAD_AES_256_GCM inline:" +
+		"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=|1000;" +
+		"inline:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+	attr, err = ParseCryptoAttribute(value)
+	assert.NoError(t, err)
+	assert.Equal(t, ProtectionProfileAeadAes256Gcm, attr.Profile)
+	assert.Len(t, attr.Keys, 2)
+	assert.Equal(t, uint64(1000), attr.Keys[0].Lifetime)
+	assert.Nil(t, attr.Keys[1].MKI)
+	assert.Len(t, attr.Keys[1].MasterSalt, 12)
+	encoded, err := attr.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, value, encoded)
+}
+
+func TestParseCryptoAttributeErrors(t *testing.T) {
+	keySalt := "PS1uQCVeeCFCanVmcjkpPywjNWhcYD0mXXtxaVBR"
+	for _, line := range []string{
+		"",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80",
+		"a=crypto:x AES_CM_128_HMAC_SHA1_80 inline:" + keySalt,
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 uri:" + keySalt,
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:AAAA",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + keySalt + "|2^0",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + keySalt + "|0",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + keySalt + "|1:0",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + keySalt + "|256:1",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + keySalt + "|1:1|2^20",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + keySalt + "|1:1|2:1",
+		"a=crypto:1 AES_CM_128_HMAC_SHA1_80 inline:" + keySalt + "|2^20|1:1|1",
+	} {
+		_, err := ParseCryptoAttribute(line)
+		assert.ErrorIs(t, err, errInvalidCryptoAttribute, line)
+	}
+
+	_, err := ParseCryptoAttribute("a=crypto:1 SEED_128_CCM_80 inline:" + keySalt)
+	assert.ErrorIs(t, err, ErrUnsupportedProfile)
+
+	attr, err := ParseCryptoAttribute("a=crypto:1 F8_128_HMAC_SHA1_80 inline:" + keySalt)
+	assert.NoError(t, err)
+	assert.Equal(t, ProtectionProfileAes128F8HmacSha1_80, attr.Profile)
+}
+
+func TestNewCryptoAttribute(t *testing.T) {
+	attr, err := NewCryptoAttribute(3, ProtectionProfileAes256CmHmacSha1_32)
+	assert.NoError(t, err)
+	assert.Len(t, attr.Keys[0].MasterKey, 32)
+	assert.Len(t, attr.Keys[0].MasterSalt, 14)
+
+	parsed, err := ParseCryptoAttribute(attr.String())
+	assert.NoError(t, err)
+	assert.Equal(t, attr, parsed)
+
+	attr, err = NewCryptoAttribute(1, ProtectionProfileAeadAes128Gcm8)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(attr.String(), "a=crypto:1 AEAD_AES_128_GCM_8 inline:"))
+
+	_, err = NewCryptoAttribute(1, ProtectionProfileDoubleAeadAes128Gcm)
+	assert.ErrorIs(t, err, ErrUnsupportedProfile)
+	assert.Empty(t, (&CryptoAttribute{Profile: ProtectionProfileDoubleAeadAes128Gcm}).String())
+}
+
+func TestCryptoAttributeCreateContext(t *testing.T) {
+	sender, err := NewCryptoAttribute(1, profileCTR)
+	assert.NoError(t, err)
+	sender.Keys[0].MKI = []byte{1}
+
+	receiver, err := ParseCryptoAttribute(sender.String())
+	assert.NoError(t, err)
+	second, err := NewCryptoAttribute(1, profileCTR)
+	assert.NoError(t, err)
+	second.Keys[0].MKI = []byte{2, 2}
+	receiver.Keys = append(receiver.Keys, second.Keys[0])
+
+	encryptCtx, err := sender.CreateContext()
+	assert.NoError(t, err)
+	decryptCtx, err := receiver.CreateContext()
+	assert.NoError(t, err)
+	decrypted, err := decryptCtx.DecryptRTP(nil, encryptTestRTP(t, encryptCtx, 1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x02, 0x03}, decrypted[len(decrypted)-4:])
+
+	encryptCtx, err = second.CreateContext()
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, encryptCtx, 2), nil)
+	assert.NoError(t, err)
+
+	second.Keys = append(second.Keys, sender.Keys[0])
+	second.Keys[0].MKI = nil
+	_, err = second.CreateContext()
+	assert.ErrorIs(t, err, errInvalidCryptoAttribute)
+	_, err = (&CryptoAttribute{Profile: profileCTR}).CreateContext()
+	assert.ErrorIs(t, err, errInvalidCryptoAttribute)
+}
+
+func TestCryptoAttributeKeyDerivationRate(t *testing.T) {
+	attr, err := NewCryptoAttribute(1, profileCTR)
+	assert.NoError(t, err)
+	attr.SessionParams = []string{"UNENCRYPTED_SRTCP", "KDR=2"}
+
+	ctx, err := attr.CreateContext()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), ctx.keyDerivationRate)
+
+	config := &Config{}
+	assert.NoError(t, config.ExtractSessionKeysFromSDES(attr, attr))
+	assert.Len(t, config.LocalOptions, 2)
+	assert.Len(t, config.RemoteOptions, 2)
+
+	for _, kdr := range []string{"25", "-1", "x"} {
+		attr.SessionParams = []string{"KDR=" + kdr}
+		_, err = attr.CreateContext()
+		assert.ErrorIs(t, err, errInvalidCryptoAttribute)
+		assert.ErrorIs(t, config.ExtractSessionKeysFromSDES(attr, attr), errInvalidCryptoAttribute)
+	}
+}
+
+func TestCryptoAttributeUnencrypted(t *testing.T) {
+	attr, err := NewCryptoAttribute(1, profileCTR)
+	assert.NoError(t, err)
+
+	attr.SessionParams = []string{"UNENCRYPTED_SRTCP"}
+	ctx, err := attr.CreateContext()
+	assert.NoError(t, err)
+	assert.True(t, ctx.encryptSRTP)
+	assert.False(t, ctx.encryptSRTCP)
+
+	attr.SessionParams = []string{"UNENCRYPTED_SRTP", "UNENCRYPTED_SRTCP"}
+	ctx, err = attr.CreateContext()
+	assert.NoError(t, err)
+	assert.False(t, ctx.encryptSRTP)
+	assert.False(t, ctx.encryptSRTCP)
+
+	attr.SessionParams = []string{"UNAUTHENTICATED_SRTP"}
+	_, err = attr.CreateContext()
+	assert.ErrorIs(t, err, errUnsupportedSessionParam)
+	assert.ErrorIs(t, (&Config{}).ExtractSessionKeysFromSDES(attr, attr), errUnsupportedSessionParam)
+}
+
+func TestConfigExtractSessionKeysFromSDES(t *testing.T) {
+	local, err := NewCryptoAttribute(1, profileGCM)
+	assert.NoError(t, err)
+	local.Keys[0].MKI = []byte{1}
+	remote, err := NewCryptoAttribute(1, profileGCM)
+	assert.NoError(t, err)
+
+	config := &Config{}
+	assert.NoError(t, config.ExtractSessionKeysFromSDES(local, remote))
+	assert.Equal(t, profileGCM, config.Profile)
+	assert.Equal(t, local.Keys[0].MasterKey, config.Keys.LocalMasterKey)
+	assert.Equal(t, local.Keys[0].MasterSalt, config.Keys.LocalMasterSalt)
+	assert.Equal(t, remote.Keys[0].MasterKey, config.Keys.RemoteMasterKey)
+	assert.Equal(t, remote.Keys[0].MasterSalt, config.Keys.RemoteMasterSalt)
+	assert.Len(t, config.LocalOptions, 1)
+	assert.Empty(t, config.RemoteOptions)
+
+	remote.Profile = profileCTR
+	assert.ErrorIs(t, config.ExtractSessionKeysFromSDES(local, remote), errSDESProfileMismatch)
+	remote.Profile = profileGCM
+	remote.Keys = nil
+	assert.ErrorIs(t, config.ExtractSessionKeysFromSDES(local, remote), errInvalidCryptoAttribute)
+}
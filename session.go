@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/pion/logging"
+	"github.com/pion/rtp"
 	"github.com/pion/transport/v4/packetio"
 )
 
@@ -22,9 +23,15 @@ type streamSession interface {
 
 type session struct {
 	localContextMutex           sync.Mutex
+	remoteContextMutex          sync.Mutex
 	localContext, remoteContext *Context
 	localOptions, remoteOptions []ContextOption
 
+	// asyncWriter is set by SessionSRTP when Config.WriteWorkers is positive. Local packets are then
+	// encrypted with its ConcurrentContext, which uses localContext as the template, so localContext
+	// must not be used to encrypt packets.
+	asyncWriter *asyncWriter
+
 	newStream           chan readStream
 	acceptStreamTimeout time.Time
 
@@ -35,8 +42,10 @@ type session struct {
 	readStreams       map[uint32]readStream
 	readStreamsLock   sync.Mutex
 
-	log           logging.LeveledLogger
-	bufferFactory func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser
+	log            logging.LeveledLogger
+	bufferFactory  func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser
+	bufferSize     int
+	onDecryptError func(err error, pkt []byte)
 
 	nextConn net.Conn
 }
@@ -52,12 +61,76 @@ type Config struct {
 	LoggerFactory       logging.LoggerFactory
 	AcceptStreamTimeout time.Time
 
+	// ReadStreamBufferSize limits size of packets buffered by a single read stream, in bytes, when
+	// BufferFactory is not set. When the buffer is full, received packets of the stream are dropped, and
+	// ErrReadStreamBufferFull is reported by OnDecryptError. Zero value uses the default of 1MB
+	// for SessionSRTP and 100KB for SessionSRTCP. Together with SetReadDeadline of read streams, it allows
+	// to bound memory and blocking time of slow consumers, e.g. recorders.
+	ReadStreamBufferSize int
+
+	// AcceptStreamFunc is called by SessionSRTP with the first decrypted packet of SSRC which has
+	// no read stream yet, before the stream is created. When it returns false, the packet is dropped,
+	// and the function is called again for the next packet of the SSRC. When it returns true, the read
+	// stream is created and returned by AcceptStream. The function may also open the stream itself
+	// with SessionSRTP.OpenReadStream, e.g. to attach metadata to it; such streams are not returned
+	// by AcceptStream. The function is called from the goroutine reading packets, so it should not
+	// block. Nil value accepts all streams. It is not called for RTX and FEC streams associated with
+	// their primary streams by SessionSRTP.AssociateRepairStream.
+	AcceptStreamFunc func(ssrc uint32, firstPacket []byte) (accept bool)
+
+	// OnDecryptError is called by the session when a received packet cannot be decrypted,
+	// authenticated or parsed, or its read stream buffer is full, and therefore is dropped.
+	// pkt is a copy of the packet as received, valid only until the function returns. The function
+	// is called from the goroutine reading packets, so it should not block. It may be used to log or
+	// count failures, or to trigger re-keying when authentication fails too often.
+	OnDecryptError func(err error, pkt []byte, isRTCP bool)
+
+	// WriteWorkers enables asynchronous encryption of RTP packets written to SessionSRTP, e.g. when
+	// a single goroutine writes many simulcast layers at high bitrates. When it is positive, write methods
+	// of WriteStreamSRTP queue packets for WriteWorkers goroutines, which encrypt and send them in
+	// parallel, and return the size of the unencrypted packet. Packets of the same SSRC are handled
+	// by the same goroutine, so their order is preserved. Each goroutine queues up to WriteQueueSize
+	// packets (256 by default); when the queue is full, writes block until there is space. Errors of
+	// encryption and sending are passed to OnWriteError, or logged when it is nil. Packets queued when
+	// the session is closed are still sent. It is not used by SessionSRTCP.
+	WriteWorkers   int
+	WriteQueueSize int
+	OnWriteError   func(err error)
+
+	// RTCPRawCompound disables splitting of received compound RTCP packets by SessionSRTCP. By default,
+	// each packet of a compound packet is delivered separately to read streams of SSRCs it is addressed to.
+	// When it is set, the whole decrypted compound packet is delivered as received to read streams of all
+	// SSRCs the compound packet is addressed to, once per stream.
+	RTCPRawCompound bool
+
+	// Framing sets framing of packets sent over the connection. FramingRFC4571 allows to use
+	// stream-oriented connections, e.g. TCP fallback paths, TURN over TCP or RTSP interleaved transports:
+	// received frames are reassembled before they are decrypted, and sent packets are framed.
+	// Zero value is used for packet-oriented connections, e.g. UDP.
+	Framing Framing
+
+	// KeyManager receives KeyReady event when keys are set by ExtractSessionKeysFromDTLS or
+	// ExtractSessionKeysFromSDES. Use KeyEvents option to receive events of the contexts too.
+	KeyManager KeyManager
+
 	// List of local/remote context options.
 	// ReplayProtection is enabled on remote context by default.
 	// Default replay protection window size is 64.
 	LocalOptions, RemoteOptions []ContextOption
 }
 
+// decryptErrorHandler returns OnDecryptError callback bound to the session type, or nil when it is not set.
+func (c *Config) decryptErrorHandler(isRTCP bool) func(err error, pkt []byte) {
+	if c.OnDecryptError == nil {
+		return nil
+	}
+	onDecryptError := c.OnDecryptError
+
+	return func(err error, pkt []byte) {
+		onDecryptError(err, pkt, isRTCP)
+	}
+}
+
 // SessionKeys bundles the keys required to setup an SRTP session.
 type SessionKeys struct {
 	LocalMasterKey   []byte
@@ -66,6 +139,36 @@ type SessionKeys struct {
 	RemoteMasterSalt []byte
 }
 
+// writeBufferSize is the size of pooled write buffers. 1472 is the maximum Ethernet UDP payload.
+// We give ourselves 20 bytes of slack for any authentication tags, which is more than enough for
+// either CTR or GCM. If the buffer is too small, it is expanded by writeBuffer.grow or by encryption.
+const writeBufferSize = 1492
+
+// writeBuffer holds buffers used for encrypting packets written to sessions.
+type writeBuffer struct {
+	buf    []byte
+	header rtp.Header
+}
+
+// grow returns buffer for packet of given size, with 20 bytes of slack for auth tag like
+// writeBufferSize. Bigger buffer is kept for later use.
+func (b *writeBuffer) grow(size int) []byte {
+	if len(b.buf) < size+20 {
+		b.buf = make([]byte, size+20)
+	}
+
+	return b.buf
+}
+
+// writeBufferPool is a global pool of buffers used for encrypted packets by SessionSRTP and SessionSRTCP.
+// Since it's global, buffers can be shared between different sessions, which amortizes the cost of
+// allocating the pool.
+var writeBufferPool = sync.Pool{ // nolint:gochecknoglobals
+	New: func() any {
+		return &writeBuffer{buf: make([]byte, writeBufferSize)}
+	},
+}
+
 func (s *session) getOrCreateReadStream(ssrc uint32, child streamSession, proto func() readStream) (readStream, bool) {
 	s.readStreamsLock.Lock()
 	defer s.readStreamsLock.Unlock()
@@ -91,6 +194,15 @@ func (s *session) getOrCreateReadStream(ssrc uint32, child streamSession, proto
 	return rStream, true
 }
 
+func (s *session) hasReadStream(ssrc uint32) bool {
+	s.readStreamsLock.Lock()
+	defer s.readStreamsLock.Unlock()
+
+	_, ok := s.readStreams[ssrc]
+
+	return ok
+}
+
 func (s *session) removeReadStream(ssrc uint32) {
 	s.readStreamsLock.Lock()
 	defer s.readStreamsLock.Unlock()
@@ -145,6 +257,11 @@ func (s *session) start(
 		}()
 
 		b := make([]byte, 8192)
+		// Packets are decrypted in place, so a copy is kept for the decrypt error callback.
+		var received []byte
+		if s.onDecryptError != nil {
+			received = make([]byte, len(b))
+		}
 		for {
 			var i int
 			i, err = s.nextConn.Read(b)
@@ -156,8 +273,17 @@ func (s *session) start(
 				return
 			}
 
+			if received != nil {
+				copy(received, b[:i])
+			}
 			if err = child.decrypt(b[:i]); err != nil {
-				s.log.Info(err.Error())
+				// Full buffers are reported by OnDecryptError only, not to flood the log with every dropped packet.
+				if !errors.Is(err, ErrReadStreamBufferFull) {
+					s.log.Info(err.Error())
+				}
+				if s.onDecryptError != nil {
+					s.onDecryptError(err, received[:i])
+				}
 			}
 		}
 	}()
@@ -166,3 +292,37 @@ func (s *session) start(
 
 	return nil
 }
+
+// updateMasterKeys replaces master keys of local and remote contexts. New keys are validated for
+// both contexts before any of them is changed.
+func (s *session) updateMasterKeys(keys SessionKeys) error {
+	localCipher, err := s.localContext.newMasterKeyCipher(keys.LocalMasterKey, keys.LocalMasterSalt)
+	if err != nil {
+		return err
+	}
+	remoteCipher, err := s.remoteContext.newMasterKeyCipher(keys.RemoteMasterKey, keys.RemoteMasterSalt)
+	if err != nil {
+		return err
+	}
+
+	if s.asyncWriter != nil {
+		ctx := s.asyncWriter.ctx
+		ctx.mu.Lock()
+		ctx.setMasterKeyCipher(localCipher, keys.LocalMasterKey)
+		ctx.mu.Unlock()
+	} else {
+		s.localContextMutex.Lock()
+		s.localContext.setMasterKeyCipher(localCipher)
+		s.localContext.recordMasterKey(s.localContext.profile, s.localContext.sendMKI,
+			keys.LocalMasterKey, keys.LocalMasterSalt)
+		s.localContextMutex.Unlock()
+	}
+
+	s.remoteContextMutex.Lock()
+	s.remoteContext.setMasterKeyCipher(remoteCipher)
+	s.remoteContext.recordMasterKey(s.remoteContext.profile, s.remoteContext.sendMKI,
+		keys.RemoteMasterKey, keys.RemoteMasterSalt)
+	s.remoteContextMutex.Unlock()
+
+	return nil
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/v4/packetio"
+)
+
+const muxBufferSize = 1000 * 1000
+
+// NewSessionMux creates SRTP and SRTCP sessions which share conn, carrying both RTP and RTCP packets
+// (rtcp-mux). Received packets are demultiplexed by payload type, as described in RFC 5761
+// section 4, and passed to the matching session. conn is closed when both sessions are closed.
+func NewSessionMux(conn net.Conn, config *Config) (*SessionSRTP, *SessionSRTCP, error) {
+	if config == nil {
+		return nil, nil, errNoConfig
+	} else if conn == nil {
+		return nil, nil, errNoConn
+	}
+
+	// Packets are framed by the shared connection, before they are demultiplexed.
+	conn = config.framedConn(conn)
+	sessionConfig := *config
+	sessionConfig.Framing = FramingNone
+
+	mux := &sessionMux{conn: conn, open: 2}
+	mux.rtp = newMuxConn(mux)
+	mux.rtcp = newMuxConn(mux)
+	go mux.readLoop()
+
+	srtpSession, err := NewSessionSRTP(mux.rtp, &sessionConfig)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, nil, err
+	}
+
+	srtcpSession, err := NewSessionSRTCP(mux.rtcp, &sessionConfig)
+	if err != nil {
+		_ = srtpSession.Close()
+		_ = mux.rtcp.Close()
+
+		return nil, nil, err
+	}
+
+	return srtpSession, srtcpSession, nil
+}
+
+// isRTCPPacket checks if multiplexed packet is RTCP one, using its packet type field.
+// See RFC 5761 section 4.
+func isRTCPPacket(buf []byte) bool {
+	return len(buf) >= 2 && buf[1] >= 192 && buf[1] <= 223
+}
+
+// sessionMux reads packets from connection shared by SRTP and SRTCP sessions, and demultiplexes them.
+type sessionMux struct {
+	conn      net.Conn
+	rtp, rtcp *muxConn
+
+	mu   sync.Mutex
+	open int
+}
+
+func (m *sessionMux) readLoop() {
+	defer func() {
+		_ = m.rtp.buffer.Close()
+		_ = m.rtcp.buffer.Close()
+	}()
+
+	buf := make([]byte, 8192)
+	for {
+		n, err := m.conn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		endpoint := m.rtp
+		if isRTCPPacket(buf[:n]) {
+			endpoint = m.rtcp
+		}
+		// Packets are dropped when the buffer is full or closed, like in read streams.
+		_, _ = endpoint.buffer.Write(buf[:n])
+	}
+}
+
+// endpointClosed closes the shared connection after both endpoints are closed.
+func (m *sessionMux) endpointClosed() error {
+	m.mu.Lock()
+	m.open--
+	last := m.open == 0
+	m.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+
+	return m.conn.Close()
+}
+
+// muxConn is net.Conn of a single session using connection demultiplexed by sessionMux.
+type muxConn struct {
+	mux       *sessionMux
+	buffer    *packetio.Buffer
+	closeOnce sync.Once
+}
+
+func newMuxConn(mux *sessionMux) *muxConn {
+	buffer := packetio.NewBuffer()
+	buffer.SetLimitSize(muxBufferSize)
+
+	return &muxConn{mux: mux, buffer: buffer}
+}
+
+func (c *muxConn) Read(b []byte) (int, error) {
+	return c.buffer.Read(b)
+}
+
+func (c *muxConn) Write(b []byte) (int, error) {
+	return c.mux.conn.Write(b)
+}
+
+func (c *muxConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		_ = c.buffer.Close()
+		err = c.mux.endpointClosed()
+	})
+
+	return err
+}
+
+func (c *muxConn) LocalAddr() net.Addr {
+	return c.mux.conn.LocalAddr()
+}
+
+func (c *muxConn) RemoteAddr() net.Addr {
+	return c.mux.conn.RemoteAddr()
+}
+
+func (c *muxConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+
+	return c.SetWriteDeadline(t)
+}
+
+func (c *muxConn) SetReadDeadline(t time.Time) error {
+	return c.buffer.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets write deadline of the shared connection, so it applies to both sessions.
+func (c *muxConn) SetWriteDeadline(t time.Time) error {
+	return c.mux.conn.SetWriteDeadline(t)
+}
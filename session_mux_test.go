@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionMuxBadInit(t *testing.T) {
+	_, _, err := NewSessionMux(nil, nil)
+	assert.ErrorIs(t, err, errNoConfig)
+
+	_, _, err = NewSessionMux(nil, &Config{})
+	assert.ErrorIs(t, err, errNoConn)
+
+	aPipe, bPipe := net.Pipe()
+	defer func() { assert.NoError(t, bPipe.Close()) }()
+	_, _, err = NewSessionMux(aPipe, &Config{Profile: ProtectionProfileAes128CmHmacSha1_80})
+	assert.Error(t, err)
+	_, err = aPipe.Write([]byte{0})
+	assert.ErrorIs(t, err, io.ErrClosedPipe, "conn should be closed when session can not be created")
+}
+
+func TestSessionMux(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	session, pipe, config := buildSessionSRTP(t)
+	assert.NoError(t, session.Close())
+	assert.NoError(t, pipe.Close())
+
+	aPipe, bPipe := net.Pipe()
+	aSRTP, aSRTCP, err := NewSessionMux(aPipe, config)
+	assert.NoError(t, err)
+	bSRTP, bSRTCP, err := NewSessionMux(bPipe, config)
+	assert.NoError(t, err)
+
+	testPayload := []byte{0x00, 0x01, 0x03, 0x04}
+	rtpWriteStream, err := aSRTP.OpenWriteStream()
+	assert.NoError(t, err)
+	_, err = rtpWriteStream.WriteRTP(&rtp.Header{Version: 2, SSRC: 5000, PayloadType: 96}, testPayload)
+	assert.NoError(t, err)
+
+	rtcpPayload, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 5000}})
+	assert.NoError(t, err)
+	rtcpWriteStream, err := aSRTCP.OpenWriteStream()
+	assert.NoError(t, err)
+	_, err = rtcpWriteStream.Write(rtcpPayload)
+	assert.NoError(t, err)
+
+	rtpReadStream, ssrc, err := bSRTP.AcceptStream()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(5000), ssrc)
+	readBuffer := make([]byte, 100)
+	n, err := rtpReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, testPayload, readBuffer[n-len(testPayload):n])
+
+	rtcpReadStream, ssrc, err := bSRTCP.AcceptStream()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(5000), ssrc)
+	n, err = rtcpReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpPayload, readBuffer[:n])
+
+	assert.NoError(t, aSRTP.Close())
+	assert.NoError(t, aSRTCP.Close())
+	assert.NoError(t, bSRTCP.Close())
+	assert.NoError(t, bSRTP.Close())
+}
+
+func TestIsRTCPPacket(t *testing.T) {
+	assert.False(t, isRTCPPacket([]byte{0x80}))
+	assert.False(t, isRTCPPacket([]byte{0x80, 96}))
+	assert.False(t, isRTCPPacket([]byte{0x80, 0x80 | 96}))
+	assert.False(t, isRTCPPacket([]byte{0x80, 191}))
+	assert.True(t, isRTCPPacket([]byte{0x80, 192}))
+	assert.True(t, isRTCPPacket([]byte{0x80, 200}))
+	assert.True(t, isRTCPPacket([]byte{0x80, 223}))
+	assert.False(t, isRTCPPacket([]byte{0x80, 224}))
+}
@@ -21,6 +21,8 @@ const defaultSessionSRTCPReplayProtectionWindow = 64
 type SessionSRTCP struct {
 	session
 	writeStream *WriteStreamSRTCP
+	// rawCompound is set by Config.RTCPRawCompound.
+	rawCompound bool
 }
 
 // NewSessionSRTCP creates a SRTCP session using conn as the underlying transport.
@@ -35,6 +37,7 @@ func NewSessionSRTCP(conn net.Conn, config *Config) (*SessionSRTCP, error) { //n
 	if loggerFactory == nil {
 		loggerFactory = logging.NewDefaultLoggerFactory()
 	}
+	conn = config.framedConn(conn)
 
 	localOpts := append(
 		[]ContextOption{},
@@ -59,8 +62,11 @@ func NewSessionSRTCP(conn net.Conn, config *Config) (*SessionSRTCP, error) { //n
 			started:             make(chan any),
 			closed:              make(chan any),
 			bufferFactory:       config.BufferFactory,
+			bufferSize:          config.ReadStreamBufferSize,
+			onDecryptError:      config.decryptErrorHandler(true),
 			log:                 loggerFactory.NewLogger("srtp"),
 		},
+		rawCompound: config.RTCPRawCompound,
 	}
 	srtcpSession.writeStream = &WriteStreamSRTCP{srtcpSession}
 
@@ -109,6 +115,12 @@ func (s *SessionSRTCP) AcceptStream() (*ReadStreamSRTCP, uint32, error) {
 	return readStream, stream.GetSSRC(), nil
 }
 
+// UpdateMasterKeys replaces local and remote master keys and salts of the session, keeping SRTCP index
+// and replay protection state intact. See Context.UpdateMasterKey for details.
+func (s *SessionSRTCP) UpdateMasterKeys(keys SessionKeys) error {
+	return s.session.updateMasterKeys(keys)
+}
+
 // Close ends the session.
 func (s *SessionSRTCP) Close() error {
 	return s.session.close()
@@ -121,14 +133,14 @@ func (s *SessionSRTCP) write(buf []byte) (int, error) {
 		return 0, errStartedChannelUsedIncorrectly
 	}
 
-	pbuf, ok := bufferpool.Get().(*[]byte)
+	wbuf, ok := writeBufferPool.Get().(*writeBuffer)
 	if !ok {
-		return 0, errStartedChannelUsedIncorrectly
+		return 0, errFailedTypeAssertion
 	}
-	defer bufferpool.Put(pbuf)
+	defer writeBufferPool.Put(wbuf)
 
 	s.session.localContextMutex.Lock()
-	encrypted, err := s.localContext.EncryptRTCP(*pbuf, buf, nil)
+	encrypted, err := s.localContext.EncryptRTCP(wbuf.buf, buf, nil)
 	s.session.localContextMutex.Unlock()
 
 	if err != nil {
@@ -159,9 +171,10 @@ func destinationSSRC(pkts ...rtcp.Packet) []uint32 {
 	return out
 }
 
-//nolint:cyclop
 func (s *SessionSRTCP) decrypt(buf []byte) error {
+	s.session.remoteContextMutex.Lock()
 	decrypted, err := s.remoteContext.DecryptRTCP(buf, buf, nil)
+	s.session.remoteContextMutex.Unlock()
 	if err != nil {
 		return err
 	}
@@ -171,6 +184,19 @@ func (s *SessionSRTCP) decrypt(buf []byte) error {
 		return err
 	}
 
+	if s.rawCompound {
+		var writeErrs error
+		for _, ssrc := range destinationSSRC(pkts...) {
+			if err = s.writeToStream(ssrc, decrypted); errors.Is(err, ErrReadStreamBufferFull) {
+				writeErrs = errors.Join(writeErrs, err)
+			} else if err != nil {
+				return filterSessionClosed(err)
+			}
+		}
+
+		return writeErrs
+	}
+
 	var compoundSSRCs []uint32
 	var marshalErrs error
 	for _, pkt := range pkts {
@@ -195,27 +221,46 @@ func (s *SessionSRTCP) decrypt(buf []byte) error {
 		}
 
 		for _, ssrc := range destinations {
-			r, isNew := s.session.getOrCreateReadStream(ssrc, s, newReadStreamSRTCP)
-			if r == nil {
-				return nil // Session has been closed
-			} else if isNew {
-				if !s.session.acceptStreamTimeout.IsZero() {
-					_ = s.session.nextConn.SetReadDeadline(time.Time{})
-				}
-				s.session.newStream <- r // Notify AcceptStream
+			if err = s.writeToStream(ssrc, marshaled); errors.Is(err, ErrReadStreamBufferFull) {
+				// Full buffer of one stream does not prevent delivery to other ones.
+				marshalErrs = errors.Join(marshalErrs, err)
+			} else if err != nil {
+				return filterSessionClosed(err)
 			}
+		}
+	}
 
-			readStream, ok := r.(*ReadStreamSRTCP)
-			if !ok {
-				return errFailedTypeAssertion
-			}
+	return marshalErrs
+}
 
-			_, err = readStream.write(marshaled)
-			if err != nil {
-				return err
-			}
+// filterSessionClosed returns nil for errSessionClosed, so packets received while the session is closed
+// are dropped silently.
+func filterSessionClosed(err error) error {
+	if errors.Is(err, errSessionClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// writeToStream writes packet to read stream of the SSRC, and creates the stream when it does not exist.
+func (s *SessionSRTCP) writeToStream(ssrc uint32, pkt []byte) error {
+	r, isNew := s.session.getOrCreateReadStream(ssrc, s, newReadStreamSRTCP)
+	if r == nil {
+		return errSessionClosed
+	} else if isNew {
+		if !s.session.acceptStreamTimeout.IsZero() {
+			_ = s.session.nextConn.SetReadDeadline(time.Time{})
 		}
+		s.session.newStream <- r // Notify AcceptStream
 	}
 
-	return marshalErrs
+	readStream, ok := r.(*ReadStreamSRTCP)
+	if !ok {
+		return errFailedTypeAssertion
+	}
+
+	_, err := readStream.write(pkt)
+
+	return err
 }
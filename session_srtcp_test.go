@@ -446,6 +446,64 @@ func TestSessionSRTCPCompoundPacketWithEmptyDestinationSSRC(t *testing.T) {
 	assert.NoError(t, bReadStreamRR.Close())
 }
 
+func TestSessionSRTCPReadRTCPPackets(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	senderReport := &rtcp.SenderReport{SSRC: 1, NTPTime: 0xda8bd1fcdddda05a, RTPTime: 0xaaf4edd5}
+	cname := rtcp.NewCNAMESourceDescription(1, "cname")
+	pli := &rtcp.PictureLossIndication{SenderSSRC: 1, MediaSSRC: 2}
+	compound := rtcp.CompoundPacket{senderReport, cname, pli}
+
+	for _, rawCompound := range []bool{false, true} {
+		aSession, bPipe, config := buildSessionSRTCP(t)
+		bConfig := *config
+		bConfig.RTCPRawCompound = rawCompound
+		bSession, err := NewSessionSRTCP(bPipe, &bConfig)
+		assert.NoError(t, err)
+
+		senderStream, err := bSession.OpenReadStream(1)
+		assert.NoError(t, err)
+		mediaStream, err := bSession.OpenReadStream(2)
+		assert.NoError(t, err)
+
+		encrypted, err := encryptSRTCP(aSession.session.localContext, &compound)
+		assert.NoError(t, err)
+		assert.NoError(t, bSession.decrypt(encrypted))
+
+		readBuffer := make([]byte, 200)
+		if rawCompound {
+			// Whole compound packet is delivered to each stream it is addressed to.
+			for _, stream := range []*ReadStreamSRTCP{senderStream, mediaStream} {
+				pkts, err := stream.ReadRTCPPackets(readBuffer)
+				assert.NoError(t, err)
+				assert.Equal(t, []rtcp.Packet{senderReport, cname, pli}, pkts)
+			}
+		} else {
+			pkts, err := senderStream.ReadRTCPPackets(readBuffer)
+			assert.NoError(t, err)
+			assert.Equal(t, []rtcp.Packet{senderReport}, pkts)
+			pkts, err = senderStream.ReadRTCPPackets(readBuffer)
+			assert.NoError(t, err)
+			assert.Equal(t, []rtcp.Packet{cname}, pkts)
+			// PLI is addressed to its media SSRC only.
+			pkts, err = mediaStream.ReadRTCPPackets(readBuffer)
+			assert.NoError(t, err)
+			assert.Equal(t, []rtcp.Packet{pli}, pkts)
+		}
+
+		assert.NoError(t, senderStream.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+		_, err = senderStream.ReadRTCPPackets(readBuffer)
+		assert.True(t, errIsTimeout(err), "no more packets should be delivered")
+
+		assert.NoError(t, aSession.Close())
+		assert.NoError(t, bSession.Close())
+	}
+}
+
 func TestSessionSRTCPDecryptInvalidRTCP(t *testing.T) {
 	lim := test.TimeOut(time.Second * 5)
 	defer lim.Stop()
@@ -727,6 +785,91 @@ func TestSessionSRTCPFailedAuthDoesNotGrowStreams(t *testing.T) {
 	assert.NoError(t, bSession.Close())
 }
 
+func TestSessionSRTCPOnDecryptError(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	decryptErrors := make(chan []byte, 1)
+	aSession, bPipe, config := buildSessionSRTCP(t)
+	bConfig := *config
+	bConfig.OnDecryptError = func(err error, pkt []byte, isRTCP bool) {
+		assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+		assert.True(t, isRTCP)
+		decryptErrors <- append([]byte{}, pkt...)
+	}
+	bSession, err := NewSessionSRTCP(bPipe, &bConfig)
+	assert.NoError(t, err)
+
+	encrypted, err := encryptSRTCP(aSession.session.localContext, &rtcp.PictureLossIndication{MediaSSRC: 5000})
+	assert.NoError(t, err)
+	encrypted[len(encrypted)-1] ^= 0xFF
+	_, err = aSession.session.nextConn.Write(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, encrypted, <-decryptErrors)
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestSessionSRTCPReadStreamBufferSize(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	decryptErrors := make(chan error, 1)
+	aSession, bPipe, config := buildSessionSRTCP(t)
+	bConfig := *config
+	bConfig.ReadStreamBufferSize = 60
+	bConfig.OnDecryptError = func(err error, _ []byte, isRTCP bool) {
+		assert.True(t, isRTCP)
+		decryptErrors <- err
+	}
+	bSession, err := NewSessionSRTCP(bPipe, &bConfig)
+	assert.NoError(t, err)
+
+	bReadStream5000, err := bSession.OpenReadStream(5000)
+	assert.NoError(t, err)
+	bReadStream5001, err := bSession.OpenReadStream(5001)
+	assert.NoError(t, err)
+
+	pli := &rtcp.PictureLossIndication{MediaSSRC: 5000}
+	encrypted, err := encryptSRTCP(aSession.session.localContext, pli)
+	assert.NoError(t, err)
+	_, err = aSession.session.nextConn.Write(encrypted)
+	assert.NoError(t, err)
+
+	// Buffer of the first stream is full, but the second one still gets the packet.
+	encrypted, err = encryptSRTCP(aSession.session.localContext, &rtcp.ReceiverReport{
+		Reports: []rtcp.ReceptionReport{{SSRC: 5000}, {SSRC: 5001}},
+	})
+	assert.NoError(t, err)
+	_, err = aSession.session.nextConn.Write(encrypted)
+	assert.NoError(t, err)
+	err = <-decryptErrors
+	assert.ErrorIs(t, err, ErrReadStreamBufferFull)
+	assert.Contains(t, err.Error(), "ssrc=5000")
+
+	readBuffer := make([]byte, 100)
+	_, header, err := bReadStream5000.ReadRTCP(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcp.TypePayloadSpecificFeedback, header.Type)
+	_, header, err = bReadStream5001.ReadRTCP(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcp.TypeReceiverReport, header.Type)
+
+	assert.NoError(t, bReadStream5000.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	_, err = bReadStream5000.Read(readBuffer)
+	assert.True(t, errIsTimeout(err))
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
 func encryptSRTCP(context *Context, pkt rtcp.Packet) ([]byte, error) {
 	decryptedRaw, err := pkt.Marshal()
 	if err != nil {
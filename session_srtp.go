@@ -4,6 +4,7 @@
 package srtp
 
 import (
+	"fmt"
 	"net"
 	"sync"
 	"time"
@@ -20,7 +21,15 @@ const defaultSessionSRTPReplayProtectionWindow = 64
 // instead of making everyone re-implement.
 type SessionSRTP struct {
 	session
-	writeStream *WriteStreamSRTP
+	writeStream  *WriteStreamSRTP
+	acceptStream func(ssrc uint32, firstPacket []byte) bool
+
+	// RTX and FEC streams by their SSRCs, added by AssociateRepairStream.
+	repairStreams     map[uint32]*repairStream
+	repairStreamsLock sync.Mutex
+
+	// readHeader is used for parsing received packets, to avoid per-packet allocations.
+	readHeader rtp.Header
 }
 
 // NewSessionSRTP creates a SRTP session using conn as the underlying transport.
@@ -35,6 +44,7 @@ func NewSessionSRTP(conn net.Conn, config *Config) (*SessionSRTP, error) { //nol
 	if loggerFactory == nil {
 		loggerFactory = logging.NewDefaultLoggerFactory()
 	}
+	conn = config.framedConn(conn)
 
 	localOpts := append(
 		[]ContextOption{},
@@ -59,8 +69,11 @@ func NewSessionSRTP(conn net.Conn, config *Config) (*SessionSRTP, error) { //nol
 			started:             make(chan any),
 			closed:              make(chan any),
 			bufferFactory:       config.BufferFactory,
+			bufferSize:          config.ReadStreamBufferSize,
+			onDecryptError:      config.decryptErrorHandler(false),
 			log:                 loggerFactory.NewLogger("srtp"),
 		},
+		acceptStream: config.AcceptStreamFunc,
 	}
 	srtpSession.writeStream = &WriteStreamSRTP{srtpSession}
 
@@ -74,6 +87,21 @@ func NewSessionSRTP(conn net.Conn, config *Config) (*SessionSRTP, error) { //nol
 		return nil, err
 	}
 
+	if config.WriteWorkers > 0 {
+		onWriteError := config.OnWriteError
+		if onWriteError == nil {
+			onWriteError = func(err error) {
+				srtpSession.session.log.Warnf("failed to write SRTP packet: %v", err)
+			}
+		}
+		// localContext is used as the template, so it is not used to encrypt packets anymore, and
+		// all packets are encrypted with the state of the ConcurrentContext.
+		srtpSession.session.asyncWriter = newAsyncWriter(
+			NewConcurrentContext(srtpSession.session.localContext), conn,
+			config.WriteWorkers, config.WriteQueueSize, onWriteError,
+		)
+	}
+
 	return srtpSession, nil
 }
 
@@ -109,35 +137,45 @@ func (s *SessionSRTP) AcceptStream() (*ReadStreamSRTP, uint32, error) {
 	return readStream, stream.GetSSRC(), nil
 }
 
+// UpdateMasterKeys replaces local and remote master keys and salts of the session, keeping ROC and
+// replay protection state intact. See Context.UpdateMasterKey for details. Packets written after
+// the call are encrypted with the new local keys, and received packets are decrypted with the
+// new remote keys, so both sides must switch keys at the same time.
+func (s *SessionSRTP) UpdateMasterKeys(keys SessionKeys) error {
+	return s.session.updateMasterKeys(keys)
+}
+
 // Close ends the session.
 func (s *SessionSRTP) Close() error {
+	if s.session.asyncWriter != nil {
+		s.session.asyncWriter.close()
+	}
+
 	return s.session.close()
 }
 
 func (s *SessionSRTP) write(b []byte) (int, error) {
-	packet := &rtp.Packet{}
+	if _, ok := <-s.session.started; ok {
+		return 0, errStartedChannelUsedIncorrectly
+	}
+
+	wbuf, ok := writeBufferPool.Get().(*writeBuffer)
+	if !ok {
+		return 0, errFailedTypeAssertion
+	}
+
+	headerLen, err := wbuf.header.Unmarshal(b)
+	if err != nil {
+		writeBufferPool.Put(wbuf)
 
-	if err := packet.Unmarshal(b); err != nil {
 		return 0, err
 	}
 
-	return s.writeRTP(&packet.Header, packet.Payload)
-}
+	// Packet is encrypted in place in the pooled buffer, so b is not modified.
+	buf := wbuf.grow(len(b))
+	copy(buf, b)
 
-// bufferpool is a global pool of buffers used for encrypted packets in
-// writeRTP below.  Since it's global, buffers can be shared between
-// different sessions, which amortizes the cost of allocating the pool.
-//
-// 1472 is the maximum Ethernet UDP payload.  We give ourselves 20 bytes
-// of slack for any authentication tags, which is more than enough for
-// either CTR or GCM.  If the buffer is too small, no harm, it will just
-// get expanded by growBuffer.
-var bufferpool = sync.Pool{ // nolint:gochecknoglobals
-	New: func() any {
-		buf := make([]byte, 1492)
-
-		return &buf
-	},
+	return s.send(wbuf, &wbuf.header, headerLen, len(b))
 }
 
 func (s *SessionSRTP) writeRTP(header *rtp.Header, payload []byte) (int, error) {
@@ -145,30 +183,82 @@ func (s *SessionSRTP) writeRTP(header *rtp.Header, payload []byte) (int, error)
 		return 0, errStartedChannelUsedIncorrectly
 	}
 
-	// encryptRTP will either return our buffer, or, if it is too
-	// small, allocate a new buffer itself.  In either case, it is
-	// safe to put the buffer back into the pool, but only after
-	// nextConn.Write has returned.
-	pbuf, ok := bufferpool.Get().(*[]byte)
+	wbuf, ok := writeBufferPool.Get().(*writeBuffer)
 	if !ok {
-		return 0, errStartedChannelUsedIncorrectly
+		return 0, errFailedTypeAssertion
 	}
-	defer bufferpool.Put(pbuf)
 
-	buf := *pbuf
 	headerLen, marshalSize := rtp.HeaderAndPacketMarshalSize(header, payload) // nolint:staticcheck
-	if len(buf) < marshalSize+20 {
-		// The buffer is too small, so we need to allocate a new one. Add 20 bytes for auth tag like
-		// for bufferpool above.
-		buf = make([]byte, marshalSize+20)
-	}
+	buf := wbuf.grow(marshalSize)
 	_, err := rtp.MarshalPacketTo(buf, header, payload) // nolint:staticcheck
 	if err != nil {
+		writeBufferPool.Put(wbuf)
+
+		return 0, err
+	}
+
+	return s.send(wbuf, header, headerLen, marshalSize)
+}
+
+func (s *SessionSRTP) writeRTPRaw(headerBytes, payload []byte) (int, error) {
+	if _, ok := <-s.session.started; ok {
+		return 0, errStartedChannelUsedIncorrectly
+	}
+
+	wbuf, ok := writeBufferPool.Get().(*writeBuffer)
+	if !ok {
+		return 0, errFailedTypeAssertion
+	}
+
+	// Header is parsed only to get fields used for encryption, e.g. SSRC and sequence number.
+	headerLen, err := wbuf.header.Unmarshal(headerBytes)
+	if err != nil {
+		writeBufferPool.Put(wbuf)
+
 		return 0, err
+	} else if headerLen != len(headerBytes) {
+		writeBufferPool.Put(wbuf)
+
+		return 0, fmt.Errorf("%w: expected(%d) actual(%d)", errRTPHeaderLengthMismatch, headerLen, len(headerBytes))
 	}
 
+	packetLen := len(headerBytes) + len(payload)
+	buf := wbuf.grow(packetLen)
+	copy(buf, headerBytes)
+	copy(buf[headerLen:], payload)
+
+	return s.send(wbuf, &wbuf.header, headerLen, packetLen)
+}
+
+// send encrypts the packet of given size prepared in the pooled buffer, and writes it to the connection.
+// When Config.WriteWorkers is set, the packet is queued for the asyncWriter instead. send takes ownership
+// of wbuf.
+func (s *SessionSRTP) send(wbuf *writeBuffer, header *rtp.Header, headerLen, size int) (int, error) {
+	buf := wbuf.buf
+	if s.session.asyncWriter != nil {
+		// Header of the caller may be modified after return, so it is parsed again from the packet.
+		if header != &wbuf.header {
+			if _, err := wbuf.header.Unmarshal(buf[:size]); err != nil {
+				writeBufferPool.Put(wbuf)
+
+				return 0, err
+			}
+		}
+		if err := s.session.asyncWriter.enqueue(wbuf, headerLen, size); err != nil {
+			return 0, err
+		}
+
+		return size, nil
+	}
+
+	// encryptRTP will either return our buffer, or, if it is too
+	// small, allocate a new buffer itself.  In either case, it is
+	// safe to put the buffer back into the pool, but only after
+	// nextConn.Write has returned.
+	defer writeBufferPool.Put(wbuf)
+
 	s.session.localContextMutex.Lock()
-	encrypted, err := s.localContext.encryptRTP(buf, header, headerLen, buf[:marshalSize])
+	encrypted, err := s.localContext.encryptRTP(buf, header, headerLen, buf[:size])
 	s.session.localContextMutex.Unlock()
 
 	if err != nil {
@@ -178,14 +268,38 @@ func (s *SessionSRTP) writeRTP(header *rtp.Header, payload []byte) (int, error)
 	return s.session.nextConn.Write(encrypted)
 }
 
+// lastSentIndex returns SRTP packet index of the highest packet of the SSRC encrypted by the session.
+func (s *SessionSRTP) lastSentIndex(ssrc uint32) (uint64, bool) {
+	if s.session.asyncWriter != nil {
+		return s.session.asyncWriter.ctx.lastSRTPIndex(ssrc)
+	}
+
+	s.session.localContextMutex.Lock()
+	defer s.session.localContextMutex.Unlock()
+
+	return s.localContext.lastSRTPIndex(ssrc)
+}
+
+// discardRemoteSSRCState removes state created by decrypting packets of SSRC which stream was rejected.
+func (s *SessionSRTP) discardRemoteSSRCState(ssrc uint32) {
+	s.session.remoteContextMutex.Lock()
+	s.remoteContext.discardSRTPSSRCState(ssrc)
+	s.session.remoteContextMutex.Unlock()
+}
+
 func (s *SessionSRTP) setWriteDeadline(t time.Time) error {
 	return s.session.nextConn.SetWriteDeadline(t)
 }
 
 func (s *SessionSRTP) decrypt(buf []byte) error {
-	header := &rtp.Header{}
+	// decrypt is called by the read loop only, so the header can be reused between packets.
+	header := &s.readHeader
 	headerLen, err := header.Unmarshal(buf)
 	if err != nil {
+		s.session.remoteContextMutex.Lock()
+		err = s.remoteContext.headerFailure(err, buf, false)
+		s.session.remoteContextMutex.Unlock()
+
 		return err
 	}
 
@@ -194,11 +308,27 @@ func (s *SessionSRTP) decrypt(buf []byte) error {
 	// used to allocate per-SSRC state (stream, replay detector, etc.) until after
 	// the auth tag has been verified. Doing so before authentication would allow an
 	// unauthenticated peer to exhaust memory by spoofing arbitrary SSRCs.
+	s.session.remoteContextMutex.Lock()
 	decrypted, err := s.remoteContext.decryptRTP(buf, buf, header, headerLen)
+	s.session.remoteContextMutex.Unlock()
 	if err != nil {
 		return err
 	}
 
+	repair := s.repairStream(header.SSRC)
+	if (repair != nil || s.acceptStream != nil) && !s.session.hasReadStream(header.SSRC) {
+		switch {
+		case repair != nil && !s.session.hasReadStream(repair.primarySSRC):
+			s.discardRemoteSSRCState(header.SSRC)
+
+			return nil // Repair stream of the stream which was not accepted
+		case repair == nil && s.acceptStream != nil && !s.acceptStream(header.SSRC, decrypted):
+			s.discardRemoteSSRCState(header.SSRC)
+
+			return nil // Stream rejected by AcceptStreamFunc
+		}
+	}
+
 	r, isNew := s.session.getOrCreateReadStream(header.SSRC, s, newReadStreamSRTP)
 	if r == nil {
 		return nil // Session has been closed
@@ -218,6 +348,10 @@ func (s *SessionSRTP) decrypt(buf []byte) error {
 	if err != nil {
 		return err
 	}
+	if repair != nil {
+		repair.packets.Add(1)
+		repair.bytes.Add(uint64(len(decrypted)))
+	}
 
 	return nil
 }
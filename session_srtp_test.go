@@ -4,6 +4,8 @@
 package srtp
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"io"
 	"net"
@@ -11,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pion/logging"
 	"github.com/pion/rtp"
 	"github.com/pion/transport/v4/test"
 	"github.com/stretchr/testify/assert"
@@ -405,6 +408,95 @@ func TestSessionSRTPFailedAuthDoesNotGrowStreams(t *testing.T) {
 	assert.NoError(t, bSession.Close())
 }
 
+func TestSessionSRTPOnDecryptError(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	type decryptError struct {
+		err    error
+		pkt    []byte
+		isRTCP bool
+	}
+	decryptErrors := make(chan decryptError, 1)
+
+	aSession, bPipe, config := buildSessionSRTP(t)
+	bConfig := *config
+	bConfig.OnDecryptError = func(err error, pkt []byte, isRTCP bool) {
+		decryptErrors <- decryptError{err, append([]byte{}, pkt...), isRTCP}
+	}
+	bSession, err := NewSessionSRTP(bPipe, &bConfig)
+	assert.NoError(t, err)
+
+	encrypted, err := encryptSRTP(aSession.session.localContext, &rtp.Packet{
+		Payload: []byte{0x00, 0x01, 0x02, 0x03},
+		Header:  rtp.Header{SSRC: 5000, SequenceNumber: 1},
+	})
+	assert.NoError(t, err)
+	encrypted[len(encrypted)-1] ^= 0xFF
+	_, err = aSession.session.nextConn.Write(encrypted)
+	assert.NoError(t, err)
+
+	decryptErr := <-decryptErrors
+	assert.ErrorIs(t, decryptErr.err, ErrFailedToVerifyAuthTag)
+	assert.Equal(t, encrypted, decryptErr.pkt)
+	assert.False(t, decryptErr.isRTCP)
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestSessionSRTPReadStreamBufferSize(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	decryptErrors := make(chan error, 1)
+	aSession, bPipe, config := buildSessionSRTP(t)
+	bConfig := *config
+	bConfig.ReadStreamBufferSize = 40
+	var log bytes.Buffer
+	bConfig.LoggerFactory = &logging.DefaultLoggerFactory{Writer: &log, DefaultLogLevel: logging.LogLevelInfo}
+	bConfig.OnDecryptError = func(err error, _ []byte, isRTCP bool) {
+		assert.False(t, isRTCP)
+		decryptErrors <- err
+	}
+	bSession, err := NewSessionSRTP(bPipe, &bConfig)
+	assert.NoError(t, err)
+
+	aWriteStream, err := aSession.OpenWriteStream()
+	assert.NoError(t, err)
+	payload := make([]byte, 20)
+	_, err = aWriteStream.WriteRTP(&rtp.Header{SSRC: 5000, SequenceNumber: 1}, payload)
+	assert.NoError(t, err)
+	bReadStream, _, err := bSession.AcceptStream()
+	assert.NoError(t, err)
+
+	// The second packet does not fit in the buffer.
+	_, err = aWriteStream.WriteRTP(&rtp.Header{SSRC: 5000, SequenceNumber: 2}, payload)
+	assert.NoError(t, err)
+	err = <-decryptErrors
+	assert.ErrorIs(t, err, ErrReadStreamBufferFull)
+	assert.Contains(t, err.Error(), "ssrc=5000")
+	assert.NotContains(t, log.String(), ErrReadStreamBufferFull.Error())
+
+	readBuffer := make([]byte, 100)
+	n, err := bReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 32, n)
+
+	assert.NoError(t, bReadStream.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+	_, err = bReadStream.Read(readBuffer)
+	assert.True(t, errIsTimeout(err))
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
 func encryptSRTP(context *Context, pkt *rtp.Packet) ([]byte, error) {
 	decryptedRaw, err := pkt.Marshal()
 	if err != nil {
@@ -467,3 +559,443 @@ func TestSessionSRTPPacketWithPadding(t *testing.T) {
 	assert.NoError(t, aSession.Close())
 	assert.NoError(t, bSession.Close())
 }
+
+func TestSessionSRTPUpdateMasterKeys(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		testSSRC      = 5000
+		rtpHeaderSize = 12
+	)
+	testPayload := []byte{0x00, 0x01, 0x03, 0x04}
+	readBuffer := make([]byte, rtpHeaderSize+len(testPayload))
+	aSession, bSession := buildSessionSRTPPair(t)
+
+	aWriteStream, err := aSession.OpenWriteStream()
+	assert.NoError(t, err)
+
+	_, err = aWriteStream.WriteRTP(&rtp.Header{SSRC: testSSRC, SequenceNumber: 65535}, testPayload)
+	assert.NoError(t, err)
+	bReadStream, _, err := bSession.AcceptStream()
+	assert.NoError(t, err)
+	_, err = bReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+
+	newKeys := SessionKeys{
+		LocalMasterKey:   bytes.Repeat([]byte{0x01}, 16),
+		LocalMasterSalt:  bytes.Repeat([]byte{0x02}, 14),
+		RemoteMasterKey:  bytes.Repeat([]byte{0x01}, 16),
+		RemoteMasterSalt: bytes.Repeat([]byte{0x02}, 14),
+	}
+	invalidKeys := newKeys
+	invalidKeys.RemoteMasterKey = invalidKeys.RemoteMasterKey[:8]
+	assert.Error(t, aSession.UpdateMasterKeys(invalidKeys))
+	assert.NoError(t, aSession.UpdateMasterKeys(newKeys))
+	assert.NoError(t, bSession.UpdateMasterKeys(newKeys))
+
+	// Next packet rolls over ROC. It is decrypted with the new keys and the kept ROC.
+	_, err = aWriteStream.WriteRTP(&rtp.Header{SSRC: testSSRC, SequenceNumber: 0}, testPayload)
+	assert.NoError(t, err)
+	n, err := bReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, testPayload, readBuffer[rtpHeaderSize:n])
+
+	bSession.session.remoteContextMutex.Lock()
+	roc, ok := bSession.session.remoteContext.ROC(testSSRC)
+	bSession.session.remoteContextMutex.Unlock()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), roc)
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestSessionSRTPAcceptStreamFunc(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const rtpHeaderSize = 12
+	testPayload := []byte{0x00, 0x01, 0x03, 0x04}
+	readBuffer := make([]byte, rtpHeaderSize+len(testPayload))
+
+	aSession, bPipe, config := buildSessionSRTP(t)
+
+	var bSession *SessionSRTP
+	var mu sync.Mutex
+	var seen []uint32
+	openedStreams := make(chan *ReadStreamSRTP, 1)
+	bConfig := *config
+	bConfig.AcceptStreamFunc = func(ssrc uint32, firstPacket []byte) bool {
+		mu.Lock()
+		seen = append(seen, ssrc)
+		mu.Unlock()
+		assert.Equal(t, testPayload, firstPacket[rtpHeaderSize:])
+
+		switch ssrc {
+		case 2:
+			return true
+		case 3:
+			// Stream opened by the callback is not returned by AcceptStream.
+			readStream, err := bSession.OpenReadStream(ssrc)
+			assert.NoError(t, err)
+			openedStreams <- readStream
+
+			return true
+		default:
+			return false
+		}
+	}
+	bSession, err := NewSessionSRTP(bPipe, &bConfig)
+	assert.NoError(t, err)
+
+	aWriteStream, err := aSession.OpenWriteStream()
+	assert.NoError(t, err)
+	for _, ssrc := range []uint32{1, 3, 2} {
+		_, err = aWriteStream.WriteRTP(&rtp.Header{SSRC: ssrc}, append([]byte{}, testPayload...))
+		assert.NoError(t, err)
+	}
+
+	bReadStream, ssrc, err := bSession.AcceptStream()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), ssrc)
+	_, err = bReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+
+	openedStream := <-openedStreams
+	_, err = openedStream.Read(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, testPayload, readBuffer[rtpHeaderSize:])
+
+	mu.Lock()
+	assert.Equal(t, []uint32{1, 3, 2}, seen)
+	mu.Unlock()
+
+	// State of rejected SSRC is removed.
+	bSession.session.remoteContextMutex.Lock()
+	_, ok := bSession.remoteContext.srtpSSRCStates[1]
+	assert.False(t, ok)
+	_, ok = bSession.remoteContext.srtpSSRCStates[2]
+	assert.True(t, ok)
+	bSession.session.remoteContextMutex.Unlock()
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestSessionSRTPRepairStreams(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	testPayload := []byte{0x00, 0x01, 0x03, 0x04}
+	readBuffer := make([]byte, 100)
+
+	aSession, bPipe, config := buildSessionSRTP(t)
+
+	var mu sync.Mutex
+	var seen []uint32
+	bConfig := *config
+	bConfig.AcceptStreamFunc = func(ssrc uint32, _ []byte) bool {
+		mu.Lock()
+		seen = append(seen, ssrc)
+		mu.Unlock()
+
+		return ssrc == 1
+	}
+	bSession, err := NewSessionSRTP(bPipe, &bConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, bSession.AssociateRepairStream(10, 1, StreamKindRTX))
+	assert.NoError(t, bSession.AssociateRepairStream(20, 2, StreamKindFEC))
+	assert.ErrorIs(t, bSession.AssociateRepairStream(30, 1, StreamKindMedia), errInvalidRepairStream)
+	assert.ErrorIs(t, bSession.AssociateRepairStream(1, 1, StreamKindRTX), errInvalidRepairStream)
+	assert.ErrorIs(t, bSession.AssociateRepairStream(30, 10, StreamKindRTX), errInvalidRepairStream)
+	assert.ErrorIs(t, bSession.AssociateRepairStream(1, 3, StreamKindRTX), errInvalidRepairStream)
+
+	aWriteStream, err := aSession.OpenWriteStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	writeRTP := func(ssrc uint32) {
+		_, err := aWriteStream.WriteRTP(&rtp.Header{SSRC: ssrc}, append([]byte{}, testPayload...))
+		assert.NoError(t, err)
+	}
+	writeRTP(20)
+	writeRTP(1)
+
+	primaryStream, ssrc, err := bSession.AcceptStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint32(1), ssrc)
+	assert.Equal(t, StreamKindMedia, primaryStream.Kind())
+	_, ok := primaryStream.PrimarySSRC()
+	assert.False(t, ok)
+	n, err := primaryStream.Read(readBuffer)
+	assert.NoError(t, err)
+
+	// Repair stream of the accepted stream is accepted without calling AcceptStreamFunc.
+	writeRTP(10)
+	rtxStream, ssrc, err := bSession.AcceptStream()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint32(10), ssrc)
+	assert.Equal(t, StreamKindRTX, rtxStream.Kind())
+	primarySSRC, ok := rtxStream.PrimarySSRC()
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), primarySSRC)
+	_, err = rtxStream.Read(readBuffer)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ReadStreamStats{
+		Packets: 1, Bytes: uint64(n), RepairPackets: 1, RepairBytes: uint64(n), //nolint:gosec // G115
+	}, primaryStream.Stats())
+	mu.Lock()
+	assert.Equal(t, []uint32{1}, seen)
+	mu.Unlock()
+
+	// Repair streams are closed together with their primary stream.
+	assert.NoError(t, primaryStream.Close())
+	_, err = rtxStream.Read(readBuffer)
+	assert.Error(t, err)
+	assert.Nil(t, bSession.repairStream(10))
+	assert.NotNil(t, bSession.repairStream(20))
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestSessionSRTPWriteRTPRaw(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const testSSRC = 5000
+	testPayload := []byte{0x00, 0x01, 0x03, 0x04}
+	aSession, bSession := buildSessionSRTPPair(t)
+
+	aWriteStream, err := aSession.OpenWriteStream()
+	assert.NoError(t, err)
+
+	header := &rtp.Header{Version: 2, SSRC: testSSRC, SequenceNumber: 1, CSRC: []uint32{1}}
+	assert.NoError(t, header.SetExtension(1, []byte{0x05}))
+	headerBytes, err := header.Marshal()
+	assert.NoError(t, err)
+
+	// Header bytes must contain the header only.
+	_, err = aWriteStream.WriteRTPRaw(append(headerBytes, 0x00), testPayload)
+	assert.ErrorIs(t, err, errRTPHeaderLengthMismatch)
+
+	n, err := aWriteStream.WriteRTPRaw(headerBytes, testPayload)
+	assert.NoError(t, err)
+	assert.Equal(t, len(headerBytes)+len(testPayload)+10, n)
+
+	bReadStream, ssrc, err := bSession.AcceptStream()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(testSSRC), ssrc)
+
+	readBuffer := make([]byte, 100)
+	n, err = bReadStream.Read(readBuffer)
+	assert.NoError(t, err)
+	assert.Equal(t, append(headerBytes, testPayload...), readBuffer[:n])
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
+
+func TestSessionSRTPWriteWorkers(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const packetsPerSSRC = 20
+	ssrcs := []uint32{5000, 5001, 5002}
+	testPayload := []byte{0x00, 0x01, 0x03, 0x04}
+
+	aPipe, bPipe := net.Pipe()
+	key := []byte{0xE1, 0xF9, 0x7A, 0x0D, 0x3E, 0x01, 0x8B, 0xE0, 0xD6, 0x4F, 0xA3, 0x2C, 0x06, 0xDE, 0x41, 0x39}
+	salt := []byte{0x0E, 0xC6, 0x75, 0xAD, 0x49, 0x8A, 0xFE, 0xEB, 0xB6, 0x96, 0x0B, 0x3A, 0xAB, 0xE6}
+	config := &Config{
+		Profile: ProtectionProfileAes128CmHmacSha1_80,
+		Keys:    SessionKeys{key, salt, key, salt},
+	}
+	asyncConfig := *config
+	asyncConfig.WriteWorkers = 2
+	asyncConfig.WriteQueueSize = 4
+	var writeErrsMu sync.Mutex
+	var writeErrs []error
+	asyncConfig.OnWriteError = func(err error) {
+		writeErrsMu.Lock()
+		writeErrs = append(writeErrs, err)
+		writeErrsMu.Unlock()
+	}
+	aSession, err := NewSessionSRTP(aPipe, &asyncConfig)
+	assert.NoError(t, err)
+	bSession, err := NewSessionSRTP(bPipe, config)
+	assert.NoError(t, err)
+
+	aWriteStream, err := aSession.OpenWriteStream()
+	assert.NoError(t, err)
+
+	// Writes block when queues are full, so streams are read concurrently.
+	var receivedMu sync.Mutex
+	received := map[uint32][]uint16{}
+	var readers sync.WaitGroup
+	readers.Add(len(ssrcs))
+	go func() {
+		for range ssrcs {
+			readStream, ssrc, errAccept := bSession.AcceptStream()
+			if !assert.NoError(t, errAccept) {
+				return
+			}
+			go func() {
+				defer readers.Done()
+				readBuffer := make([]byte, 100)
+				for range packetsPerSSRC {
+					_, header, errRead := readStream.ReadRTP(readBuffer)
+					if !assert.NoError(t, errRead) {
+						return
+					}
+					receivedMu.Lock()
+					received[ssrc] = append(received[ssrc], header.SequenceNumber)
+					receivedMu.Unlock()
+				}
+			}()
+		}
+	}()
+
+	for seq := range uint16(packetsPerSSRC) {
+		for _, ssrc := range ssrcs {
+			n, errWrite := aWriteStream.WriteRTP(&rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: seq}, testPayload)
+			assert.NoError(t, errWrite)
+			assert.Equal(t, 12+len(testPayload), n)
+		}
+	}
+
+	readers.Wait()
+	for _, ssrc := range ssrcs {
+		expected := make([]uint16, packetsPerSSRC)
+		for i := range expected {
+			expected[i] = uint16(i) //nolint:gosec // G115
+		}
+		assert.Equal(t, expected, received[ssrc], "packets of SSRC %d are reordered", ssrc)
+	}
+	assert.Equal(t, uint64(len(ssrcs)*packetsPerSSRC), aSession.Stats().Local.SRTPEncrypted)
+	assert.NoError(t, aSession.UpdateMasterKeys(config.Keys))
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+	assert.Empty(t, writeErrs)
+
+	_, err = aWriteStream.WriteRTP(&rtp.Header{Version: 2, SSRC: ssrcs[0]}, testPayload)
+	assert.ErrorIs(t, err, errStreamAlreadyClosed)
+}
+
+func TestSessionSRTPRewritingWriteStream(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	aSession, bSession := buildSessionSRTPPair(t)
+	aWriteStream, err := aSession.OpenRewritingWriteStream()
+	assert.NoError(t, err)
+	// Read streams are opened before packets are written, so AcceptStream is not needed.
+	readStreams := map[uint32]*ReadStreamSRTP{}
+	for _, ssrc := range []uint32{7, 8} {
+		readStreams[ssrc], err = bSession.OpenReadStream(ssrc)
+		assert.NoError(t, err)
+	}
+
+	type packet struct {
+		ssrc      uint32
+		seq       uint16
+		timestamp uint32
+	}
+	for _, testCase := range []struct {
+		target   uint32
+		source   packet
+		expected packet
+	}{
+		{target: 7, source: packet{1000, 10, 100}, expected: packet{7, 10, 100}},
+		{target: 7, source: packet{1000, 12, 200}, expected: packet{7, 12, 200}},
+		// Late packet does not move the last sequence number back.
+		{target: 7, source: packet{1000, 11, 150}, expected: packet{7, 11, 150}},
+		// Source switch continues from the last sequence number and timestamp.
+		{target: 7, source: packet{2000, 500, 9000}, expected: packet{7, 13, 201}},
+		{target: 7, source: packet{2000, 501, 9100}, expected: packet{7, 14, 301}},
+		{target: 7, source: packet{1000, 13, 250}, expected: packet{7, 15, 302}},
+		// Other target stream has independent state.
+		{target: 8, source: packet{1000, 14, 300}, expected: packet{8, 14, 300}},
+	} {
+		pkt := &rtp.Packet{
+			Header: rtp.Header{
+				Version: 2, SSRC: testCase.source.ssrc, SequenceNumber: testCase.source.seq,
+				Timestamp: testCase.source.timestamp,
+			},
+			Payload: []byte{0x00, 0x01, 0x03, 0x04},
+		}
+		raw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		_, err = aWriteStream.Write(testCase.target, raw)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.source.ssrc, binary.BigEndian.Uint32(raw[8:]), "packet must not be modified")
+
+		_, header, errRead := readStreams[testCase.target].ReadRTP(make([]byte, 100))
+		assert.NoError(t, errRead)
+		assert.Equal(t, testCase.expected, packet{header.SSRC, header.SequenceNumber, header.Timestamp})
+	}
+
+	aWriteStream.RemoveTarget(7)
+	raw, err := (&rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 2000, SequenceNumber: 600, Timestamp: 1}}).Marshal()
+	assert.NoError(t, err)
+	_, err = aWriteStream.Write(7, raw)
+	assert.NoError(t, err)
+	_, header, err := readStreams[7].ReadRTP(make([]byte, 100))
+	assert.NoError(t, err)
+	// Sequence numbers continue from the last packet sent before RemoveTarget.
+	assert.Equal(t, uint16(16), header.SequenceNumber)
+
+	// Reordered packet of the new source, which would be rewritten to already sent sequence number,
+	// is dropped.
+	for _, testCase := range []struct {
+		ssrc uint32
+		seq  uint16
+		err  error
+	}{
+		{ssrc: 1000, seq: 15}, {ssrc: 1000, seq: 16}, {ssrc: 1000, seq: 17},
+		{ssrc: 3000, seq: 500}, {ssrc: 3000, seq: 498, err: errRewrittenIndexUsed}, {ssrc: 3000, seq: 501},
+	} {
+		raw, err = (&rtp.Packet{Header: rtp.Header{Version: 2, SSRC: testCase.ssrc, SequenceNumber: testCase.seq}}).Marshal()
+		assert.NoError(t, err)
+		_, err = aWriteStream.Write(8, raw)
+		assert.ErrorIs(t, err, testCase.err)
+	}
+	var seqs []uint16
+	for range 5 {
+		_, header, err = readStreams[8].ReadRTP(make([]byte, 100))
+		assert.NoError(t, err)
+		seqs = append(seqs, header.SequenceNumber)
+	}
+	assert.Equal(t, []uint16{15, 16, 17, 18, 19}, seqs)
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
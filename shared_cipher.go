@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bytes"
+	"maps"
+)
+
+// SharedCipher holds session keys derived from a master key, which can be shared between multiple Contexts.
+// Derived keys are immutable, so Contexts created from the same SharedCipher can be used independently,
+// including from different goroutines. ROC, SRTCP index and replay protection state is kept per Context.
+type SharedCipher struct {
+	template *Context
+}
+
+// NewSharedCipher derives session keys from the master key and salt. Options related to the cipher
+// (MasterKeyIndicator, SRTPEncryption/SRTPNoEncryption, SRTCPEncryption/SRTCPNoEncryption,
+// SRTPAuthenticationTagLength, KeyDerivationRate, Cryptex and UnsafeGCMMasterSaltLength) are stored
+// in the SharedCipher and apply to all Contexts created from it. Other options are ignored, pass them
+// to CreateContextWithSharedCipher instead.
+func NewSharedCipher(
+	masterKey, masterSalt []byte,
+	profile ProtectionProfile,
+	opts ...ContextOption,
+) (*SharedCipher, error) {
+	template, err := CreateContext(masterKey, masterSalt, profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SharedCipher{template: template}, nil
+}
+
+// CreateContextWithSharedCipher creates a new SRTP Context which uses session keys from the given SharedCipher.
+// Options which change cipher configuration must match ones used for creating the SharedCipher.
+func CreateContextWithSharedCipher(shared *SharedCipher, opts ...ContextOption) (*Context, error) {
+	template := shared.template
+	ctx := &Context{
+		srtpSSRCStates:    map[uint32]*srtpSSRCState{},
+		srtcpSSRCStates:   map[uint32]*srtcpSSRCState{},
+		profile:           template.profile,
+		mkis:              map[string]srtpCipher{},
+		sendMKI:           template.sendMKI,
+		encryptSRTP:       template.encryptSRTP,
+		encryptSRTCP:      template.encryptSRTCP,
+		authTagRTPLen:     template.authTagRTPLen,
+		keyDerivationRate: template.keyDerivationRate,
+		cryptexMode:       template.cryptexMode,
+		gcmMasterSaltLen:  template.gcmMasterSaltLen,
+	}
+
+	for _, o := range append(
+		[]ContextOption{ // Default options
+			SRTPNoReplayProtection(),
+			SRTCPNoReplayProtection(),
+		},
+		opts..., // User specified options
+	) {
+		if errOpt := o(ctx); errOpt != nil {
+			return nil, errOpt
+		}
+	}
+
+	if !template.sameCipherConfig(ctx) {
+		return nil, errSharedCipherConfigMismatch
+	}
+
+	if err := ctx.checkRCCMode(); err != nil {
+		return nil, err
+	}
+
+	ctx.cipher = template.cipher.clone()
+	if len(ctx.sendMKI) != 0 {
+		ctx.mkis[string(ctx.sendMKI)] = ctx.cipher
+	}
+
+	return ctx, nil
+}
+
+// sameCipherConfig checks if other Context uses the same cipher configuration as this one.
+func (c *Context) sameCipherConfig(other *Context) bool {
+	sameAuthTagLen := (c.authTagRTPLen == nil && other.authTagRTPLen == nil) ||
+		(c.authTagRTPLen != nil && other.authTagRTPLen != nil && *c.authTagRTPLen == *other.authTagRTPLen)
+
+	return c.profile == other.profile &&
+		bytes.Equal(c.sendMKI, other.sendMKI) &&
+		c.encryptSRTP == other.encryptSRTP &&
+		c.encryptSRTCP == other.encryptSRTCP &&
+		sameAuthTagLen &&
+		c.keyDerivationRate == other.keyDerivationRate &&
+		c.gcmMasterSaltLen == other.gcmMasterSaltLen &&
+		(c.cryptexMode == CryptexModeDisabled) == (other.cryptexMode == CryptexModeDisabled)
+}
+
+// Clone returns a new Context with the same configuration and keys as c, e.g. to re-encrypt packets
+// for multiple receivers in an SFU. Session keys are shared with c, so key derivation is not repeated.
+// Replay protection, stats and other per-SSRC state are not copied, and are kept separately by each
+// Context, so the clone can be used independently from c, including from a different goroutine.
+// Keys learned from EKT Fields of received packets are not copied.
+//
+// Newest ROC and SRTCP index of each SSRC used by c are remembered by the clone, like for SSRCs
+// removed by RemoveSSRC, so the clone continues them instead of starting from zero. Packets older
+// than them are rejected as replayed by the clone. Because c and the clone share session keys,
+// after cloning only one of them may keep sending a given SSRC: both would use the same SRTCP indexes,
+// and the same SRTP IVs for different packets with the same sequence number, which breaks encryption.
+// Operation is not thread-safe, you need to provide synchronization with other calls on c.
+func (c *Context) Clone() *Context {
+	clone := c.cloneConfig()
+	clone.evictedSSRCs = c.cloneEvictedSSRCs()
+
+	return clone
+}
+
+// cloneConfig returns a new Context with the same configuration and keys as c, without any per-SSRC state.
+func (c *Context) cloneConfig() *Context {
+	clone := *c
+	clone.srtpSSRCStates = map[uint32]*srtpSSRCState{}
+	clone.srtcpSSRCStates = map[uint32]*srtcpSSRCState{}
+	clone.cipher = c.cipher.clone()
+	clone.mkis = make(map[string]srtpCipher, len(c.mkis))
+	for mki, cipher := range c.mkis {
+		if cipher == c.cipher {
+			clone.mkis[mki] = clone.cipher
+		} else {
+			clone.mkis[mki] = cipher.clone()
+		}
+	}
+	clone.mkiLifetimes = maps.Clone(c.mkiLifetimes)
+	clone.keyExpiryBlocker = nil
+	clone.selectedCiphers = nil
+	clone.keyUsage = 0
+	clone.failureSample = nil
+	// Scratch buffers are written by every operation, so they must not be shared with the clone.
+	clone.rocRecoveryBuf = nil
+	clone.failureTimingBuf = nil
+	clone.authScratchBuf = nil
+	clone.mkiLookup = nil
+	clone.hasSendSSRC = false
+	clone.sendSSRC = 0
+	clone.ektKeys = maps.Clone(c.ektKeys)
+	clone.ektReceiveStates = nil
+	clone.ssrcCiphers = c.cloneSSRCCiphers()
+	clone.ssrcProfiles = maps.Clone(c.ssrcProfiles)
+	clone.persistedKeys = maps.Clone(c.persistedKeys)
+	clone.evictedSSRCs = nil
+	clone.srtpLRU = nil
+	clone.srtcpLRU = nil
+	clone.stats = contextStats{}
+
+	return &clone
+}
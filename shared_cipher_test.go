@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedCipher(t *testing.T) {
+	testSharedCipher := func(t *testing.T, profile ProtectionProfile) {
+		t.Helper()
+
+		keyLen, err := profile.KeyLen()
+		assert.NoError(t, err)
+		saltLen, err := profile.SaltLen()
+		assert.NoError(t, err)
+		masterKey := make([]byte, keyLen)
+		masterSalt := make([]byte, saltLen)
+		masterKey[0] = 1
+
+		shared, err := NewSharedCipher(masterKey, masterSalt, profile)
+		assert.NoError(t, err)
+
+		ctx1, err := CreateContextWithSharedCipher(shared)
+		assert.NoError(t, err)
+		ctx2, err := CreateContextWithSharedCipher(shared, SRTPReplayProtection(64))
+		assert.NoError(t, err)
+
+		decryptCtx, err := CreateContext(masterKey, masterSalt, profile, SRTPReplayProtection(64))
+		assert.NoError(t, err)
+
+		ctx1.SetROC(1, 10)
+
+		pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: 100, SSRC: 1}}
+		pktRaw, err := pkt.Marshal()
+		assert.NoError(t, err)
+
+		encrypted1, err := ctx1.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		encrypted2, err := ctx2.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		assert.NotEqual(t, encrypted1, encrypted2, "packets use different ROC")
+
+		roc, ok := ctx2.ROC(1)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(0), roc, "ROC of one Context must not affect other Context")
+
+		decrypted, err := decryptCtx.DecryptRTP(nil, encrypted2, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, pktRaw, decrypted)
+
+		// Replay protection state is independent too.
+		_, err = ctx2.DecryptRTP(nil, encrypted2, nil)
+		assert.NoError(t, err)
+		_, err = ctx2.DecryptRTP(nil, encrypted2, nil)
+		assert.ErrorIs(t, err, errDuplicated)
+		_, ok = ctx1.ROC(2)
+		assert.False(t, ok)
+	}
+
+	t.Run("CTR", func(t *testing.T) { testSharedCipher(t, profileCTR) })
+	t.Run("GCM", func(t *testing.T) { testSharedCipher(t, profileGCM) })
+}
+
+func TestSharedCipherSharesKeys(t *testing.T) {
+	shared, err := NewSharedCipher(make([]byte, 16), make([]byte, 14), profileCTR)
+	assert.NoError(t, err)
+
+	ctx1, err := CreateContextWithSharedCipher(shared)
+	assert.NoError(t, err)
+	ctx2, err := CreateContextWithSharedCipher(shared)
+	assert.NoError(t, err)
+
+	cipher1, ok := ctx1.cipher.(*srtpCipherAesCmHmacSha1)
+	assert.True(t, ok)
+	cipher2, ok := ctx2.cipher.(*srtpCipherAesCmHmacSha1)
+	assert.True(t, ok)
+	assert.Same(t, cipher1.srtpBlock, cipher2.srtpBlock)
+	assert.NotSame(t, cipher1, cipher2)
+	assert.NotSame(t, cipher1.srtpSessionAuth, cipher2.srtpSessionAuth)
+}
+
+func TestSharedCipherConfigMismatch(t *testing.T) {
+	mki := []byte{1, 2, 3, 4}
+	shared, err := NewSharedCipher(make([]byte, 16), make([]byte, 14), profileCTR, MasterKeyIndicator(mki))
+	assert.NoError(t, err)
+
+	ctx, err := CreateContextWithSharedCipher(shared, MasterKeyIndicator(mki))
+	assert.NoError(t, err)
+	assert.Equal(t, mki, ctx.sendMKI)
+
+	_, err = CreateContextWithSharedCipher(shared, MasterKeyIndicator([]byte{5, 6, 7, 8}))
+	assert.ErrorIs(t, err, errSharedCipherConfigMismatch)
+
+	_, err = CreateContextWithSharedCipher(shared, SRTPNoEncryption())
+	assert.ErrorIs(t, err, errSharedCipherConfigMismatch)
+
+	_, err = CreateContextWithSharedCipher(shared, SRTPAuthenticationTagLength(4))
+	assert.ErrorIs(t, err, errSharedCipherConfigMismatch)
+
+	_, err = CreateContextWithSharedCipher(shared, Cryptex(CryptexModeEnabled))
+	assert.ErrorIs(t, err, errSharedCipherConfigMismatch)
+}
+
+func TestContextClone(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			ctx, err := buildTestContext(profile, MasterKeyIndicator([]byte{1}), SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, MasterKeyIndicator([]byte{1}))
+			assert.NoError(t, err)
+
+			pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: 100, SSRC: 1}}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+
+			ctx.SetROC(1, 10)
+			encrypted, err := ctx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+
+			ctx.SetIndex(1, 5)
+
+			clone := ctx.Clone()
+			_, ok := clone.ROC(1)
+			assert.False(t, ok, "per-SSRC state must not be copied")
+			assert.Equal(t, Stats{ROC: map[uint32]uint32{}}, clone.Stats())
+			rtcpRaw, err := rtcp.Marshal([]rtcp.Packet{&rtcp.PictureLossIndication{SenderSSRC: 1}})
+			assert.NoError(t, err)
+			_, err = clone.EncryptRTCP(nil, rtcpRaw, nil)
+			assert.NoError(t, err)
+			index, _ := clone.Index(1)
+			assert.Equal(t, uint32(6), index, "the clone must continue SRTCP index")
+
+			// The clone continues ROC of the original Context.
+			cloneEncrypted, err := clone.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, encrypted, cloneEncrypted)
+			pkt.SequenceNumber = 200
+			pktRaw, err = pkt.Marshal()
+			assert.NoError(t, err)
+			cloneEncrypted, err = clone.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			roc, _ := clone.ROC(1)
+			assert.Equal(t, uint32(10), roc)
+			ctx.SetROC(1, 20)
+			roc, _ = clone.ROC(1)
+			assert.Equal(t, uint32(10), roc, "ROC of the original Context must not affect the clone")
+
+			decryptCtx.SetROC(1, 10)
+			decrypted, err := decryptCtx.DecryptRTP(nil, cloneEncrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, pktRaw, decrypted)
+
+			// Options are copied, so the clone uses replay protection too. Indexes of the original
+			// Context are remembered, so packets it sent are rejected.
+			_, err = clone.DecryptRTP(nil, encrypted, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+			_, err = clone.DecryptRTP(nil, cloneEncrypted, nil)
+			assert.NoError(t, err)
+			_, err = clone.DecryptRTP(nil, cloneEncrypted, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+
+			// MKIs are copied, and changes to them do not affect the original Context.
+			assert.Equal(t, clone.cipher, clone.mkis[string([]byte{1})])
+			assert.NotSame(t, ctx.cipher, clone.cipher)
+			keyLen, err := profile.KeyLen()
+			assert.NoError(t, err)
+			saltLen, err := profile.SaltLen()
+			assert.NoError(t, err)
+			assert.NoError(t, clone.AddCipherForMKI([]byte{2}, make([]byte, keyLen), make([]byte, saltLen)))
+			assert.ErrorIs(t, ctx.SetSendMKI([]byte{2}), ErrMKINotFound)
+		})
+	}
+}
+
+func TestContextCloneConcurrent(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			ctx, err := buildTestContext(profile, UniformDecryptFailures(func(error, bool) {}))
+			assert.NoError(t, err)
+
+			// Scratch buffers of the Context are allocated before it is cloned.
+			packet := encryptTestRTPForSSRC(t, encryptCtx, 3, 1)
+			assert.NoError(t, ctx.VerifyRTP(packet))
+			packet[len(packet)-1] ^= 0xff
+			_, err = ctx.DecryptRTP(nil, packet, nil)
+			assert.Error(t, err)
+			clone := ctx.Clone()
+
+			// The Context and its clone are used from different goroutines, each for its own SSRC.
+			// Scratch buffers written by VerifyRTP and by failed decryption must not be shared.
+			const packets = 100
+			var wg sync.WaitGroup
+			for ssrc, verifyCtx := range map[uint32]*Context{1: ctx, 2: clone} {
+				encrypted := make([][]byte, packets)
+				for i := range encrypted {
+					encrypted[i] = encryptTestRTPForSSRC(t, encryptCtx, ssrc, uint16(i)) //nolint:gosec // G115
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for _, packet := range encrypted {
+						assert.NoError(t, verifyCtx.VerifyRTP(packet))
+						forged := append([]byte{}, packet...)
+						forged[len(forged)-1] ^= 0xff
+						_, errDecrypt := verifyCtx.DecryptRTP(nil, forged, nil)
+						assert.Error(t, errDecrypt)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
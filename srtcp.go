@@ -29,56 +29,130 @@ const (
 )
 
 func (c *Context) decryptRTCP(dst, encrypted []byte) ([]byte, error) {
-	authTagLen, err := c.cipher.AuthTagRTCPLen()
+	ssrc, size := rtcpSSRC(encrypted), len(encrypted)
+	if c.failureSampler == nil {
+		out, err := c.doDecryptRTCP(dst, encrypted)
+		c.recordDecrypted(ssrc, true, size, err)
+
+		return out, c.uniformDecryptFailure(err, encrypted, true)
+	}
+
+	// Sample is taken before decryption, because decryption may be done in place.
+	c.takeFailureSample(encrypted)
+	out, err := c.doDecryptRTCP(dst, encrypted)
+	if err != nil {
+		c.reportFailureSample(err)
+	}
+	c.recordDecrypted(ssrc, true, size, err)
+
+	return out, c.uniformDecryptFailure(err, encrypted, true)
+}
+
+func (c *Context) doDecryptRTCP(dst, encrypted []byte) ([]byte, error) {
+	if err := c.checkRTCPLimits(encrypted); err != nil {
+		return nil, err
+	}
+
+	cipher, mki := c.cipher, c.sendMKI
+	var ssrcCipher srtpCipher
+	if len(encrypted) >= srtcpHeaderSize {
+		ssrcCipher = c.ssrcCiphers[binary.BigEndian.Uint32(encrypted[4:])]
+	}
+	if ssrcCipher != nil {
+		cipher = ssrcCipher
+	} else if len(c.mkis) > 0 {
+		// Ciphers for different MKIs may use different auth tag and MKI lengths, so the cipher must be
+		// known before the packet length is checked and the SRTCP index is read.
+		var err error
+		cipher, mki, err = c.findCipherForMKI(encrypted, srtcpHeaderSize+srtcpIndexSize, true, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	authTagLen, err := cipher.AuthTagRTCPLen()
 	if err != nil {
 		return nil, err
 	}
-	aeadAuthTagLen, err := c.cipher.AEADAuthTagLen()
+	aeadAuthTagLen, err := cipher.AEADAuthTagLen()
 	if err != nil {
 		return nil, err
 	}
-	mkiLen := len(c.sendMKI)
+	mkiLen := len(mki)
 
 	// Verify that encrypted packet is long enough
 	if len(encrypted) < (srtcpHeaderSize + aeadAuthTagLen + srtcpIndexSize + mkiLen + authTagLen) {
 		return nil, fmt.Errorf("%w: %d", errTooShortRTCP, len(encrypted))
 	}
 
-	index := c.cipher.getRTCPIndex(encrypted)
 	ssrc := binary.BigEndian.Uint32(encrypted[4:])
 
+	isEncrypted := encrypted[len(encrypted)-mkiLen-authTagLen-srtcpIndexSize]&srtcpEncryptionFlag != 0
+	if c.rejectUnencryptedSRTCP && !isEncrypted {
+		return nil, fmt.Errorf("%w: ssrc=%d", ErrSRTCPNotEncrypted, ssrc)
+	}
+	if c.strictSRTCPEncryptionFlag {
+		if expected := cipher.srtcpEncryptionEnabled(); isEncrypted != expected {
+			return nil, fmt.Errorf("%w: ssrc=%d E=%t, expected E=%t",
+				ErrSRTCPEncryptionFlagMismatch, ssrc, isEncrypted, expected)
+		}
+	}
+
 	// The SSRC is read from the unauthenticated RTCP header at this point.
 	// getSRTCPSSRCState is called in read-only mode so that no new map entry is
 	// inserted until after the auth tag has been verified. The state is committed
-	// to the map by setSRTCPSSRCState only after markAsValid() succeeds below.
+	// to the map by setSRTCPSSRCState only after the replay token is committed below.
+	if err := c.checkNewSRTCPSSRCState(ssrc); err != nil {
+		return nil, err
+	}
 	ssrcState, existingState := c.getSRTCPSSRCState(ssrc, false)
+	indexSource := c.getSRTCPIndexSource()
+	index := indexSource.receivedIndex(cipher, encrypted, ssrcState)
 
 	// The replay check is intentionally performed before authentication.
 	// Rejecting already-seen sequence numbers here avoids the CPU cost of
 	// AES decryption and HMAC/GCM verification on flooded duplicate packets.
-	// Safety relies on the replay detector only committing the index as "seen"
-	// when markAsValid() is explicitly called after successful authentication.
-	markAsValid, ok := ssrcState.replayDetector.Check(uint64(index))
+	// The index is only reserved here. It is committed as "seen" after
+	// successful authentication, and released on any error.
+	token, ok := ssrcState.replayGuard.reserve(uint64(index))
+	var rolloverGuard *replayGuard
+	if !ok && c.srtcpIndexRollover && existingState && ssrcState.keyGeneration != c.keyGeneration &&
+		cipher == c.cipher {
+		// Sender may start SRTCP indexes again after re-keying, see SRTCPIndexRolloverOnRekey.
+		rolloverGuard = newReplayGuard(c.newSRTCPReplayDetector(ssrc))
+		token, ok = rolloverGuard.reserve(uint64(index))
+	}
 	if !ok {
 		return nil, &duplicatedError{Proto: "srtcp", SSRC: ssrc, Index: index}
 	}
+	defer token.release()
 
-	cipher := c.cipher
-	if len(c.mkis) > 0 {
-		// Find cipher for MKI
-		actualMKI := encrypted[len(encrypted)-mkiLen-authTagLen : len(encrypted)-authTagLen]
-		cipher, ok = c.mkis[string(actualMKI)]
-		if !ok {
-			return nil, ErrMKINotFound
-		}
-	}
-
+	packetLen := len(encrypted)
 	out, err := cipher.decryptRTCP(dst, encrypted, index, ssrc)
+	if c.traceHook != nil {
+		c.traceRTCP(true, cipher, mki, ssrc, index, encrypted[:packetLen], err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	markAsValid()
+	if c.validateRTCPCompound {
+		// Malformed packet is rejected before its index is committed, like one which failed authentication.
+		if _, err = rtcp.Unmarshal(out); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedRTCP, err)
+		}
+	}
+
+	if !token.commit() {
+		return nil, &duplicatedError{Proto: "srtcp", SSRC: ssrc, Index: index}
+	}
+	indexSource.acceptIndex(ssrcState, index)
+	if rolloverGuard != nil {
+		ssrcState.replayGuard = rolloverGuard
+	}
+	if cipher == c.cipher {
+		ssrcState.keyGeneration = c.keyGeneration
+	}
 
 	if !existingState {
 		c.setSRTCPSSRCState(ssrcState)
@@ -94,7 +168,7 @@ func (c *Context) DecryptRTCP(dst, encrypted []byte, header *rtcp.Header) ([]byt
 	}
 
 	if err := header.Unmarshal(encrypted); err != nil {
-		return nil, err
+		return nil, c.headerFailure(err, encrypted, true)
 	}
 
 	return c.decryptRTCP(dst, encrypted)
@@ -106,23 +180,151 @@ func (c *Context) encryptRTCP(dst, decrypted []byte) ([]byte, error) {
 	}
 
 	ssrc := binary.BigEndian.Uint32(decrypted[4:])
+	if err := c.checkNewSRTCPSSRCState(ssrc); err != nil {
+		return nil, err
+	}
 	ssrcState, _ := c.getSRTCPSSRCState(ssrc, true)
 
-	if ssrcState.srtcpIndex >= maxSRTCPIndex {
+	c.rolloverSRTCPIndex(ssrcState)
+	index, err := c.getSRTCPIndexSource().nextIndex(ssrcState)
+	if err != nil {
+		return nil, err
+	}
+	c.checkSRTCPIndexWarning(ssrcState)
+
+	dst = reserveEncryptBuffer(dst, len(decrypted)+c.RTCPOverhead())
+	cipher := c.sendCipher(ssrc)
+	out, err := cipher.encryptRTCP(dst, decrypted, index, ssrc)
+	if err != nil {
+		return nil, err
+	}
+	if c.traceHook != nil {
+		c.traceRTCP(false, cipher, c.sendMKI, ssrc, index, out, nil)
+	}
+	c.recordEncrypted(ssrc, true, len(out))
+
+	return out, nil
+}
+
+// rolloverSRTCPIndex starts SRTCP indexes of the SSRC from 1 again when they are exhausted, and the send key
+// was changed since they were started. See SRTCPIndexRolloverOnRekey option.
+func (c *Context) rolloverSRTCPIndex(state *srtcpSSRCState) {
+	if !c.srtcpIndexRollover || state.srtcpIndex < maxSRTCPIndex || state.keyGeneration == c.keyGeneration {
+		return
+	}
+	state.srtcpIndex = 0
+	state.keyGeneration = c.keyGeneration
+	state.indexWarned = false
+}
+
+// checkSRTCPIndexWarning calls callback set by OnSRTCPIndexWarning option when SRTCP index of the SSRC
+// reaches the warning threshold, and emits RekeyRequired event.
+func (c *Context) checkSRTCPIndexWarning(state *srtcpSSRCState) {
+	if c.onSRTCPIndexWarning == nil || state.indexWarned || state.srtcpIndex < maxSRTCPIndex-c.srtcpIndexWarning {
+		return
+	}
+	state.indexWarned = true
+	c.onSRTCPIndexWarning(state.ssrc, state.srtcpIndex)
+	c.emitKeyEvent(KeyEvent{Type: RekeyRequired, MKI: c.sendMKI, HasSSRC: true, SSRC: state.ssrc})
+}
+
+// srtcpIndexSource provides SRTCP index of encrypted and decrypted packets. It separates index handling
+// from the rest of SRTCP processing, so SRTCP variants with implicit index, estimated like SRTP
+// packet index, can be supported. The default one is explicitSRTCPIndex.
+type srtcpIndexSource interface {
+	// nextIndex returns index for the next packet to encrypt, and stores it in the state.
+	nextIndex(state *srtcpSSRCState) (uint32, error)
+	// receivedIndex returns index of received packet. The state must not be modified, because
+	// the packet is not authenticated yet.
+	receivedIndex(cipher srtpCipher, encrypted []byte, state *srtcpSSRCState) uint32
+	// acceptIndex is called after received packet with given index is authenticated.
+	acceptIndex(state *srtcpSSRCState, index uint32)
+}
+
+// explicitSRTCPIndex uses SRTCP index sent in every SRTCP packet, as defined in RFC 3711.
+type explicitSRTCPIndex struct{}
+
+func (explicitSRTCPIndex) nextIndex(state *srtcpSSRCState) (uint32, error) {
+	if state.srtcpIndex >= maxSRTCPIndex {
 		// ... when 2^48 SRTP packets or 2^31 SRTCP packets have been secured with the same key
 		// (whichever occurs before), the key management MUST be called to provide new master key(s)
 		// (previously stored and used keys MUST NOT be used again), or the session MUST be terminated.
 		// https://www.rfc-editor.org/rfc/rfc3711#section-9.2
-		return nil, errExceededMaxPackets
+		return 0, &srtcpIndexExhaustedError{SSRC: state.ssrc}
 	}
 
 	// We roll over early because MSB is used for marking as encrypted
-	ssrcState.srtcpIndex++
+	state.srtcpIndex++
+
+	return state.srtcpIndex, nil
+}
+
+func (explicitSRTCPIndex) receivedIndex(cipher srtpCipher, encrypted []byte, _ *srtcpSSRCState) uint32 {
+	return cipher.getRTCPIndex(encrypted)
+}
+
+func (explicitSRTCPIndex) acceptIndex(*srtcpSSRCState, uint32) {}
+
+func (c *Context) getSRTCPIndexSource() srtcpIndexSource {
+	if c.srtcpIndexSource == nil {
+		return explicitSRTCPIndex{}
+	}
+
+	return c.srtcpIndexSource
+}
 
-	return c.cipher.encryptRTCP(dst, decrypted, ssrcState.srtcpIndex, ssrc)
+// EncryptRTCPWithIndex encrypts a RTCP packet using the specified SRTCP index instead of the next one.
+// It is intended for HA failover and testing. SRTCP index of the SSRC is updated if the specified
+// index is greater than the current one.
+//
+// Reusing SRTCP index with AEAD profiles allows an attacker to forge packets, so for them
+// an index lower than or equal to the last used one is rejected. For other profiles it is
+// caller's responsibility to not reuse indexes.
+func (c *Context) EncryptRTCPWithIndex(dst, plaintext []byte, index uint32) ([]byte, error) {
+	if len(plaintext) < srtcpHeaderSize {
+		return nil, fmt.Errorf("%w: %d", errTooShortRTCP, len(plaintext))
+	}
+	if index > maxSRTCPIndex {
+		return nil, fmt.Errorf("%w: %d", errInvalidSRTCPIndex, index)
+	}
+
+	ssrc := binary.BigEndian.Uint32(plaintext[4:])
+	cipher := c.sendCipher(ssrc)
+	aeadAuthTagLen, err := cipher.AEADAuthTagLen()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkNewSRTCPSSRCState(ssrc); err != nil {
+		return nil, err
+	}
+	ssrcState, existingState := c.getSRTCPSSRCState(ssrc, false)
+	if aeadAuthTagLen > 0 && existingState && index <= ssrcState.srtcpIndex {
+		return nil, fmt.Errorf("%w: %d <= %d", errSRTCPIndexReused, index, ssrcState.srtcpIndex)
+	}
+
+	dst = reserveEncryptBuffer(dst, len(plaintext)+c.RTCPOverhead())
+	out, err := cipher.encryptRTCP(dst, plaintext, index, ssrc)
+	if err != nil {
+		return nil, err
+	}
+	if c.traceHook != nil {
+		c.traceRTCP(false, cipher, c.sendMKI, ssrc, index, out, nil)
+	}
+	c.recordEncrypted(ssrc, true, len(out))
+
+	if !existingState {
+		c.setSRTCPSSRCState(ssrcState)
+	}
+	ssrcState.srtcpIndex = max(ssrcState.srtcpIndex, index)
+	c.checkSRTCPIndexWarning(ssrcState)
+
+	return out, nil
 }
 
 // EncryptRTCP Encrypts a RTCP packet.
+// If the dst buffer does not have the capacity to hold `EncryptedLen(len(decrypted))` bytes,
+// a new one will be allocated and returned.
 func (c *Context) EncryptRTCP(dst, decrypted []byte, header *rtcp.Header) ([]byte, error) {
 	if header == nil {
 		header = &rtcp.Header{}
@@ -737,3 +737,306 @@ func TestSRTCPFailedAuthDoesNotGrowSSRCMap(t *testing.T) {
 	t.Run("CTR", func(t *testing.T) { testSRTCPFailedAuthDoesNotGrowSSRCMap(t, profileCTR) })
 	t.Run("GCM", func(t *testing.T) { testSRTCPFailedAuthDoesNotGrowSSRCMap(t, profileGCM) })
 }
+
+func TestEncryptRTCPWithIndex(t *testing.T) {
+	for caseName, testCase := range rtcpTestCases() {
+		t.Run(caseName, func(t *testing.T) {
+			assertT := assert.New(t)
+			encryptContext, err := CreateContext(testCase.masterKey, testCase.masterSalt, testCase.algo)
+			assertT.NoError(err)
+			decryptContext, err := CreateContext(testCase.masterKey, testCase.masterSalt, testCase.algo)
+			assertT.NoError(err)
+
+			for _, pkt := range testCase.packets {
+				// Test vectors store SRTCP index before it is incremented by EncryptRTCP.
+				encrypted, errEncrypt := encryptContext.EncryptRTCPWithIndex(nil, pkt.decrypted, pkt.index+1)
+				assertT.NoError(errEncrypt)
+				assertT.Equal(pkt.encrypted, encrypted)
+
+				index, ok := encryptContext.Index(pkt.ssrc)
+				assertT.True(ok)
+				assertT.Equal(pkt.index+1, index)
+
+				decrypted, errDecrypt := decryptContext.DecryptRTCP(nil, encrypted, nil)
+				assertT.NoError(errDecrypt)
+				assertT.Equal(pkt.decrypted, decrypted)
+			}
+
+			pkt := testCase.packets[0]
+			encryptContext.SetIndex(pkt.ssrc, 100)
+
+			_, err = encryptContext.EncryptRTCPWithIndex(nil, pkt.decrypted, 200)
+			assertT.NoError(err)
+
+			// Lower index does not decrease SRTCP index used by EncryptRTCP.
+			_, err = encryptContext.EncryptRTCPWithIndex(nil, pkt.decrypted, 150)
+			if testCase.algo == ProtectionProfileAeadAes128Gcm {
+				assertT.ErrorIs(err, errSRTCPIndexReused)
+			} else {
+				assertT.NoError(err)
+			}
+			_, err = encryptContext.EncryptRTCPWithIndex(nil, pkt.decrypted, 200)
+			if testCase.algo == ProtectionProfileAeadAes128Gcm {
+				assertT.ErrorIs(err, errSRTCPIndexReused)
+			} else {
+				assertT.NoError(err)
+			}
+
+			encrypted, err := encryptContext.EncryptRTCP(nil, pkt.decrypted, nil)
+			assertT.NoError(err)
+			authTagLen, err := encryptContext.cipher.AuthTagRTCPLen()
+			assertT.NoError(err)
+			assertT.Equal(uint32(201), getRTCPIndex(encrypted, authTagLen))
+
+			_, err = encryptContext.EncryptRTCPWithIndex(nil, pkt.decrypted, maxSRTCPIndex+1)
+			assertT.ErrorIs(err, errInvalidSRTCPIndex)
+		})
+	}
+}
+
+// implicitSRTCPIndex is a stub index source, which does not use SRTCP index from received packets.
+type implicitSRTCPIndex struct {
+	explicitSRTCPIndex
+}
+
+func (implicitSRTCPIndex) receivedIndex(_ srtpCipher, _ []byte, state *srtcpSSRCState) uint32 {
+	return state.srtcpIndex + 1
+}
+
+func (implicitSRTCPIndex) acceptIndex(state *srtcpSSRCState, index uint32) {
+	state.srtcpIndex = index
+}
+
+func TestRTCPIndexSource(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, SRTCPReplayProtection(64))
+			assert.NoError(t, err)
+			encryptCtx.srtcpIndexSource = implicitSRTCPIndex{}
+			decryptCtx.srtcpIndexSource = implicitSRTCPIndex{}
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			var encrypted [][]byte
+			for range 3 {
+				pkt, errEncrypt := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+				assert.NoError(t, errEncrypt)
+				encrypted = append(encrypted, pkt)
+			}
+
+			for i, pkt := range encrypted {
+				decrypted, errDecrypt := decryptCtx.DecryptRTCP(nil, pkt, nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, rtcpPacket, decrypted)
+
+				index, ok := decryptCtx.Index(1)
+				assert.True(t, ok)
+				assert.Equal(t, uint32(i+1), index) //nolint:gosec // G115
+			}
+
+			encryptIndex, ok := encryptCtx.Index(1)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(3), encryptIndex)
+		})
+	}
+}
+
+func TestRTCPStrictEncryptionFlag(t *testing.T) {
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptedCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			unencryptedCtx, err := buildTestContext(profile, SRTCPNoEncryption())
+			assert.NoError(t, err)
+
+			withE, err := encryptedCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			withoutE, err := unencryptedCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+
+			// By default packets are decrypted according to their E-flag.
+			lenientCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decrypted, err := lenientCtx.DecryptRTCP(nil, withoutE, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+
+			strictCtx, err := buildTestContext(profile, SRTCPStrictEncryptionFlag())
+			assert.NoError(t, err)
+			_, err = strictCtx.DecryptRTCP(nil, withoutE, nil)
+			assert.ErrorIs(t, err, ErrSRTCPEncryptionFlagMismatch)
+			decrypted, err = strictCtx.DecryptRTCP(nil, withE, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+
+			strictUnencryptedCtx, err := buildTestContext(profile, SRTCPNoEncryption(), SRTCPStrictEncryptionFlag())
+			assert.NoError(t, err)
+			_, err = strictUnencryptedCtx.DecryptRTCP(nil, withE, nil)
+			assert.ErrorIs(t, err, ErrSRTCPEncryptionFlagMismatch)
+			decrypted, err = strictUnencryptedCtx.DecryptRTCP(nil, withoutE, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+		})
+	}
+}
+
+func TestRTCPAcceptUnencrypted(t *testing.T) {
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptedCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			unencryptedCtx, err := buildTestContext(profile, SRTCPNoEncryption())
+			assert.NoError(t, err)
+
+			withE, err := encryptedCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			withoutE, err := unencryptedCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			assert.Zero(t, withoutE[len(withoutE)-srtcpIndexSize-10]&srtcpEncryptionFlag)
+			// Packet is authenticated, so it cannot be modified.
+			tampered := append([]byte{}, withoutE...)
+			tampered[7] ^= 0x01
+
+			acceptCtx, err := buildTestContext(profile, SRTCPAcceptUnencrypted(true))
+			assert.NoError(t, err)
+			decrypted, err := acceptCtx.DecryptRTCP(nil, withoutE, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+			_, err = acceptCtx.DecryptRTCP(nil, tampered, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			for _, opts := range [][]ContextOption{
+				{SRTCPAcceptUnencrypted(false)},
+				{SRTCPNoEncryption(), SRTCPAcceptUnencrypted(false)},
+			} {
+				rejectCtx, err := buildTestContext(profile, opts...)
+				assert.NoError(t, err)
+				_, err = rejectCtx.DecryptRTCP(nil, withoutE, nil)
+				assert.ErrorIs(t, err, ErrSRTCPNotEncrypted)
+				decrypted, err = rejectCtx.DecryptRTCP(nil, withE, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, rtcpPacket, decrypted)
+			}
+		})
+	}
+}
+
+func TestRTCPValidateCompound(t *testing.T) {
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	// Second packet in the compound is truncated.
+	malformedPacket := append(append([]byte{}, rtcpPacket...), 0x80, 0xc9, 0x00, 0x01)
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			valid, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			malformed, err := encryptCtx.EncryptRTCP(nil, malformedPacket, nil)
+			assert.NoError(t, err)
+
+			lenientCtx, err := buildTestContext(profile, SRTCPReplayProtection(64))
+			assert.NoError(t, err)
+			decrypted, err := lenientCtx.DecryptRTCP(nil, malformed, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, malformedPacket, decrypted)
+
+			validatingCtx, err := buildTestContext(profile, SRTCPReplayProtection(64), SRTCPValidateCompound())
+			assert.NoError(t, err)
+			decrypted, err = validatingCtx.DecryptRTCP(nil, valid, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+			_, err = validatingCtx.DecryptRTCP(nil, malformed, nil)
+			assert.ErrorIs(t, err, ErrMalformedRTCP)
+
+			// Index of rejected packet is not marked as received by replay protection.
+			_, err = validatingCtx.DecryptRTCP(nil, malformed, nil)
+			assert.ErrorIs(t, err, ErrMalformedRTCP)
+		})
+	}
+}
+
+func TestSRTCPIndexExhausted(t *testing.T) {
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05}
+
+	type warning struct{ ssrc, index uint32 }
+	var warnings []warning
+	ctx, err := buildTestContext(profileCTR, OnSRTCPIndexWarning(2, func(ssrc, index uint32) {
+		warnings = append(warnings, warning{ssrc, index})
+	}))
+	assert.NoError(t, err)
+	ctx.SetIndex(5, maxSRTCPIndex-4)
+	for range 4 {
+		_, err = ctx.EncryptRTCP(nil, rtcpPacket, nil)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []warning{{5, maxSRTCPIndex - 2}}, warnings)
+
+	_, err = ctx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.ErrorIs(t, err, ErrSRTCPIndexExhausted)
+	assert.ErrorIs(t, err, errExceededMaxPackets)
+	assert.Contains(t, err.Error(), "ssrc=5")
+
+	// Callback is called again after the index is set.
+	ctx.SetIndex(5, maxSRTCPIndex-3)
+	_, err = ctx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 2)
+
+	_, err = buildTestContext(profileCTR, OnSRTCPIndexWarning(maxSRTCPIndex+1, nil))
+	assert.ErrorIs(t, err, errInvalidSRTCPIndex)
+}
+
+func TestSRTCPIndexRolloverOnRekey(t *testing.T) {
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x05}
+	newKey := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	newSalt := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			saltLen, err := profile.SaltLen()
+			assert.NoError(t, err)
+
+			encryptCtx, err := buildTestContext(profile, SRTCPIndexRolloverOnRekey())
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, SRTCPReplayProtection(64), SRTCPIndexRolloverOnRekey())
+			assert.NoError(t, err)
+			strictCtx, err := buildTestContext(profile, SRTCPReplayProtection(64))
+			assert.NoError(t, err)
+
+			encryptCtx.SetIndex(5, maxSRTCPIndex-1)
+			last, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			for _, ctx := range []*Context{decryptCtx, strictCtx} {
+				_, err = ctx.DecryptRTCP(nil, last, nil)
+				assert.NoError(t, err)
+			}
+
+			// Index does not roll over without a new key.
+			_, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.ErrorIs(t, err, ErrSRTCPIndexExhausted)
+
+			for _, ctx := range []*Context{encryptCtx, decryptCtx, strictCtx} {
+				assert.NoError(t, ctx.UpdateMasterKey(newKey, newSalt[:saltLen]))
+			}
+			first, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			index, ok := encryptCtx.Index(5)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(1), index)
+
+			decrypted, err := decryptCtx.DecryptRTCP(nil, first, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+			_, err = decryptCtx.DecryptRTCP(nil, first, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+			_, err = strictCtx.DecryptRTCP(nil, first, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+		})
+	}
+}
@@ -5,8 +5,12 @@
 package srtp
 
 import (
+	"cmp"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/pion/rtp"
 )
@@ -20,17 +24,102 @@ Simplified structure of SRTP Packets:
 - Auth Tag - used by non-AEAD profiles only. When RCC is used with AEAD profiles, the ROC is sent here.
 */
 
-// nolint:cyclop
 func (c *Context) decryptRTP(dst, ciphertext []byte, header *rtp.Header, headerLen int) ([]byte, error) {
-	authTagLen, err := c.cipher.AuthTagRTPLen()
+	size := len(ciphertext)
+	if c.failureSampler == nil {
+		out, err := c.doDecryptRTP(dst, ciphertext, header, headerLen, rtpDecryptFull)
+		c.recordDecrypted(header.SSRC, false, size, err)
+
+		return out, c.uniformDecryptFailure(err, ciphertext, false)
+	}
+
+	// Sample is taken before decryption, because decryption may be done in place.
+	c.takeFailureSample(ciphertext)
+	out, err := c.doDecryptRTP(dst, ciphertext, header, headerLen, rtpDecryptFull)
 	if err != nil {
+		c.reportFailureSample(err)
+	}
+	c.recordDecrypted(header.SSRC, false, size, err)
+
+	return out, c.uniformDecryptFailure(err, ciphertext, false)
+}
+
+// rtpDecryptMode selects what doDecryptRTP does with SRTP packet.
+type rtpDecryptMode int
+
+const (
+	// rtpDecryptFull decrypts and authenticates the packet, and updates per-SSRC state.
+	rtpDecryptFull rtpDecryptMode = iota
+	// rtpDecryptVerifyOnly decrypts and authenticates the packet, but ROC, replay protection
+	// and other per-SSRC state is not updated.
+	rtpDecryptVerifyOnly
+	// rtpDecryptAuthOnly authenticates the packet and updates per-SSRC state, but the packet
+	// is not decrypted.
+	rtpDecryptAuthOnly
+	// rtpDecryptHeaderOnly authenticates the packet, updates per-SSRC state, and decrypts
+	// the header only.
+	rtpDecryptHeaderOnly
+)
+
+// doDecryptRTP decrypts SRTP packet, as selected by mode.
+//
+// nolint:cyclop,gocognit
+func (c *Context) doDecryptRTP(
+	dst, ciphertext []byte, header *rtp.Header, headerLen int, mode rtpDecryptMode,
+) ([]byte, error) {
+	if err := c.checkRTPLimits(ciphertext); err != nil {
 		return nil, err
 	}
-	aeadAuthTagLen, err := c.cipher.AEADAuthTagLen()
+
+	var err error
+	var ektPlaintext *EKTPlaintext
+	var ektCipher srtpCipher
+	if c.ektKeys != nil {
+		// EKT Field is placed after the auth tag and is not covered by it.
+		if ciphertext, ektPlaintext, ektCipher, err = c.stripEKTField(ciphertext, header.SSRC); err != nil {
+			return nil, err
+		}
+	}
+
+	cipher, mki := c.cipher, c.sendMKI
+	switch {
+	case ektCipher != nil:
+		cipher = ektCipher
+	case c.ssrcCiphers[header.SSRC] != nil:
+		cipher = c.ssrcCiphers[header.SSRC]
+	case c.keySelector != nil:
+		if cipher, err = c.selectCipher(header); err != nil {
+			return nil, err
+		}
+	case len(c.mkis) > 0:
+		// Ciphers for different MKIs may use different auth tag and MKI lengths, so the cipher must be
+		// known before the packet length is checked.
+		cipher, mki, err = c.findCipherForMKI(ciphertext, headerLen, false, func(authTagLen int) int {
+			_, authTagLen = c.hasROCInPacket(header, authTagLen)
+
+			return authTagLen
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		if state, ok := c.ektReceiveStates[header.SSRC]; ok {
+			cipher = state.cipher
+		}
+	}
+
+	authTagLen, err := cipher.AuthTagRTPLen()
 	if err != nil {
 		return nil, err
 	}
-	mkiLen := len(c.sendMKI)
+	aeadAuthTagLen, err := cipher.AEADAuthTagLen()
+	if err != nil {
+		return nil, err
+	}
+	if err = checkTruncatedAuthTag(c.decryptTruncatedAuthTagPolicy, authTagLen, aeadAuthTagLen); err != nil {
+		return nil, err
+	}
+	mkiLen := len(mki)
 
 	var hasRocInPacket bool
 	hasRocInPacket, authTagLen = c.hasROCInPacket(header, authTagLen)
@@ -43,8 +132,18 @@ func (c *Context) decryptRTP(dst, ciphertext []byte, header *rtp.Header, headerL
 	// The SSRC in the RTP header is unauthenticated at this point. getSRTPSSRCState is
 	// called in read-only mode (existingState tracks whether it was pre-existing) so that
 	// no new map entry is inserted until after the auth tag has been verified. The state
-	// is committed to the map by setSRTPSSRCState only after markAsValid() succeeds below.
+	// is added to the map by setSRTPSSRCState only after the replay token is committed below.
+	if err := c.checkNewSRTPSSRCState(header.SSRC); err != nil {
+		return nil, err
+	}
 	ssrcState, existingState := c.getSRTPSSRCState(header.SSRC, false)
+	if existingState && c.isBlacklisted(ssrcState) {
+		return nil, ErrSSRCBlacklisted
+	}
+	if ektPlaintext != nil && !existingState {
+		// ROC of a new stream is learned from EKT, see RFC 8870 section 4.3.2.
+		ssrcState.index = uint64(ektPlaintext.ROC) << 16
+	}
 
 	var roc uint32
 	var diff int64
@@ -60,48 +159,261 @@ func (c *Context) decryptRTP(dst, ciphertext []byte, header *rtp.Header, headerL
 		diff = int64(ssrcState.index) - int64(index) //nolint:gosec
 	}
 
+	if err = c.checkRollovers(header.SSRC, roc); err != nil {
+		return nil, err
+	}
+	if err = c.checkKeyLifetime(mki, index); err != nil {
+		return nil, err
+	}
+
 	// The replay check is intentionally performed before authentication.
 	// Rejecting already-seen sequence numbers here avoids the CPU cost of
 	// AES decryption and HMAC/GCM verification on flooded duplicate packets.
-	// Safety relies on the replay detector only committing the index as "seen"
-	// when markAsValid() is explicitly called after successful authentication.
-	markAsValid, ok := ssrcState.replayDetector.Check(index)
+	// The index is only reserved here. It is committed as "seen" after
+	// successful authentication, and released on any error.
+	token, ok := ssrcState.replayGuard.reserve(index)
 	if !ok {
 		return nil, &duplicatedError{
 			Proto: "srtp", SSRC: header.SSRC, Index: uint32(header.SequenceNumber),
 		}
 	}
+	defer token.release()
 
 	err = c.checkCryptex(header)
 	if err != nil {
 		return nil, err
 	}
 
-	cipher := c.cipher
-	if len(c.mkis) > 0 {
-		// Find cipher for MKI
-		actualMKI := ciphertext[len(ciphertext)-mkiLen-authTagLen : len(ciphertext)-authTagLen]
-		cipher, ok = c.mkis[string(actualMKI)]
-		if !ok {
-			return nil, ErrMKINotFound
-		}
+	switch mode {
+	case rtpDecryptAuthOnly:
+	case rtpDecryptHeaderOnly:
+		dst = growBufferSize(dst, headerLen)
+	default:
+		dst = growBufferSize(dst, len(ciphertext)-authTagLen-mkiLen)
 	}
 
-	dst = growBufferSize(dst, len(ciphertext)-authTagLen-mkiLen)
+	// Decryption in place may overwrite the packet when authentication fails, so a copy is kept
+	// to retry decryption with other ROC values.
+	tryROCRecovery := c.rocRecovery && !hasRocInPacket
+	trialCiphertext := ciphertext
+	if tryROCRecovery && isSameBuffer(dst, ciphertext) {
+		c.rocRecoveryBuf = append(c.rocRecoveryBuf[:0], ciphertext...)
+		trialCiphertext = c.rocRecoveryBuf
+	}
 
-	dst, err = cipher.decryptRTP(dst, ciphertext, header, headerLen, roc, hasRocInPacket)
+	var start time.Time
+	if c.latencyRecorder != nil {
+		start = c.currentTime()
+	}
+	out, err := c.decryptRTPWithCipher(cipher, mode, dst, ciphertext, header, headerLen, roc, hasRocInPacket)
+	if c.latencyRecorder != nil {
+		c.latencyRecorder.Observe(header.SSRC, c.currentTime().Sub(start))
+	}
+	var recoveredROC bool
+	if tryROCRecovery && errors.Is(err, ErrFailedToVerifyAuthTag) {
+		if trialOut, trialROC, trialToken, ok := c.recoverROC(
+			cipher, mode, mki, dst, trialCiphertext, header, headerLen, ssrcState, roc,
+		); ok {
+			token.release()
+			out, roc, token, err = trialOut, trialROC, trialToken, nil
+			recoveredROC = true
+		}
+	}
+	dst = out
+	if c.traceHook != nil {
+		c.traceRTP(true, cipher, mki, header, roc, ciphertext, err)
+	}
 	if err != nil {
+		if existingState && mode != rtpDecryptVerifyOnly && errors.Is(err, ErrFailedToVerifyAuthTag) {
+			c.recordAuthFailure(ssrcState)
+		}
+
 		return nil, err
 	}
 
-	markAsValid()
-	ssrcState.updateRolloverCount(header.SequenceNumber, diff, hasRocInPacket, roc)
+	// The packet is authentic, so the timestamp guard only flags it: the packet is accepted, and returned
+	// together with the error. Verify-only calls do not return the packet, so the guard is not checked.
+	var timestampErr error
+	if mode == rtpDecryptFull || mode == rtpDecryptHeaderOnly {
+		timestampErr = ssrcState.checkTimestamp(header.Timestamp)
+	}
+
+	if mode == rtpDecryptVerifyOnly {
+		return dst, nil
+	}
+
+	if !token.commit() {
+		return nil, &duplicatedError{
+			Proto: "srtp", SSRC: header.SSRC, Index: uint32(header.SequenceNumber),
+		}
+	}
+	// ROC is advanced only after the packet passed the replay check and authentication,
+	// so replayed or forged packets cannot desynchronize it.
+	prevIndex := ssrcState.index
+	// When ROC was recovered, the guessed one was wrong, so the state is resynchronized to ROC
+	// of the authenticated packet.
+	ssrcState.updateRolloverCount(header.SequenceNumber, diff, hasRocInPacket || recoveredROC, roc)
+	// Flagged timestamp is not used as reference for next packets.
+	ssrcState.updateTimestamp(header.Timestamp, ssrcState.index > prevIndex && timestampErr == nil)
 
 	if !existingState {
 		c.setSRTPSSRCState(ssrcState)
 	}
+	if ektPlaintext != nil {
+		c.updateEKTReceiveState(header.SSRC, ektPlaintext.MasterKey, cipher)
+	}
+	if len(c.mkiLifetimes) > 0 {
+		c.expireReceiveKeys()
+	}
+
+	return dst, timestampErr
+}
+
+// recoverROC retries decryption of SRTP packet which failed authentication with ROC values adjacent
+// to the guessed one, as configured by SRTPROCRecovery option. On success it returns decrypted packet,
+// its ROC and reserved replay token for its index.
+func (c *Context) recoverROC(
+	cipher srtpCipher, mode rtpDecryptMode, mki, dst, ciphertext []byte, header *rtp.Header, headerLen int,
+	state *srtpSSRCState, guessedROC uint32,
+) ([]byte, uint32, replayToken, bool) {
+	for _, delta := range []int64{1, -1} {
+		trialROC := int64(guessedROC) + delta
+		if trialROC < 0 || trialROC > maxROC {
+			continue
+		}
+		roc := uint32(trialROC) //nolint:gosec // G115, checked above
+		index := uint64(roc)<<16 | uint64(header.SequenceNumber)
+		if c.checkRollovers(header.SSRC, roc) != nil || c.checkKeyLifetime(mki, index) != nil {
+			continue
+		}
+
+		token, ok := state.replayGuard.reserve(index)
+		if !ok {
+			continue
+		}
+		out, err := c.decryptRTPWithCipher(cipher, mode, dst, ciphertext, header, headerLen, roc, false)
+		if err != nil {
+			token.release()
+
+			continue
+		}
+
+		return out, roc, token, true
+	}
+
+	return nil, 0, replayToken{}, false
+}
+
+// decryptRTPWithCipher decrypts SRTP packet with cipher, only authenticates it in rtpDecryptAuthOnly mode,
+// or decrypts its header to dst in rtpDecryptHeaderOnly mode. Ciphers which cannot verify auth tag without
+// decryption, like AEAD ones, decrypt the packet to a scratch buffer in these modes. ciphertext is returned
+// unmodified in rtpDecryptAuthOnly mode.
+func (c *Context) decryptRTPWithCipher(
+	cipher srtpCipher, mode rtpDecryptMode, dst, ciphertext []byte, header *rtp.Header, headerLen int,
+	roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	if mode != rtpDecryptAuthOnly && mode != rtpDecryptHeaderOnly {
+		return cipher.decryptRTP(dst, ciphertext, header, headerLen, roc, rocInAuthTag)
+	}
+
+	if k, ok := cipher.(*kdrCipher); ok {
+		var err error
+		if cipher, err = k.cipherForIndex(uint64(roc)<<16 | uint64(header.SequenceNumber)); err != nil {
+			return nil, err
+		}
+	}
+	if decrypter, ok := cipher.(rtpHeaderDecrypter); ok && mode == rtpDecryptHeaderOnly {
+		return decrypter.decryptRTPHeader(dst, ciphertext, header, headerLen, roc, rocInAuthTag)
+	}
+	if authenticator, ok := cipher.(rtpAuthenticator); ok && mode == rtpDecryptAuthOnly {
+		return ciphertext, authenticator.authenticateRTP(ciphertext, header, roc, rocInAuthTag)
+	}
+	c.authScratchBuf = growBufferSize(c.authScratchBuf[:0], len(ciphertext))
+	decrypted, err := cipher.decryptRTP(c.authScratchBuf, ciphertext, header, headerLen, roc, rocInAuthTag)
+	if err != nil {
+		return nil, err
+	}
+	if mode == rtpDecryptHeaderOnly {
+		copy(dst, decrypted[:headerLen])
+
+		return dst, nil
+	}
 
-	return dst, nil
+	return ciphertext, nil
+}
+
+// VerifyRTP verifies auth tag of SRTP packet and checks it against the replay window, without decrypting it.
+// On success ROC, replay protection and other per-SSRC state is updated like by DecryptRTP, and the packet
+// is left unmodified, with its payload still encrypted. It allows relays which forward packets without
+// inspecting media to authenticate them hop-by-hop. For AES-CM and NULL profiles only the auth tag is
+// computed, which is much cheaper than decryption and encryption of the packet. AEAD profiles do not
+// allow to verify the auth tag separately, so packets protected with them are decrypted to a scratch buffer.
+func (c *Context) VerifyRTP(packet []byte) error {
+	header := &rtp.Header{}
+	headerLen, err := header.Unmarshal(packet)
+	if err != nil {
+		return c.headerFailure(err, packet, false)
+	}
+
+	_, err = c.doDecryptRTP(nil, packet, header, headerLen, rtpDecryptAuthOnly)
+	c.recordDecrypted(header.SSRC, false, len(packet), err)
+
+	return c.uniformDecryptFailure(err, packet, false)
+}
+
+// DecryptRTPHeader authenticates SRTP packet and returns its decrypted header, without decrypting
+// the payload. CSRCs and header extensions encrypted with Cryptex (RFC 9335), and header extensions
+// encrypted as defined in RFC 6904, are decrypted, so bandwidth estimation relays can read e.g.
+// transport-wide CC sequence numbers with minimal crypto work per packet. If a rtp.Header is provided,
+// it is set to the decrypted header. ROC, replay protection and other per-SSRC state is updated like
+// by DecryptRTP, and encrypted is not modified unless dst uses the same buffer. For AES-CM and NULL
+// profiles only the auth tag and the keystream for the header are computed. AEAD profiles do not allow
+// to verify the auth tag separately, so packets protected with them are decrypted to a scratch buffer.
+func (c *Context) DecryptRTPHeader(dst, encrypted []byte, header *rtp.Header) ([]byte, error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(encrypted)
+	if err != nil {
+		return nil, c.headerFailure(err, encrypted, false)
+	}
+
+	out, err := c.doDecryptRTP(dst, encrypted, header, headerLen, rtpDecryptHeaderOnly)
+	c.recordDecrypted(header.SSRC, false, len(encrypted), err)
+
+	return out, c.uniformDecryptFailure(err, encrypted, false)
+}
+
+// FilterAuthenticRTP verifies authentication tags of a batch of SRTP packets, and returns indexes of
+// packets which are authentic and not replayed. Packets are not modified, and ROC, replay protection
+// and other per-SSRC state is not updated, so accepted packets must be decrypted with DecryptRTP
+// afterwards. Packets with the same index in a batch are not detected as replayed.
+//
+// Returned error joins errors of all rejected packets, so it is nil when all packets are accepted.
+func (c *Context) FilterAuthenticRTP(packets [][]byte) (validIdx []int, err error) {
+	var errs []error
+	var scratch []byte
+	header := &rtp.Header{}
+	for i, packet := range packets {
+		headerLen, errUnmarshal := header.Unmarshal(packet)
+		if errUnmarshal != nil {
+			errs = append(errs, fmt.Errorf("packet %d: %w", i, errUnmarshal))
+
+			continue
+		}
+
+		// Decrypt to a separate buffer, so the packet can be decrypted again later.
+		scratch = growBufferSize(scratch[:0], len(packet))
+		if _, errVerify := c.doDecryptRTP(scratch, packet, header, headerLen, rtpDecryptVerifyOnly); errVerify != nil {
+			errs = append(errs, fmt.Errorf("packet %d: %w", i, errVerify))
+
+			continue
+		}
+		validIdx = append(validIdx, i)
+	}
+
+	return validIdx, errors.Join(errs...)
 }
 
 // DecryptRTP decrypts a RTP packet with an encrypted payload.
@@ -112,14 +424,320 @@ func (c *Context) DecryptRTP(dst, encrypted []byte, header *rtp.Header) ([]byte,
 
 	headerLen, err := header.Unmarshal(encrypted)
 	if err != nil {
-		return nil, err
+		return nil, c.headerFailure(err, encrypted, false)
 	}
 
 	return c.decryptRTP(dst, encrypted, header, headerLen)
 }
 
+// DecryptRTPWithHeaderLen decrypts a RTP packet like DecryptRTP, but without parsing its header with
+// rtp.Header.Unmarshal, so packets with headers rejected by it, e.g. with header extensions malformed
+// by middleboxes, can still be decrypted and forwarded by relays. The caller provides framing of the
+// packet: length of the header, including CSRCs and header extension, and SSRC and sequence number
+// from the fixed part of the header. Header length is not validated; when it is wrong, the packet fails
+// authentication or it is decrypted incorrectly. It cannot be used with Cryptex and header extension
+// encryption, which need the parsed header.
+func (c *Context) DecryptRTPWithHeaderLen(dst, ciphertext []byte, headerLen int, ssrc uint32, seq uint16,
+) ([]byte, error) {
+	if c.cryptexMode != CryptexModeDisabled || len(c.encryptedHeaderExtensionIDs) != 0 {
+		return nil, errParsedHeaderRequired
+	}
+	if headerLen < minSrtpHeaderSize || headerLen > len(ciphertext) {
+		return nil, fmt.Errorf("%w: header length %d, packet length %d", errTooShortRTP, headerLen, len(ciphertext))
+	}
+
+	// Only the header bytes are authenticated by AES-CM profiles, so values used for the keystream must match them.
+	if binary.BigEndian.Uint32(ciphertext[8:]) != ssrc || binary.BigEndian.Uint16(ciphertext[2:]) != seq {
+		return nil, fmt.Errorf("%w: ssrc=%d seq=%d", errRTPHeaderMismatch, ssrc, seq)
+	}
+
+	header := &rtp.Header{
+		Version:        ciphertext[0] >> 6,
+		Padding:        ciphertext[0]&0x20 != 0,
+		Marker:         ciphertext[1]&0x80 != 0,
+		PayloadType:    ciphertext[1] & 0x7f,
+		SequenceNumber: seq,
+		Timestamp:      binary.BigEndian.Uint32(ciphertext[4:]),
+		SSRC:           ssrc,
+	}
+
+	return c.decryptRTP(dst, ciphertext, header, headerLen)
+}
+
+// DecryptRTPInPlace decrypts a RTP packet in buf in place, and returns length of decrypted payload.
+// Auth tag and MKI are stripped, and decrypted payload is placed at buf[headerLen:headerLen+n], where
+// headerLen is the size of RTP header (header.MarshalSize()). No buffers are allocated for the output.
+// If a rtp.Header is provided, it is unmarshaled from the packet.
+func (c *Context) DecryptRTPInPlace(buf []byte, header *rtp.Header) (int, error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(buf)
+	if err != nil {
+		return 0, c.headerFailure(err, buf, false)
+	}
+
+	decrypted, err := c.decryptRTP(buf, buf, header, headerLen)
+	if decrypted == nil {
+		return 0, err
+	}
+
+	// err is not nil when the packet was flagged by the timestamp guard.
+	return len(decrypted) - headerLen, err
+}
+
+// DecryptRTPCopy decrypts a RTP packet like DecryptRTP, but it always returns plaintext in a newly
+// allocated buffer, which does not alias the encrypted packet. If a rtp.Header is provided, it is
+// filled with a deep copy of the header, so it does not alias the packet either.
+func (c *Context) DecryptRTPCopy(encrypted []byte, header *rtp.Header) ([]byte, error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(encrypted)
+	if err != nil {
+		return nil, c.headerFailure(err, encrypted, false)
+	}
+
+	dst := make([]byte, len(encrypted))
+	decrypted, err := c.decryptRTP(dst, encrypted, header, headerLen)
+	if decrypted == nil {
+		return nil, err
+	}
+	*header = header.Clone()
+
+	return decrypted, err
+}
+
+// DecryptRTPOrPassthrough decrypts a RTP packet like DecryptRTP. When UnsafePlaintextPassthrough option
+// is set and the packet cannot be authenticated, but it looks like a plaintext RTP packet, a copy of
+// the packet is returned as-is, and isPlaintext is set to true. Without the option it behaves like
+// DecryptRTP and isPlaintext is always false.
+//
+// Plaintext packets are not authenticated, not checked for replay and do not update any SSRC state.
+// See UnsafePlaintextPassthrough for security implications.
+func (c *Context) DecryptRTPOrPassthrough(dst, encrypted []byte, header *rtp.Header,
+) (decrypted []byte, isPlaintext bool, err error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(encrypted)
+	if err != nil {
+		return nil, false, c.headerFailure(err, encrypted, false)
+	}
+
+	if !c.plaintextPassthrough {
+		decrypted, err = c.decryptRTP(dst, encrypted, header, headerLen)
+
+		return decrypted, false, err
+	}
+
+	// Failed decryption may overwrite the buffer, e.g. AES-GCM clears it. Keep the original packet.
+	packet := encrypted
+	if isSameBuffer(dst, encrypted) {
+		packet = append([]byte{}, encrypted...)
+	}
+
+	decrypted, err = c.decryptRTP(dst, encrypted, header, headerLen)
+	if err == nil || !looksLikePlaintextRTP(packet, header, headerLen, err) {
+		return decrypted, false, err
+	}
+
+	dst = growBufferSize(dst, len(packet))
+	copy(dst, packet)
+
+	return dst, true, nil
+}
+
+// looksLikePlaintextRTP checks if packet which failed decryption with given error may be a plaintext RTP packet.
+func looksLikePlaintextRTP(packet []byte, header *rtp.Header, headerLen int, err error) bool {
+	if !errors.Is(err, ErrFailedToVerifyAuthTag) && !errors.Is(err, errTooShortRTP) &&
+		!errors.Is(err, ErrMKINotFound) {
+		return false
+	}
+	if header.Version != 2 || isCryptexPacket(header) {
+		return false
+	}
+	if header.Padding {
+		// Padding must fit in the payload and its length must not be zero.
+		paddingLen := int(packet[len(packet)-1])
+		if paddingLen == 0 || headerLen+paddingLen > len(packet) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DecryptRTPBatchGrouped decrypts a batch of RTP packets. Packets are grouped by SSRC internally, so
+// state and cipher of each SSRC are used contiguously, what improves cache locality when packets of
+// many SSRCs are interleaved. Packets of the same SSRC are decrypted in their original order.
+//
+// Results and errors are returned in the input order. dsts[i] is used as destination buffer for
+// encrypted[i], like dst in DecryptRTP. dsts may be shorter than encrypted or nil, then new buffers are
+// allocated for packets without destination buffer.
+func (c *Context) DecryptRTPBatchGrouped(dsts, encrypted [][]byte) ([][]byte, []error) {
+	decrypted := make([][]byte, len(encrypted))
+	errs := make([]error, len(encrypted))
+	headers := make([]rtp.Header, len(encrypted))
+	headerLens := make([]int, len(encrypted))
+
+	order := make([]int, 0, len(encrypted))
+	for i, packet := range encrypted {
+		headerLen, err := headers[i].Unmarshal(packet)
+		if err != nil {
+			errs[i] = c.headerFailure(err, packet, false)
+
+			continue
+		}
+		headerLens[i] = headerLen
+		order = append(order, i)
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(headers[a].SSRC, headers[b].SSRC)
+	})
+
+	for _, i := range order {
+		var dst []byte
+		if i < len(dsts) {
+			dst = dsts[i]
+		}
+		decrypted[i], errs[i] = c.decryptRTP(dst, encrypted[i], &headers[i], headerLens[i])
+	}
+
+	return decrypted, errs
+}
+
+// DecryptRTPWithExtensions decrypts a RTP packet, and returns its payload together with fully parsed
+// header, so header extensions can be read with header.GetExtension. Header extensions and CSRCs
+// encrypted with Cryptex (RFC 9335), and header extensions encrypted as defined in RFC 6904 (see
+// SRTPEncryptedHeaderExtensions option), are returned decrypted.
+// Returned payload does not include RTP padding.
+func (c *Context) DecryptRTPWithExtensions(encrypted []byte) (payload []byte, header *rtp.Header, err error) {
+	decrypted, err := c.DecryptRTP(nil, encrypted, nil)
+	if decrypted == nil {
+		return nil, nil, err
+	}
+
+	// Header is parsed again after decryption, because Cryptex encrypts header extensions.
+	pkt := &rtp.Packet{}
+	if errUnmarshal := pkt.Unmarshal(decrypted); errUnmarshal != nil {
+		return nil, nil, errUnmarshal
+	}
+
+	return pkt.Payload, &pkt.Header, err
+}
+
+// ResignRTP recomputes authentication tag of SRTP packet after its header was modified, e.g. by
+// an authenticated relay, so the packet passes verification downstream. Packet is modified in place.
+// If a rtp.Header is provided, it will be Unmarshaled using the packet. ROC is estimated from the SSRC
+// state of the Context like for decryption, but the state is not updated.
+//
+// The old auth tag is not verified, so authenticity of the packet must be checked before it is modified.
+// Resigning requires holding the session keys, and by design it defeats end-to-end integrity protection
+// between the original sender and the receiver. Packets encrypted with Cryptex cannot be resigned.
+//
+// Only HMAC profiles are supported. Resigning with AEAD profiles would seal the packet again with
+// the same IV, which breaks GCM security, so an error is returned for them.
+func (c *Context) ResignRTP(packet []byte, header *rtp.Header) ([]byte, error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(packet)
+	if err != nil {
+		return nil, err
+	}
+	if isCryptexPacket(header) {
+		return nil, errResignCryptex
+	}
+
+	cipher, mki := c.sendCipher(header.SSRC), c.sendMKI
+	if _, ok := c.ssrcCiphers[header.SSRC]; !ok && len(c.mkis) > 0 {
+		cipher, mki, err = c.findCipherForMKI(packet, headerLen, false, func(authTagLen int) int {
+			_, authTagLen = c.hasROCInPacket(header, authTagLen)
+
+			return authTagLen
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	authTagLen, err := cipher.AuthTagRTPLen()
+	if err != nil {
+		return nil, err
+	}
+	aeadAuthTagLen, err := cipher.AEADAuthTagLen()
+	if err != nil {
+		return nil, err
+	}
+	if aeadAuthTagLen > 0 {
+		return nil, errResignAEAD
+	}
+	hasRocInPacket, authTagLen := c.hasROCInPacket(header, authTagLen)
+	if len(packet) < (headerLen + len(mki) + authTagLen) {
+		return nil, fmt.Errorf("%w: %d", errTooShortRTP, len(packet))
+	}
+
+	var roc uint32
+	if hasRocInPacket {
+		roc = binary.BigEndian.Uint32(packet[len(packet)-authTagLen:])
+	} else {
+		ssrcState, _ := c.getSRTPSSRCState(header.SSRC, false)
+		roc, _, _ = ssrcState.nextRolloverCount(header.SequenceNumber)
+	}
+
+	return cipher.resignRTP(packet, header, headerLen, roc, hasRocInPacket)
+}
+
+// EncryptRTPBatch encrypts a batch of marshaled RTP packets, e.g. ones sent with a single GSO write.
+// Results and errors are returned in the input order. dsts[i] is used as destination buffer for
+// plaintexts[i], like dst in EncryptRTP. dsts may be shorter than plaintexts or nil, then new buffers
+// are allocated for packets without destination buffer.
+func (c *Context) EncryptRTPBatch(dsts, plaintexts [][]byte) ([][]byte, []error) {
+	return c.processRTPBatch(dsts, plaintexts, c.encryptRTP)
+}
+
+// DecryptRTPBatch decrypts a batch of SRTP packets in the input order, e.g. ones received with a single
+// GRO read. dsts, results and errors work like in DecryptRTPBatchGrouped.
+func (c *Context) DecryptRTPBatch(dsts, encrypted [][]byte) ([][]byte, []error) {
+	return c.processRTPBatch(dsts, encrypted, func(dst []byte, header *rtp.Header, headerLen int, packet []byte,
+	) ([]byte, error) {
+		return c.decryptRTP(dst, packet, header, headerLen)
+	})
+}
+
+func (c *Context) processRTPBatch(
+	dsts, packets [][]byte,
+	process func(dst []byte, header *rtp.Header, headerLen int, packet []byte) ([]byte, error),
+) ([][]byte, []error) {
+	results := make([][]byte, len(packets))
+	errs := make([]error, len(packets))
+	header := &rtp.Header{}
+	for i, packet := range packets {
+		headerLen, err := header.Unmarshal(packet)
+		if err != nil {
+			errs[i] = err
+
+			continue
+		}
+
+		var dst []byte
+		if i < len(dsts) {
+			dst = dsts[i]
+		}
+		results[i], errs[i] = process(dst, header, headerLen, packet)
+	}
+
+	return results, errs
+}
+
 // EncryptRTP marshals and encrypts an RTP packet, writing to the dst buffer provided.
-// If the dst buffer does not have the capacity to hold `len(plaintext) + 10` bytes,
+// If the dst buffer does not have the capacity to hold `EncryptedLen(len(plaintext))` bytes,
 // a new one will be allocated and returned.
 // If a rtp.Header is provided, it will be Unmarshaled using the plaintext.
 func (c *Context) EncryptRTP(dst []byte, plaintext []byte, header *rtp.Header) ([]byte, error) {
@@ -135,11 +753,123 @@ func (c *Context) EncryptRTP(dst []byte, plaintext []byte, header *rtp.Header) (
 	return c.encryptRTP(dst, header, headerLen, plaintext)
 }
 
+// EncryptRTPWithHeader encrypts a marshaled RTP packet like EncryptRTP, but uses the header which
+// was already unmarshaled from the plaintext, so the header is not parsed again. headerLen is the
+// length of the header returned by rtp.Header.Unmarshal. The header must match the plaintext,
+// otherwise the packet is encrypted incorrectly. An error is returned when the header is nil, or when
+// headerLen is shorter than RTP fixed header or longer than the plaintext.
+func (c *Context) EncryptRTPWithHeader(dst []byte, header *rtp.Header, headerLen int, plaintext []byte,
+) ([]byte, error) {
+	if header == nil {
+		return nil, errNilRTPHeader
+	}
+	if headerLen < minSrtpHeaderSize || headerLen > len(plaintext) {
+		return nil, fmt.Errorf("%w: header length %d, packet length %d", errTooShortRTP, headerLen, len(plaintext))
+	}
+
+	return c.encryptRTP(dst, header, headerLen, plaintext)
+}
+
+// RTPHeaderRewrite describes changes of RTP header done by Context.RewriteAndEncryptRTP, e.g. by SFU which
+// forwards packets of a source stream in its own outgoing stream.
+type RTPHeaderRewrite struct {
+	// SSRC replaces SSRC of the packet when HasSSRC is set.
+	HasSSRC bool
+	SSRC    uint32
+	// SequenceNumberOffset and TimestampOffset are added to sequence number and timestamp of the packet,
+	// with wrap-around.
+	SequenceNumberOffset uint16
+	TimestampOffset      uint32
+}
+
+// RewriteAndEncryptRTP rewrites header of a decrypted RTP packet and encrypts it with the Context,
+// e.g. after it was decrypted with Context of another leg of a SRTP-to-SRTP relay. Header fields
+// are patched in the marshaled packet, so the packet is not unmarshaled and marshaled again. When dst
+// is the same buffer as plaintext, e.g. plaintext[:0], the packet is rewritten and encrypted in place,
+// so the buffer should have capacity for the auth tag. Otherwise plaintext is not modified.
+// If a rtp.Header is provided, it will be Unmarshaled using the plaintext, and then rewritten.
+func (c *Context) RewriteAndEncryptRTP(dst, plaintext []byte, header *rtp.Header, rewrite RTPHeaderRewrite,
+) ([]byte, error) {
+	if header == nil {
+		header = &rtp.Header{}
+	}
+
+	headerLen, err := header.Unmarshal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if rewrite.HasSSRC {
+		header.SSRC = rewrite.SSRC
+	}
+	header.SequenceNumber += rewrite.SequenceNumberOffset
+	header.Timestamp += rewrite.TimestampOffset
+
+	buf := growBufferSize(dst, len(plaintext))
+	if !isSameBuffer(buf, plaintext) {
+		copy(buf, plaintext)
+	}
+	binary.BigEndian.PutUint16(buf[2:], header.SequenceNumber)
+	binary.BigEndian.PutUint32(buf[4:], header.Timestamp)
+	binary.BigEndian.PutUint32(buf[8:], header.SSRC)
+
+	return c.encryptRTP(buf, header, headerLen, buf)
+}
+
+// RTPPacketWithROC is a RTP packet together with ROC used to encrypt it. See Context.EncryptRTPSequence.
+type RTPPacketWithROC struct {
+	Header  *rtp.Header
+	Payload []byte
+	ROC     uint32
+}
+
+// EncryptRTPSequence encrypts RTP packets using ROC specified for each packet, instead of ROC estimated
+// from sequence numbers. After each packet the ROC of its SSRC is set to the specified value, so
+// EncryptRTP continues from the last packet. It is intended for generating streams with specific
+// ROC values for testing replay and ROC handling of receivers.
+//
+// On error, packets encrypted so far are returned together with the error.
+func (c *Context) EncryptRTPSequence(packets []RTPPacketWithROC) ([][]byte, error) {
+	encrypted := make([][]byte, 0, len(packets))
+	for i, packet := range packets {
+		if packet.Header == nil {
+			return encrypted, fmt.Errorf("packet %d: %w", i, errNilRTPHeader)
+		}
+		plaintext, err := (&rtp.Packet{Header: *packet.Header, Payload: packet.Payload}).Marshal()
+		if err != nil {
+			return encrypted, fmt.Errorf("packet %d: %w", i, err)
+		}
+		header := packet.Header.Clone()
+		roc := packet.ROC
+		ciphertext, err := c.doEncryptRTP(nil, &header, header.MarshalSize(), plaintext, &roc)
+		if err != nil {
+			return encrypted, fmt.Errorf("packet %d: %w", i, err)
+		}
+		encrypted = append(encrypted, ciphertext)
+	}
+
+	return encrypted, nil
+}
+
 // encryptRTP marshals and encrypts an RTP packet, writing to the dst buffer provided.
 // If the dst buffer does not have the capacity, a new one will be allocated and returned.
 // Similar to above but faster because it can avoid unmarshaling the header and marshaling the payload.
 func (c *Context) encryptRTP(dst []byte, header *rtp.Header, headerLen int, plaintext []byte,
 ) (ciphertext []byte, err error) {
+	return c.doEncryptRTP(dst, header, headerLen, plaintext, nil)
+}
+
+// doEncryptRTP encrypts an RTP packet. When forcedROC is not nil, it is used instead of ROC
+// estimated from the sequence number, and the SSRC state is updated to it.
+func (c *Context) doEncryptRTP(dst []byte, header *rtp.Header, headerLen int, plaintext []byte,
+	forcedROC *uint32,
+) (ciphertext []byte, err error) {
+	if c.headerExtensionValue != nil {
+		if header, headerLen, plaintext, err = c.insertHeaderExtension(header, headerLen, plaintext); err != nil {
+			return nil, err
+		}
+	}
+
 	// RFC 9335, section 5.1: This mechanism [Cryptex] MUST NOT be used with header extensions other than
 	// the variety described in [RFC8285].
 	if c.cryptexMode != CryptexModeDisabled && header.Extension &&
@@ -148,20 +878,98 @@ func (c *Context) encryptRTP(dst []byte, header *rtp.Header, headerLen int, plai
 		return nil, errUnsupportedHeaderExtension
 	}
 
+	// Keys set for the SSRC by SetSSRCKeys may use a different profile, so the policy is checked
+	// against the cipher which encrypts the packet.
+	cipher := c.sendCipher(header.SSRC)
+	if c.encryptTruncatedAuthTagPolicy != TruncatedAuthTagAllowed {
+		if err = c.checkEncryptTruncatedAuthTag(cipher); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.singleSSRC {
+		if c.hasSendSSRC && header.SSRC != c.sendSSRC {
+			return nil, fmt.Errorf("%w: %d, expected %d", errUnexpectedSSRC, header.SSRC, c.sendSSRC)
+		}
+		c.hasSendSSRC = true
+		c.sendSSRC = header.SSRC
+	}
+
+	if err := c.checkNewSRTPSSRCState(header.SSRC); err != nil {
+		return nil, err
+	}
 	ssrcState, _ := c.getSRTPSSRCState(header.SSRC, true)
-	roc, diff, ovf := ssrcState.nextRolloverCount(header.SequenceNumber)
-	if ovf {
-		// ... when 2^48 SRTP packets or 2^31 SRTCP packets have been secured with the same key
-		// (whichever occurs before), the key management MUST be called to provide new master key(s)
-		// (previously stored and used keys MUST NOT be used again), or the session MUST be terminated.
-		// https://www.rfc-editor.org/rfc/rfc3711#section-9.2
-		return nil, errExceededMaxPackets
+	var roc uint32
+	var diff int64
+	if forcedROC != nil {
+		roc = *forcedROC
+	} else {
+		var ovf bool
+		roc, diff, ovf = ssrcState.nextRolloverCount(header.SequenceNumber)
+		if ovf {
+			// ... when 2^48 SRTP packets or 2^31 SRTCP packets have been secured with the same key
+			// (whichever occurs before), the key management MUST be called to provide new master key(s)
+			// (previously stored and used keys MUST NOT be used again), or the session MUST be terminated.
+			// https://www.rfc-editor.org/rfc/rfc3711#section-9.2
+			return nil, errExceededMaxPackets
+		}
+	}
+
+	if err = c.checkRollovers(header.SSRC, roc); err != nil {
+		return nil, err
 	}
-	ssrcState.updateRolloverCount(header.SequenceNumber, diff, false, 0)
+	ssrcState.updateRolloverCount(header.SequenceNumber, diff, forcedROC != nil, roc)
 
 	rocInPacket := c.rccMode != RCCModeNone && header.SequenceNumber%c.rocTransmitRate == 0
 
-	return c.cipher.encryptRTP(dst, header, headerLen, plaintext, roc, rocInPacket)
+	dst = reserveEncryptBuffer(dst, len(plaintext)+c.RTPOverhead())
+	ciphertext, err = cipher.encryptRTP(dst, header, headerLen, plaintext, roc, rocInPacket)
+	if err == nil && c.traceHook != nil {
+		c.traceRTP(false, cipher, c.sendMKI, header, roc, ciphertext, nil)
+	}
+	if err == nil && c.ektKeys != nil {
+		ciphertext, err = c.appendEKTField(ciphertext, ssrcState, header.SSRC, roc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.recordEncrypted(header.SSRC, false, len(ciphertext))
+
+	return ciphertext, nil
+}
+
+// DebugKeystreamRTP returns keystream which is XORed with payload of SRTP packet with given header
+// and ROC. Payload of the packet is payloadLen bytes long. It is intended for cryptographic debugging
+// only, and it requires UnsafeDebugKeystream option. DO NOT use it in production.
+//
+// For AEAD profiles returned keystream does not include bytes used for authentication tag.
+// Cryptex is not taken into account, i.e. keystream starts after the RTP header.
+func (c *Context) DebugKeystreamRTP(header *rtp.Header, payloadLen int, roc uint32) ([]byte, error) {
+	if !c.debugKeystream {
+		return nil, errKeystreamExportDisabled
+	}
+
+	return c.sendCipher(header.SSRC).keystreamRTP(header, payloadLen, roc)
+}
+
+// insertHeaderExtension adds header extension configured by SRTPHeaderExtensionInserter option to the packet.
+// Header is copied, and plaintext packet is marshaled to a new buffer.
+func (c *Context) insertHeaderExtension(
+	header *rtp.Header, headerLen int, plaintext []byte,
+) (*rtp.Header, int, []byte, error) {
+	newHeader := header.Clone()
+	if err := newHeader.SetExtension(c.headerExtensionID, c.headerExtensionValue(header)); err != nil {
+		return nil, 0, nil, err
+	}
+
+	newHeaderLen := newHeader.MarshalSize()
+	buf := make([]byte, newHeaderLen+len(plaintext)-headerLen)
+	if _, err := newHeader.MarshalTo(buf); err != nil {
+		return nil, 0, nil, err
+	}
+	copy(buf[newHeaderLen:], plaintext[headerLen:])
+
+	return &newHeader, newHeaderLen, buf, nil
 }
 
 func (c *Context) hasROCInPacket(header *rtp.Header, authTagLen int) (bool, int) {
@@ -182,6 +990,30 @@ func (c *Context) hasROCInPacket(header *rtp.Header, authTagLen int) (bool, int)
 	return hasRocInPacket, authTagLen
 }
 
+// checkEncryptTruncatedAuthTag checks auth tag length of the cipher used for encryption against the policy.
+func (c *Context) checkEncryptTruncatedAuthTag(cipher srtpCipher) error {
+	authTagLen, err := cipher.AuthTagRTPLen()
+	if err != nil {
+		return err
+	}
+	aeadAuthTagLen, err := cipher.AEADAuthTagLen()
+	if err != nil {
+		return err
+	}
+
+	return checkTruncatedAuthTag(c.encryptTruncatedAuthTagPolicy, authTagLen, aeadAuthTagLen)
+}
+
+// checkTruncatedAuthTag checks if auth tag of AES-CM or NULL cipher is allowed by the policy.
+// AEAD ciphers do not use auth tag, so they are always allowed.
+func checkTruncatedAuthTag(policy TruncatedAuthTagPolicy, authTagLen, aeadAuthTagLen int) error {
+	if policy == TruncatedAuthTagRejected && aeadAuthTagLen == 0 && authTagLen < fullAuthTagRTPLen {
+		return fmt.Errorf("%w: %d bytes", ErrTruncatedAuthTag, authTagLen)
+	}
+
+	return nil
+}
+
 func (c *Context) checkCryptex(header *rtp.Header) error {
 	switch c.cryptexMode {
 	case CryptexModeDisabled:
@@ -197,3 +1029,42 @@ func (c *Context) checkCryptex(header *rtp.Header) error {
 
 	return nil
 }
+
+// ValidateSRTPStructure checks if buf is structurally a plausible SRTP packet protected with given profile
+// and MKI length, without decrypting or authenticating it. It verifies minimum length, RTP version,
+// RTP header (including CSRCs and header extension) and room for MKI and authentication tag.
+// It is intended for cheap pre-filtering of packets by components which do not hold keys.
+func ValidateSRTPStructure(buf []byte, profile ProtectionProfile, mkiLen int) error {
+	if !profile.isSupported() {
+		return fmt.Errorf("%w: %#v", ErrUnsupportedProfile, profile)
+	}
+	if mkiLen < 0 {
+		return fmt.Errorf("%w: %d", errInvalidMKILength, mkiLen)
+	}
+	if len(buf) < minSrtpHeaderSize {
+		return fmt.Errorf("%w: %d", errTooShortRTP, len(buf))
+	}
+	if version := buf[0] >> 6; version != rtpVersion {
+		return fmt.Errorf("%w: %d", errInvalidRTPVersion, version)
+	}
+
+	header := &rtp.Header{}
+	headerLen, err := header.Unmarshal(buf)
+	if err != nil {
+		return err
+	}
+
+	authTagLen, err := profile.AuthTagRTPLen()
+	if err != nil {
+		return err
+	}
+	aeadAuthTagLen, err := profile.AEADAuthTagLen()
+	if err != nil {
+		return err
+	}
+	if minLen := headerLen + aeadAuthTagLen + mkiLen + authTagLen; len(buf) < minLen {
+		return fmt.Errorf("%w: %d, expected at least %d", errTooShortRTP, len(buf), minLen)
+	}
+
+	return nil
+}
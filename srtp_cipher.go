@@ -22,6 +22,32 @@ type srtpCipher interface {
 
 	decryptRTP([]byte, []byte, *rtp.Header, int, uint32, bool) ([]byte, error)
 	decryptRTCP([]byte, []byte, uint32, uint32) ([]byte, error)
+
+	// resignRTP recomputes auth tag of SRTP packet in place, after its header was modified.
+	resignRTP(packet []byte, header *rtp.Header, headerLen int, roc uint32, rocInAuthTag bool) ([]byte, error)
+
+	// keystreamRTP returns keystream used for encrypting payload of SRTP packet with given header and ROC.
+	keystreamRTP(header *rtp.Header, payloadLen int, roc uint32) ([]byte, error)
+
+	// srtcpEncryptionEnabled returns true when SRTCP packets are encrypted with the cipher.
+	srtcpEncryptionEnabled() bool
+
+	// clone returns a copy of the cipher which shares immutable derived keys with the original one,
+	// but has its own scratch buffers.
+	clone() srtpCipher
+}
+
+// rtpAuthenticator is implemented by ciphers which can verify auth tag of SRTP packet without decrypting it.
+type rtpAuthenticator interface {
+	authenticateRTP(ciphertext []byte, header *rtp.Header, roc uint32, rocInAuthTag bool) error
+}
+
+// rtpHeaderDecrypter is implemented by ciphers which can authenticate SRTP packet and decrypt its header
+// without decrypting the payload.
+type rtpHeaderDecrypter interface {
+	decryptRTPHeader(
+		dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32, rocInAuthTag bool,
+	) ([]byte, error)
 }
 
 /*
@@ -4,20 +4,39 @@
 package srtp
 
 import (
-	"crypto/aes"
 	"crypto/cipher"
+	"crypto/subtle"
 	"encoding/binary"
 	"fmt"
 
 	"github.com/pion/rtp"
 )
 
+// GCMAdditionalDataFunc returns additional authenticated data (AAD) used by AEAD profiles for SRTP packet
+// with given SSRC and packet index (2^16 * ROC + SEQ), or for SRTCP packet with given SSRC and SRTCP index.
+// aad is the AAD defined by RFC 7714, and fn may return it extended or transformed. aad must not be
+// modified in place, but it is safe to append to it. See GCMAdditionalData option.
+type GCMAdditionalDataFunc func(aad []byte, ssrc uint32, index uint64, isRTCP bool) []byte
+
+const (
+	gcmSessionSaltLen = 12
+	aesCmPRFSaltLen   = 14
+
+	gcmMaxAuthTagLen = 16
+)
+
 type srtpCipherAeadAesGcm struct {
 	protectionProfileWithArgs
 
 	srtpCipher, srtcpCipher cipher.AEAD
 
 	srtpSessionSalt, srtcpSessionSalt []byte
+	// Session keys are kept for conformance testing only.
+	srtpSessionKey, srtcpSessionKey []byte
+
+	// nonceFunc is set by UnsafeGCMNonceSource option, and aadFunc by GCMAdditionalData option.
+	nonceFunc GCMNonceFunc
+	aadFunc   GCMAdditionalDataFunc
 
 	mki []byte
 
@@ -25,9 +44,16 @@ type srtpCipherAeadAesGcm struct {
 
 	useCryptex bool
 
-	// Pre-allocated buffers for IV to avoid heap allocation in hot path
-	rtpIV  [12]byte
-	rtcpIV [12]byte
+	// Session salts in fixed arrays, so IVs are built without slice bounds checks.
+	srtpSaltIV, srtcpSaltIV [gcmSessionSaltLen]byte
+
+	// Pre-allocated buffers for IV, AAD and auth tag to avoid heap allocation in hot path. They are
+	// overwritten by each packet, so clone creates separate ones for each Context.
+	rtpIV      [12]byte
+	rtcpIV     [12]byte
+	rtcpAAD    [srtcpHeaderSize + srtcpIndexSize]byte
+	rtcpTag    [gcmMaxAuthTagLen]byte
+	rtcpAADBuf []byte
 }
 
 func newSrtpCipherAeadAesGcm(
@@ -40,47 +66,62 @@ func newSrtpCipherAeadAesGcm(
 		srtpEncrypted:             encryptSRTP,
 		srtcpEncrypted:            encryptSRTCP,
 		useCryptex:                useCryptex,
+		nonceFunc:                 profile.gcmNonceFunc,
+		aadFunc:                   profile.gcmAADFunc,
 	}
 
-	srtpSessionKey, err := aesCmKeyDerivation(labelSRTPEncryption, masterKey, masterSalt, 0, len(masterKey))
+	// Non-standard master salts longer than 12 bytes (see UnsafeGCMMasterSaltLength) are used
+	// by AES-CM PRF as they are, and 12-byte session salts are derived from them.
+	sessionSaltLen := min(len(masterSalt), gcmSessionSaltLen)
+
+	srtpSessionKey, err := profile.deriveSessionKey(labelSRTPEncryption, masterKey, masterSalt, len(masterKey))
 	if err != nil {
 		return nil, err
 	}
 
-	srtpBlock, err := aes.NewCipher(srtpSessionKey)
+	srtpCipher.srtpSessionKey = srtpSessionKey
+	srtpBlock, err := profile.newBlockCipher(srtpSessionKey)
 	if err != nil {
 		return nil, err
 	}
 
-	srtpCipher.srtpCipher, err = cipher.NewGCM(srtpBlock)
+	authTagLen, err := profile.AEADAuthTagLen()
 	if err != nil {
 		return nil, err
 	}
 
-	srtcpSessionKey, err := aesCmKeyDerivation(labelSRTCPEncryption, masterKey, masterSalt, 0, len(masterKey))
+	srtpCipher.srtpCipher, err = newGCMWithTagLen(srtpBlock, authTagLen)
 	if err != nil {
 		return nil, err
 	}
 
-	srtcpBlock, err := aes.NewCipher(srtcpSessionKey)
+	srtcpSessionKey, err := profile.deriveSessionKey(labelSRTCPEncryption, masterKey, masterSalt, len(masterKey))
 	if err != nil {
 		return nil, err
 	}
 
-	srtpCipher.srtcpCipher, err = cipher.NewGCM(srtcpBlock)
+	srtpCipher.srtcpSessionKey = srtcpSessionKey
+	srtcpBlock, err := profile.newBlockCipher(srtcpSessionKey)
 	if err != nil {
 		return nil, err
 	}
 
-	if srtpCipher.srtpSessionSalt, err = aesCmKeyDerivation(
-		labelSRTPSalt, masterKey, masterSalt, 0, len(masterSalt),
+	srtpCipher.srtcpCipher, err = newGCMWithTagLen(srtcpBlock, authTagLen)
+	if err != nil {
+		return nil, err
+	}
+
+	if srtpCipher.srtpSessionSalt, err = profile.deriveSessionKey(
+		labelSRTPSalt, masterKey, masterSalt, sessionSaltLen,
 	); err != nil {
 		return nil, err
-	} else if srtpCipher.srtcpSessionSalt, err = aesCmKeyDerivation(
-		labelSRTCPSalt, masterKey, masterSalt, 0, len(masterSalt),
+	} else if srtpCipher.srtcpSessionSalt, err = profile.deriveSessionKey(
+		labelSRTCPSalt, masterKey, masterSalt, sessionSaltLen,
 	); err != nil {
 		return nil, err
 	}
+	copy(srtpCipher.srtpSaltIV[:], srtpCipher.srtpSessionSalt)
+	copy(srtpCipher.srtcpSaltIV[:], srtpCipher.srtcpSessionSalt)
 
 	mkiLen := len(mki)
 	if mkiLen > 0 {
@@ -91,6 +132,71 @@ func newSrtpCipherAeadAesGcm(
 	return srtpCipher, nil
 }
 
+// newGCMWithTagLen returns GCM AEAD with authentication tag of given length. crypto/cipher does not
+// support tags shorter than 12 bytes, which are used by AEAD_AES_*_GCM_8 profiles, so such tags are
+// handled by truncatedTagGCM.
+func newGCMWithTagLen(block cipher.Block, tagLen int) (cipher.AEAD, error) {
+	gcm, err := cipher.NewGCM(block)
+	if err != nil || tagLen == gcm.Overhead() {
+		return gcm, err
+	}
+
+	return &truncatedTagGCM{AEAD: gcm, tagLen: tagLen}, nil
+}
+
+// truncatedTagGCM is GCM AEAD with authentication tag truncated to tagLen bytes, see RFC 5116
+// section 5.1. Seal and Open use temporary buffers, so they allocate.
+type truncatedTagGCM struct {
+	cipher.AEAD
+	tagLen int
+}
+
+func (g *truncatedTagGCM) Overhead() int {
+	return g.tagLen
+}
+
+func (g *truncatedTagGCM) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	sealed := g.AEAD.Seal(nil, nonce, plaintext, additionalData)
+
+	return append(dst, sealed[:len(plaintext)+g.tagLen]...)
+}
+
+func (g *truncatedTagGCM) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < g.tagLen {
+		return nil, ErrFailedToVerifyAuthTag
+	}
+	dataLen := len(ciphertext) - g.tagLen
+
+	// GCM encrypts data in CTR mode, and the ciphertext does not depend on AAD. Sealing zeros gives
+	// the keystream. The plaintext is then sealed again to get the expected auth tag.
+	plaintext := g.AEAD.Seal(nil, nonce, make([]byte, dataLen), nil)[:dataLen]
+	for i := range plaintext {
+		plaintext[i] ^= ciphertext[i]
+	}
+	sealed := g.AEAD.Seal(nil, nonce, plaintext, additionalData)
+	if subtle.ConstantTimeCompare(sealed[dataLen:dataLen+g.tagLen], ciphertext[dataLen:]) != 1 {
+		return nil, ErrFailedToVerifyAuthTag
+	}
+
+	return append(dst, plaintext...), nil
+}
+
+func (s *srtpCipherAeadAesGcm) srtcpEncryptionEnabled() bool {
+	return s.srtcpEncrypted
+}
+
+func (s *srtpCipherAeadAesGcm) clone() srtpCipher {
+	// AEAD objects are stateless, only the per-packet buffers need to be separate.
+	clone := *s
+	clone.rtpIV = [12]byte{}
+	clone.rtcpIV = [12]byte{}
+	clone.rtcpAAD = [srtcpHeaderSize + srtcpIndexSize]byte{}
+	clone.rtcpTag = [gcmMaxAuthTagLen]byte{}
+	clone.rtcpAADBuf = nil
+
+	return &clone
+}
+
 func (s *srtpCipherAeadAesGcm) encryptRTP(
 	dst []byte,
 	header *rtp.Header,
@@ -138,7 +244,8 @@ func (s *srtpCipherAeadAesGcm) doEncryptRTP(dst []byte, header *rtp.Header, head
 ) error {
 	s.rtpInitializationVector(header, roc)
 	encrypt := func(dst, plaintext []byte, headerLen int) error {
-		s.srtpCipher.Seal(dst[headerLen:headerLen], s.rtpIV[:], plaintext[headerLen:], plaintext[:headerLen])
+		aad := s.rtpAdditionalData(plaintext[:headerLen], header, roc)
+		s.srtpCipher.Seal(dst[headerLen:headerLen], s.rtpIV[:], plaintext[headerLen:], aad)
 
 		return nil
 	}
@@ -154,13 +261,14 @@ func (s *srtpCipherAeadAesGcm) doEncryptRTP(dst []byte, header *rtp.Header, head
 		if !sameBuffer {
 			copy(dst, plaintext[:headerLen])
 		}
-		s.srtpCipher.Seal(dst[headerLen:headerLen], s.rtpIV[:], plaintext[headerLen:], dst[:headerLen])
+		aad := s.rtpAdditionalData(dst[:headerLen], header, roc)
+		s.srtpCipher.Seal(dst[headerLen:headerLen], s.rtpIV[:], plaintext[headerLen:], aad)
 	default:
 		clearLen := headerLen + payloadLen
 		if !sameBuffer {
 			copy(dst, plaintext)
 		}
-		s.srtpCipher.Seal(dst[clearLen:clearLen], s.rtpIV[:], nil, dst[:clearLen])
+		s.srtpCipher.Seal(dst[clearLen:clearLen], s.rtpIV[:], nil, s.rtpAdditionalData(dst[:clearLen], header, roc))
 	}
 
 	// Add MKI after the encrypted payload
@@ -214,7 +322,8 @@ func (s *srtpCipherAeadAesGcm) doDecryptRTP(dst, ciphertext []byte, header *rtp.
 ) error {
 	s.rtpInitializationVector(header, roc)
 	decrypt := func(dst, ciphertext []byte, headerLen int) error {
-		_, err := s.srtpCipher.Open(dst[headerLen:headerLen], s.rtpIV[:], ciphertext[headerLen:nEnd], ciphertext[:headerLen])
+		aad := s.rtpAdditionalData(ciphertext[:headerLen], header, roc)
+		_, err := s.srtpCipher.Open(dst[headerLen:headerLen], s.rtpIV[:], ciphertext[headerLen:nEnd], aad)
 
 		return err
 	}
@@ -236,7 +345,7 @@ func (s *srtpCipherAeadAesGcm) doDecryptRTP(dst, ciphertext []byte, header *rtp.
 	default:
 		nDataEnd := nEnd - authTagLen
 		if _, err := s.srtpCipher.Open(
-			nil, s.rtpIV[:], ciphertext[nDataEnd:nEnd], ciphertext[:nDataEnd],
+			nil, s.rtpIV[:], ciphertext[nDataEnd:nEnd], s.rtpAdditionalData(ciphertext[:nDataEnd], header, roc),
 		); err != nil {
 			return fmt.Errorf("%w: %w", ErrFailedToVerifyAuthTag, err)
 		}
@@ -249,6 +358,25 @@ func (s *srtpCipherAeadAesGcm) doDecryptRTP(dst, ciphertext []byte, header *rtp.
 	return nil
 }
 
+// resignRTP is not supported: sealing a modified packet again with the same IV would give two auth
+// tags for one nonce, which leaks the GHASH key.
+func (s *srtpCipherAeadAesGcm) resignRTP([]byte, *rtp.Header, int, uint32, bool) ([]byte, error) {
+	return nil, errResignAEAD
+}
+
+func (s *srtpCipherAeadAesGcm) keystreamRTP(header *rtp.Header, payloadLen int, roc uint32) ([]byte, error) {
+	if !s.srtpEncrypted {
+		return nil, errKeystreamNotAvailable
+	}
+
+	// GCM encrypts data in CTR mode, and the ciphertext does not depend on AAD. Sealing zeros gives
+	// the keystream followed by the auth tag.
+	s.rtpInitializationVector(header, roc)
+	sealed := s.srtpCipher.Seal(nil, s.rtpIV[:], make([]byte, payloadLen), nil)
+
+	return sealed[:payloadLen], nil
+}
+
 func (s *srtpCipherAeadAesGcm) encryptRTCP(dst, decrypted []byte, srtcpIndex uint32, ssrc uint32) ([]byte, error) {
 	authTagLen, err := s.AEADAuthTagLen()
 	if err != nil {
@@ -261,14 +389,17 @@ func (s *srtpCipherAeadAesGcm) encryptRTCP(dst, decrypted []byte, srtcpIndex uin
 
 	s.rtcpInitializationVector(srtcpIndex, ssrc)
 	if s.srtcpEncrypted {
-		aad := s.rtcpAdditionalAuthenticatedData(decrypted, srtcpIndex)
+		s.rtcpAdditionalAuthenticatedData(decrypted, srtcpIndex)
 		if !sameBuffer {
 			// Copy the header unencrypted.
 			copy(dst[:srtcpHeaderSize], decrypted[:srtcpHeaderSize])
 		}
 		// Copy index to the proper place.
-		copy(dst[aadPos:aadPos+srtcpIndexSize], aad[8:12])
-		s.srtcpCipher.Seal(dst[srtcpHeaderSize:srtcpHeaderSize], s.rtcpIV[:], decrypted[srtcpHeaderSize:], aad[:])
+		copy(dst[aadPos:aadPos+srtcpIndexSize], s.rtcpAAD[srtcpHeaderSize:])
+		s.srtcpCipher.Seal(
+			dst[srtcpHeaderSize:srtcpHeaderSize], s.rtcpIV[:], decrypted[srtcpHeaderSize:],
+			s.rtcpAdditionalData(s.rtcpAAD[:], ssrc, srtcpIndex),
+		)
 	} else {
 		// Copy the packet unencrypted.
 		if !sameBuffer {
@@ -277,8 +408,9 @@ func (s *srtpCipherAeadAesGcm) encryptRTCP(dst, decrypted []byte, srtcpIndex uin
 		// Append the SRTCP index to the end of the packet - this will form the AAD.
 		binary.BigEndian.PutUint32(dst[len(decrypted):], srtcpIndex)
 		// Generate the authentication tag.
-		tag := make([]byte, authTagLen)
-		s.srtcpCipher.Seal(tag[0:0], s.rtcpIV[:], nil, dst[:len(decrypted)+srtcpIndexSize])
+		tag := s.rtcpTag[:authTagLen]
+		aad := s.rtcpAdditionalData(dst[:len(decrypted)+srtcpIndexSize], ssrc, srtcpIndex)
+		s.srtcpCipher.Seal(tag[0:0], s.rtcpIV[:], nil, aad)
 		// Copy index to the proper place.
 		copy(dst[aadPos:], dst[len(decrypted):len(decrypted)+srtcpIndexSize])
 		// Copy the auth tag after RTCP payload.
@@ -308,18 +440,20 @@ func (s *srtpCipherAeadAesGcm) decryptRTCP(dst, encrypted []byte, srtcpIndex, ss
 	isEncrypted := encrypted[aadPos]&srtcpEncryptionFlag != 0
 	s.rtcpInitializationVector(srtcpIndex, ssrc)
 	if isEncrypted {
-		aad := s.rtcpAdditionalAuthenticatedData(encrypted, srtcpIndex)
+		s.rtcpAdditionalAuthenticatedData(encrypted, srtcpIndex)
 		if _, err := s.srtcpCipher.Open(dst[srtcpHeaderSize:srtcpHeaderSize], s.rtcpIV[:], encrypted[srtcpHeaderSize:aadPos],
-			aad[:]); err != nil {
+			s.rtcpAdditionalData(s.rtcpAAD[:], ssrc, srtcpIndex)); err != nil {
 			return nil, fmt.Errorf("%w: %w", ErrFailedToVerifyAuthTag, err)
 		}
 	} else {
-		// Prepare AAD for received packet.
+		// Prepare AAD for received packet in the buffer kept by the cipher.
 		dataEnd := aadPos - authTagLen
-		aad := make([]byte, dataEnd+4)
+		s.rtcpAADBuf = growBufferSize(s.rtcpAADBuf, dataEnd+srtcpIndexSize)
+		aad := s.rtcpAADBuf
 		copy(aad, encrypted[:dataEnd])
-		copy(aad[dataEnd:], encrypted[aadPos:aadPos+4])
+		copy(aad[dataEnd:], encrypted[aadPos:aadPos+srtcpIndexSize])
 		// Verify the auth tag.
+		aad = s.rtcpAdditionalData(aad, ssrc, srtcpIndex)
 		if _, err := s.srtcpCipher.Open(nil, s.rtcpIV[:], encrypted[dataEnd:aadPos], aad); err != nil {
 			return nil, fmt.Errorf("%w: %w", ErrFailedToVerifyAuthTag, err)
 		}
@@ -344,14 +478,13 @@ func (s *srtpCipherAeadAesGcm) decryptRTCP(dst, encrypted []byte, srtcpIndex, ss
 //
 // https://tools.ietf.org/html/rfc7714#section-8.1
 func (s *srtpCipherAeadAesGcm) rtpInitializationVector(header *rtp.Header, roc uint32) {
-	s.rtpIV = [12]byte{}
-	binary.BigEndian.PutUint32(s.rtpIV[2:], header.SSRC)
-	binary.BigEndian.PutUint32(s.rtpIV[6:], roc)
-	binary.BigEndian.PutUint16(s.rtpIV[10:], header.SequenceNumber)
+	index := uint64(roc)<<16 | uint64(header.SequenceNumber)
+	if s.nonceFunc != nil {
+		s.rtpIV = s.nonceFunc(s.srtpSaltIV, header.SSRC, index, false)
 
-	for i := range s.rtpIV {
-		s.rtpIV[i] ^= s.srtpSessionSalt[i]
+		return
 	}
+	buildGCMNonce(&s.rtpIV, &s.srtpSaltIV, header.SSRC, index, false)
 }
 
 // The 12-octet IV used by AES-GCM SRTCP is formed by first
@@ -362,13 +495,12 @@ func (s *srtpCipherAeadAesGcm) rtpInitializationVector(header *rtp.Header, roc u
 //
 // https://tools.ietf.org/html/rfc7714#section-9.1
 func (s *srtpCipherAeadAesGcm) rtcpInitializationVector(srtcpIndex uint32, ssrc uint32) {
-	s.rtcpIV = [12]byte{}
-	binary.BigEndian.PutUint32(s.rtcpIV[2:], ssrc)
-	binary.BigEndian.PutUint32(s.rtcpIV[8:], srtcpIndex)
+	if s.nonceFunc != nil {
+		s.rtcpIV = s.nonceFunc(s.srtcpSaltIV, ssrc, uint64(srtcpIndex), true)
 
-	for i := range s.rtcpIV {
-		s.rtcpIV[i] ^= s.srtcpSessionSalt[i]
+		return
 	}
+	buildGCMNonce(&s.rtcpIV, &s.srtcpSaltIV, ssrc, uint64(srtcpIndex), true)
 }
 
 // In an SRTCP packet, a 1-bit Encryption flag is prepended to the
@@ -376,14 +508,31 @@ func (s *srtpCipherAeadAesGcm) rtcpInitializationVector(srtcpIndex uint32, ssrc
 // "ESRTCP word"
 //
 // https://tools.ietf.org/html/rfc7714#section-17
-func (s *srtpCipherAeadAesGcm) rtcpAdditionalAuthenticatedData(rtcpPacket []byte, srtcpIndex uint32) [12]byte {
-	var aad [12]byte
+//
+// AAD is written to rtcpAAD buffer of the cipher.
+func (s *srtpCipherAeadAesGcm) rtcpAdditionalAuthenticatedData(rtcpPacket []byte, srtcpIndex uint32) {
+	copy(s.rtcpAAD[:], rtcpPacket[:srtcpHeaderSize])
+	binary.BigEndian.PutUint32(s.rtcpAAD[srtcpHeaderSize:], srtcpIndex)
+	s.rtcpAAD[srtcpHeaderSize] |= srtcpEncryptionFlag
+}
+
+// rtpAdditionalData returns AAD of SRTP packet, customized by GCMAdditionalData option.
+// Capacity of aad is limited, so appending to it does not overwrite the packet.
+func (s *srtpCipherAeadAesGcm) rtpAdditionalData(aad []byte, header *rtp.Header, roc uint32) []byte {
+	if s.aadFunc == nil {
+		return aad
+	}
 
-	copy(aad[:], rtcpPacket[:8])
-	binary.BigEndian.PutUint32(aad[8:], srtcpIndex)
-	aad[8] |= srtcpEncryptionFlag
+	return s.aadFunc(aad[:len(aad):len(aad)], header.SSRC, uint64(roc)<<16|uint64(header.SequenceNumber), false)
+}
+
+// rtcpAdditionalData returns AAD of SRTCP packet, customized by GCMAdditionalData option.
+func (s *srtpCipherAeadAesGcm) rtcpAdditionalData(aad []byte, ssrc, srtcpIndex uint32) []byte {
+	if s.aadFunc == nil {
+		return aad
+	}
 
-	return aad
+	return s.aadFunc(aad[:len(aad):len(aad)], ssrc, uint64(srtcpIndex), true)
 }
 
 func (s *srtpCipherAeadAesGcm) getRTCPIndex(in []byte) uint32 {
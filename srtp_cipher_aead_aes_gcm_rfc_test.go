@@ -123,9 +123,36 @@ func createRfcAeadTestCiphers(t *testing.T) []testRfcAeadCipher {
 	aes256Gcm.keys.srtcpSessionSalt = aes256Gcm.keys.srtpSessionSalt
 	tests = append(tests, aes256Gcm)
 
+	// GCM with 8-byte tag gives the same output with the tag truncated to its first 8 bytes.
+	tests = append(tests,
+		truncateRfcAeadTestCipherTag(aes128Gcm, ProtectionProfileAeadAes128Gcm8),
+		truncateRfcAeadTestCipherTag(aes256Gcm, ProtectionProfileAeadAes256Gcm8),
+	)
+
 	return tests
 }
 
+// truncateRfcAeadTestCipherTag converts test case with 16-byte tag to one with 8-byte tag.
+func truncateRfcAeadTestCipherTag(testCase testRfcAeadCipher, profile ProtectionProfile) testRfcAeadCipher {
+	const truncatedLen = 8
+	truncateRTP := func(packet []byte) []byte {
+		return packet[:len(packet)-truncatedLen]
+	}
+	truncateRTCP := func(packet []byte) []byte {
+		tagEnd := len(packet) - srtcpIndexSize
+
+		return append(append([]byte{}, packet[:tagEnd-truncatedLen]...), packet[tagEnd:]...)
+	}
+
+	testCase.profile = profile
+	testCase.encryptedRTPPacket = truncateRTP(testCase.encryptedRTPPacket)
+	testCase.authenticatedRTPPacket = truncateRTP(testCase.authenticatedRTPPacket)
+	testCase.encryptedRTCPPacket = truncateRTCP(testCase.encryptedRTCPPacket)
+	testCase.authenticatedRTCPPacket = truncateRTCP(testCase.authenticatedRTCPPacket)
+
+	return testCase
+}
+
 func TestAeadCiphersWithRfcTestVectors(t *testing.T) {
 	for _, testCase := range createRfcAeadTestCiphers(t) {
 		t.Run(testCase.profile.String(), func(t *testing.T) {
@@ -227,3 +254,33 @@ func TestAeadCiphersWithRfcTestVectors(t *testing.T) {
 		})
 	}
 }
+
+func TestAeadCipherWithShortTagInPlace(t *testing.T) {
+	for _, profile := range []ProtectionProfile{ProtectionProfileAeadAes128Gcm8, ProtectionProfileAeadAes256Gcm8} {
+		t.Run(profile.String(), func(t *testing.T) {
+			keyLen, err := profile.KeyLen()
+			assert.NoError(t, err)
+			encryptCtx, err := CreateContext(make([]byte, keyLen), make([]byte, 12), profile)
+			assert.NoError(t, err)
+			decryptCtx, err := CreateContext(make([]byte, keyLen), make([]byte, 12), profile)
+			assert.NoError(t, err)
+
+			plaintext := fromHex(t, `8040f17b 8041f8d3 5501a0b2 47616c6c 69612065`)
+			buf := make([]byte, len(plaintext), len(plaintext)+8)
+			copy(buf, plaintext)
+			encrypted, err := encryptCtx.EncryptRTP(buf, buf, nil)
+			assert.NoError(t, err)
+			assert.Same(t, &buf[0], &encrypted[0])
+			assert.Len(t, encrypted, len(plaintext)+8)
+
+			tampered := append([]byte{}, encrypted...)
+			tampered[len(tampered)-1] ^= 0x01
+			_, err = decryptCtx.DecryptRTP(nil, tampered, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			decrypted, err := decryptCtx.DecryptRTP(encrypted, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		})
+	}
+}
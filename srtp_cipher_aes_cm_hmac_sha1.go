@@ -4,10 +4,8 @@
 package srtp
 
 import (
-	"crypto/aes"
 	"crypto/cipher"
 	"crypto/hmac"
-	"crypto/sha1" //nolint:gosec
 	"crypto/subtle"
 	"encoding/binary"
 	"hash"
@@ -18,23 +16,34 @@ import (
 type srtpCipherAesCmHmacSha1 struct {
 	protectionProfileWithArgs
 
-	srtpSessionSalt []byte
-	srtpSessionAuth hash.Hash
-	srtpBlock       cipher.Block
-	srtpEncrypted   bool
+	srtpSessionKey     []byte
+	srtpSessionSalt    []byte
+	srtpSessionAuthKey []byte
+	srtpSessionAuth    hash.Hash
+	srtpBlock          cipher.Block
+	srtpEncrypted      bool
 
-	srtcpSessionSalt []byte
-	srtcpSessionAuth hash.Hash
-	srtcpBlock       cipher.Block
-	srtcpEncrypted   bool
+	srtcpSessionKey     []byte
+	srtcpSessionSalt    []byte
+	srtcpSessionAuthKey []byte
+	srtcpSessionAuth    hash.Hash
+	srtcpBlock          cipher.Block
+	srtcpEncrypted      bool
+
+	// srtpF8Block and srtcpF8Block are set for f8 profiles only. They encrypt f8 IVs, and are keyed with
+	// session keys XORed with masking keys, see RFC 3711 section 4.1.2.1.
+	srtpF8Block, srtcpF8Block cipher.Block
 
 	mki []byte
 
 	useCryptex bool
 
+	// headerExtensionEncryption is nil when RFC 6904 header extension encryption is disabled.
+	headerExtensionEncryption *headerExtensionEncryption
+
 	// Pre-allocated buffers for auth tag to avoid heap allocation in hot path.
-	authBuf     [4 + sha1.Size]byte // 4 bytes ROC + 20 bytes SHA1
-	rtcpAuthBuf [sha1.Size]byte
+	authBuf     [4 + maxAuthDigestSize]byte // 4 bytes ROC + HMAC digest
+	rtcpAuthBuf [maxAuthDigestSize]byte
 }
 
 //nolint:cyclop
@@ -57,47 +66,61 @@ func newSrtpCipherAesCmHmacSha1(
 		useCryptex:                useCryptex,
 	}
 
-	srtpSessionKey, err := aesCmKeyDerivation(labelSRTPEncryption, masterKey, masterSalt, 0, len(masterKey))
+	srtpSessionKey, err := profile.deriveSessionKey(labelSRTPEncryption, masterKey, masterSalt, len(masterKey))
 	if err != nil {
 		return nil, err
-	} else if srtpCipher.srtpBlock, err = aes.NewCipher(srtpSessionKey); err != nil {
+	} else if srtpCipher.srtpBlock, err = profile.newBlockCipher(srtpSessionKey); err != nil {
 		return nil, err
 	}
 
-	srtcpSessionKey, err := aesCmKeyDerivation(labelSRTCPEncryption, masterKey, masterSalt, 0, len(masterKey))
+	srtcpSessionKey, err := profile.deriveSessionKey(labelSRTCPEncryption, masterKey, masterSalt, len(masterKey))
 	if err != nil {
 		return nil, err
-	} else if srtpCipher.srtcpBlock, err = aes.NewCipher(srtcpSessionKey); err != nil {
+	} else if srtpCipher.srtcpBlock, err = profile.newBlockCipher(srtcpSessionKey); err != nil {
 		return nil, err
 	}
+	// Session keys are kept for conformance testing only.
+	srtpCipher.srtpSessionKey, srtpCipher.srtcpSessionKey = srtpSessionKey, srtcpSessionKey
 
-	if srtpCipher.srtpSessionSalt, err = aesCmKeyDerivation(
-		labelSRTPSalt, masterKey, masterSalt, 0, len(masterSalt),
+	if srtpCipher.srtpSessionSalt, err = profile.deriveSessionKey(
+		labelSRTPSalt, masterKey, masterSalt, len(masterSalt),
 	); err != nil {
 		return nil, err
-	} else if srtpCipher.srtcpSessionSalt, err = aesCmKeyDerivation(
-		labelSRTCPSalt, masterKey, masterSalt, 0, len(masterSalt),
+	} else if srtpCipher.srtcpSessionSalt, err = profile.deriveSessionKey(
+		labelSRTCPSalt, masterKey, masterSalt, len(masterSalt),
 	); err != nil {
 		return nil, err
 	}
 
+	if profile.isF8() {
+		if srtpCipher.srtpF8Block, err = newF8IVBlock(profile, srtpSessionKey, srtpCipher.srtpSessionSalt); err != nil {
+			return nil, err
+		} else if srtpCipher.srtcpF8Block, err = newF8IVBlock(
+			profile, srtcpSessionKey, srtpCipher.srtcpSessionSalt,
+		); err != nil {
+			return nil, err
+		}
+	}
+
 	authKeyLen, err := profile.AuthKeyLen()
 	if err != nil {
 		return nil, err
 	}
 
-	srtpSessionAuthTag, err := aesCmKeyDerivation(labelSRTPAuthenticationTag, masterKey, masterSalt, 0, authKeyLen)
+	srtpSessionAuthTag, err := profile.deriveSessionKey(labelSRTPAuthenticationTag, masterKey, masterSalt, authKeyLen)
 	if err != nil {
 		return nil, err
 	}
 
-	srtcpSessionAuthTag, err := aesCmKeyDerivation(labelSRTCPAuthenticationTag, masterKey, masterSalt, 0, authKeyLen)
+	srtcpSessionAuthTag, err := profile.deriveSessionKey(labelSRTCPAuthenticationTag, masterKey, masterSalt, authKeyLen)
 	if err != nil {
 		return nil, err
 	}
 
-	srtpCipher.srtcpSessionAuth = hmac.New(sha1.New, srtcpSessionAuthTag)
-	srtpCipher.srtpSessionAuth = hmac.New(sha1.New, srtpSessionAuthTag)
+	srtpCipher.srtcpSessionAuthKey = srtcpSessionAuthTag
+	srtpCipher.srtpSessionAuthKey = srtpSessionAuthTag
+	srtpCipher.srtcpSessionAuth = hmac.New(profile.authHashFunc(), srtcpSessionAuthTag)
+	srtpCipher.srtpSessionAuth = hmac.New(profile.authHashFunc(), srtpSessionAuthTag)
 
 	mkiLen := len(mki)
 	if mkiLen > 0 {
@@ -108,6 +131,34 @@ func newSrtpCipherAesCmHmacSha1(
 	return srtpCipher, nil
 }
 
+func (s *srtpCipherAesCmHmacSha1) srtcpEncryptionEnabled() bool {
+	return s.srtcpEncrypted
+}
+
+func (s *srtpCipherAesCmHmacSha1) clone() srtpCipher {
+	// HMAC objects keep internal state, so they cannot be shared. AES blocks are stateless.
+	return &srtpCipherAesCmHmacSha1{
+		protectionProfileWithArgs: s.protectionProfileWithArgs,
+		srtpSessionKey:            s.srtpSessionKey,
+		srtpSessionSalt:           s.srtpSessionSalt,
+		srtpSessionAuthKey:        s.srtpSessionAuthKey,
+		srtpSessionAuth:           hmac.New(s.authHashFunc(), s.srtpSessionAuthKey),
+		srtpBlock:                 s.srtpBlock,
+		srtpEncrypted:             s.srtpEncrypted,
+		srtcpSessionKey:           s.srtcpSessionKey,
+		srtcpSessionSalt:          s.srtcpSessionSalt,
+		srtcpSessionAuthKey:       s.srtcpSessionAuthKey,
+		srtcpSessionAuth:          hmac.New(s.authHashFunc(), s.srtcpSessionAuthKey),
+		srtcpBlock:                s.srtcpBlock,
+		srtcpEncrypted:            s.srtcpEncrypted,
+		srtpF8Block:               s.srtpF8Block,
+		srtcpF8Block:              s.srtcpF8Block,
+		mki:                       s.mki,
+		useCryptex:                s.useCryptex,
+		headerExtensionEncryption: s.headerExtensionEncryption,
+	}
+}
+
 func (s *srtpCipherAesCmHmacSha1) encryptRTP(
 	dst []byte,
 	header *rtp.Header,
@@ -152,9 +203,7 @@ func (s *srtpCipherAesCmHmacSha1) doEncryptRTP(dst []byte, header *rtp.Header, h
 	roc uint32, rocInAuthTag bool, sameBuffer bool, payloadLen int,
 ) error {
 	encrypt := func(dst, plaintext []byte, headerLen int) error {
-		counter := generateCounter(header.SequenceNumber, roc, header.SSRC, s.srtpSessionSalt)
-
-		return xorBytesCTR(s.srtpBlock, counter[:], dst[headerLen:], plaintext[headerLen:])
+		return s.xorRTPPayload(dst[headerLen:], plaintext[headerLen:], header, roc)
 	}
 
 	var err error
@@ -166,6 +215,11 @@ func (s *srtpCipherAesCmHmacSha1) doEncryptRTP(dst []byte, header *rtp.Header, h
 		if !sameBuffer {
 			copy(dst, plaintext[:headerLen])
 		}
+		if s.headerExtensionEncryption != nil {
+			if err = s.headerExtensionEncryption.apply(dst, header, headerLen, roc); err != nil {
+				return err
+			}
+		}
 		// Encrypt the payload
 		err = encrypt(dst, plaintext, headerLen)
 	case !sameBuffer:
@@ -202,11 +256,32 @@ func (s *srtpCipherAesCmHmacSha1) decryptRTP(
 	roc uint32,
 	rocInAuthTag bool,
 ) ([]byte, error) {
-	// Split the auth tag and the cipher text into two parts.
 	authTagLen, err := s.AuthTagRTPLen()
 	if err != nil {
 		return nil, err
 	}
+	if err = s.authenticateRTP(ciphertext, header, roc, rocInAuthTag); err != nil {
+		return nil, err
+	}
+	ciphertext = ciphertext[:len(ciphertext)-len(s.mki)-authTagLen]
+
+	sameBuffer := isSameBuffer(dst, ciphertext)
+
+	err = s.doDecryptRTP(dst, ciphertext, header, headerLen, roc, sameBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+func (s *srtpCipherAesCmHmacSha1) authenticateRTP(
+	ciphertext []byte, _ *rtp.Header, roc uint32, rocInAuthTag bool,
+) error {
+	authTagLen, err := s.AuthTagRTPLen()
+	if err != nil {
+		return err
+	}
 
 	// Split the auth tag and the cipher text into two parts.
 	actualTag := ciphertext[len(ciphertext)-authTagLen:]
@@ -215,19 +290,30 @@ func (s *srtpCipherAesCmHmacSha1) decryptRTP(
 	// Generate the auth tag we expect to see from the ciphertext.
 	expectedTag, err := s.generateSrtpAuthTag(ciphertext, roc, rocInAuthTag)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// See if the auth tag actually matches.
 	// We use a constant time comparison to prevent timing attacks.
 	if subtle.ConstantTimeCompare(actualTag, expectedTag) != 1 {
-		return nil, ErrFailedToVerifyAuthTag
+		return ErrFailedToVerifyAuthTag
 	}
 
-	sameBuffer := isSameBuffer(dst, ciphertext)
+	return nil
+}
 
-	err = s.doDecryptRTP(dst, ciphertext, header, headerLen, roc, sameBuffer)
-	if err != nil {
+// decryptRTPHeader authenticates SRTP packet and decrypts its header only. Keystream is generated for
+// the header only, i.e. for CSRCs and header extensions encrypted with Cryptex.
+func (s *srtpCipherAesCmHmacSha1) decryptRTPHeader(
+	dst, ciphertext []byte, header *rtp.Header, headerLen int, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	if err := s.authenticateRTP(ciphertext, header, roc, rocInAuthTag); err != nil {
+		return nil, err
+	}
+
+	dst = growBufferSize(dst, headerLen)
+	sameBuffer := isSameBuffer(dst, ciphertext)
+	if err := s.doDecryptRTP(dst, ciphertext[:headerLen], header, headerLen, roc, sameBuffer); err != nil {
 		return nil, err
 	}
 
@@ -238,9 +324,7 @@ func (s *srtpCipherAesCmHmacSha1) doDecryptRTP(dst, ciphertext []byte, header *r
 	sameBuffer bool,
 ) error {
 	decrypt := func(dst, ciphertext []byte, headerLen int) error {
-		counter := generateCounter(header.SequenceNumber, roc, header.SSRC, s.srtpSessionSalt)
-
-		return xorBytesCTR(s.srtpBlock, counter[:], dst[headerLen:], ciphertext[headerLen:])
+		return s.xorRTPPayload(dst[headerLen:], ciphertext[headerLen:], header, roc)
 	}
 
 	switch {
@@ -254,6 +338,15 @@ func (s *srtpCipherAesCmHmacSha1) doDecryptRTP(dst, ciphertext []byte, header *r
 		if !sameBuffer {
 			copy(dst, ciphertext[:headerLen])
 		}
+		if s.headerExtensionEncryption != nil {
+			if err := s.headerExtensionEncryption.apply(dst, header, headerLen, roc); err != nil {
+				return err
+			}
+			// Update header extensions with decrypted values.
+			if _, err := header.Unmarshal(dst); err != nil {
+				return err
+			}
+		}
 
 		// Decrypt the ciphertext for the payload.
 		err := decrypt(dst, ciphertext, headerLen)
@@ -268,6 +361,39 @@ func (s *srtpCipherAesCmHmacSha1) doDecryptRTP(dst, ciphertext []byte, header *r
 	return nil
 }
 
+func (s *srtpCipherAesCmHmacSha1) resignRTP(
+	packet []byte, _ *rtp.Header, _ int, roc uint32, rocInAuthTag bool,
+) ([]byte, error) {
+	authTagLen, err := s.AuthTagRTPLen()
+	if err != nil {
+		return nil, err
+	}
+
+	// Payload is encrypted independently of the header, so only the auth tag needs to be updated.
+	n := len(packet) - len(s.mki) - authTagLen
+	authTag, err := s.generateSrtpAuthTag(packet[:n], roc, rocInAuthTag)
+	if err != nil {
+		return nil, err
+	}
+	copy(packet[n+len(s.mki):], authTag)
+
+	return packet, nil
+}
+
+func (s *srtpCipherAesCmHmacSha1) keystreamRTP(header *rtp.Header, payloadLen int, roc uint32) ([]byte, error) {
+	if !s.srtpEncrypted {
+		return nil, errKeystreamNotAvailable
+	}
+
+	// Encrypting zeros gives the keystream.
+	keystream := make([]byte, payloadLen)
+	if err := s.xorRTPPayload(keystream, keystream, header, roc); err != nil {
+		return nil, err
+	}
+
+	return keystream, nil
+}
+
 func (s *srtpCipherAesCmHmacSha1) encryptRTCP(dst, decrypted []byte, srtcpIndex uint32, ssrc uint32) ([]byte, error) {
 	authTagLen, err := s.AuthTagRTCPLen()
 	if err != nil {
@@ -286,8 +412,9 @@ func (s *srtpCipherAesCmHmacSha1) encryptRTCP(dst, decrypted []byte, srtcpIndex
 
 	// Encrypt everything after header
 	if s.srtcpEncrypted {
-		counter := generateCounter(uint16(srtcpIndex&0xffff), srtcpIndex>>16, ssrc, s.srtcpSessionSalt) //nolint:gosec // G115
-		if err = xorBytesCTR(s.srtcpBlock, counter[:], dst[srtcpHeaderSize:], decrypted[srtcpHeaderSize:]); err != nil {
+		if err = s.xorRTCPPayload(
+			dst[srtcpHeaderSize:], decrypted[srtcpHeaderSize:], decrypted[:srtcpHeaderSize], srtcpIndex, ssrc,
+		); err != nil {
 			return nil, err
 		}
 
@@ -355,8 +482,9 @@ func (s *srtpCipherAesCmHmacSha1) decryptRTCP(dst, encrypted []byte, index, ssrc
 
 	isEncrypted := encrypted[decryptedLen]&srtcpEncryptionFlag != 0
 	if isEncrypted {
-		counter := generateCounter(uint16(index&0xffff), index>>16, ssrc, s.srtcpSessionSalt) //nolint:gosec // G115
-		err = xorBytesCTR(s.srtcpBlock, counter[:], dst[srtcpHeaderSize:], encrypted[srtcpHeaderSize:decryptedLen])
+		err = s.xorRTCPPayload(
+			dst[srtcpHeaderSize:], encrypted[srtcpHeaderSize:decryptedLen], encrypted[:srtcpHeaderSize], index, ssrc,
+		)
 	} else if !sameBuffer {
 		copy(dst[srtcpHeaderSize:], encrypted[srtcpHeaderSize:])
 	}
@@ -364,6 +492,31 @@ func (s *srtpCipherAesCmHmacSha1) decryptRTCP(dst, encrypted []byte, index, ssrc
 	return dst, err
 }
 
+// xorRTPPayload encrypts or decrypts payload of SRTP packet with AES-CM or f8 keystream.
+func (s *srtpCipherAesCmHmacSha1) xorRTPPayload(dst, src []byte, header *rtp.Header, roc uint32) error {
+	if s.srtpF8Block != nil {
+		iv := f8RTPIV(header, roc)
+
+		return xorBytesF8(s.srtpBlock, s.srtpF8Block, iv[:], dst, src)
+	}
+	counter := generateCounter(header.SequenceNumber, roc, header.SSRC, s.srtpSessionSalt)
+
+	return xorBytesCTR(s.srtpBlock, counter[:], dst, src, s.fips)
+}
+
+// xorRTCPPayload encrypts or decrypts payload of SRTCP packet with AES-CM or f8 keystream. rtcpHeader
+// is the first 8 bytes of the packet, which are used by f8 IV.
+func (s *srtpCipherAesCmHmacSha1) xorRTCPPayload(dst, src, rtcpHeader []byte, srtcpIndex, ssrc uint32) error {
+	if s.srtcpF8Block != nil {
+		iv := f8RTCPIV(rtcpHeader, srtcpIndex)
+
+		return xorBytesF8(s.srtcpBlock, s.srtcpF8Block, iv[:], dst, src)
+	}
+	counter := generateCounter(uint16(srtcpIndex&0xffff), srtcpIndex>>16, ssrc, s.srtcpSessionSalt) //nolint:gosec // G115
+
+	return xorBytesCTR(s.srtcpBlock, counter[:], dst, src, s.fips)
+}
+
 func (s *srtpCipherAesCmHmacSha1) generateSrtpAuthTag(buf []byte, roc uint32, rocInAuthTag bool) ([]byte, error) {
 	// https://tools.ietf.org/html/rfc3711#section-4.2
 	// In the case of SRTP, M SHALL consist of the Authenticated
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"encoding/binary"
+
+	"github.com/pion/rtp"
+)
+
+const (
+	// ohbConfigLen is the length of mandatory Config byte of Original Header Block.
+	ohbConfigLen = 1
+
+	ohbConfigReservedMask  = 0xf0
+	ohbConfigMarkerPresent = 0x08
+	ohbConfigMarkerValue   = 0x04
+	ohbConfigPTPresent     = 0x02
+	ohbConfigSeqPresent    = 0x01
+)
+
+// srtpCipherDoubleAeadAesGcm implements double encryption transform from RFC 8723, used in PERC.
+// SRTP packets are encrypted twice: first with the inner (end-to-end) AES-GCM transform, and then
+// with the outer (hop-by-hop) one. The outer transform also protects Original Header Block (OHB),
+// which Media Distributors use to restore original values of header fields changed on the way.
+// SRTCP packets are protected by the outer transform only.
+//
+// See https://www.rfc-editor.org/rfc/rfc8723
+type srtpCipherDoubleAeadAesGcm struct {
+	protectionProfileWithArgs
+
+	inner, outer *srtpCipherAeadAesGcm
+}
+
+func newSrtpCipherDoubleAeadAesGcm(
+	profile protectionProfileWithArgs,
+	masterKey, masterSalt, mki []byte,
+	encryptSRTP, encryptSRTCP bool,
+) (*srtpCipherDoubleAeadAesGcm, error) {
+	singleProfile := protectionProfileWithArgs{
+		ProtectionProfile: profile.singleAEADProfile(),
+		indexOverKdr:      profile.indexOverKdr,
+	}
+
+	// Master key and salt are concatenations of inner and outer ones, see RFC 8723 section 5.2.
+	keyLen, saltLen := len(masterKey)/2, len(masterSalt)/2
+	inner, err := newSrtpCipherAeadAesGcm(
+		singleProfile, masterKey[:keyLen], masterSalt[:saltLen], nil, encryptSRTP, encryptSRTCP, false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	outer, err := newSrtpCipherAeadAesGcm(
+		singleProfile, masterKey[keyLen:], masterSalt[saltLen:], mki, encryptSRTP, encryptSRTCP, false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &srtpCipherDoubleAeadAesGcm{
+		protectionProfileWithArgs: profile,
+		inner:                     inner,
+		outer:                     outer,
+	}, nil
+}
+
+func (s *srtpCipherDoubleAeadAesGcm) srtcpEncryptionEnabled() bool {
+	return s.outer.srtcpEncryptionEnabled()
+}
+
+func (s *srtpCipherDoubleAeadAesGcm) clone() srtpCipher {
+	clone := *s
+	clone.inner, _ = s.inner.clone().(*srtpCipherAeadAesGcm)
+	clone.outer, _ = s.outer.clone().(*srtpCipherAeadAesGcm)
+
+	return &clone
+}
+
+func (s *srtpCipherDoubleAeadAesGcm) getRTCPIndex(in []byte) uint32 {
+	return s.outer.getRTCPIndex(in)
+}
+
+// innerRTPHeader returns header used by the inner transform. Header extensions are not protected
+// end to end, so they are removed from it.
+func innerRTPHeader(header *rtp.Header) rtp.Header {
+	inner := header.Clone()
+	inner.Extension = false
+	inner.ExtensionProfile = 0
+	inner.Extensions = nil
+
+	return inner
+}
+
+func (s *srtpCipherDoubleAeadAesGcm) encryptRTP(
+	dst []byte,
+	header *rtp.Header,
+	headerLen int,
+	plaintext []byte,
+	roc uint32,
+	rocInAuthTag bool,
+) ([]byte, error) {
+	innerHeader := innerRTPHeader(header)
+	innerHeaderLen := innerHeader.MarshalSize()
+	innerPlaintext := make([]byte, innerHeaderLen+len(plaintext)-headerLen)
+	if _, err := innerHeader.MarshalTo(innerPlaintext); err != nil {
+		return nil, err
+	}
+	copy(innerPlaintext[innerHeaderLen:], plaintext[headerLen:])
+
+	innerCiphertext, err := s.inner.encryptRTP(nil, &innerHeader, innerHeaderLen, innerPlaintext, roc, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Outer plaintext is the original header, inner ciphertext with its auth tag, and empty OHB.
+	innerPayloadLen := len(innerCiphertext) - innerHeaderLen
+	outerPlaintext := make([]byte, headerLen+innerPayloadLen+ohbConfigLen)
+	copy(outerPlaintext, plaintext[:headerLen])
+	copy(outerPlaintext[headerLen:], innerCiphertext[innerHeaderLen:])
+
+	return s.outer.encryptRTP(dst, header, headerLen, outerPlaintext, roc, rocInAuthTag)
+}
+
+func (s *srtpCipherDoubleAeadAesGcm) decryptRTP(
+	dst, ciphertext []byte,
+	header *rtp.Header,
+	headerLen int,
+	roc uint32,
+	rocInAuthTag bool,
+) ([]byte, error) {
+	outerPlaintext, err := s.outer.decryptRTP(nil, ciphertext, header, headerLen, roc, rocInAuthTag)
+	if err != nil {
+		return nil, err
+	}
+
+	innerHeader := innerRTPHeader(header)
+	innerPayload, err := applyOriginalHeaderBlock(&innerHeader, outerPlaintext[headerLen:])
+	if err != nil {
+		return nil, err
+	}
+
+	innerHeaderLen := innerHeader.MarshalSize()
+	innerCiphertext := make([]byte, innerHeaderLen+len(innerPayload))
+	if _, err = innerHeader.MarshalTo(innerCiphertext); err != nil {
+		return nil, err
+	}
+	copy(innerCiphertext[innerHeaderLen:], innerPayload)
+
+	innerPlaintext, err := s.inner.decryptRTP(nil, innerCiphertext, &innerHeader, innerHeaderLen, roc, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Output packet keeps the received header, possibly modified by Media Distributor.
+	dst = growBufferSize(dst, headerLen+len(innerPlaintext)-innerHeaderLen)
+	copy(dst, outerPlaintext[:headerLen])
+	copy(dst[headerLen:], innerPlaintext[innerHeaderLen:])
+
+	return dst, nil
+}
+
+// applyOriginalHeaderBlock parses OHB at the end of outer plaintext payload, restores original header
+// values in the header, and returns inner ciphertext which precedes OHB.
+func applyOriginalHeaderBlock(header *rtp.Header, payload []byte) ([]byte, error) {
+	if len(payload) < ohbConfigLen {
+		return nil, errInvalidOHB
+	}
+	config := payload[len(payload)-ohbConfigLen]
+	if config&ohbConfigReservedMask != 0 {
+		return nil, errInvalidOHB
+	}
+
+	ohbLen := ohbConfigLen
+	if config&ohbConfigPTPresent != 0 {
+		ohbLen++
+	}
+	if config&ohbConfigSeqPresent != 0 {
+		ohbLen += 2
+	}
+	if len(payload) < ohbLen {
+		return nil, errInvalidOHB
+	}
+
+	pos := len(payload) - ohbLen
+	if config&ohbConfigPTPresent != 0 {
+		header.PayloadType = payload[pos] & 0x7f
+		pos++
+	}
+	if config&ohbConfigSeqPresent != 0 {
+		header.SequenceNumber = binary.BigEndian.Uint16(payload[pos:])
+	}
+	if config&ohbConfigMarkerPresent != 0 {
+		header.Marker = config&ohbConfigMarkerValue != 0
+	}
+
+	return payload[:len(payload)-ohbLen], nil
+}
+
+// resignRTP is not supported, the outer transform is AEAD too.
+func (s *srtpCipherDoubleAeadAesGcm) resignRTP([]byte, *rtp.Header, int, uint32, bool) ([]byte, error) {
+	return nil, errResignAEAD
+}
+
+// keystreamRTP returns keystream of the outer transform.
+func (s *srtpCipherDoubleAeadAesGcm) keystreamRTP(header *rtp.Header, payloadLen int, roc uint32) ([]byte, error) {
+	return s.outer.keystreamRTP(header, payloadLen, roc)
+}
+
+func (s *srtpCipherDoubleAeadAesGcm) encryptRTCP(dst, decrypted []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	return s.outer.encryptRTCP(dst, decrypted, srtcpIndex, ssrc)
+}
+
+func (s *srtpCipherDoubleAeadAesGcm) decryptRTCP(dst, encrypted []byte, srtcpIndex, ssrc uint32) ([]byte, error) {
+	return s.outer.decryptRTCP(dst, encrypted, srtcpIndex, ssrc)
+}
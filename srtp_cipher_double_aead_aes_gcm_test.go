@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func buildDoubleAeadTestContext(t *testing.T, profile ProtectionProfile, opts ...ContextOption) *Context {
+	t.Helper()
+
+	keyLen, err := profile.KeyLen()
+	assert.NoError(t, err)
+	saltLen, err := profile.SaltLen()
+	assert.NoError(t, err)
+
+	masterKey := make([]byte, keyLen)
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+	masterSalt := make([]byte, saltLen)
+	for i := range masterSalt {
+		masterSalt[i] = byte(0x80 + i)
+	}
+
+	ctx, err := CreateContext(masterKey, masterSalt, profile, opts...)
+	assert.NoError(t, err)
+
+	return ctx
+}
+
+func TestDoubleAeadRoundTrip(t *testing.T) {
+	for _, profile := range []ProtectionProfile{
+		ProtectionProfileDoubleAeadAes128Gcm,
+		ProtectionProfileDoubleAeadAes256Gcm,
+	} {
+		t.Run(profile.String(), func(t *testing.T) {
+			encryptCtx := buildDoubleAeadTestContext(t, profile)
+			decryptCtx := buildDoubleAeadTestContext(t, profile)
+
+			packet := &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    96,
+					SequenceNumber: 1234,
+					Timestamp:      5678,
+					SSRC:           0xcafebabe,
+				},
+				Payload: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			}
+			assert.NoError(t, packet.SetExtension(1, []byte{0xaa, 0xbb}))
+			plaintext, err := packet.Marshal()
+			assert.NoError(t, err)
+
+			encrypted, err := encryptCtx.EncryptRTP(nil, plaintext, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, len(plaintext)+profile.RTPOverhead(0), len(encrypted))
+
+			decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+
+			// Tampering with the header is detected by the outer transform.
+			encrypted, err = encryptCtx.EncryptRTP(nil, plaintext, nil)
+			assert.NoError(t, err)
+			encrypted[1] ^= 0x01
+			_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			rtcpPacket := []byte{0x81, 0xce, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02}
+			encryptedRTCP, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, len(rtcpPacket)+profile.RTCPOverhead(0), len(encryptedRTCP))
+			decryptedRTCP, err := decryptCtx.DecryptRTCP(nil, encryptedRTCP, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decryptedRTCP)
+		})
+	}
+}
+
+func TestDoubleAeadOriginalHeaderBlock(t *testing.T) {
+	profile := ProtectionProfileDoubleAeadAes128Gcm
+	encryptCtx := buildDoubleAeadTestContext(t, profile)
+	decryptCtx := buildDoubleAeadTestContext(t, profile)
+
+	packet := &rtp.Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    96,
+			SequenceNumber: 1234,
+			SSRC:           0xcafebabe,
+		},
+		Payload: []byte{0x01, 0x02, 0x03, 0x04},
+	}
+	plaintext, err := packet.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, plaintext, nil)
+	assert.NoError(t, err)
+
+	// Simulate Media Distributor, which changes payload type and marker bit, and stores original
+	// values in OHB protected by the outer transform.
+	doubleCipher, ok := encryptCtx.cipher.(*srtpCipherDoubleAeadAesGcm)
+	assert.True(t, ok)
+	header := packet.Header
+	headerLen := header.MarshalSize()
+	outerPlaintext, err := doubleCipher.outer.decryptRTP(nil, encrypted, &header, headerLen, 0, false)
+	assert.NoError(t, err)
+
+	modifiedHeader := packet.Header
+	modifiedHeader.PayloadType = 111
+	modifiedHeader.Marker = true
+	modifiedPlaintext, err := modifiedHeader.Marshal()
+	assert.NoError(t, err)
+	innerPayload := outerPlaintext[headerLen : len(outerPlaintext)-ohbConfigLen]
+	modifiedPlaintext = append(modifiedPlaintext, innerPayload...)
+	modifiedPlaintext = append(modifiedPlaintext, 96, ohbConfigMarkerPresent|ohbConfigPTPresent)
+	modified, err := doubleCipher.outer.encryptRTP(nil, &modifiedHeader, headerLen, modifiedPlaintext, 0, false)
+	assert.NoError(t, err)
+
+	decryptedHeader := &rtp.Header{}
+	decrypted, err := decryptCtx.DecryptRTP(nil, modified, decryptedHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(111), decryptedHeader.PayloadType)
+	assert.True(t, decryptedHeader.Marker)
+	assert.Equal(t, packet.Payload, decrypted[headerLen:])
+
+	// Without OHB the inner transform fails, because payload type differs from the original one.
+	modifiedPlaintext = modifiedPlaintext[:len(modifiedPlaintext)-2]
+	modifiedPlaintext = append(modifiedPlaintext, 0)
+	modifiedHeader.SequenceNumber++
+	modified, err = doubleCipher.outer.encryptRTP(nil, &modifiedHeader, headerLen, modifiedPlaintext, 0, false)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, modified, nil)
+	assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+}
+
+func TestDoubleAeadInvalidOriginalHeaderBlock(t *testing.T) {
+	header := rtp.Header{}
+	for _, payload := range [][]byte{
+		{},
+		{0x10},
+		{ohbConfigPTPresent},
+		{0x00, ohbConfigSeqPresent},
+	} {
+		_, err := applyOriginalHeaderBlock(&header, payload)
+		assert.ErrorIs(t, err, errInvalidOHB)
+	}
+
+	payload, err := applyOriginalHeaderBlock(
+		&header, []byte{0xaa, 0x60, 0x12, 0x34, ohbConfigPTPresent | ohbConfigSeqPresent},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xaa}, payload)
+	assert.Equal(t, uint8(0x60), header.PayloadType)
+	assert.Equal(t, uint16(0x1234), header.SequenceNumber)
+}
+
+func TestDoubleAeadUnsupportedOptions(t *testing.T) {
+	profile := ProtectionProfileDoubleAeadAes128Gcm
+	_, err := CreateContext(make([]byte, 32), make([]byte, 24), profile, Cryptex(CryptexModeEnabled))
+	assert.ErrorIs(t, err, errDoubleAEADCryptex)
+
+	_, err = CreateContext(make([]byte, 32), make([]byte, 24), profile, RolloverCounterCarryingTransform(RCCMode3, 10))
+	assert.ErrorIs(t, err, errUnsupportedRccMode)
+}
@@ -36,9 +36,12 @@ func TestRCC(t *testing.T) {
 	profiles := []ProtectionProfile{
 		ProtectionProfileAes128CmHmacSha1_80,
 		ProtectionProfileAes128CmHmacSha1_32,
+		ProtectionProfileAes192CmHmacSha1_80,
+		ProtectionProfileAes192CmHmacSha1_32,
 		ProtectionProfileAes256CmHmacSha1_80,
 		ProtectionProfileAes256CmHmacSha1_32,
 		ProtectionProfileAeadAes128Gcm,
+		ProtectionProfileAeadAes192Gcm,
 		ProtectionProfileAeadAes256Gcm,
 	}
 	useMkiInTest := map[string]bool{
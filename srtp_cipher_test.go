@@ -4,9 +4,12 @@
 package srtp
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/pion/rtp"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type testCipher struct {
@@ -892,3 +895,184 @@ func TestSrtpCipher(t *testing.T) {
 		})
 	}
 }
+
+func TestGCMNonStandardMasterSaltLength(t *testing.T) {
+	masterKey := bytes.Repeat([]byte{0x11}, 16)
+	masterSalt := make([]byte, 14)
+	for i := range masterSalt {
+		masterSalt[i] = byte(i)
+	}
+
+	_, err := CreateContext(masterKey, masterSalt, profileGCM)
+	assert.ErrorIs(t, err, errShortSrtpMasterSalt)
+	_, err = CreateContext(masterKey, masterSalt, profileGCM, UnsafeGCMMasterSaltLength(15))
+	assert.ErrorIs(t, err, errInvalidGCMMasterSaltLength)
+	_, err = CreateContext(masterKey, masterSalt, profileGCM, UnsafeGCMMasterSaltLength(11))
+	assert.ErrorIs(t, err, errInvalidGCMMasterSaltLength)
+
+	encryptCtx, err := CreateContext(masterKey, masterSalt, profileGCM, UnsafeGCMMasterSaltLength(14))
+	assert.NoError(t, err)
+	decryptCtx, err := CreateContext(masterKey, masterSalt, profileGCM, UnsafeGCMMasterSaltLength(14))
+	assert.NoError(t, err)
+
+	pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: 1, SSRC: 1}, Payload: rtpTestCaseDecrypted()}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pktRaw, decrypted)
+
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	encrypted, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.NoError(t, err)
+	decrypted, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, rtcpPacket, decrypted)
+
+	// Standard 12-byte salt is a prefix of the long one, but session keys differ, because
+	// the PRF uses all 14 bytes of it.
+	standardCtx, err := CreateContext(masterKey, masterSalt[:12], profileGCM)
+	assert.NoError(t, err)
+	encrypted, err = encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	_, err = standardCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+	// Option is ignored for AES-CM profiles.
+	_, err = CreateContext(masterKey, masterSalt, profileCTR, UnsafeGCMMasterSaltLength(12))
+	assert.NoError(t, err)
+}
+
+func TestGCMAdditionalData(t *testing.T) {
+	trailer := []byte{0xde, 0xad, 0xbe, 0xef}
+	type aadCall struct {
+		ssrc   uint32
+		index  uint64
+		isRTCP bool
+	}
+	var calls []aadCall
+	appendTrailer := func(aad []byte, ssrc uint32, index uint64, isRTCP bool) []byte {
+		calls = append(calls, aadCall{ssrc, index, isRTCP})
+
+		return append(aad, trailer...)
+	}
+
+	for name, opts := range map[string][]ContextOption{
+		"Encrypted":   nil,
+		"Unencrypted": {SRTPNoEncryption(), SRTCPNoEncryption()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			calls = nil
+			encryptCtx, err := buildTestContext(profileGCM, append(opts, GCMAdditionalData(appendTrailer))...)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profileGCM, append(opts, GCMAdditionalData(appendTrailer))...)
+			assert.NoError(t, err)
+			standardCtx, err := buildTestContext(profileGCM, opts...)
+			assert.NoError(t, err)
+
+			pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SequenceNumber: 1, SSRC: 1}, Payload: rtpTestCaseDecrypted()}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			// Packet is encrypted in place, so appending to AAD must not overwrite it.
+			encrypted, err := encryptCtx.EncryptRTP(nil, append([]byte{}, pktRaw...), nil)
+			assert.NoError(t, err)
+			encryptedInPlace := append(make([]byte, 0, 100), pktRaw...)
+			encryptedInPlace, err = encryptCtx.Clone().EncryptRTP(encryptedInPlace, encryptedInPlace, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, encrypted, encryptedInPlace)
+
+			decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, pktRaw, decrypted)
+			_, err = standardCtx.DecryptRTP(nil, encrypted, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+			encrypted, err = encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, err)
+			decrypted, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, rtcpPacket, decrypted)
+			_, err = standardCtx.DecryptRTCP(nil, encrypted, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			assert.Contains(t, calls, aadCall{ssrc: 1, index: 1, isRTCP: false})
+			assert.Contains(t, calls, aadCall{ssrc: 1, index: 1, isRTCP: true})
+		})
+	}
+
+	// Option is ignored for AES-CM profiles.
+	encryptCtx, err := buildTestContext(profileCTR, GCMAdditionalData(appendTrailer))
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	pktRaw, err := (&rtp.Packet{Header: rtp.Header{Version: 2, SequenceNumber: 1, SSRC: 1}}).Marshal()
+	assert.NoError(t, err)
+	encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+}
+
+func benchmarkGCMCipher(b *testing.B, encryptSRTCP bool) {
+	b.Helper()
+
+	ctxOpt := SRTCPEncryption()
+	if !encryptSRTCP {
+		ctxOpt = SRTCPNoEncryption()
+	}
+	ctx, err := buildTestContext(profileGCM, ctxOpt)
+	require.NoError(b, err)
+	cipher := ctx.cipher
+
+	header := &rtp.Header{Version: 2, SSRC: 0x01020304, SequenceNumber: 1}
+	rtpPacket, err := (&rtp.Packet{Header: *header, Payload: make([]byte, 1200)}).Marshal()
+	require.NoError(b, err)
+	headerLen := header.MarshalSize()
+	rtcpPacket := append([]byte{0x80, 0xc9, 0x01, 0x2c, 0x01, 0x02, 0x03, 0x04}, make([]byte, 1192)...)
+
+	encryptedRTP, err := cipher.encryptRTP(nil, header, headerLen, rtpPacket, 0, false)
+	require.NoError(b, err)
+	encryptedRTCP, err := cipher.encryptRTCP(nil, rtcpPacket, 1, 0x01020304)
+	require.NoError(b, err)
+	buf := make([]byte, 0, len(rtpPacket)+100)
+
+	b.Run("EncryptRTP", func(b *testing.B) {
+		b.SetBytes(int64(len(rtpPacket)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err = cipher.encryptRTP(buf, header, headerLen, rtpPacket, 0, false)
+		}
+	})
+	b.Run("DecryptRTP", func(b *testing.B) {
+		b.SetBytes(int64(len(encryptedRTP)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err = cipher.decryptRTP(buf, encryptedRTP, header, headerLen, 0, false)
+		}
+	})
+	b.Run("EncryptRTCP", func(b *testing.B) {
+		b.SetBytes(int64(len(rtcpPacket)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err = cipher.encryptRTCP(buf, rtcpPacket, 1, 0x01020304)
+		}
+	})
+	b.Run("DecryptRTCP", func(b *testing.B) {
+		b.SetBytes(int64(len(encryptedRTCP)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err = cipher.decryptRTCP(buf, encryptedRTCP, 1, 0x01020304)
+		}
+	})
+	assert.NoError(b, err)
+}
+
+// BenchmarkGCMCipher measures encryption and decryption of 1200-byte packets by AEAD_AES_128_GCM cipher.
+// Nonces and temporary buffers are kept by the cipher, so it should not allocate.
+func BenchmarkGCMCipher(b *testing.B) {
+	b.Run("SRTCPEncryption", func(b *testing.B) { benchmarkGCMCipher(b, true) })
+	b.Run("SRTCPNoEncryption", func(b *testing.B) { benchmarkGCMCipher(b, false) })
+}
@@ -5,7 +5,6 @@ package srtp
 
 import (
 	"crypto/aes"
-	"crypto/cipher"
 	"crypto/hmac"
 	"crypto/sha1" // nolint:gosec
 )
@@ -50,6 +49,8 @@ func newSrtpCipherAesCmHmacSha1WithDerivedKeys(
 	srtpCipher.srtpSessionSalt = keys.srtpSessionSalt
 	srtpCipher.srtcpSessionSalt = keys.srtcpSessionSalt
 
+	srtpCipher.srtpSessionAuthKey = keys.srtpSessionAuthTag
+	srtpCipher.srtcpSessionAuthKey = keys.srtcpSessionAuthTag
 	srtpCipher.srtcpSessionAuth = hmac.New(sha1.New, keys.srtcpSessionAuthTag)
 	srtpCipher.srtpSessionAuth = hmac.New(sha1.New, keys.srtpSessionAuthTag)
 
@@ -67,12 +68,17 @@ func newSrtpCipherAeadAesGcmWithDerivedKeys(
 		srtcpEncrypted:            encryptSRTCP,
 	}
 
+	authTagLen, err := profile.AEADAuthTagLen()
+	if err != nil {
+		return nil, err
+	}
+
 	srtpBlock, err := aes.NewCipher(keys.srtpSessionKey)
 	if err != nil {
 		return nil, err
 	}
 
-	srtpCipher.srtpCipher, err = cipher.NewGCM(srtpBlock)
+	srtpCipher.srtpCipher, err = newGCMWithTagLen(srtpBlock, authTagLen)
 	if err != nil {
 		return nil, err
 	}
@@ -82,19 +88,21 @@ func newSrtpCipherAeadAesGcmWithDerivedKeys(
 		return nil, err
 	}
 
-	srtpCipher.srtcpCipher, err = cipher.NewGCM(srtcpBlock)
+	srtpCipher.srtcpCipher, err = newGCMWithTagLen(srtcpBlock, authTagLen)
 	if err != nil {
 		return nil, err
 	}
 
 	srtpCipher.srtpSessionSalt = keys.srtpSessionSalt
 	srtpCipher.srtcpSessionSalt = keys.srtcpSessionSalt
+	copy(srtpCipher.srtpSaltIV[:], keys.srtpSessionSalt)
+	copy(srtpCipher.srtcpSaltIV[:], keys.srtcpSessionSalt)
 
 	return srtpCipher, nil
 }
 
 // createContextWithCipher creates a new SRTP Context with a pre-created cipher. This is used for testing purposes only.
-func createContextWithCipher(profile ProtectionProfile, cipher srtpCipher) (*Context, error) {
+func createContextWithCipher(profile ProtectionProfile, cipher srtpCipher, opts ...ContextOption) (*Context, error) {
 	ctx := &Context{
 		srtpSSRCStates:  map[uint32]*srtpSSRCState{},
 		srtcpSSRCStates: map[uint32]*srtcpSSRCState{},
@@ -110,6 +118,11 @@ func createContextWithCipher(profile ProtectionProfile, cipher srtpCipher) (*Con
 	if err != nil {
 		return nil, err
 	}
+	for _, o := range opts {
+		if err = o(ctx); err != nil {
+			return nil, err
+		}
+	}
 
 	return ctx, nil
 }
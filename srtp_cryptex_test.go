@@ -4,6 +4,7 @@
 package srtp
 
 import (
+	"bytes"
 	"slices"
 	"testing"
 
@@ -387,3 +388,39 @@ func TestCryptexModes(t *testing.T) {
 		})
 	}
 }
+
+func TestDecryptRTPWithExtensions(t *testing.T) {
+	const midExtensionID = 1
+
+	for name, cryptexMode := range map[string]CryptexMode{
+		"Disabled": CryptexModeDisabled, "Enabled": CryptexModeEnabled,
+	} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profileCTR, Cryptex(cryptexMode))
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profileCTR, Cryptex(cryptexMode))
+			assert.NoError(t, err)
+
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{SequenceNumber: 1, SSRC: 1, CSRC: []uint32{2}},
+				Payload: rtpTestCaseDecrypted(),
+			}
+			assert.NoError(t, pkt.Header.SetExtension(midExtensionID, []byte("audio")))
+			pkt.PaddingSize = 4
+			pkt.Padding = true
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+
+			encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, cryptexMode == CryptexModeDisabled, bytes.Contains(encrypted, []byte("audio")))
+
+			payload, header, err := decryptCtx.DecryptRTPWithExtensions(encrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, pkt.Payload, payload)
+			assert.Equal(t, []byte("audio"), header.GetExtension(midExtensionID))
+			assert.Equal(t, []uint32{2}, header.CSRC)
+			assert.Equal(t, uint16(rtp.ExtensionProfileOneByte), header.ExtensionProfile)
+		})
+	}
+}
@@ -4,7 +4,10 @@
 package srtp
 
 import (
+	"bytes"
+	"encoding/binary"
 	"testing"
+	"time"
 
 	"github.com/pion/rtp"
 	"github.com/pion/transport/v4/replaydetector"
@@ -463,6 +466,35 @@ func TestRTPReplayDetectorFactory(t *testing.T) {
 	assertT.Equal(1, cntFactory)
 }
 
+func TestRTPReplayDetectorFactoryForSSRC(t *testing.T) {
+	// Detectors shared by two contexts, like state kept in a store shared by media servers.
+	detectors := map[uint32]replaydetector.ReplayDetector{}
+	factory := SRTPReplayDetectorFactoryForSSRC(func(ssrc uint32) replaydetector.ReplayDetector {
+		if _, ok := detectors[ssrc]; !ok {
+			detectors[ssrc] = replaydetector.New(64, maxROC<<16|maxSequenceNumber)
+		}
+
+		return detectors[ssrc]
+	})
+
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	aCtx, err := buildTestContext(profileCTR, factory)
+	assert.NoError(t, err)
+	bCtx, err := buildTestContext(profileCTR, factory)
+	assert.NoError(t, err)
+
+	encrypted := encryptTestRTP(t, encryptCtx, 1)
+	_, err = aCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	_, err = bCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+	_, err = bCtx.DecryptRTP(nil, encryptTestRTP(t, encryptCtx, 2), nil)
+	assert.NoError(t, err)
+	assert.Len(t, detectors, 1)
+	assert.Contains(t, detectors, uint32(defaultSsrc))
+}
+
 func benchmarkEncryptRTP(b *testing.B, profile ProtectionProfile, size int) {
 	b.Helper()
 
@@ -948,3 +980,1527 @@ func TestSRTPFailedAuthDoesNotGrowSSRCMap(t *testing.T) {
 	t.Run("CTR", func(t *testing.T) { testSRTPFailedAuthDoesNotGrowSSRCMap(t, profileCTR) })
 	t.Run("GCM", func(t *testing.T) { testSRTPFailedAuthDoesNotGrowSSRCMap(t, profileGCM) })
 }
+
+func TestRTPTimestampGuard(t *testing.T) {
+	testRTPTimestampGuard := func(t *testing.T, profile ProtectionProfile) {
+		t.Helper()
+
+		encryptCtx, err := buildTestContext(profile)
+		assert.NoError(t, err)
+		decryptCtx, err := buildTestContext(profile)
+		assert.NoError(t, err)
+
+		decryptCtx.SetTimestampGuard(defaultSsrc, 1000)
+
+		decrypt := func(seq uint16, timestamp uint32) error {
+			pkt := &rtp.Packet{
+				Payload: rtpTestCaseDecrypted(),
+				Header:  rtp.Header{SequenceNumber: seq, Timestamp: timestamp, SSRC: defaultSsrc},
+			}
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(t, errMarshal)
+
+			encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, errEncrypt)
+
+			_, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+
+			return errDecrypt
+		}
+
+		assert.NoError(t, decrypt(100, 50000))
+		assert.NoError(t, decrypt(101, 51000))
+		// Small regression within threshold is accepted.
+		assert.NoError(t, decrypt(102, 50500))
+		// Reference timestamp is taken from the newest packet.
+		assert.NoError(t, decrypt(99, 50000))
+
+		err = decrypt(103, 40000)
+		assert.ErrorIs(t, err, ErrTimestampRegression)
+
+		// Timestamp of flagged packet is not used as reference.
+		assert.NoError(t, decrypt(104, 50500))
+		// Timestamp wraparound is not a regression.
+		assert.NoError(t, decrypt(105, 0x7FFFFF00))
+		assert.NoError(t, decrypt(106, 0xFFFFFE00))
+		assert.NoError(t, decrypt(107, 0x00000100))
+		assert.ErrorIs(t, decrypt(108, 0xFFFFF000), ErrTimestampRegression)
+
+		// Flagged packet is authentic, so it is accepted and returned together with the error.
+		pkt := &rtp.Packet{
+			Payload: rtpTestCaseDecrypted(),
+			Header:  rtp.Header{SequenceNumber: 109, Timestamp: 0xFFFFF000, SSRC: defaultSsrc},
+		}
+		pktRaw, err := pkt.Marshal()
+		assert.NoError(t, err)
+		encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.ErrorIs(t, err, ErrTimestampRegression)
+		assert.Equal(t, pktRaw, decrypted)
+		assert.Equal(t, uint64(109), decryptCtx.srtpSSRCStates[defaultSsrc].index)
+
+		buf := bytes.Clone(encrypted)
+		n, err := decryptCtx.DecryptRTPInPlace(buf, nil)
+		assert.ErrorIs(t, err, ErrTimestampRegression)
+		assert.Equal(t, len(pkt.Payload), n)
+
+		// VerifyRTP does not return the packet, so the timestamp is not checked.
+		pkt.SequenceNumber = 110
+		pktRaw, err = pkt.Marshal()
+		assert.NoError(t, err)
+		encrypted, err = encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, decryptCtx.VerifyRTP(encrypted))
+
+		roc, ok := decryptCtx.ROC(defaultSsrc)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(0), roc)
+	}
+
+	t.Run("CTR", func(t *testing.T) { testRTPTimestampGuard(t, profileCTR) })
+	t.Run("GCM", func(t *testing.T) { testRTPTimestampGuard(t, profileGCM) })
+}
+
+func TestRTPVaryingHeaderLength(t *testing.T) {
+	testRTPVaryingHeaderLength := func(t *testing.T, profile ProtectionProfile, cryptex bool) {
+		t.Helper()
+
+		opts := []ContextOption{SRTPReplayProtection(64)}
+		if cryptex {
+			opts = append(opts, Cryptex(CryptexModeEnabled))
+		}
+		encryptCtx, err := buildTestContext(profile, opts...)
+		assert.NoError(t, err)
+		decryptCtx, err := buildTestContext(profile, opts...)
+		assert.NoError(t, err)
+
+		for i := range 16 {
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: uint16(1000 + i), SSRC: 0x11223344}, //nolint:gosec // G115
+				Payload: rtpTestCaseDecrypted(),
+			}
+			// Each packet uses a different combination of CSRCs and header extensions.
+			if i&1 != 0 {
+				pkt.CSRC = make([]uint32, i%5+1)
+				for j := range pkt.CSRC {
+					pkt.CSRC[j] = uint32(j + 1) //nolint:gosec // G115
+				}
+			}
+			if i&2 != 0 {
+				err = pkt.SetExtension(1, make([]byte, i%3+1))
+				assert.NoError(t, err)
+			}
+			if i&4 != 0 {
+				pkt.ExtensionProfile = rtp.ExtensionProfileTwoByte
+				err = pkt.SetExtension(2, make([]byte, i+20))
+				assert.NoError(t, err)
+			}
+
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(t, errMarshal)
+
+			encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, errEncrypt)
+
+			header := &rtp.Header{}
+			decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, header)
+			assert.NoError(t, errDecrypt, "packet %d", i)
+			assert.Equal(t, pkt.CSRC, header.CSRC, "packet %d", i)
+			if cryptex {
+				// Cryptex adds an empty header extension to packets with CSRCs only.
+				decryptedPkt := &rtp.Packet{}
+				assert.NoError(t, decryptedPkt.Unmarshal(decrypted))
+				assert.Equal(t, pkt.Payload, decryptedPkt.Payload, "packet %d", i)
+				assert.Equal(t, pkt.Extensions, decryptedPkt.Extensions, "packet %d", i)
+			} else {
+				assert.Equal(t, pktRaw, decrypted, "packet %d", i)
+			}
+		}
+	}
+
+	t.Run("CTR", func(t *testing.T) { testRTPVaryingHeaderLength(t, profileCTR, false) })
+	t.Run("GCM", func(t *testing.T) { testRTPVaryingHeaderLength(t, profileGCM, false) })
+	t.Run("CTR-Cryptex", func(t *testing.T) { testRTPVaryingHeaderLength(t, profileCTR, true) })
+	t.Run("GCM-Cryptex", func(t *testing.T) { testRTPVaryingHeaderLength(t, profileGCM, true) })
+}
+
+func TestRTPAuthFailureLimit(t *testing.T) {
+	testRTPAuthFailureLimit := func(t *testing.T, profile ProtectionProfile) {
+		t.Helper()
+
+		now := time.Unix(1000, 0)
+		clock := func() time.Time { return now }
+
+		encryptCtx, err := buildTestContext(profile)
+		assert.NoError(t, err)
+		decryptCtx, err := buildTestContext(profile, Clock(clock),
+			SRTPAuthFailureLimit(3, time.Second, 10*time.Second))
+		assert.NoError(t, err)
+
+		seq := uint16(1000)
+		encrypt := func(ssrc uint32) []byte {
+			seq++
+			pkt := &rtp.Packet{Payload: rtpTestCaseDecrypted(), Header: rtp.Header{SequenceNumber: seq, SSRC: ssrc}}
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(t, errMarshal)
+			encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, errEncrypt)
+
+			return encrypted
+		}
+		forged := func(ssrc uint32) []byte {
+			encrypted := encrypt(ssrc)
+			encrypted[len(encrypted)-1] ^= 0xFF
+
+			return encrypted
+		}
+
+		// Unknown SSRCs are not tracked.
+		for range 10 {
+			_, err = decryptCtx.DecryptRTP(nil, forged(2), nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+		}
+
+		_, err = decryptCtx.DecryptRTP(nil, encrypt(1), nil)
+		assert.NoError(t, err)
+
+		// Failures spread over time do not exceed the rate.
+		for range 5 {
+			now = now.Add(1100 * time.Millisecond)
+			_, err = decryptCtx.DecryptRTP(nil, forged(1), nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+			_, err = decryptCtx.DecryptRTP(nil, forged(1), nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+		}
+		_, err = decryptCtx.DecryptRTP(nil, encrypt(1), nil)
+		assert.NoError(t, err)
+
+		// Burst of failures exceeds the limit.
+		now = now.Add(2 * time.Second)
+		for range 4 {
+			_, err = decryptCtx.DecryptRTP(nil, forged(1), nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+		}
+		_, err = decryptCtx.DecryptRTP(nil, encrypt(1), nil)
+		assert.ErrorIs(t, err, ErrSSRCBlacklisted)
+
+		now = now.Add(9 * time.Second)
+		_, err = decryptCtx.DecryptRTP(nil, forged(1), nil)
+		assert.ErrorIs(t, err, ErrSSRCBlacklisted)
+
+		// Other SSRCs are not affected.
+		_, err = decryptCtx.DecryptRTP(nil, encrypt(3), nil)
+		assert.NoError(t, err)
+
+		// SSRC is re-admitted after cooldown.
+		now = now.Add(time.Second)
+		_, err = decryptCtx.DecryptRTP(nil, encrypt(1), nil)
+		assert.NoError(t, err)
+	}
+
+	t.Run("CTR", func(t *testing.T) { testRTPAuthFailureLimit(t, profileCTR) })
+	t.Run("GCM", func(t *testing.T) { testRTPAuthFailureLimit(t, profileGCM) })
+}
+
+func TestInvalidRTPAuthFailureLimit(t *testing.T) {
+	_, err := buildTestContext(profileCTR, SRTPAuthFailureLimit(1, 0, time.Second))
+	assert.ErrorIs(t, err, errInvalidAuthFailureLimit)
+	_, err = buildTestContext(profileCTR, SRTPAuthFailureLimit(1, time.Second, 0))
+	assert.ErrorIs(t, err, errInvalidAuthFailureLimit)
+}
+
+func TestRTPKeySelector(t *testing.T) {
+	const midExtensionID = 1
+
+	keysA := SessionKeys{RemoteMasterKey: make([]byte, 16), RemoteMasterSalt: make([]byte, 14)}
+	keysB := SessionKeys{RemoteMasterKey: make([]byte, 16), RemoteMasterSalt: make([]byte, 12)}
+	keysB.RemoteMasterKey[0] = 1
+
+	encryptCtxA, err := CreateContext(keysA.RemoteMasterKey, keysA.RemoteMasterSalt, profileCTR)
+	assert.NoError(t, err)
+	encryptCtxB, err := CreateContext(keysB.RemoteMasterKey, keysB.RemoteMasterSalt, profileGCM)
+	assert.NoError(t, err)
+
+	selectorCalls := 0
+	decryptCtx, err := CreateContext(keysA.RemoteMasterKey, keysA.RemoteMasterSalt, profileCTR,
+		SRTPKeySelector(func(header *rtp.Header) (SessionKeys, ProtectionProfile, error) {
+			selectorCalls++
+			switch string(header.GetExtension(midExtensionID)) {
+			case "a":
+				return keysA, profileCTR, nil
+			case "b":
+				return keysB, profileGCM, nil
+			default:
+				return SessionKeys{}, 0, errPayloadDiffers
+			}
+		}),
+	)
+	assert.NoError(t, err)
+
+	encrypt := func(ctx *Context, mid string, seq uint16) ([]byte, []byte) {
+		pkt := &rtp.Packet{
+			Header:  rtp.Header{SequenceNumber: seq, SSRC: uint32(seq)},
+			Payload: rtpTestCaseDecrypted(),
+		}
+		assert.NoError(t, pkt.SetExtension(midExtensionID, []byte(mid)))
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted, errEncrypt := ctx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+
+		return pktRaw, encrypted
+	}
+
+	for i := range uint16(4) {
+		pktRaw, encrypted := encrypt(encryptCtxA, "a", 10+i)
+		decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, errDecrypt)
+		assert.Equal(t, pktRaw, decrypted)
+
+		pktRaw, encrypted = encrypt(encryptCtxB, "b", 20+i)
+		decrypted, errDecrypt = decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, errDecrypt)
+		assert.Equal(t, pktRaw, decrypted)
+	}
+	assert.Equal(t, 8, selectorCalls)
+	assert.Len(t, decryptCtx.selectedCiphers, 2)
+
+	// Packet with wrong MID uses wrong key.
+	_, encrypted := encrypt(encryptCtxB, "a", 30)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+	// Selector errors are returned.
+	_, encrypted = encrypt(encryptCtxA, "c", 31)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, errPayloadDiffers)
+}
+
+func TestRTPKeySelectorCacheLimit(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	// Key depends on unauthenticated SSRC, so every packet with a new SSRC creates a new cipher.
+	decryptCtx, err := buildTestContext(profileCTR,
+		SRTPKeySelector(func(header *rtp.Header) (SessionKeys, ProtectionProfile, error) {
+			keys := SessionKeys{RemoteMasterKey: make([]byte, 16), RemoteMasterSalt: make([]byte, 14)}
+			binary.BigEndian.PutUint32(keys.RemoteMasterKey, header.SSRC)
+
+			return keys, profileCTR, nil
+		}),
+	)
+	assert.NoError(t, err)
+
+	for ssrc := range uint32(4 * selectedCipherCacheSize) {
+		encrypted := encryptTestRTPForSSRC(t, encryptCtx, ssrc+1, 1)
+		_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+		assert.LessOrEqual(t, len(decryptCtx.selectedCiphers), selectedCipherCacheSize)
+	}
+}
+
+func TestRTPAndRTCPStateSeparation(t *testing.T) {
+	testRTPAndRTCPStateSeparation := func(t *testing.T, profile ProtectionProfile) {
+		t.Helper()
+
+		const ssrc = 0x11223344
+		const roc = 0xFFFF0000
+		const rtcpIndex = maxSRTCPIndex - 20
+
+		encryptCtx, err := buildTestContext(profile)
+		assert.NoError(t, err)
+		decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64), SRTCPReplayProtection(64))
+		assert.NoError(t, err)
+
+		encryptCtx.SetROC(ssrc, roc)
+		encryptCtx.SetIndex(ssrc, rtcpIndex)
+		decryptCtx.SetROC(ssrc, roc)
+
+		rtcpPacket := []byte{
+			0x80, 0xc9, 0x00, 0x01, 0x11, 0x22, 0x33, 0x44,
+		}
+		for i := range 10 {
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{SequenceNumber: uint16(i), SSRC: ssrc}, //nolint:gosec // G115
+				Payload: rtpTestCaseDecrypted(),
+			}
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(t, errMarshal)
+
+			encryptedRTP, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, errEncrypt)
+			encryptedRTCP, errEncrypt := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			assert.NoError(t, errEncrypt)
+
+			decryptedRTCP, errDecrypt := decryptCtx.DecryptRTCP(nil, encryptedRTCP, nil)
+			assert.NoError(t, errDecrypt)
+			assert.Equal(t, rtcpPacket, decryptedRTCP)
+			decryptedRTP, errDecrypt := decryptCtx.DecryptRTP(nil, encryptedRTP, nil)
+			assert.NoError(t, errDecrypt)
+			assert.Equal(t, pktRaw, decryptedRTP)
+		}
+
+		encryptROC, ok := encryptCtx.ROC(ssrc)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(roc), encryptROC)
+		encryptIndex, ok := encryptCtx.Index(ssrc)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(rtcpIndex+10), encryptIndex)
+
+		decryptROC, ok := decryptCtx.ROC(ssrc)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(roc), decryptROC)
+		index, ok := decryptCtx.srtpSSRCStates[ssrc]
+		assert.True(t, ok)
+		assert.Equal(t, uint64(roc)<<16|9, index.index)
+	}
+
+	t.Run("CTR", func(t *testing.T) { testRTPAndRTCPStateSeparation(t, profileCTR) })
+	t.Run("GCM", func(t *testing.T) { testRTPAndRTCPStateSeparation(t, profileGCM) })
+}
+
+func TestDebugKeystreamRTP(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			ctx, err := buildTestContext(profile, UnsafeDebugKeystream())
+			assert.NoError(t, err)
+			ctx.SetROC(defaultSsrc, 3)
+
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{SequenceNumber: 5000, SSRC: defaultSsrc, Timestamp: 1234},
+				Payload: rtpTestCaseDecrypted(),
+			}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+
+			encrypted, err := ctx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+
+			keystream, err := ctx.DebugKeystreamRTP(&pkt.Header, len(pkt.Payload), 3)
+			assert.NoError(t, err)
+			assert.Len(t, keystream, len(pkt.Payload))
+
+			headerLen := pkt.Header.MarshalSize()
+			for i := range keystream {
+				assert.Equal(t, encrypted[headerLen+i], pkt.Payload[i]^keystream[i])
+			}
+		})
+	}
+}
+
+func TestDebugKeystreamRTPErrors(t *testing.T) {
+	header := &rtp.Header{SSRC: defaultSsrc}
+
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	_, err = ctx.DebugKeystreamRTP(header, 10, 0)
+	assert.ErrorIs(t, err, errKeystreamExportDisabled)
+
+	ctx, err = buildTestContext(profileCTR, UnsafeDebugKeystream(), SRTPNoEncryption())
+	assert.NoError(t, err)
+	_, err = ctx.DebugKeystreamRTP(header, 10, 0)
+	assert.ErrorIs(t, err, errKeystreamNotAvailable)
+}
+
+type testLatencyRecorder struct {
+	ssrcs     []uint32
+	durations []time.Duration
+}
+
+func (r *testLatencyRecorder) Observe(ssrc uint32, d time.Duration) {
+	r.ssrcs = append(r.ssrcs, ssrc)
+	r.durations = append(r.durations, d)
+}
+
+func TestRTPDecryptLatencyRecorder(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := func() time.Time {
+		now = now.Add(time.Millisecond)
+
+		return now
+	}
+	recorder := &testLatencyRecorder{}
+
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, Clock(clock), SRTPDecryptLatencyRecorder(recorder))
+	assert.NoError(t, err)
+
+	for _, ssrc := range []uint32{1, 2} {
+		pkt := &rtp.Packet{Header: rtp.Header{SSRC: ssrc}, Payload: rtpTestCaseDecrypted()}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, errEncrypt)
+
+		_, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, errDecrypt)
+	}
+
+	assert.Equal(t, []uint32{1, 2}, recorder.ssrcs)
+	assert.Equal(t, []time.Duration{time.Millisecond, time.Millisecond}, recorder.durations)
+}
+
+func TestFilterAuthenticRTP(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+
+			var packets [][]byte
+			var plaintexts [][]byte
+			for seq := range uint16(5) {
+				pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+				encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+				assert.NoError(t, errEncrypt)
+				packets = append(packets, encrypted)
+				plaintexts = append(plaintexts, pktRaw)
+			}
+
+			// Packet 0 is replayed, packet 2 is forged and packet 4 is too short.
+			_, err = decryptCtx.DecryptRTP(nil, append([]byte{}, packets[0]...), nil)
+			assert.NoError(t, err)
+			packets[2][len(packets[2])-1] ^= 0x01
+			packets[4] = packets[4][:12]
+
+			original := make([][]byte, len(packets))
+			for i, packet := range packets {
+				original[i] = append([]byte{}, packet...)
+			}
+			stateBefore := decryptCtx.StateSnapshot()
+
+			validIdx, err := decryptCtx.FilterAuthenticRTP(packets)
+			assert.Equal(t, []int{1, 3}, validIdx)
+			assert.ErrorIs(t, err, errDuplicated)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+			assert.ErrorIs(t, err, errTooShortRTP)
+
+			assert.Equal(t, original, packets)
+			assert.Empty(t, DiffStates(stateBefore, decryptCtx.StateSnapshot()))
+
+			for _, i := range validIdx {
+				decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, packets[i], nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, plaintexts[i], decrypted)
+			}
+
+			validIdx, err = decryptCtx.FilterAuthenticRTP(packets[1:2])
+			assert.Empty(t, validIdx)
+			assert.ErrorIs(t, err, errDuplicated)
+		})
+	}
+}
+
+func TestVerifyRTP(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			relayCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+
+			for seq := range uint16(3) {
+				pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+				encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+				assert.NoError(t, errEncrypt)
+
+				original := append([]byte{}, encrypted...)
+				assert.NoError(t, relayCtx.VerifyRTP(encrypted))
+				assert.Equal(t, original, encrypted)
+				assert.ErrorIs(t, relayCtx.VerifyRTP(encrypted), errDuplicated)
+
+				// Verified packet is forwarded as-is and decrypted by the receiver.
+				decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, encrypted, nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, pktRaw, decrypted)
+			}
+
+			roc, ok := relayCtx.ROC(defaultSsrc)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(0), roc)
+			// Only AEAD packets are decrypted to verify them.
+			assert.Equal(t, profile == profileGCM, relayCtx.authScratchBuf != nil)
+
+			pktRaw, err := (&rtp.Packet{Header: rtp.Header{SequenceNumber: 3, SSRC: defaultSsrc}}).Marshal()
+			assert.NoError(t, err)
+			forged, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			forged[len(forged)-1] ^= 0x01
+			assert.ErrorIs(t, relayCtx.VerifyRTP(forged), ErrFailedToVerifyAuthTag)
+			assert.Error(t, relayCtx.VerifyRTP(forged[:5]))
+		})
+	}
+}
+
+func TestDecryptRTPHeader(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile, Cryptex(CryptexModeEnabled))
+			assert.NoError(t, err)
+			relayCtx, err := buildTestContext(profile, Cryptex(CryptexModeEnabled), SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, Cryptex(CryptexModeEnabled))
+			assert.NoError(t, err)
+
+			pkt := &rtp.Packet{
+				Header: rtp.Header{
+					Version: 2, SequenceNumber: 1, SSRC: defaultSsrc, CSRC: []uint32{0x01020304},
+					Extension: true, ExtensionProfile: rtp.ExtensionProfileOneByte,
+				},
+				Payload: rtpTestCaseDecrypted(),
+			}
+			assert.NoError(t, pkt.Header.SetExtension(5, []byte{0x12, 0x34}))
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			original := append([]byte{}, encrypted...)
+
+			header := &rtp.Header{}
+			decryptedHeader, err := relayCtx.DecryptRTPHeader(nil, encrypted, header)
+			assert.NoError(t, err)
+			assert.Equal(t, pktRaw[:pkt.Header.MarshalSize()], decryptedHeader)
+			assert.Equal(t, []byte{0x12, 0x34}, header.GetExtension(5))
+			assert.Equal(t, []uint32{0x01020304}, header.CSRC)
+			assert.Equal(t, original, encrypted)
+			_, err = relayCtx.DecryptRTPHeader(nil, encrypted, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+			// Payload is decrypted only by AEAD profiles, to verify the auth tag.
+			assert.Equal(t, profile == profileGCM, relayCtx.authScratchBuf != nil)
+
+			// Packet is forwarded as-is and decrypted by the receiver.
+			decrypted, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, pktRaw, decrypted)
+
+			pkt.SequenceNumber = 2
+			pktRaw, err = pkt.Marshal()
+			assert.NoError(t, err)
+			forged, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			forged[len(forged)-1] ^= 0x01
+			_, err = relayCtx.DecryptRTPHeader(nil, forged, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+		})
+	}
+}
+
+func TestRTPBatch(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+
+			var plaintexts [][]byte
+			for seq := range uint16(4) {
+				pkt := &rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: seq, SSRC: defaultSsrc},
+					Payload: rtpTestCaseDecrypted(),
+				}
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+				plaintexts = append(plaintexts, pktRaw)
+			}
+
+			// Buffer is provided for the first packet only.
+			dsts := [][]byte{make([]byte, 0, 100)}
+			encrypted, errs := encryptCtx.EncryptRTPBatch(dsts, append(plaintexts, []byte{0x80}))
+			assert.Len(t, encrypted, len(plaintexts)+1)
+			assert.Equal(t, []error{nil, nil, nil, nil}, errs[:len(plaintexts)])
+			assert.Error(t, errs[len(plaintexts)])
+			assert.Nil(t, encrypted[len(plaintexts)])
+			assert.Same(t, &dsts[0][:1][0], &encrypted[0][0], "dst buffer should be reused")
+
+			// Packet 1 is forged and packet 3 is too short.
+			encrypted = encrypted[:len(plaintexts)]
+			encrypted[1][len(encrypted[1])-1] ^= 0x01
+			encrypted[3] = encrypted[3][:12]
+
+			decrypted, errs := decryptCtx.DecryptRTPBatch(nil, encrypted)
+			assert.NoError(t, errs[0])
+			assert.ErrorIs(t, errs[1], ErrFailedToVerifyAuthTag)
+			assert.NoError(t, errs[2])
+			assert.ErrorIs(t, errs[3], errTooShortRTP)
+			assert.Equal(t, [][]byte{plaintexts[0], nil, plaintexts[2], nil}, decrypted)
+
+			// Decrypted packets are replay protected.
+			_, errs = decryptCtx.DecryptRTPBatch(nil, encrypted[:1])
+			assert.ErrorIs(t, errs[0], errDuplicated)
+		})
+	}
+}
+
+func TestRTPSingleSSRC(t *testing.T) {
+	encrypt := func(ctx *Context, ssrc uint32, seq uint16) error {
+		pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: ssrc}, Payload: rtpTestCaseDecrypted()}
+		pktRaw, err := pkt.Marshal()
+		assert.NoError(t, err)
+		_, err = ctx.EncryptRTP(nil, pktRaw, nil)
+
+		return err
+	}
+
+	t.Run("Strict", func(t *testing.T) {
+		ctx, err := buildTestContext(profileCTR, SRTPSingleSSRC())
+		assert.NoError(t, err)
+
+		assert.NoError(t, encrypt(ctx, 1, 0))
+		assert.NoError(t, encrypt(ctx, 1, 1))
+		assert.ErrorIs(t, encrypt(ctx, 2, 0), errUnexpectedSSRC)
+		assert.NoError(t, encrypt(ctx, 1, 2))
+
+		_, ok := ctx.ROC(2)
+		assert.False(t, ok)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		ctx, err := buildTestContext(profileCTR)
+		assert.NoError(t, err)
+
+		assert.NoError(t, encrypt(ctx, 1, 0))
+		assert.NoError(t, encrypt(ctx, 2, 0))
+		assert.NoError(t, encrypt(ctx, 1, 1))
+	})
+}
+
+func TestRTPHeaderExtensionInserter(t *testing.T) {
+	const extensionID = 5
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			var counter byte
+			encryptCtx, err := buildTestContext(profile, SRTPHeaderExtensionInserter(extensionID,
+				func(header *rtp.Header) []byte {
+					counter++
+
+					return []byte{byte(header.SequenceNumber), counter}
+				},
+			))
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+
+			for seq := range uint16(3) {
+				pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, SSRC: 1}, Payload: rtpTestCaseDecrypted()}
+				if seq == 2 {
+					assert.NoError(t, pkt.Header.SetExtension(1, []byte{0xAA}))
+				}
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+
+				header := &rtp.Header{}
+				encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, header)
+				assert.NoError(t, errEncrypt)
+				assert.Nil(t, header.GetExtension(extensionID))
+
+				// Modify the first byte of header extension.
+				tampered := append([]byte{}, encrypted...)
+				tampered[16] ^= 0x01
+				_, errDecrypt := decryptCtx.DecryptRTP(nil, tampered, nil)
+				assert.ErrorIs(t, errDecrypt, ErrFailedToVerifyAuthTag)
+
+				payload, decryptedHeader, errDecrypt := decryptCtx.DecryptRTPWithExtensions(encrypted)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, pkt.Payload, payload)
+				assert.Equal(t, []byte{byte(seq), counter}, decryptedHeader.GetExtension(extensionID))
+				if seq == 2 {
+					assert.Equal(t, []byte{0xAA}, decryptedHeader.GetExtension(1))
+				}
+			}
+		})
+	}
+}
+
+func TestResignRTP(t *testing.T) {
+	for name, opts := range map[string]struct {
+		profile ProtectionProfile
+		opts    []ContextOption
+	}{
+		"CTR":              {profileCTR, nil},
+		"CTR_NoEncryption": {profileCTR, []ContextOption{SRTPNoEncryption()}},
+		"CTR_RCC":          {profileCTR, []ContextOption{RolloverCounterCarryingTransform(RCCMode2, 1)}},
+		"CTR_MKI":          {profileCTR, []ContextOption{MasterKeyIndicator([]byte{1, 2})}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(opts.profile, opts.opts...)
+			assert.NoError(t, err)
+			relayCtx, err := buildTestContext(opts.profile, opts.opts...)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(opts.profile, opts.opts...)
+			assert.NoError(t, err)
+			encryptCtx.SetROC(defaultSsrc, 2)
+			relayCtx.SetROC(defaultSsrc, 2)
+			decryptCtx.SetROC(defaultSsrc, 2)
+
+			pkt := &rtp.Packet{Header: rtp.Header{SequenceNumber: 100, SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+
+			// Set the marker bit.
+			encrypted[1] |= 0x80
+			_, err = decryptCtx.DecryptRTP(nil, append([]byte{}, encrypted...), nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			header := &rtp.Header{}
+			resigned, err := relayCtx.ResignRTP(encrypted, header)
+			assert.NoError(t, err)
+			assert.True(t, header.Marker)
+
+			decrypted, err := decryptCtx.DecryptRTP(nil, resigned, nil)
+			assert.NoError(t, err)
+			pktRaw[1] |= 0x80
+			assert.Equal(t, pktRaw, decrypted)
+		})
+	}
+}
+
+func TestResignRTPErrors(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR, Cryptex(CryptexModeEnabled))
+	assert.NoError(t, err)
+
+	pkt := &rtp.Packet{Header: rtp.Header{SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+	assert.NoError(t, pkt.Header.SetExtension(1, []byte{0x01}))
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := ctx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+
+	_, err = ctx.ResignRTP(encrypted, nil)
+	assert.ErrorIs(t, err, errResignCryptex)
+
+	_, err = ctx.ResignRTP([]byte{0x80, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0xAA}, nil)
+	assert.ErrorIs(t, err, errTooShortRTP)
+
+	for _, profile := range []ProtectionProfile{profileGCM, ProtectionProfileDoubleAeadAes128Gcm} {
+		keyLen, err := profile.KeyLen()
+		assert.NoError(t, err)
+		saltLen, err := profile.SaltLen()
+		assert.NoError(t, err)
+		ctx, err = CreateContext(make([]byte, keyLen), make([]byte, saltLen), profile)
+		assert.NoError(t, err)
+		pkt = &rtp.Packet{Header: rtp.Header{SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+		pktRaw, err = pkt.Marshal()
+		assert.NoError(t, err)
+		encrypted, err = ctx.EncryptRTP(nil, pktRaw, nil)
+		assert.NoError(t, err)
+		_, err = ctx.ResignRTP(encrypted, nil)
+		assert.ErrorIs(t, err, errResignAEAD)
+	}
+}
+
+func TestDecryptFailureSampler(t *testing.T) {
+	type failure struct {
+		reason error
+		sample []byte
+	}
+	var failures []failure
+	sampler := func(reason error, sample []byte) {
+		failures = append(failures, failure{reason, sample})
+	}
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			failures = nil
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, DecryptFailureSampler(16, sampler))
+			assert.NoError(t, err)
+
+			pkt := &rtp.Packet{Header: rtp.Header{SSRC: defaultSsrc}, Payload: rtpTestCaseDecrypted()}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+
+			_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+			assert.Empty(t, failures)
+
+			// Decrypt in place, so the buffer is modified.
+			encrypted[len(encrypted)-1] ^= 0x01
+			expectedSample := append([]byte{}, encrypted[:16]...)
+			_, err = decryptCtx.DecryptRTP(encrypted, encrypted, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00}
+			_, err = decryptCtx.DecryptRTCP(nil, rtcpPacket, nil)
+			assert.ErrorIs(t, err, errTooShortRTCP)
+
+			assert.Len(t, failures, 2)
+			assert.ErrorIs(t, failures[0].reason, ErrFailedToVerifyAuthTag)
+			assert.Equal(t, expectedSample, failures[0].sample)
+			assert.ErrorIs(t, failures[1].reason, errTooShortRTCP)
+			assert.Equal(t, rtcpPacket, failures[1].sample)
+		})
+	}
+
+	ctx, err := buildTestContext(profileCTR, DecryptFailureSampler(0, sampler))
+	assert.NoError(t, err)
+	assert.Equal(t, defaultFailureSampleSize, ctx.failureSampleSize)
+}
+
+func encryptInterleavedRTP(tb testing.TB, profile ProtectionProfile, ssrcCount, packetsPerSSRC int) [][]byte {
+	tb.Helper()
+
+	encryptCtx, err := buildTestContext(profile)
+	assert.NoError(tb, err)
+
+	encrypted := make([][]byte, 0, ssrcCount*packetsPerSSRC)
+	for seq := 0; seq < packetsPerSSRC; seq++ {
+		for ssrc := 0; ssrc < ssrcCount; ssrc++ {
+			pkt := &rtp.Packet{
+				Header: rtp.Header{
+					SSRC:           uint32(ssrc),    //nolint:gosec // G115
+					SequenceNumber: uint16(seq + 1), //nolint:gosec // G115
+				},
+				Payload: rtpTestCaseDecrypted(),
+			}
+			pktRaw, errMarshal := pkt.Marshal()
+			assert.NoError(tb, errMarshal)
+			encryptedRaw, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(tb, errEncrypt)
+			encrypted = append(encrypted, encryptedRaw)
+		}
+	}
+
+	return encrypted
+}
+
+func TestDecryptRTPBatchGrouped(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encrypted := encryptInterleavedRTP(t, profile, 3, 4)
+			encrypted[4][len(encrypted[4])-1] ^= 0x01
+			encrypted = append(encrypted, []byte{0x80})
+
+			expectedCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			expectedDecrypted := make([][]byte, len(encrypted))
+			expectedErrs := make([]error, len(encrypted))
+			for i, packet := range encrypted {
+				expectedDecrypted[i], expectedErrs[i] = expectedCtx.DecryptRTP(nil, packet, nil)
+			}
+
+			decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			// Only some packets have destination buffers.
+			dsts := make([][]byte, 2)
+			dsts[1] = make([]byte, 0, 1500)
+			decrypted, errs := decryptCtx.DecryptRTPBatchGrouped(dsts, encrypted)
+			assert.Equal(t, expectedDecrypted, decrypted)
+			assert.Equal(t, expectedErrs, errs)
+			assert.ErrorIs(t, errs[4], ErrFailedToVerifyAuthTag)
+			assert.Error(t, errs[len(errs)-1])
+			assert.Equal(t, &dsts[1][:1][0], &decrypted[1][0])
+
+			// State is updated, so packets are detected as replayed.
+			_, errs = decryptCtx.DecryptRTPBatchGrouped(nil, encrypted[:1])
+			assert.ErrorIs(t, errs[0], errDuplicated)
+		})
+	}
+}
+
+func benchmarkDecryptRTPBatch(b *testing.B, profile ProtectionProfile, grouped bool) {
+	b.Helper()
+
+	encrypted := encryptInterleavedRTP(b, profile, 16, 64)
+	dsts := make([][]byte, len(encrypted))
+	for i := range dsts {
+		dsts[i] = make([]byte, 0, 1500)
+	}
+
+	decryptCtx, err := buildTestContext(profile)
+	assert.NoError(b, err)
+
+	b.SetBytes(int64(len(encrypted) * len(encrypted[0])))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if grouped {
+			_, errs := decryptCtx.DecryptRTPBatchGrouped(dsts, encrypted)
+			assert.NoError(b, errs[0])
+
+			continue
+		}
+		for j, packet := range encrypted {
+			_, errDecrypt := decryptCtx.DecryptRTP(dsts[j], packet, nil)
+			assert.NoError(b, errDecrypt)
+		}
+	}
+}
+
+func BenchmarkDecryptRTPBatchGrouped(b *testing.B) {
+	b.Run("CTR/Grouped", func(b *testing.B) { benchmarkDecryptRTPBatch(b, profileCTR, true) })
+	b.Run("CTR/Loop", func(b *testing.B) { benchmarkDecryptRTPBatch(b, profileCTR, false) })
+	b.Run("GCM/Grouped", func(b *testing.B) { benchmarkDecryptRTPBatch(b, profileGCM, true) })
+	b.Run("GCM/Loop", func(b *testing.B) { benchmarkDecryptRTPBatch(b, profileGCM, false) })
+}
+
+type rejectingReplayDetector struct{}
+
+func (r *rejectingReplayDetector) Check(uint64) (func() bool, bool) {
+	return nil, false
+}
+
+func TestDecryptRTPROCUpdateOrder(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+
+			encrypt := func(seq uint16) []byte {
+				pkt := &rtp.Packet{Header: rtp.Header{SSRC: defaultSsrc, SequenceNumber: seq}, Payload: rtpTestCaseDecrypted()}
+				pktRaw, errMarshal := pkt.Marshal()
+				assert.NoError(t, errMarshal)
+				encrypted, errEncrypt := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+				assert.NoError(t, errEncrypt)
+
+				return encrypted
+			}
+			beforeWrap := encrypt(65534)
+			afterWrap := encrypt(5)
+
+			decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTP(nil, beforeWrap, nil)
+			assert.NoError(t, err)
+
+			assertROC := func(expected uint32) {
+				t.Helper()
+				roc, ok := decryptCtx.ROC(defaultSsrc)
+				assert.True(t, ok)
+				assert.Equal(t, expected, roc)
+			}
+			assertROC(0)
+
+			// (a) Replay-rejected packet does not advance ROC.
+			state := decryptCtx.srtpSSRCStates[defaultSsrc]
+			replayGuard := state.replayGuard
+			state.replayGuard = newReplayGuard(&rejectingReplayDetector{})
+			_, err = decryptCtx.DecryptRTP(nil, afterWrap, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+			assertROC(0)
+			state.replayGuard = replayGuard
+
+			// (b) Packet which failed authentication does not advance ROC.
+			tampered := append([]byte{}, afterWrap...)
+			tampered[len(tampered)-1] ^= 0x01
+			_, err = decryptCtx.DecryptRTP(nil, tampered, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+			assertROC(0)
+
+			// (c) Successfully decrypted packet advances ROC.
+			_, err = decryptCtx.DecryptRTP(nil, afterWrap, nil)
+			assert.NoError(t, err)
+			assertROC(1)
+		})
+	}
+}
+
+func TestEncryptRTPSequence(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			expectedCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64))
+			assert.NoError(t, err)
+
+			// Start with ROC 0x10. It would be ROC 0 for a new SSRC without the sequence.
+			expectedCtx.SetROC(defaultSsrc, 0x10)
+			decryptCtx.SetROC(defaultSsrc, 0x10)
+
+			var packets []RTPPacketWithROC
+			var plaintexts [][]byte
+			roc := uint32(0x10)
+			for seq := uint16(65533); seq != 3; seq++ {
+				if seq == 0 {
+					roc++
+				}
+				header := &rtp.Header{SSRC: defaultSsrc, SequenceNumber: seq}
+				packets = append(packets, RTPPacketWithROC{Header: header, Payload: rtpTestCaseDecrypted(), ROC: roc})
+				plaintext, errMarshal := (&rtp.Packet{Header: *header, Payload: rtpTestCaseDecrypted()}).Marshal()
+				assert.NoError(t, errMarshal)
+				plaintexts = append(plaintexts, plaintext)
+			}
+
+			encrypted, err := encryptCtx.EncryptRTPSequence(packets)
+			assert.NoError(t, err)
+			assert.Len(t, encrypted, len(packets))
+
+			for i, ciphertext := range encrypted {
+				expected, errEncrypt := expectedCtx.EncryptRTP(nil, plaintexts[i], nil)
+				assert.NoError(t, errEncrypt)
+				assert.Equal(t, expected, ciphertext)
+
+				decrypted, errDecrypt := decryptCtx.DecryptRTP(nil, ciphertext, nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, plaintexts[i], decrypted)
+			}
+
+			roc, ok := encryptCtx.ROC(defaultSsrc)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(0x11), roc)
+			roc, ok = decryptCtx.ROC(defaultSsrc)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(0x11), roc)
+
+			// Header passed in the sequence is not modified.
+			assert.Equal(t, &rtp.Header{SSRC: defaultSsrc, SequenceNumber: 65533}, packets[0].Header)
+
+			// Packets before the one without header are returned.
+			encrypted, err = encryptCtx.EncryptRTPSequence([]RTPPacketWithROC{packets[0], {ROC: roc}})
+			assert.ErrorIs(t, err, errNilRTPHeader)
+			assert.Len(t, encrypted, 1)
+		})
+	}
+}
+
+func TestDecryptRTPOrPassthrough(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, SRTPReplayProtection(64), UnsafePlaintextPassthrough())
+			assert.NoError(t, err)
+			strictCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+
+			marshal := func(header rtp.Header, payload []byte) []byte {
+				raw, errMarshal := (&rtp.Packet{Header: header, Payload: payload}).Marshal()
+				assert.NoError(t, errMarshal)
+
+				return raw
+			}
+
+			for seq := uint16(1); seq <= 6; seq++ {
+				header := rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: seq}
+				plaintext := marshal(header, rtpTestCaseDecrypted())
+				packet := plaintext
+				encrypted := seq%2 == 0
+				if encrypted {
+					packet, err = encryptCtx.EncryptRTP(nil, plaintext, nil)
+					assert.NoError(t, err)
+				}
+
+				_, _, err = strictCtx.DecryptRTPOrPassthrough(nil, packet, nil)
+				if encrypted {
+					assert.NoError(t, err)
+				} else {
+					assert.Error(t, err)
+				}
+
+				// Decrypt in place.
+				packet = append([]byte{}, packet...)
+				decrypted, isPlaintext, errDecrypt := decryptCtx.DecryptRTPOrPassthrough(packet, packet, nil)
+				assert.NoError(t, errDecrypt)
+				assert.Equal(t, !encrypted, isPlaintext)
+				assert.Equal(t, plaintext, decrypted)
+			}
+
+			// Short plaintext packet is passed through too.
+			short := marshal(rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: 7}, []byte{0x01})
+			decrypted, isPlaintext, err := decryptCtx.DecryptRTPOrPassthrough(nil, short, nil)
+			assert.NoError(t, err)
+			assert.True(t, isPlaintext)
+			assert.Equal(t, short, decrypted)
+
+			// Packets which do not look like RTP are rejected.
+			notRTP := marshal(rtp.Header{Version: 1, SSRC: defaultSsrc, SequenceNumber: 8}, rtpTestCaseDecrypted())
+			_, isPlaintext, err = decryptCtx.DecryptRTPOrPassthrough(nil, notRTP, nil)
+			assert.Error(t, err)
+			assert.False(t, isPlaintext)
+
+			badPadding := marshal(rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: 9}, rtpTestCaseDecrypted())
+			badPadding[0] |= 0x20
+			badPadding[len(badPadding)-1] = 0xff
+			_, isPlaintext, err = decryptCtx.DecryptRTPOrPassthrough(nil, badPadding, nil)
+			assert.Error(t, err)
+			assert.False(t, isPlaintext)
+
+			// Replay protection still works for encrypted packets.
+			plaintext := marshal(rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: 2}, rtpTestCaseDecrypted())
+			replayed, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			packet, err := replayed.EncryptRTP(nil, plaintext, nil)
+			assert.NoError(t, err)
+			_, isPlaintext, err = decryptCtx.DecryptRTPOrPassthrough(nil, packet, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+			assert.False(t, isPlaintext)
+		})
+	}
+}
+
+func TestDecryptRTPCopy(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: 1, CSRC: []uint32{1, 2}},
+				Payload: rtpTestCaseDecrypted(),
+			}
+			assert.NoError(t, pkt.SetExtension(1, []byte{0xaa, 0xbb}))
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+
+			header := &rtp.Header{}
+			decrypted, err := decryptCtx.DecryptRTPCopy(encrypted, header)
+			assert.NoError(t, err)
+			assert.Equal(t, pktRaw, decrypted)
+
+			// Overwrite the input, the plaintext and the header must not change.
+			for i := range encrypted {
+				encrypted[i] = 0xff
+			}
+			assert.Equal(t, pktRaw, decrypted)
+			assert.Equal(t, []byte{0xaa, 0xbb}, header.GetExtension(1))
+			assert.Equal(t, []uint32{1, 2}, header.CSRC)
+		})
+	}
+}
+
+func TestDecryptRTPInPlace(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			mki := MasterKeyIndicator([]byte{1, 2, 3, 4})
+			encryptCtx, err := buildTestContext(profile, mki)
+			assert.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, mki)
+			assert.NoError(t, err)
+
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: 1, CSRC: []uint32{1}},
+				Payload: rtpTestCaseDecrypted(),
+			}
+			pktRaw, err := pkt.Marshal()
+			assert.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+
+			buf := bytes.Clone(encrypted)
+			header := &rtp.Header{}
+			n, err := decryptCtx.DecryptRTPInPlace(buf, header)
+			assert.NoError(t, err)
+			assert.Equal(t, len(pkt.Payload), n)
+			assert.Equal(t, uint16(1), header.SequenceNumber)
+			headerLen := header.MarshalSize()
+			assert.Equal(t, pktRaw, buf[:headerLen+n])
+
+			allocs := testing.AllocsPerRun(10, func() {
+				copy(buf, encrypted)
+				_, _ = decryptCtx.DecryptRTPInPlace(buf, header)
+			})
+			assert.Zero(t, allocs)
+
+			copy(buf, encrypted)
+			buf[headerLen] ^= 0xff
+			_, err = decryptCtx.DecryptRTPInPlace(buf, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			_, err = decryptCtx.DecryptRTPInPlace([]byte{0x80}, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidateSRTPStructure(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR, MasterKeyIndicator([]byte{1, 2}))
+	assert.NoError(t, err)
+	pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: defaultSsrc, CSRC: []uint32{1}}, Payload: []byte{0x01}}
+	assert.NoError(t, pkt.SetExtension(1, []byte{0xaa}))
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+	valid, err := encryptCtx.EncryptRTP(nil, pktRaw, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ValidateSRTPStructure(valid, profileCTR, 2))
+	assert.NoError(t, ValidateSRTPStructure(valid, ProtectionProfileAes128CmHmacSha1_32, 0))
+
+	// Header without payload, with room for GCM tag.
+	assert.NoError(t, ValidateSRTPStructure(append([]byte{0x80}, make([]byte, 11+16)...), profileGCM, 0))
+
+	wrongVersion := append([]byte{}, valid...)
+	wrongVersion[0] = wrongVersion[0]&0x3f | 0x40
+	truncatedCSRC := []byte{0x8f, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	for name, test := range map[string]struct {
+		buf     []byte
+		profile ProtectionProfile
+		mkiLen  int
+		err     error
+	}{
+		"UnsupportedProfile": {valid, 0x1234, 0, ErrUnsupportedProfile},
+		"NegativeMKILength":  {valid, profileCTR, -1, errInvalidMKILength},
+		"Empty":              {nil, profileCTR, 0, errTooShortRTP},
+		"ShortHeader":        {valid[:11], profileCTR, 0, errTooShortRTP},
+		"WrongVersion":       {wrongVersion, profileCTR, 2, errInvalidRTPVersion},
+		"TruncatedCSRC":      {truncatedCSRC, profileCTR, 0, nil},
+		"NoRoomForTag":       {valid, profileGCM, 2, errTooShortRTP},
+		"NoRoomForMKI":       {valid, profileCTR, 4, errTooShortRTP},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateSRTPStructure(test.buf, test.profile, test.mkiLen)
+			assert.Error(t, err)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestSRTPTruncatedAuthTagPolicy(t *testing.T) {
+	pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: defaultSsrc, SequenceNumber: 1}, Payload: []byte{0x01}}
+	pktRaw, err := pkt.Marshal()
+	assert.NoError(t, err)
+
+	for name, test := range map[string]struct {
+		profile ProtectionProfile
+		opts    []ContextOption
+		err     error
+	}{
+		"Full":      {ProtectionProfileAes128CmHmacSha1_80, nil, nil},
+		"Truncated": {ProtectionProfileAes128CmHmacSha1_32, nil, ErrTruncatedAuthTag},
+		"Custom":    {profileCTR, []ContextOption{SRTPAuthenticationTagLength(8)}, ErrTruncatedAuthTag},
+		"AEAD":      {ProtectionProfileAeadAes128Gcm8, nil, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sendOnly, err := buildTestContext(test.profile,
+				append(test.opts, SRTPTruncatedAuthTagPolicy(TruncatedAuthTagAllowed, TruncatedAuthTagRejected))...)
+			assert.NoError(t, err)
+			encrypted, err := sendOnly.EncryptRTP(nil, pktRaw, nil)
+			assert.NoError(t, err)
+			_, err = sendOnly.DecryptRTP(nil, encrypted, nil)
+			assert.ErrorIs(t, err, test.err)
+
+			receiveOnly, err := buildTestContext(test.profile,
+				append(test.opts, SRTPTruncatedAuthTagPolicy(TruncatedAuthTagRejected, TruncatedAuthTagAllowed))...)
+			assert.NoError(t, err)
+			_, err = receiveOnly.EncryptRTP(nil, pktRaw, nil)
+			assert.ErrorIs(t, err, test.err)
+			_, err = receiveOnly.DecryptRTP(nil, encrypted, nil)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSRTPTruncatedAuthTagPolicySSRCKeys(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR, SRTPTruncatedAuthTagPolicy(TruncatedAuthTagRejected, TruncatedAuthTagAllowed))
+	assert.NoError(t, err)
+	assert.NoError(t, ctx.SetSSRCKeys(2, ProtectionProfileAes128CmHmacSha1_32, make([]byte, 16), make([]byte, 14)))
+
+	encrypt := func(ssrc uint32) error {
+		pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: 1}, Payload: []byte{0x01}}
+		pktRaw, errMarshal := pkt.Marshal()
+		assert.NoError(t, errMarshal)
+		_, errEncrypt := ctx.EncryptRTP(nil, pktRaw, nil)
+
+		return errEncrypt
+	}
+
+	// Policy is checked against keys of the SSRC, not the ones of the Context.
+	assert.NoError(t, encrypt(1))
+	assert.ErrorIs(t, encrypt(2), ErrTruncatedAuthTag)
+}
+
+func TestEncryptRTPWithHeader(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	referenceCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+
+	pkt := &rtp.Packet{Header: rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 5}, Payload: []byte{0x01, 0x02}}
+	plaintext, err := pkt.Marshal()
+	assert.NoError(t, err)
+	header := &rtp.Header{}
+	headerLen, err := header.Unmarshal(plaintext)
+	assert.NoError(t, err)
+
+	encrypted, err := encryptCtx.EncryptRTPWithHeader(nil, header, headerLen, plaintext)
+	assert.NoError(t, err)
+	expected, err := referenceCtx.EncryptRTP(nil, plaintext, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, encrypted)
+
+	_, err = encryptCtx.EncryptRTPWithHeader(nil, nil, headerLen, plaintext)
+	assert.ErrorIs(t, err, errNilRTPHeader)
+	for _, invalidLen := range []int{-1, 0, minSrtpHeaderSize - 1, len(plaintext) + 1} {
+		_, err = encryptCtx.EncryptRTPWithHeader(nil, header, invalidLen, plaintext)
+		assert.ErrorIs(t, err, errTooShortRTP)
+	}
+}
+
+func TestDecryptRTPWithHeaderLen(t *testing.T) {
+	// Header extension element is longer than the extension, so rtp.Header.Unmarshal rejects the header.
+	plaintext := []byte{
+		0x90, 0x60, 0x00, 0x05, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01,
+		0xbe, 0xde, 0x00, 0x01, 0x13, 0x01, 0x02, 0x03,
+		0xaa, 0xbb,
+	}
+	const headerLen = 20
+	_, err := (&rtp.Header{}).Unmarshal(plaintext)
+	assert.Error(t, err)
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTPWithHeader(nil, &rtp.Header{SSRC: 1, SequenceNumber: 5},
+				headerLen, plaintext)
+			assert.NoError(t, err)
+
+			decryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+			assert.Error(t, err)
+			_, err = decryptCtx.DecryptRTPWithHeaderLen(nil, encrypted, headerLen, 1, 6)
+			assert.ErrorIs(t, err, errRTPHeaderMismatch)
+			_, err = decryptCtx.DecryptRTPWithHeaderLen(nil, encrypted, headerLen, 2, 5)
+			assert.ErrorIs(t, err, errRTPHeaderMismatch)
+			_, err = decryptCtx.DecryptRTPWithHeaderLen(nil, encrypted, len(encrypted)+1, 1, 5)
+			assert.ErrorIs(t, err, errTooShortRTP)
+			decrypted, err := decryptCtx.DecryptRTPWithHeaderLen(nil, encrypted, headerLen, 1, 5)
+			assert.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		})
+	}
+
+	ctx, err := buildTestContext(profileCTR, Cryptex(CryptexModeEnabled))
+	assert.NoError(t, err)
+	_, err = ctx.DecryptRTPWithHeaderLen(nil, plaintext, headerLen, 1, 5)
+	assert.ErrorIs(t, err, errParsedHeaderRequired)
+}
+
+func TestSRTPROCRecovery(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			encryptCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			packet1 := encryptTestRTP(t, encryptCtx, 100)
+			// Sender rolled over while all packets were lost.
+			encryptCtx.SetROC(defaultSsrc, 1)
+			packet2 := encryptTestRTP(t, encryptCtx, 200)
+			packet3 := encryptTestRTP(t, encryptCtx, 201)
+
+			plainCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			_, err = plainCtx.DecryptRTP(nil, packet1, nil)
+			assert.NoError(t, err)
+			_, err = plainCtx.DecryptRTP(nil, packet2, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			recoveryCtx, err := buildTestContext(profile, SRTPROCRecovery(), SRTPReplayProtection(64))
+			assert.NoError(t, err)
+			_, err = recoveryCtx.DecryptRTP(nil, packet1, nil)
+			assert.NoError(t, err)
+			// Packet is decrypted in place, so the copy is used for retries.
+			inPlace := append([]byte{}, packet2...)
+			decrypted, err := recoveryCtx.DecryptRTP(inPlace, inPlace, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{0x00, 0x01, 0x02, 0x03}, decrypted[len(decrypted)-4:])
+			roc, ok := recoveryCtx.ROC(defaultSsrc)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(1), roc)
+			_, err = recoveryCtx.DecryptRTP(nil, packet2, nil)
+			assert.ErrorIs(t, err, errDuplicated)
+			_, err = recoveryCtx.DecryptRTP(nil, packet3, nil)
+			assert.NoError(t, err)
+
+			// Receiver joined mid-stream.
+			joinCtx, err := buildTestContext(profile, SRTPROCRecovery())
+			assert.NoError(t, err)
+			_, err = joinCtx.DecryptRTP(nil, packet3, nil)
+			assert.NoError(t, err)
+			roc, ok = joinCtx.ROC(defaultSsrc)
+			assert.True(t, ok)
+			assert.Equal(t, uint32(1), roc)
+
+			// Forged packets still fail.
+			forged := append([]byte{}, packet3...)
+			forged[len(forged)-1] ^= 0x01
+			_, err = joinCtx.DecryptRTP(nil, forged, nil)
+			assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+		})
+	}
+}
+
+func TestRewriteAndEncryptRTP(t *testing.T) {
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		t.Run(name, func(t *testing.T) {
+			sourceCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			relayInCtx, err := buildTestContext(profile)
+			assert.NoError(t, err)
+			// Outgoing leg of the relay uses other keys.
+			keyLen, err := profile.KeyLen()
+			assert.NoError(t, err)
+			saltLen, err := profile.SaltLen()
+			assert.NoError(t, err)
+			relayOutCtx, err := CreateContext(make([]byte, keyLen), make([]byte, saltLen), profile)
+			assert.NoError(t, err)
+			receiverCtx, err := CreateContext(make([]byte, keyLen), make([]byte, saltLen), profile)
+			assert.NoError(t, err)
+
+			rewrite := RTPHeaderRewrite{
+				HasSSRC: true, SSRC: 0x11223344, SequenceNumberOffset: 10, TimestampOffset: 0xfffffff0,
+			}
+			for _, inPlace := range []bool{false, true} {
+				pkt := &rtp.Packet{
+					Header:  rtp.Header{Version: 2, SSRC: 1, SequenceNumber: 65530, Timestamp: 100, CSRC: []uint32{2}},
+					Payload: []byte{0x00, 0x01, 0x02, 0x03},
+				}
+				if inPlace {
+					pkt.SequenceNumber++
+				}
+				raw, err := pkt.Marshal()
+				assert.NoError(t, err)
+				encrypted, err := sourceCtx.EncryptRTP(nil, raw, nil)
+				assert.NoError(t, err)
+
+				decrypted, err := relayInCtx.DecryptRTP(nil, encrypted, nil)
+				assert.NoError(t, err)
+				original := append([]byte{}, decrypted...)
+				var dst []byte
+				if inPlace {
+					dst = decrypted[:0]
+				}
+				header := &rtp.Header{}
+				relayed, err := relayOutCtx.RewriteAndEncryptRTP(dst, decrypted, header, rewrite)
+				assert.NoError(t, err)
+				if !inPlace {
+					assert.Equal(t, original, decrypted, "plaintext must not be modified")
+				}
+				assert.Equal(t, uint32(0x11223344), header.SSRC)
+
+				received := &rtp.Packet{}
+				out, err := receiverCtx.DecryptRTP(nil, relayed, nil)
+				assert.NoError(t, err)
+				assert.NoError(t, received.Unmarshal(out))
+				assert.Equal(t, uint32(0x11223344), received.SSRC)
+				assert.Equal(t, pkt.SequenceNumber+10, received.SequenceNumber)
+				assert.Equal(t, uint32(100-16), received.Timestamp)
+				assert.Equal(t, []uint32{2}, received.CSRC)
+				assert.Equal(t, pkt.Payload, received.Payload)
+			}
+		})
+	}
+}
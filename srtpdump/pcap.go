@@ -0,0 +1,328 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtpdump
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+	"time"
+)
+
+const (
+	pcapMagicMicroseconds = 0xa1b2c3d4
+	pcapMagicNanoseconds  = 0xa1b23c4d
+	pcapHeaderLen         = 24
+	pcapRecordHeaderLen   = 16
+	pcapMaxSnapLen        = 262144
+
+	linkTypeNull      = 0
+	linkTypeEthernet  = 1
+	linkTypeRaw       = 101
+	linkTypeLinuxSLL  = 113
+	linkTypeIPv4      = 228
+	linkTypeIPv6      = 229
+	linkTypeLinuxSLL2 = 276
+
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+
+	ipv4HeaderLen = 20
+	ipv6HeaderLen = 40
+	udpHeaderLen  = 8
+	protocolUDP   = 17
+)
+
+// PcapReader reads UDP packets from a classic pcap file, with Ethernet, Linux cooked capture, BSD
+// loopback or raw IP link layer. Non-UDP packets and fragmented IPv4 packets are skipped. The pcapng
+// format is not supported.
+type PcapReader struct {
+	r           io.Reader
+	byteOrder   binary.ByteOrder
+	nanoseconds bool
+	linkType    uint32
+	header      [pcapRecordHeaderLen]byte
+}
+
+// NewPcapReader creates PcapReader, and reads the file header from r.
+func NewPcapReader(r io.Reader) (*PcapReader, error) {
+	var header [pcapHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+	}
+
+	reader := &PcapReader{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(header[:]) == pcapMagicMicroseconds:
+		reader.byteOrder = binary.LittleEndian
+	case binary.LittleEndian.Uint32(header[:]) == pcapMagicNanoseconds:
+		reader.byteOrder, reader.nanoseconds = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(header[:]) == pcapMagicMicroseconds:
+		reader.byteOrder = binary.BigEndian
+	case binary.BigEndian.Uint32(header[:]) == pcapMagicNanoseconds:
+		reader.byteOrder, reader.nanoseconds = binary.BigEndian, true
+	default:
+		return nil, fmt.Errorf("%w: magic %#x", errUnsupportedFormat, header[:4])
+	}
+
+	reader.linkType = reader.byteOrder.Uint32(header[20:]) & 0x0fffffff
+	switch reader.linkType {
+	case linkTypeNull, linkTypeEthernet, linkTypeRaw, linkTypeLinuxSLL, linkTypeIPv4, linkTypeIPv6, linkTypeLinuxSLL2:
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnsupportedLinkType, reader.linkType)
+	}
+
+	return reader, nil
+}
+
+// ReadPacket reads the next UDP packet.
+func (r *PcapReader) ReadPacket() (*Packet, error) {
+	for {
+		if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+			if err == io.EOF { //nolint:errorlint // io.ReadFull returns io.EOF as is
+				return nil, io.EOF
+			}
+
+			return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+		}
+
+		capturedLen := r.byteOrder.Uint32(r.header[8:])
+		if capturedLen > pcapMaxSnapLen {
+			return nil, fmt.Errorf("%w: record length %d", errInvalidRecord, capturedLen)
+		}
+		frame := make([]byte, capturedLen)
+		if _, err := io.ReadFull(r.r, frame); err != nil {
+			return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+		}
+
+		pkt, ok := r.parseFrame(frame)
+		if !ok {
+			continue
+		}
+
+		sec := int64(r.byteOrder.Uint32(r.header[0:]))
+		frac := int64(r.byteOrder.Uint32(r.header[4:]))
+		if !r.nanoseconds {
+			frac *= int64(time.Microsecond)
+		}
+		pkt.Timestamp = time.Unix(sec, frac)
+
+		return pkt, nil
+	}
+}
+
+// parseFrame extracts UDP packet from the link layer frame.
+func (r *PcapReader) parseFrame(frame []byte) (*Packet, bool) {
+	switch r.linkType {
+	case linkTypeNull:
+		if len(frame) < 4 {
+			return nil, false
+		}
+
+		return parseIP(frame[4:])
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return nil, false
+		}
+		etherType, payload := binary.BigEndian.Uint16(frame[12:]), frame[14:]
+		for (etherType == etherTypeVLAN || etherType == etherTypeQinQ) && len(payload) >= 4 {
+			etherType, payload = binary.BigEndian.Uint16(payload[2:]), payload[4:]
+		}
+		if etherType != etherTypeIPv4 && etherType != etherTypeIPv6 {
+			return nil, false
+		}
+
+		return parseIP(payload)
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return nil, false
+		}
+
+		return parseIP(frame[16:])
+	case linkTypeLinuxSLL2:
+		if len(frame) < 20 {
+			return nil, false
+		}
+
+		return parseIP(frame[20:])
+	default:
+		return parseIP(frame)
+	}
+}
+
+// parseIP extracts UDP packet from IPv4 or IPv6 packet.
+func parseIP(packet []byte) (*Packet, bool) {
+	if len(packet) < 1 {
+		return nil, false
+	}
+
+	var src, dst netip.Addr
+	var udp []byte
+	switch packet[0] >> 4 {
+	case 4:
+		headerLen := int(packet[0]&0x0f) * 4
+		if headerLen < ipv4HeaderLen || len(packet) < headerLen || packet[9] != protocolUDP {
+			return nil, false
+		}
+		// Fragments other than the first one do not have UDP header, and the first one is incomplete.
+		if binary.BigEndian.Uint16(packet[6:])&0x3fff != 0 {
+			return nil, false
+		}
+		totalLen := int(binary.BigEndian.Uint16(packet[2:]))
+		if totalLen < headerLen || totalLen > len(packet) {
+			return nil, false
+		}
+		src, dst = netip.AddrFrom4([4]byte(packet[12:16])), netip.AddrFrom4([4]byte(packet[16:20]))
+		udp = packet[headerLen:totalLen]
+	case 6:
+		// Extension headers are not supported.
+		if len(packet) < ipv6HeaderLen || packet[6] != protocolUDP {
+			return nil, false
+		}
+		payloadLen := int(binary.BigEndian.Uint16(packet[4:]))
+		if ipv6HeaderLen+payloadLen > len(packet) {
+			return nil, false
+		}
+		src, dst = netip.AddrFrom16([16]byte(packet[8:24])), netip.AddrFrom16([16]byte(packet[24:40]))
+		udp = packet[ipv6HeaderLen : ipv6HeaderLen+payloadLen]
+	default:
+		return nil, false
+	}
+
+	if len(udp) < udpHeaderLen {
+		return nil, false
+	}
+	udpLen := int(binary.BigEndian.Uint16(udp[4:]))
+	if udpLen < udpHeaderLen || udpLen > len(udp) {
+		return nil, false
+	}
+
+	return &Packet{
+		Source:      netip.AddrPortFrom(src, binary.BigEndian.Uint16(udp[0:])),
+		Destination: netip.AddrPortFrom(dst, binary.BigEndian.Uint16(udp[2:])),
+		Data:        append([]byte{}, udp[udpHeaderLen:udpLen]...),
+	}, true
+}
+
+// PcapWriter writes UDP packets to a classic pcap file, with raw IP link layer and nanosecond timestamps.
+// Packets with unknown addresses are written with unspecified address and zero port.
+type PcapWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewPcapWriter creates PcapWriter, and writes the file header to w.
+func NewPcapWriter(w io.Writer) (*PcapWriter, error) {
+	var header [pcapHeaderLen]byte
+	binary.LittleEndian.PutUint32(header[0:], pcapMagicNanoseconds)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], pcapMaxSnapLen)
+	binary.LittleEndian.PutUint32(header[20:], linkTypeRaw)
+	if _, err := w.Write(header[:]); err != nil {
+		return nil, err
+	}
+
+	return &PcapWriter{w: w}, nil
+}
+
+// WritePacket writes the packet.
+func (w *PcapWriter) WritePacket(pkt *Packet) error {
+	src, dst := pkt.Source, pkt.Destination
+	switch {
+	case !src.IsValid() && !dst.IsValid():
+		src = netip.AddrPortFrom(netip.IPv4Unspecified(), 0)
+		dst = src
+	case !src.IsValid():
+		src = netip.AddrPortFrom(unspecifiedAddr(dst.Addr()), 0)
+	case !dst.IsValid():
+		dst = netip.AddrPortFrom(unspecifiedAddr(src.Addr()), 0)
+	}
+	src = netip.AddrPortFrom(src.Addr().Unmap(), src.Port())
+	dst = netip.AddrPortFrom(dst.Addr().Unmap(), dst.Port())
+	if src.Addr().Is4() != dst.Addr().Is4() {
+		return errAddressFamily
+	}
+
+	ipHeaderLen := ipv4HeaderLen
+	if !src.Addr().Is4() {
+		ipHeaderLen = ipv6HeaderLen
+	}
+	udpLen := udpHeaderLen + len(pkt.Data)
+	if udpLen > 0xffff-ipHeaderLen {
+		return fmt.Errorf("%w: %d", errPacketTooLong, len(pkt.Data))
+	}
+	frameLen := ipHeaderLen + udpLen
+
+	w.buf = append(w.buf[:0], make([]byte, pcapRecordHeaderLen+frameLen)...)
+	record, ip := w.buf[:pcapRecordHeaderLen], w.buf[pcapRecordHeaderLen:]
+	binary.LittleEndian.PutUint32(record[0:], uint32(pkt.Timestamp.Unix()))       //nolint:gosec // G115
+	binary.LittleEndian.PutUint32(record[4:], uint32(pkt.Timestamp.Nanosecond())) //nolint:gosec // G115
+	binary.LittleEndian.PutUint32(record[8:], uint32(frameLen))                   //nolint:gosec // G115
+	binary.LittleEndian.PutUint32(record[12:], uint32(frameLen))                  //nolint:gosec // G115
+
+	udp := ip[ipHeaderLen:]
+	binary.BigEndian.PutUint16(udp[0:], src.Port())
+	binary.BigEndian.PutUint16(udp[2:], dst.Port())
+	binary.BigEndian.PutUint16(udp[4:], uint16(udpLen)) //nolint:gosec // G115
+	copy(udp[udpHeaderLen:], pkt.Data)
+
+	srcAddr, dstAddr := src.Addr().AsSlice(), dst.Addr().AsSlice()
+	if src.Addr().Is4() {
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:], uint16(frameLen)) //nolint:gosec // G115
+		ip[8] = 64
+		ip[9] = protocolUDP
+		copy(ip[12:], srcAddr)
+		copy(ip[16:], dstAddr)
+		binary.BigEndian.PutUint16(ip[10:], ^onesComplementSum(0, ip[:ipv4HeaderLen]))
+		// UDP checksum is optional for IPv4, zero means that it is not computed.
+	} else {
+		ip[0] = 0x60
+		binary.BigEndian.PutUint16(ip[4:], uint16(udpLen)) //nolint:gosec // G115
+		ip[6] = protocolUDP
+		ip[7] = 64
+		copy(ip[8:], srcAddr)
+		copy(ip[24:], dstAddr)
+		// UDP checksum is mandatory for IPv6, it covers pseudo-header with addresses, length and protocol.
+		sum := onesComplementSum(0, ip[8:40])
+		sum = onesComplementSum(sum, []byte{0, 0, byte(udpLen >> 8), byte(udpLen), 0, 0, 0, protocolUDP})
+		checksum := ^onesComplementSum(sum, udp)
+		if checksum == 0 {
+			checksum = 0xffff
+		}
+		binary.BigEndian.PutUint16(udp[6:], checksum)
+	}
+
+	_, err := w.w.Write(w.buf)
+
+	return err
+}
+
+func unspecifiedAddr(addr netip.Addr) netip.Addr {
+	if addr.Unmap().Is4() {
+		return netip.IPv4Unspecified()
+	}
+
+	return netip.IPv6Unspecified()
+}
+
+// onesComplementSum adds data to the Internet checksum sum from RFC 1071.
+func onesComplementSum(sum uint16, data []byte) uint16 {
+	acc := uint32(sum)
+	for i := 0; i+1 < len(data); i += 2 {
+		acc += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		acc += uint32(data[len(data)-1]) << 8
+	}
+	for acc > 0xffff {
+		acc = acc&0xffff + acc>>16
+	}
+
+	return uint16(acc) //nolint:gosec // G115
+}
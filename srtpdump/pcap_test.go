@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtpdump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPcapRoundTrip(t *testing.T) {
+	packets := []*Packet{
+		{
+			Timestamp:   time.Unix(1700000000, 123456789),
+			Source:      netip.MustParseAddrPort("192.0.2.1:5004"),
+			Destination: netip.MustParseAddrPort("192.0.2.2:5006"),
+			Data:        []byte{0x80, 0x60, 0x00, 0x01, 0xaa},
+		},
+		{
+			Timestamp:   time.Unix(1700000001, 0),
+			Source:      netip.MustParseAddrPort("[2001:db8::1]:5004"),
+			Destination: netip.MustParseAddrPort("[2001:db8::2]:5006"),
+			Data:        []byte{0x80, 0x60, 0x00, 0x02},
+		},
+		{
+			Timestamp:   time.Unix(1700000002, 0),
+			Source:      netip.MustParseAddrPort("192.0.2.1:5004"),
+			Destination: netip.AddrPortFrom(netip.IPv4Unspecified(), 0),
+			Data:        []byte{},
+		},
+	}
+
+	var buf bytes.Buffer
+	writer, err := NewPcapWriter(&buf)
+	assert.NoError(t, err)
+	for _, pkt := range packets {
+		assert.NoError(t, writer.WritePacket(pkt))
+	}
+	// Missing destination is written as unspecified address.
+	assert.NoError(t, writer.WritePacket(&Packet{
+		Timestamp: packets[2].Timestamp, Source: packets[2].Source, Data: packets[2].Data,
+	}))
+	packets = append(packets, packets[2])
+
+	assert.ErrorIs(t, writer.WritePacket(&Packet{
+		Source:      netip.MustParseAddrPort("192.0.2.1:5004"),
+		Destination: netip.MustParseAddrPort("[2001:db8::2]:5006"),
+	}), errAddressFamily)
+	assert.ErrorIs(t, writer.WritePacket(&Packet{Data: make([]byte, 0x10000)}), errPacketTooLong)
+
+	data := buf.Bytes()
+	// IPv4 header checksum of the first packet is valid.
+	assert.Equal(t, uint16(0xffff), onesComplementSum(0, data[pcapHeaderLen+pcapRecordHeaderLen:][:ipv4HeaderLen]))
+
+	reader, err := NewPcapReader(&buf)
+	assert.NoError(t, err)
+	for _, expected := range packets {
+		pkt, err := reader.ReadPacket()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, pkt)
+	}
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestPcapReaderEthernet(t *testing.T) {
+	ipv4 := func(protocol byte, flags uint16, payload []byte) []byte {
+		packet := make([]byte, ipv4HeaderLen+udpHeaderLen+len(payload))
+		packet[0] = 0x45
+		binary.BigEndian.PutUint16(packet[2:], uint16(len(packet))) //nolint:gosec // G115
+		binary.BigEndian.PutUint16(packet[6:], flags)
+		packet[9] = protocol
+		copy(packet[12:], []byte{10, 0, 0, 1, 10, 0, 0, 2})
+		binary.BigEndian.PutUint16(packet[20:], 1000)
+		binary.BigEndian.PutUint16(packet[22:], 2000)
+		binary.BigEndian.PutUint16(packet[24:], uint16(udpHeaderLen+len(payload))) //nolint:gosec // G115
+		copy(packet[28:], payload)
+
+		return packet
+	}
+	ethernet := func(vlan bool, etherType uint16, payload []byte) []byte {
+		frame := make([]byte, 12)
+		if vlan {
+			frame = binary.BigEndian.AppendUint16(frame, etherTypeVLAN)
+			frame = binary.BigEndian.AppendUint16(frame, 42)
+		}
+		frame = binary.BigEndian.AppendUint16(frame, etherType)
+
+		return append(frame, payload...)
+	}
+
+	// Big endian file with microsecond timestamps.
+	file := binary.BigEndian.AppendUint32(nil, pcapMagicMicroseconds)
+	file = append(file, 0, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff)
+	file = binary.BigEndian.AppendUint32(file, linkTypeEthernet)
+	for i, frame := range [][]byte{
+		ethernet(false, 0x0806, make([]byte, 28)),
+		ethernet(false, etherTypeIPv4, ipv4(6, 0, []byte{1})),
+		ethernet(false, etherTypeIPv4, ipv4(protocolUDP, 0x2000, []byte{2})),
+		ethernet(true, etherTypeIPv4, ipv4(protocolUDP, 0x4000, []byte{3})),
+	} {
+		file = binary.BigEndian.AppendUint32(file, 1700000000)
+		file = binary.BigEndian.AppendUint32(file, uint32(i))          //nolint:gosec // G115
+		file = binary.BigEndian.AppendUint32(file, uint32(len(frame))) //nolint:gosec // G115
+		file = binary.BigEndian.AppendUint32(file, uint32(len(frame))) //nolint:gosec // G115
+		file = append(file, frame...)
+	}
+
+	reader, err := NewPcapReader(bytes.NewReader(file))
+	assert.NoError(t, err)
+	// ARP, TCP and fragmented packets are skipped.
+	pkt, err := reader.ReadPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, &Packet{
+		Timestamp:   time.Unix(1700000000, 3000),
+		Source:      netip.MustParseAddrPort("10.0.0.1:1000"),
+		Destination: netip.MustParseAddrPort("10.0.0.2:2000"),
+		Data:        []byte{3},
+	}, pkt)
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, io.EOF)
+
+	reader, err = NewPcapReader(bytes.NewReader(file[:len(file)-1]))
+	assert.NoError(t, err)
+	for err == nil {
+		_, err = reader.ReadPacket()
+	}
+	assert.ErrorIs(t, err, errTruncatedFile)
+}
+
+func TestPcapReaderInvalidHeader(t *testing.T) {
+	// pcapng section header block.
+	_, err := NewPcapReader(bytes.NewReader(append([]byte{0x0a, 0x0d, 0x0d, 0x0a}, make([]byte, 20)...)))
+	assert.ErrorIs(t, err, errUnsupportedFormat)
+
+	header := binary.LittleEndian.AppendUint32(nil, pcapMagicMicroseconds)
+	header = append(header, make([]byte, 16)...)
+	header = binary.LittleEndian.AppendUint32(header, 147)
+	_, err = NewPcapReader(bytes.NewReader(header))
+	assert.ErrorIs(t, err, errUnsupportedLinkType)
+
+	_, err = NewPcapReader(bytes.NewReader(header[:10]))
+	assert.ErrorIs(t, err, errTruncatedFile)
+}
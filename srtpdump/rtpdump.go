@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtpdump
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	rtpdumpMagic           = "#!rtpplay1.0 "
+	rtpdumpMaxLineLen      = 256
+	rtpdumpHeaderLen       = 16
+	rtpdumpRecordHeaderLen = 8
+)
+
+// RTPDumpReader reads packets from a rtpdump file, in the binary format written by rtpdump -F dump
+// and read by rtpplay. Packets have Source set to the address from the file header, and no Destination.
+type RTPDumpReader struct {
+	r      *bufio.Reader
+	addr   netip.AddrPort
+	start  time.Time
+	header [rtpdumpRecordHeaderLen]byte
+}
+
+// NewRTPDumpReader creates RTPDumpReader, and reads the file header from r.
+func NewRTPDumpReader(r io.Reader) (*RTPDumpReader, error) {
+	reader := &RTPDumpReader{r: bufio.NewReader(r)}
+
+	magic := make([]byte, len(rtpdumpMagic))
+	if _, err := io.ReadFull(reader.r, magic); err != nil {
+		return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+	}
+	if string(magic) != rtpdumpMagic {
+		return nil, fmt.Errorf("%w: magic %q", errUnsupportedFormat, magic)
+	}
+
+	var line []byte
+	for {
+		b, err := reader.r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+		}
+		if b == '\n' {
+			break
+		}
+		if len(line) >= rtpdumpMaxLineLen {
+			return nil, fmt.Errorf("%w: header line is too long", errUnsupportedFormat)
+		}
+		line = append(line, b)
+	}
+
+	// The address is written as "address/port". It is informational only, so parse errors are ignored.
+	if addr, port, ok := strings.Cut(strings.TrimSpace(string(line)), "/"); ok {
+		if addrPort, err := netip.ParseAddrPort(addr + ":" + port); err == nil {
+			reader.addr = addrPort
+		} else if addrPort, err = netip.ParseAddrPort("[" + addr + "]:" + port); err == nil {
+			reader.addr = addrPort
+		}
+	}
+
+	var header [rtpdumpHeaderLen]byte
+	if _, err := io.ReadFull(reader.r, header[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+	}
+	reader.start = time.Unix(
+		int64(binary.BigEndian.Uint32(header[0:])),
+		int64(binary.BigEndian.Uint32(header[4:]))*int64(time.Microsecond),
+	)
+
+	return reader, nil
+}
+
+// Addr returns the address from the file header. It is not valid if the header does not contain it.
+func (r *RTPDumpReader) Addr() netip.AddrPort {
+	return r.addr
+}
+
+// Start returns the recording start time from the file header.
+func (r *RTPDumpReader) Start() time.Time {
+	return r.start
+}
+
+// ReadPacket reads the next packet.
+func (r *RTPDumpReader) ReadPacket() (*Packet, error) {
+	if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+		if err == io.EOF { //nolint:errorlint // io.ReadFull returns io.EOF as is
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+	}
+
+	length := int(binary.BigEndian.Uint16(r.header[0:]))
+	if length < rtpdumpRecordHeaderLen {
+		return nil, fmt.Errorf("%w: record length %d", errInvalidRecord, length)
+	}
+	data := make([]byte, length-rtpdumpRecordHeaderLen)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, fmt.Errorf("%w: %w", errTruncatedFile, err)
+	}
+
+	// Packet length may be larger than the record length, when rtpdump saved only packet headers.
+	// Such packets cannot be decrypted, and are returned as they are.
+	offset := time.Duration(binary.BigEndian.Uint32(r.header[4:])) * time.Millisecond
+
+	return &Packet{
+		Timestamp: r.start.Add(offset),
+		Source:    r.addr,
+		Data:      data,
+	}, nil
+}
+
+// RTPDumpWriter writes packets to a rtpdump file. Packet addresses are not stored, only the address
+// passed to NewRTPDumpWriter is written to the file header.
+type RTPDumpWriter struct {
+	w     io.Writer
+	start time.Time
+	buf   []byte
+}
+
+// NewRTPDumpWriter creates RTPDumpWriter, and writes the file header with addr and start time to w.
+// Packet timestamps are stored as millisecond offsets from start.
+func NewRTPDumpWriter(w io.Writer, addr netip.AddrPort, start time.Time) (*RTPDumpWriter, error) {
+	if !addr.IsValid() {
+		addr = netip.AddrPortFrom(netip.IPv4Unspecified(), 0)
+	}
+	line := fmt.Sprintf("%s%s/%d\n", rtpdumpMagic, addr.Addr().Unmap(), addr.Port())
+
+	header := make([]byte, len(line)+rtpdumpHeaderLen)
+	copy(header, line)
+	fileHeader := header[len(line):]
+	binary.BigEndian.PutUint32(fileHeader[0:], uint32(start.Unix()))                             //nolint:gosec // G115
+	binary.BigEndian.PutUint32(fileHeader[4:], uint32(start.Nanosecond()/int(time.Microsecond))) //nolint:gosec // G115
+	if addr4 := addr.Addr().Unmap(); addr4.Is4() {
+		copy(fileHeader[8:], addr4.AsSlice())
+	}
+	binary.BigEndian.PutUint16(fileHeader[12:], addr.Port())
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &RTPDumpWriter{w: w, start: start}, nil
+}
+
+// WritePacket writes the packet.
+func (w *RTPDumpWriter) WritePacket(pkt *Packet) error {
+	length := rtpdumpRecordHeaderLen + len(pkt.Data)
+	if length > 0xffff {
+		return fmt.Errorf("%w: %d", errPacketTooLong, len(pkt.Data))
+	}
+
+	var offset time.Duration
+	if !pkt.Timestamp.IsZero() && pkt.Timestamp.After(w.start) {
+		offset = pkt.Timestamp.Sub(w.start)
+	}
+
+	w.buf = append(w.buf[:0], make([]byte, rtpdumpRecordHeaderLen)...)
+	binary.BigEndian.PutUint16(w.buf[0:], uint16(length)) //nolint:gosec // G115
+	// Packet length is zero for RTCP packets.
+	if !isRTCP(pkt.Data) {
+		binary.BigEndian.PutUint16(w.buf[2:], uint16(len(pkt.Data))) //nolint:gosec // G115
+	}
+	binary.BigEndian.PutUint32(w.buf[4:], uint32(offset/time.Millisecond)) //nolint:gosec // G115
+	w.buf = append(w.buf, pkt.Data...)
+
+	_, err := w.w.Write(w.buf)
+
+	return err
+}
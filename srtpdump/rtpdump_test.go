@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtpdump
+
+import (
+	"bytes"
+	"io"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTPDumpRoundTrip(t *testing.T) {
+	addr := netip.MustParseAddrPort("192.0.2.1:5004")
+	start := time.Unix(1699999999, 999500000)
+	packets := testPackets(t)
+
+	var buf bytes.Buffer
+	writer, err := NewRTPDumpWriter(&buf, addr, start)
+	assert.NoError(t, err)
+	for _, pkt := range packets {
+		assert.NoError(t, writer.WritePacket(pkt))
+	}
+	assert.ErrorIs(t, writer.WritePacket(&Packet{Data: make([]byte, 0x10000)}), errPacketTooLong)
+
+	data := buf.Bytes()
+	assert.True(t, bytes.HasPrefix(data, []byte("#!rtpplay1.0 192.0.2.1/5004\n")))
+	// Packet length of the first RTP packet, and zero packet length of the RTCP packet.
+	record := data[len("#!rtpplay1.0 192.0.2.1/5004\n")+rtpdumpHeaderLen:]
+	assert.Equal(t, []byte{0, 8 + 112, 0, 112}, record[:4])
+	rtcpRecord := record[5*(8+112):]
+	assert.Equal(t, []byte{0, 0}, rtcpRecord[2:4])
+
+	reader, err := NewRTPDumpReader(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, addr, reader.Addr())
+	assert.Equal(t, start, reader.Start())
+	for _, expected := range packets {
+		pkt, err := reader.ReadPacket()
+		assert.NoError(t, err)
+		assert.Equal(t, expected.Data, pkt.Data)
+		assert.Equal(t, addr, pkt.Source)
+		assert.False(t, pkt.Destination.IsValid())
+		// Offsets are stored with millisecond precision.
+		assert.Equal(t, expected.Timestamp.Sub(start).Truncate(time.Millisecond), pkt.Timestamp.Sub(start))
+	}
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestRTPDumpReaderIPv6(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := NewRTPDumpWriter(&buf, netip.MustParseAddrPort("[2001:db8::1]:5004"), time.Unix(1700000000, 0))
+	assert.NoError(t, err)
+
+	reader, err := NewRTPDumpReader(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParseAddrPort("[2001:db8::1]:5004"), reader.Addr())
+}
+
+func TestRTPDumpReaderInvalid(t *testing.T) {
+	_, err := NewRTPDumpReader(bytes.NewReader([]byte("#!rtpplay2.0 1.2.3.4/5\n")))
+	assert.ErrorIs(t, err, errUnsupportedFormat)
+
+	_, err = NewRTPDumpReader(bytes.NewReader([]byte("#!rtpplay1.0 1.2.3.4/5\n0123")))
+	assert.ErrorIs(t, err, errTruncatedFile)
+
+	file := append([]byte("#!rtpplay1.0 1.2.3.4/5\n"), make([]byte, rtpdumpHeaderLen)...)
+	reader, err := NewRTPDumpReader(bytes.NewReader(append(file, 0, 4, 0, 0, 0, 0, 0, 0)))
+	assert.NoError(t, err)
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, errInvalidRecord)
+
+	reader, err = NewRTPDumpReader(bytes.NewReader(append(file, 0, 10, 0, 0, 0, 0, 0, 0, 1)))
+	assert.NoError(t, err)
+	_, err = reader.ReadPacket()
+	assert.ErrorIs(t, err, errTruncatedFile)
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package srtpdump reads and writes recorded SRTP sessions, in pcap and rtpdump formats, and decrypts
+// or encrypts them with srtp.Context. It is intended for debugging interoperability problems and for
+// building test corpora.
+package srtpdump
+
+import (
+	"errors"
+	"io"
+	"net/netip"
+	"time"
+
+	"github.com/pion/srtp/v3"
+)
+
+var (
+	errUnsupportedFormat   = errors.New("unsupported capture file format")
+	errUnsupportedLinkType = errors.New("unsupported pcap link type")
+	errTruncatedFile       = errors.New("truncated capture file")
+	errInvalidRecord       = errors.New("invalid capture record")
+	errAddressFamily       = errors.New("source and destination address families do not match")
+	errPacketTooLong       = errors.New("packet is too long")
+)
+
+// Packet is an UDP packet read from or written to a capture file.
+type Packet struct {
+	// Timestamp is the capture time of the packet.
+	Timestamp time.Time
+	// Source and Destination are UDP addresses of the packet. They are not valid when they are not
+	// known, e.g. Destination of packets read from rtpdump files.
+	Source, Destination netip.AddrPort
+	// Data is the UDP payload, e.g. a SRTP or SRTCP packet.
+	Data []byte
+}
+
+// PacketReader reads packets from a capture file. ReadPacket returns io.EOF at the end of the file.
+type PacketReader interface {
+	ReadPacket() (*Packet, error)
+}
+
+// PacketWriter writes packets to a capture file.
+type PacketWriter interface {
+	WritePacket(pkt *Packet) error
+}
+
+// Decrypt reads SRTP and SRTCP packets from r, decrypts them with ctx, and writes decrypted RTP
+// and RTCP packets to w. Other packets, e.g. STUN or DTLS ones, are skipped. Packets which cannot
+// be decrypted are passed to onError, if it is not nil, and skipped. Decrypt returns when all packets
+// are read, or when reading or writing fails.
+func Decrypt(r PacketReader, w PacketWriter, ctx *srtp.Context, onError func(pkt *Packet, err error)) error {
+	return transform(r, w, onError, func(data []byte) ([]byte, error) {
+		if isRTCP(data) {
+			return ctx.DecryptRTCP(nil, data, nil)
+		}
+
+		return ctx.DecryptRTP(nil, data, nil)
+	})
+}
+
+// Encrypt reads RTP and RTCP packets from r, encrypts them with ctx, and writes SRTP and SRTCP packets
+// to w, e.g. to generate test captures. It skips packets and handles errors like Decrypt.
+func Encrypt(r PacketReader, w PacketWriter, ctx *srtp.Context, onError func(pkt *Packet, err error)) error {
+	return transform(r, w, onError, func(data []byte) ([]byte, error) {
+		if isRTCP(data) {
+			return ctx.EncryptRTCP(nil, data, nil)
+		}
+
+		return ctx.EncryptRTP(nil, data, nil)
+	})
+}
+
+func transform(
+	r PacketReader, w PacketWriter, onError func(pkt *Packet, err error), fn func(data []byte) ([]byte, error),
+) error {
+	for {
+		pkt, err := r.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !isRTPOrRTCP(pkt.Data) {
+			continue
+		}
+
+		out, err := fn(pkt.Data)
+		if err != nil {
+			if onError != nil {
+				onError(pkt, err)
+			}
+
+			continue
+		}
+
+		pkt.Data = out
+		if err = w.WritePacket(pkt); err != nil {
+			return err
+		}
+	}
+}
+
+// isRTPOrRTCP checks if the packet is RTP or RTCP, as opposed to other protocols multiplexed on
+// the same port, see RFC 7983 section 7.
+func isRTPOrRTCP(data []byte) bool {
+	return len(data) >= 4 && data[0] >= 128 && data[0] <= 191
+}
+
+// isRTCP checks if RTP or RTCP packet is RTCP, using the packet type like in RFC 5761 section 4.
+func isRTCP(data []byte) bool {
+	return len(data) >= 2 && data[1] >= 192 && data[1] <= 223
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtpdump
+
+import (
+	"bytes"
+	"io"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type sliceReader struct {
+	packets []*Packet
+}
+
+func (r *sliceReader) ReadPacket() (*Packet, error) {
+	if len(r.packets) == 0 {
+		return nil, io.EOF
+	}
+	pkt := r.packets[0]
+	r.packets = r.packets[1:]
+
+	return pkt, nil
+}
+
+type sliceWriter struct {
+	packets []*Packet
+}
+
+func (w *sliceWriter) WritePacket(pkt *Packet) error {
+	w.packets = append(w.packets, pkt)
+
+	return nil
+}
+
+func testContext(t *testing.T) *srtp.Context {
+	t.Helper()
+
+	ctx, err := srtp.CreateContext(
+		[]byte{0x0d, 0xcd, 0x21, 0x3e, 0x4c, 0xbc, 0xf2, 0x8f, 0x01, 0x7f, 0x6f, 0x6d, 0x6e, 0x7e, 0x37, 0x0e},
+		[]byte{0x62, 0x77, 0x60, 0x38, 0xc0, 0x6d, 0xc9, 0x41, 0x9f, 0x6d, 0xd9, 0x43, 0x3e, 0x7c},
+		srtp.ProtectionProfileAes128CmHmacSha1_80,
+	)
+	assert.NoError(t, err)
+
+	return ctx
+}
+
+func testPackets(t *testing.T) []*Packet {
+	t.Helper()
+
+	start := time.Unix(1700000000, 0)
+	src := netip.MustParseAddrPort("192.0.2.1:5004")
+	dst := netip.MustParseAddrPort("192.0.2.2:5006")
+
+	var packets []*Packet
+	for i := 0; i < 5; i++ {
+		rtpPacket, err := (&rtp.Packet{
+			Header: rtp.Header{
+				Version:        2,
+				PayloadType:    96,
+				SequenceNumber: uint16(1000 + i), //nolint:gosec // G115
+				Timestamp:      uint32(3000 * i), //nolint:gosec // G115
+				SSRC:           0x11223344,
+			},
+			Payload: bytes.Repeat([]byte{byte(i)}, 100),
+		}).Marshal()
+		assert.NoError(t, err)
+		packets = append(packets, &Packet{
+			Timestamp:   start.Add(time.Duration(i) * 20 * time.Millisecond),
+			Source:      src,
+			Destination: dst,
+			Data:        rtpPacket,
+		})
+	}
+
+	rtcpPacket, err := (&rtcp.SenderReport{SSRC: 0x11223344, PacketCount: 5}).Marshal()
+	assert.NoError(t, err)
+	packets = append(packets, &Packet{
+		Timestamp:   start.Add(100 * time.Millisecond),
+		Source:      src,
+		Destination: dst,
+		Data:        rtcpPacket,
+	})
+
+	return packets
+}
+
+func clonePackets(packets []*Packet) []*Packet {
+	out := make([]*Packet, 0, len(packets))
+	for _, pkt := range packets {
+		clone := *pkt
+		clone.Data = append([]byte{}, pkt.Data...)
+		out = append(out, &clone)
+	}
+
+	return out
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	packets := testPackets(t)
+	// Packets of other protocols are skipped.
+	stun := &Packet{Data: []byte{0x00, 0x01, 0x00, 0x00}}
+
+	encrypted := &sliceWriter{}
+	assert.NoError(t, Encrypt(
+		&sliceReader{packets: append(clonePackets(packets), stun)}, encrypted, testContext(t), nil,
+	))
+	assert.Len(t, encrypted.packets, len(packets))
+	for i, pkt := range encrypted.packets {
+		assert.NotEqual(t, packets[i].Data, pkt.Data)
+		assert.Equal(t, packets[i].Source, pkt.Source)
+	}
+
+	var buf bytes.Buffer
+	writer, err := NewPcapWriter(&buf)
+	assert.NoError(t, err)
+	for _, pkt := range encrypted.packets {
+		assert.NoError(t, writer.WritePacket(pkt))
+	}
+
+	reader, err := NewPcapReader(&buf)
+	assert.NoError(t, err)
+	decrypted := &sliceWriter{}
+	assert.NoError(t, Decrypt(reader, decrypted, testContext(t), func(*Packet, error) {
+		assert.Fail(t, "unexpected error")
+	}))
+	assert.Equal(t, packets, decrypted.packets)
+}
+
+func TestDecryptError(t *testing.T) {
+	encrypted := &sliceWriter{}
+	assert.NoError(t, Encrypt(&sliceReader{packets: testPackets(t)}, encrypted, testContext(t), nil))
+	encrypted.packets[1].Data[20] ^= 0xff
+
+	var failed []*Packet
+	decrypted := &sliceWriter{}
+	assert.NoError(t, Decrypt(
+		&sliceReader{packets: encrypted.packets}, decrypted, testContext(t), func(pkt *Packet, err error) {
+			assert.ErrorIs(t, err, srtp.ErrFailedToVerifyAuthTag)
+			failed = append(failed, pkt)
+		},
+	))
+	assert.Len(t, decrypted.packets, len(encrypted.packets)-1)
+	assert.Equal(t, []*Packet{encrypted.packets[1]}, failed)
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtptest
+
+import (
+	"bytes"
+
+	"github.com/pion/rtp"
+)
+
+// TestingT is the subset of testing.TB used by assertion helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Receiver decrypts SRTP packets. It is implemented by srtp.Context.
+type Receiver interface {
+	DecryptRTP(dst, encrypted []byte, header *rtp.Header) ([]byte, error)
+}
+
+// packetID identifies a packet of SRTP stream.
+type packetID struct {
+	ssrc uint32
+	roc  uint32
+	seq  uint16
+}
+
+// AssertReceive decrypts packets with receiver in the order of pkts, e.g. after Impairment.Apply,
+// and checks that each packet is decrypted to the packet sent by Peer. When replayProtection is set,
+// copies of already received packets must be rejected; otherwise they must be decrypted too.
+// Failures are reported with t.Errorf. It returns true when all packets were received as expected.
+func AssertReceive(t TestingT, receiver Receiver, pkts []Packet, replayProtection bool) bool {
+	t.Helper()
+
+	ok := true
+	received := map[packetID]bool{}
+	for idx := range pkts {
+		pkt := &pkts[idx]
+		id := packetID{pkt.Header.SSRC, pkt.ROC, pkt.Header.SequenceNumber}
+		replayed := received[id]
+		received[id] = true
+
+		// Encrypted packet is copied, so it is not modified by the receiver.
+		decrypted, err := receiver.DecryptRTP(nil, append([]byte{}, pkt.Encrypted...), nil)
+		switch {
+		case replayed && replayProtection:
+			if err == nil {
+				t.Errorf("srtptest: replayed packet %d (%s) was accepted", idx, pkt)
+				ok = false
+			}
+		case err != nil:
+			t.Errorf("srtptest: failed to decrypt packet %d (%s): %v", idx, pkt, err)
+			ok = false
+		case !bytes.Equal(decrypted, pkt.Decrypted):
+			t.Errorf("srtptest: packet %d (%s) decrypted to %x, expected %x", idx, pkt, decrypted, pkt.Decrypted)
+			ok = false
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtptest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pion/srtp/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records failures reported by assertion helpers.
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertReceive(t *testing.T) {
+	peer, err := NewPeer(PeerConfig{SSRC: 1})
+	require.NoError(t, err)
+	pkts, err := peer.Generate(3, 10)
+	require.NoError(t, err)
+	replayed := append(append([]Packet{}, pkts...), pkts[1])
+
+	// Replayed packet is accepted by receiver without replay protection.
+	receiver, err := peer.ReceiverContext(srtp.SRTPNoReplayProtection())
+	require.NoError(t, err)
+	assert.True(t, AssertReceive(t, receiver, replayed, false))
+
+	receiver, err = peer.ReceiverContext(srtp.SRTPNoReplayProtection())
+	require.NoError(t, err)
+	rt := &recordingT{}
+	assert.False(t, AssertReceive(rt, receiver, replayed, true))
+	assert.Equal(t, []string{"srtptest: replayed packet 3 (SSRC=1 seq=1 ROC=0) was accepted"}, rt.errors)
+
+	// Packets of other peer cannot be decrypted.
+	otherPeer, err := NewPeer(PeerConfig{SSRC: 1, MKIs: [][]byte{{0x01}}})
+	require.NoError(t, err)
+	receiver, err = otherPeer.ReceiverContext()
+	require.NoError(t, err)
+	rt = &recordingT{}
+	assert.False(t, AssertReceive(rt, receiver, pkts[:1], false))
+	assert.Len(t, rt.errors, 1)
+
+	// Packet decrypted to other content is reported.
+	receiver, err = peer.ReceiverContext()
+	require.NoError(t, err)
+	modified := append([]Packet{}, pkts[0])
+	modified[0].Decrypted = []byte{0x00}
+	rt = &recordingT{}
+	assert.False(t, AssertReceive(rt, receiver, modified, false))
+	assert.Len(t, rt.errors, 1)
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtptest
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Impairment describes simulated network conditions. Random decisions are made with generator
+// seeded with Seed, so results are reproducible.
+type Impairment struct {
+	// Loss is probability of dropping a packet.
+	Loss float64
+	// Duplication is probability of delivering a packet twice.
+	Duplication float64
+	// ReorderDepth is the maximum number of positions a packet is moved by Apply. It should be
+	// smaller than the replay protection window of the receiver, so reordered packets are accepted.
+	ReorderDepth int
+	// Jitter is the maximum delay of packets written to connection returned by NewImpairedConn.
+	// Packets delayed differently are delivered reordered.
+	Jitter time.Duration
+	Seed   uint64
+}
+
+func (i Impairment) newRand() *rand.Rand {
+	return rand.New(rand.NewPCG(i.Seed, i.Seed)) //nolint:gosec // G404, reproducible test data
+}
+
+// Apply returns packets as they would be received over impaired network. Jitter is not used.
+// pkts is not modified.
+func (i Impairment) Apply(pkts []Packet) []Packet {
+	rng := i.newRand()
+
+	type received struct {
+		Packet
+		position int
+	}
+	out := make([]received, 0, len(pkts))
+	for idx, pkt := range pkts {
+		if rng.Float64() < i.Loss {
+			continue
+		}
+		out = append(out, received{pkt, idx + rng.IntN(i.ReorderDepth+1)})
+		if rng.Float64() < i.Duplication {
+			pkt.Duplicate = true
+			out = append(out, received{pkt, idx + rng.IntN(i.ReorderDepth+1)})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b received) int {
+		return cmp.Compare(a.position, b.position)
+	})
+
+	result := make([]Packet, len(out))
+	for idx := range out {
+		result[idx] = out[idx].Packet
+	}
+
+	return result
+}
+
+// impairedConn is net.Conn which drops, duplicates and delays written packets.
+type impairedConn struct {
+	net.Conn
+	impairment Impairment
+
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	pending sync.WaitGroup
+}
+
+// NewImpairedConn returns net.Conn which writes packets to conn as impaired network would deliver
+// them: some packets are dropped or written twice, and they are delayed by up to Impairment.Jitter.
+// Write returns before delayed packets are written. Close closes conn, and waits until delayed writes
+// finish. ReorderDepth is not used, packets are reordered by jitter.
+func NewImpairedConn(conn net.Conn, impairment Impairment) net.Conn {
+	return &impairedConn{Conn: conn, impairment: impairment, rng: impairment.newRand()}
+}
+
+// Write writes b to the connection. Dropped packets are reported as written.
+func (c *impairedConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	drop := c.rng.Float64() < c.impairment.Loss
+	copies := 1
+	if c.rng.Float64() < c.impairment.Duplication {
+		copies++
+	}
+	var delays [2]time.Duration
+	if c.impairment.Jitter > 0 {
+		for idx := range copies {
+			delays[idx] = time.Duration(c.rng.Int64N(int64(c.impairment.Jitter)))
+		}
+	}
+	c.mu.Unlock()
+
+	if drop {
+		return len(b), nil
+	}
+
+	for _, delay := range delays[:copies] {
+		if delay == 0 {
+			if _, err := c.Conn.Write(b); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		pkt := append([]byte{}, b...)
+		c.pending.Add(1)
+		time.AfterFunc(delay, func() {
+			defer c.pending.Done()
+			_, _ = c.Conn.Write(pkt)
+		})
+	}
+
+	return len(b), nil
+}
+
+// Close closes the connection, and waits for delayed writes.
+func (c *impairedConn) Close() error {
+	err := c.Conn.Close()
+	c.pending.Wait()
+
+	return err
+}
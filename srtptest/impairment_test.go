@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtptest
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpairmentApply(t *testing.T) {
+	peer, err := NewPeer(PeerConfig{})
+	require.NoError(t, err)
+	pkts, err := peer.Generate(100, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, pkts, Impairment{}.Apply(pkts))
+	assert.Empty(t, Impairment{Loss: 1}.Apply(pkts))
+
+	duplicated := Impairment{Duplication: 1}.Apply(pkts)
+	if assert.Len(t, duplicated, 2*len(pkts)) {
+		for i := range pkts {
+			assert.Equal(t, pkts[i].Encrypted, duplicated[2*i].Encrypted)
+			assert.False(t, duplicated[2*i].Duplicate)
+			assert.Equal(t, pkts[i].Encrypted, duplicated[2*i+1].Encrypted)
+			assert.True(t, duplicated[2*i+1].Duplicate)
+		}
+	}
+
+	impairment := Impairment{Loss: 0.2, Duplication: 0.2, ReorderDepth: 3, Seed: 42}
+	impaired := impairment.Apply(pkts)
+	assert.Equal(t, impaired, impairment.Apply(pkts), "results should be reproducible")
+	assert.NotEqual(t, pkts, impaired)
+	for i, pkt := range impaired {
+		// Packets are not received later than packets sent more than ReorderDepth packets after them.
+		for _, later := range impaired[i+1:] {
+			assert.GreaterOrEqual(t, int16(later.Header.SequenceNumber-pkt.Header.SequenceNumber), int16(-3)) //nolint:gosec
+		}
+	}
+}
+
+func TestImpairedConn(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	aPipe, bPipe := net.Pipe()
+	conn := NewImpairedConn(aPipe, Impairment{Duplication: 1, Jitter: 10 * time.Millisecond, Seed: 1})
+
+	n, err := conn.Write([]byte{0x01, 0x02})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	buf := make([]byte, 10)
+	for range 2 {
+		n, err = bPipe.Read(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x01, 0x02}, buf[:n])
+	}
+
+	conn = NewImpairedConn(aPipe, Impairment{Loss: 1})
+	n, err = conn.Write([]byte{0x01, 0x02})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	assert.NoError(t, conn.Close())
+	assert.NoError(t, bPipe.Close())
+}
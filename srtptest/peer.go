@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtptest
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
+)
+
+const defaultTimestampStep = 3000
+
+// PeerConfig is used to configure Peer.
+type PeerConfig struct {
+	// Profile is the protection profile. Zero value uses ProtectionProfileAes128CmHmacSha1_80.
+	Profile srtp.ProtectionProfile
+	// SSRC and PayloadType are set in headers of generated packets.
+	SSRC        uint32
+	PayloadType uint8
+	// SequenceNumber and ROC of the first packet, e.g. SequenceNumber close to 65535 to test ROC wraps.
+	SequenceNumber uint16
+	ROC            uint32
+	// Timestamp of the first packet, incremented by TimestampStep (3000 by default) for each packet.
+	Timestamp     uint32
+	TimestampStep uint32
+	// MKIs enables MKI. MKI i uses keys returned by Keys with keyIndex i, and the first one is used
+	// for sending initially.
+	MKIs [][]byte
+	// Options are additional options of the sending Context.
+	Options []srtp.ContextOption
+}
+
+// Peer is a reference SRTP sender. It generates RTP packets with consecutive sequence numbers and
+// deterministic payloads, and encrypts them with keys returned by Keys.
+type Peer struct {
+	config    PeerConfig
+	ctx       *srtp.Context
+	seq       uint16
+	roc       uint32
+	timestamp uint32
+	mki       []byte
+}
+
+// NewPeer creates Peer with given configuration.
+func NewPeer(config PeerConfig) (*Peer, error) {
+	if config.Profile == 0 {
+		config.Profile = srtp.ProtectionProfileAes128CmHmacSha1_80
+	}
+	if config.TimestampStep == 0 {
+		config.TimestampStep = defaultTimestampStep
+	}
+
+	ctx, err := newContext(config, config.Options...)
+	if err != nil {
+		return nil, err
+	}
+	ctx.SetROC(config.SSRC, config.ROC)
+
+	peer := &Peer{
+		config:    config,
+		ctx:       ctx,
+		seq:       config.SequenceNumber,
+		roc:       config.ROC,
+		timestamp: config.Timestamp,
+	}
+	if len(config.MKIs) != 0 {
+		peer.mki = config.MKIs[0]
+	}
+
+	return peer, nil
+}
+
+// newContext creates Context with keys of the peer.
+func newContext(config PeerConfig, opts ...srtp.ContextOption) (*srtp.Context, error) {
+	masterKey, masterSalt, err := Keys(config.Profile, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.MKIs) != 0 {
+		opts = append([]srtp.ContextOption{srtp.MasterKeyIndicator(config.MKIs[0])}, opts...)
+	}
+
+	ctx, err := srtp.CreateContext(masterKey, masterSalt, config.Profile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(config.MKIs); i++ {
+		if masterKey, masterSalt, err = Keys(config.Profile, i); err != nil {
+			return nil, err
+		}
+		if err = ctx.AddCipherForMKI(config.MKIs[i], masterKey, masterSalt); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx, nil
+}
+
+// ReceiverContext creates Context which decrypts packets sent by the peer: it has the same keys
+// and MKIs, and ROC of the first packet. opts are added to options of the Context, e.g. replay
+// protection.
+func (p *Peer) ReceiverContext(opts ...srtp.ContextOption) (*srtp.Context, error) {
+	ctx, err := newContext(p.config, opts...)
+	if err != nil {
+		return nil, err
+	}
+	ctx.SetROC(p.config.SSRC, p.config.ROC)
+
+	return ctx, nil
+}
+
+// SwitchMKI changes MKI of the key used to encrypt next packets. mki must be one of PeerConfig.MKIs.
+func (p *Peer) SwitchMKI(mki []byte) error {
+	if len(p.config.MKIs) == 0 {
+		return errMKIsRequired
+	}
+	if !slices.ContainsFunc(p.config.MKIs, func(m []byte) bool { return bytes.Equal(m, mki) }) {
+		return errUnknownMKI
+	}
+	if err := p.ctx.SetSendMKI(mki); err != nil {
+		return err
+	}
+	p.mki = mki
+
+	return nil
+}
+
+// NextRTP generates and encrypts the next packet with given payload.
+func (p *Peer) NextRTP(payload []byte) (Packet, error) {
+	pkt := Packet{
+		Header: rtp.Header{
+			Version:        2,
+			PayloadType:    p.config.PayloadType,
+			SequenceNumber: p.seq,
+			Timestamp:      p.timestamp,
+			SSRC:           p.config.SSRC,
+		},
+		ROC: p.roc,
+		MKI: p.mki,
+	}
+
+	var err error
+	pkt.Decrypted, err = (&rtp.Packet{Header: pkt.Header, Payload: payload}).Marshal()
+	if err != nil {
+		return Packet{}, err
+	}
+	pkt.Encrypted, err = p.ctx.EncryptRTP(nil, pkt.Decrypted, nil)
+	if err != nil {
+		return Packet{}, err
+	}
+
+	p.seq++
+	if p.seq == 0 {
+		p.roc++
+	}
+	p.timestamp += p.config.TimestampStep
+
+	return pkt, nil
+}
+
+// Generate generates and encrypts count packets with payloads of payloadLen bytes. Payloads are
+// deterministic, and differ between packets.
+func (p *Peer) Generate(count, payloadLen int) ([]Packet, error) {
+	if payloadLen <= 0 {
+		return nil, errNoPayload
+	}
+
+	pkts := make([]Packet, 0, count)
+	for range count {
+		payload := make([]byte, payloadLen)
+		for i := range payload {
+			payload[i] = byte(int(p.seq) + i) //nolint:gosec // G115, wraps intentionally
+		}
+		pkt, err := p.NextRTP(payload)
+		if err != nil {
+			return nil, err
+		}
+		pkts = append(pkts, pkt)
+	}
+
+	return pkts, nil
+}
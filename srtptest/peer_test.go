@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtptest
+
+import (
+	"testing"
+
+	"github.com/pion/srtp/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	masterKey, masterSalt, err := Keys(srtp.ProtectionProfileAes128CmHmacSha1_80, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, rfc3711MasterKey, masterKey)
+	assert.Equal(t, rfc3711MasterSalt, masterSalt)
+
+	otherKey, otherSalt, err := Keys(srtp.ProtectionProfileAes128CmHmacSha1_80, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, masterKey, otherKey)
+	assert.NotEqual(t, masterSalt, otherSalt)
+
+	masterKey, masterSalt, err = Keys(srtp.ProtectionProfileAeadAes256Gcm, 0)
+	assert.NoError(t, err)
+	assert.Len(t, masterKey, 32)
+	assert.Len(t, masterSalt, 12)
+
+	_, _, err = Keys(srtp.ProtectionProfile(0x1234), 0)
+	assert.Error(t, err)
+}
+
+func TestPeer(t *testing.T) {
+	for _, profile := range []srtp.ProtectionProfile{
+		srtp.ProtectionProfileAes128CmHmacSha1_80, srtp.ProtectionProfileAeadAes128Gcm,
+	} {
+		t.Run(profile.String(), func(t *testing.T) {
+			mkis := [][]byte{{0x01}, {0x02}}
+			peer, err := NewPeer(PeerConfig{
+				Profile: profile, SSRC: 5000, PayloadType: 96, SequenceNumber: 65500, ROC: 7, MKIs: mkis,
+			})
+			require.NoError(t, err)
+
+			pkts, err := peer.Generate(50, 20)
+			require.NoError(t, err)
+			assert.NoError(t, peer.SwitchMKI(mkis[1]))
+			more, err := peer.Generate(50, 20)
+			require.NoError(t, err)
+			pkts = append(pkts, more...)
+
+			// Sequence numbers wrap, and ROC is incremented.
+			assert.Equal(t, uint16(65500), pkts[0].Header.SequenceNumber)
+			assert.Equal(t, uint32(7), pkts[35].ROC)
+			assert.Equal(t, uint16(0), pkts[36].Header.SequenceNumber)
+			assert.Equal(t, uint32(8), pkts[36].ROC)
+			assert.Equal(t, pkts[0].Header.Timestamp+3000, pkts[1].Header.Timestamp)
+			assert.Equal(t, mkis[0], pkts[49].MKI)
+			assert.Equal(t, mkis[1], pkts[50].MKI)
+
+			receiver, err := peer.ReceiverContext(srtp.SRTPReplayProtection(64))
+			require.NoError(t, err)
+			impaired := Impairment{Loss: 0.1, Duplication: 0.1, ReorderDepth: 5, Seed: 1}.Apply(pkts)
+			assert.True(t, AssertReceive(t, receiver, impaired, true))
+		})
+	}
+}
+
+func TestPeerErrors(t *testing.T) {
+	_, err := NewPeer(PeerConfig{Profile: srtp.ProtectionProfile(0x1234)})
+	assert.Error(t, err)
+
+	peer, err := NewPeer(PeerConfig{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, peer.SwitchMKI([]byte{0x01}), errMKIsRequired)
+	_, err = peer.Generate(1, 0)
+	assert.ErrorIs(t, err, errNoPayload)
+
+	peer, err = NewPeer(PeerConfig{MKIs: [][]byte{{0x01}}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, peer.SwitchMKI([]byte{0x02}), errUnknownMKI)
+}
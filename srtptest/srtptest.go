@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package srtptest provides a reference SRTP peer, simulated network impairments and assertion helpers,
+// so applications and libraries built on srtp can test their integration code, e.g. handling of packet
+// loss, reordering, duplication, ROC wraps and MKI switches, without hand-rolling packet generators.
+// It is intended for use in tests only.
+package srtptest
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pion/rtp"
+	"github.com/pion/srtp/v3"
+)
+
+var (
+	errNoPayload    = errors.New("payload length must be positive")
+	errUnknownMKI   = errors.New("MKI is not configured for the peer")
+	errMKIsRequired = errors.New("MKI switching requires MKIs in PeerConfig")
+)
+
+// RFC 3711 appendix B.2 test vectors, used as keys of AES_CM_128 profiles.
+//
+//nolint:gochecknoglobals
+var (
+	rfc3711MasterKey = []byte{
+		0xe1, 0xf9, 0x7a, 0x0d, 0x3e, 0x01, 0x8b, 0xe0, 0xd6, 0x4f, 0xa3, 0x2c, 0x06, 0xde, 0x41, 0x39,
+	}
+	rfc3711MasterSalt = []byte{0x0e, 0xc6, 0x75, 0xad, 0x49, 0x8a, 0xfe, 0xeb, 0xb6, 0x96, 0x0b, 0x3a, 0xab, 0xe6}
+)
+
+// Keys returns deterministic master key and salt of the profile, so both sides of a test can use
+// the same keys without exchanging them. keyIndex selects one of different keys, e.g. for MKIs.
+// Key 0 of profiles with 16-byte key and 14-byte salt is the test key from RFC 3711 appendix B.2.
+// The keys are public, they must not be used outside of tests.
+func Keys(profile srtp.ProtectionProfile, keyIndex int) (masterKey, masterSalt []byte, err error) {
+	keyLen, err := profile.KeyLen()
+	if err != nil {
+		return nil, nil, err
+	}
+	saltLen, err := profile.SaltLen()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if keyIndex == 0 && keyLen == len(rfc3711MasterKey) && saltLen == len(rfc3711MasterSalt) {
+		return append([]byte{}, rfc3711MasterKey...), append([]byte{}, rfc3711MasterSalt...), nil
+	}
+
+	masterKey = make([]byte, keyLen)
+	for i := range masterKey {
+		masterKey[i] = byte(0x10*keyIndex + i + 1) //nolint:gosec // G115, wraps intentionally
+	}
+	masterSalt = make([]byte, saltLen)
+	for i := range masterSalt {
+		masterSalt[i] = byte(0xa0+i) ^ byte(keyIndex) //nolint:gosec // G115, wraps intentionally
+	}
+
+	return masterKey, masterSalt, nil
+}
+
+// Packet is a RTP packet generated by Peer.
+type Packet struct {
+	// Header is the header of the packet.
+	Header rtp.Header
+	// ROC is the rollover counter used to encrypt the packet.
+	ROC uint32
+	// MKI is the MKI of the key used to encrypt the packet, or nil when MKI is not used.
+	MKI []byte
+	// Decrypted is the marshaled RTP packet, and Encrypted is the SRTP packet.
+	Decrypted []byte
+	Encrypted []byte
+	// Duplicate is set for copies of packets added by Impairment.
+	Duplicate bool
+}
+
+// String returns short description of the packet, used in assertion messages.
+func (p *Packet) String() string {
+	return fmt.Sprintf("SSRC=%d seq=%d ROC=%d", p.Header.SSRC, p.Header.SequenceNumber, p.ROC)
+}
@@ -0,0 +1,337 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import "container/list"
+
+// minEvictedSSRCs is the minimum number of evicted SSRCs whose indexes are remembered.
+const minEvictedSSRCs = 1024
+
+// evictedSSRC keeps newest indexes of SRTP and SRTCP states of an evicted or removed SSRC. They are used
+// to continue ROC and SRTCP index, and to protect against replay of old packets, when the SSRC appears
+// again.
+type evictedSSRC struct {
+	hasSRTPIndex  bool
+	srtpIndex     uint64
+	hasSRTCPIndex bool
+	srtcpIndex    uint32
+}
+
+// RemoveSSRC removes SRTP and SRTCP state of the SSRC, e.g. after BYE was received. Like for states
+// evicted due to SSRCStateLimit, newest indexes of the SSRC are remembered, so the removed SSRC cannot be
+// used to replay old packets. Use FinalizeSSRC to get final ROC of the SSRC instead.
+func (c *Context) RemoveSSRC(ssrc uint32) {
+	if state, ok := c.srtpSSRCStates[ssrc]; ok {
+		c.retireSRTPSSRCState(state)
+	}
+	if state, ok := c.srtcpSSRCStates[ssrc]; ok {
+		c.retireSRTCPSSRCState(state)
+	}
+	delete(c.ektReceiveStates, ssrc)
+}
+
+// discardSRTPSSRCState removes SRTP state of the SSRC without remembering its index, e.g. when stream
+// of the SSRC was rejected, so rejected SSRCs do not keep any state in the Context.
+func (c *Context) discardSRTPSSRCState(ssrc uint32) {
+	if state, ok := c.srtpSSRCStates[ssrc]; ok {
+		c.deleteSRTPSSRCState(state)
+	}
+}
+
+func (c *Context) hasSSRCStateLimit() bool {
+	return c.maxSSRCStates > 0 || c.ssrcIdleTimeout > 0
+}
+
+// checkNewSRTPSSRCState returns ErrTooManySSRCs when the SSRC has no SRTP state, and state for it cannot
+// be added: the Context keeps the maximum number of states, and the least recently used one cannot be
+// evicted, because indexes of more evicted SSRCs cannot be remembered.
+func (c *Context) checkNewSRTPSSRCState(ssrc uint32) error {
+	if _, ok := c.srtpSSRCStates[ssrc]; ok || c.maxSSRCStates == 0 || len(c.srtpSSRCStates) < c.maxSSRCStates {
+		return nil
+	}
+	if c.srtpLRU == nil || c.srtpLRU.Len() == 0 {
+		return nil
+	}
+	lru, _ := c.srtpLRU.Back().Value.(*srtpSSRCState)
+	_, hasIndex := lru.newestIndex()
+	if hasIndex && !c.canRememberEvictedSSRC(lru.ssrc) && !c.releasesEvictedSSRC(ssrc, false) {
+		return ErrTooManySSRCs
+	}
+
+	return nil
+}
+
+// checkNewSRTCPSSRCState is checkNewSRTPSSRCState for SRTCP states.
+func (c *Context) checkNewSRTCPSSRCState(ssrc uint32) error {
+	if _, ok := c.srtcpSSRCStates[ssrc]; ok || c.maxSSRCStates == 0 || len(c.srtcpSSRCStates) < c.maxSSRCStates {
+		return nil
+	}
+	if c.srtcpLRU == nil || c.srtcpLRU.Len() == 0 {
+		return nil
+	}
+	lru, _ := c.srtcpLRU.Back().Value.(*srtcpSSRCState)
+	_, hasIndex := lru.newestIndex()
+	if hasIndex && !c.canRememberEvictedSSRC(lru.ssrc) && !c.releasesEvictedSSRC(ssrc, true) {
+		return ErrTooManySSRCs
+	}
+
+	return nil
+}
+
+// addSRTPSSRCStateToLRU evicts idle SRTP states, and the least recently used one if the Context has
+// the maximum number of them, and adds the new state as the most recently used one. States are kept
+// in the LRU list ordered by their last use, so the least recently used ones are at its back.
+func (c *Context) addSRTPSSRCStateToLRU(state *srtpSSRCState) {
+	if c.srtpLRU == nil {
+		c.srtpLRU = list.New()
+	}
+	now := c.currentTime()
+	for c.ssrcIdleTimeout > 0 && c.srtpLRU.Len() > 0 {
+		lru, _ := c.srtpLRU.Back().Value.(*srtpSSRCState)
+		if now.Sub(lru.lastUsed) <= c.ssrcIdleTimeout || !c.evictSRTPSSRCState(lru) {
+			break
+		}
+	}
+	if c.maxSSRCStates > 0 && len(c.srtpSSRCStates) >= c.maxSSRCStates && c.srtpLRU.Len() > 0 {
+		lru, _ := c.srtpLRU.Back().Value.(*srtpSSRCState)
+		c.evictSRTPSSRCState(lru)
+	}
+	state.lastUsed = now
+	state.lruElem = c.srtpLRU.PushFront(state)
+}
+
+// addSRTCPSSRCStateToLRU is addSRTPSSRCStateToLRU for SRTCP states.
+func (c *Context) addSRTCPSSRCStateToLRU(state *srtcpSSRCState) {
+	if c.srtcpLRU == nil {
+		c.srtcpLRU = list.New()
+	}
+	now := c.currentTime()
+	for c.ssrcIdleTimeout > 0 && c.srtcpLRU.Len() > 0 {
+		lru, _ := c.srtcpLRU.Back().Value.(*srtcpSSRCState)
+		if now.Sub(lru.lastUsed) <= c.ssrcIdleTimeout || !c.evictSRTCPSSRCState(lru) {
+			break
+		}
+	}
+	if c.maxSSRCStates > 0 && len(c.srtcpSSRCStates) >= c.maxSSRCStates && c.srtcpLRU.Len() > 0 {
+		lru, _ := c.srtcpLRU.Back().Value.(*srtcpSSRCState)
+		c.evictSRTCPSSRCState(lru)
+	}
+	state.lastUsed = now
+	state.lruElem = c.srtcpLRU.PushFront(state)
+}
+
+// touchSRTPSSRCState marks the state as the most recently used one.
+func (c *Context) touchSRTPSSRCState(state *srtpSSRCState) {
+	state.lastUsed = c.currentTime()
+	if state.lruElem != nil {
+		c.srtpLRU.MoveToFront(state.lruElem)
+	}
+}
+
+// touchSRTCPSSRCState marks the state as the most recently used one.
+func (c *Context) touchSRTCPSSRCState(state *srtcpSSRCState) {
+	state.lastUsed = c.currentTime()
+	if state.lruElem != nil {
+		c.srtcpLRU.MoveToFront(state.lruElem)
+	}
+}
+
+// evictSRTPSSRCState removes the state, and remembers its newest index. It returns false and keeps
+// the state when indexes of more evicted SSRCs cannot be remembered.
+func (c *Context) evictSRTPSSRCState(state *srtpSSRCState) bool {
+	if _, hasIndex := state.newestIndex(); hasIndex && !c.canRememberEvictedSSRC(state.ssrc) {
+		return false
+	}
+	c.retireSRTPSSRCState(state)
+
+	return true
+}
+
+// evictSRTCPSSRCState is evictSRTPSSRCState for SRTCP states.
+func (c *Context) evictSRTCPSSRCState(state *srtcpSSRCState) bool {
+	if _, hasIndex := state.newestIndex(); hasIndex && !c.canRememberEvictedSSRC(state.ssrc) {
+		return false
+	}
+	c.retireSRTCPSSRCState(state)
+
+	return true
+}
+
+// retireSRTPSSRCState removes the state, and remembers its newest index.
+func (c *Context) retireSRTPSSRCState(state *srtpSSRCState) {
+	c.deleteSRTPSSRCState(state)
+
+	index, ok := state.newestIndex()
+	if !ok {
+		return
+	}
+	evicted := c.evictedSSRC(state.ssrc)
+	evicted.hasSRTPIndex = true
+	evicted.srtpIndex = index
+}
+
+// retireSRTCPSSRCState removes the state, and remembers its newest index.
+func (c *Context) retireSRTCPSSRCState(state *srtcpSSRCState) {
+	c.deleteSRTCPSSRCState(state)
+
+	index, ok := state.newestIndex()
+	if !ok {
+		return
+	}
+	evicted := c.evictedSSRC(state.ssrc)
+	evicted.hasSRTCPIndex = true
+	evicted.srtcpIndex = index
+}
+
+// deleteSRTPSSRCState removes the state from the Context, without remembering its index.
+func (c *Context) deleteSRTPSSRCState(state *srtpSSRCState) {
+	delete(c.srtpSSRCStates, state.ssrc)
+	if state.lruElem != nil {
+		c.srtpLRU.Remove(state.lruElem)
+		state.lruElem = nil
+	}
+}
+
+// deleteSRTCPSSRCState removes the state from the Context, without remembering its index.
+func (c *Context) deleteSRTCPSSRCState(state *srtcpSSRCState) {
+	delete(c.srtcpSSRCStates, state.ssrc)
+	if state.lruElem != nil {
+		c.srtcpLRU.Remove(state.lruElem)
+		state.lruElem = nil
+	}
+}
+
+// newestIndex returns the newest sent or received index of the state, or false if no packet was
+// processed yet.
+func (s *srtpSSRCState) newestIndex() (uint64, bool) {
+	index := s.index
+	if top, ok := s.replayGuard.top(); ok {
+		index = max(index, top)
+	}
+
+	return index, s.rolloverHasProcessed || index != 0
+}
+
+// newestIndex returns the newest sent or received SRTCP index of the state, or false if no packet was
+// processed yet.
+func (s *srtcpSSRCState) newestIndex() (uint32, bool) {
+	index := s.srtcpIndex
+	if top, ok := s.replayGuard.top(); ok {
+		index = max(index, uint32(top)) //nolint:gosec // G115, SRTCP index has 31 bits
+	}
+
+	return index, index != 0
+}
+
+// cloneEvictedSSRCs returns remembered indexes of evicted SSRCs together with newest indexes of current
+// SSRC states, so a cloned Context continues ROC and SRTCP index of SSRCs used by c.
+func (c *Context) cloneEvictedSSRCs() map[uint32]*evictedSSRC {
+	cloned := make(map[uint32]*evictedSSRC, len(c.evictedSSRCs))
+	entry := func(ssrc uint32) *evictedSSRC {
+		evicted, ok := cloned[ssrc]
+		if !ok {
+			evicted = &evictedSSRC{}
+			cloned[ssrc] = evicted
+		}
+
+		return evicted
+	}
+	for ssrc, evicted := range c.evictedSSRCs {
+		evictedCopy := *evicted
+		cloned[ssrc] = &evictedCopy
+	}
+	for ssrc, state := range c.srtpSSRCStates {
+		if index, ok := state.newestIndex(); ok {
+			evicted := entry(ssrc)
+			evicted.hasSRTPIndex = true
+			evicted.srtpIndex = index
+		}
+	}
+	for ssrc, state := range c.srtcpSSRCStates {
+		if index, ok := state.newestIndex(); ok {
+			evicted := entry(ssrc)
+			evicted.hasSRTCPIndex = true
+			evicted.srtcpIndex = index
+		}
+	}
+	if len(cloned) == 0 {
+		return nil
+	}
+
+	return cloned
+}
+
+// canRememberEvictedSSRC returns true when index of the SSRC can be remembered after its state
+// is evicted. Indexes of up to max(maxSSRCStates, 1024) evicted SSRCs are remembered.
+func (c *Context) canRememberEvictedSSRC(ssrc uint32) bool {
+	_, ok := c.evictedSSRCs[ssrc]
+
+	return ok || len(c.evictedSSRCs) < max(c.maxSSRCStates, minEvictedSSRCs)
+}
+
+// releasesEvictedSSRC returns true when adding SRTP or SRTCP state of the SSRC removes its entry
+// of evicted SSRCs, so index of another SSRC can be remembered instead.
+func (c *Context) releasesEvictedSSRC(ssrc uint32, isRTCP bool) bool {
+	evicted, ok := c.evictedSSRCs[ssrc]
+	if !ok {
+		return false
+	}
+	if isRTCP {
+		return !evicted.hasSRTPIndex
+	}
+
+	return !evicted.hasSRTCPIndex
+}
+
+// evictedSSRC returns entry for evicted SSRC, and creates it if needed.
+func (c *Context) evictedSSRC(ssrc uint32) *evictedSSRC {
+	if evicted, ok := c.evictedSSRCs[ssrc]; ok {
+		return evicted
+	}
+
+	if c.evictedSSRCs == nil {
+		c.evictedSSRCs = map[uint32]*evictedSSRC{}
+	}
+	evicted := &evictedSSRC{}
+	c.evictedSSRCs[ssrc] = evicted
+
+	return evicted
+}
+
+// restoreEvictedSRTPState sets index of a new SRTP state to the one of evicted state of the same SSRC.
+func (c *Context) restoreEvictedSRTPState(state *srtpSSRCState) {
+	evicted, ok := c.evictedSSRCs[state.ssrc]
+	if !ok || !evicted.hasSRTPIndex {
+		return
+	}
+	state.index = evicted.srtpIndex
+	state.rolloverHasProcessed = true
+	state.replayGuard.setFloor(evicted.srtpIndex)
+}
+
+// restoreEvictedSRTCPState sets index of a new SRTCP state to the one of evicted state of the same SSRC.
+func (c *Context) restoreEvictedSRTCPState(state *srtcpSSRCState) {
+	evicted, ok := c.evictedSSRCs[state.ssrc]
+	if !ok || !evicted.hasSRTCPIndex {
+		return
+	}
+	state.srtcpIndex = evicted.srtcpIndex
+	state.replayGuard.setFloor(uint64(evicted.srtcpIndex))
+}
+
+// forgetEvictedSSRC drops remembered SRTP or SRTCP index of the SSRC, after its state was added again.
+func (c *Context) forgetEvictedSSRC(ssrc uint32, isRTCP bool) {
+	evicted, ok := c.evictedSSRCs[ssrc]
+	if !ok {
+		return
+	}
+	if isRTCP {
+		evicted.hasSRTCPIndex = false
+	} else {
+		evicted.hasSRTPIndex = false
+	}
+	if !evicted.hasSRTPIndex && !evicted.hasSRTCPIndex {
+		delete(c.evictedSSRCs, ssrc)
+	}
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptTestRTPForSSRC(t *testing.T, ctx *Context, ssrc uint32, seq uint16) []byte {
+	t.Helper()
+
+	packet := &rtp.Packet{
+		Header:  rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: seq},
+		Payload: []byte{0x00, 0x01, 0x02, 0x03},
+	}
+	raw, err := packet.Marshal()
+	assert.NoError(t, err)
+	encrypted, err := ctx.EncryptRTP(nil, raw, nil)
+	assert.NoError(t, err)
+
+	return encrypted
+}
+
+func TestSSRCStateLimitLRU(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := func() time.Time {
+		now = now.Add(time.Millisecond)
+
+		return now
+	}
+
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, Clock(clock), SRTPReplayProtection(64), SSRCStateLimit(2, 0))
+	assert.NoError(t, err)
+
+	decrypt := func(packet []byte) error {
+		_, errDecrypt := decryptCtx.DecryptRTP(nil, packet, nil)
+
+		return errDecrypt
+	}
+
+	packet1 := encryptTestRTPForSSRC(t, encryptCtx, 1, 65535)
+	assert.NoError(t, decrypt(packet1))
+	assert.NoError(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, 2, 1)))
+	// SSRC 1 is the most recently used one, so SSRC 2 is evicted.
+	assert.ErrorIs(t, decrypt(packet1), errDuplicated)
+	assert.NoError(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, 3, 1)))
+	assert.Len(t, decryptCtx.srtpSSRCStates, 2)
+	assert.Contains(t, decryptCtx.srtpSSRCStates, uint32(1))
+	assert.Contains(t, decryptCtx.srtpSSRCStates, uint32(3))
+
+	// Evict SSRC 1. Replayed packet must be rejected, and ROC must continue after the rollover.
+	assert.NoError(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, 2, 2)))
+	assert.NotContains(t, decryptCtx.srtpSSRCStates, uint32(1))
+	assert.ErrorIs(t, decrypt(packet1), errDuplicated)
+	assert.NoError(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, 1, 0)))
+	roc, ok := decryptCtx.ROC(1)
+	assert.True(t, ok)
+	assert.Equal(t, uint32(1), roc)
+}
+
+func TestSSRCStateLimitIdleTimeout(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+
+	ctx, err := buildTestContext(profileCTR, Clock(clock), SSRCStateLimit(0, time.Minute))
+	assert.NoError(t, err)
+
+	rtcpPacket := func(ssrc byte) []byte {
+		return []byte{0x81, 0xc8, 0x00, 0x01, 0x00, 0x00, 0x00, ssrc}
+	}
+
+	encryptTestRTPForSSRC(t, ctx, 1, 1)
+	_, err = ctx.EncryptRTCP(nil, rtcpPacket(1), nil)
+	assert.NoError(t, err)
+
+	now = now.Add(30 * time.Second)
+	encryptTestRTPForSSRC(t, ctx, 2, 1)
+	_, err = ctx.EncryptRTCP(nil, rtcpPacket(2), nil)
+	assert.NoError(t, err)
+
+	now = now.Add(45 * time.Second)
+	encryptTestRTPForSSRC(t, ctx, 3, 1)
+	_, err = ctx.EncryptRTCP(nil, rtcpPacket(3), nil)
+	assert.NoError(t, err)
+	assert.Len(t, ctx.srtpSSRCStates, 2)
+	assert.NotContains(t, ctx.srtpSSRCStates, uint32(1))
+	assert.Len(t, ctx.srtcpSSRCStates, 2)
+	assert.NotContains(t, ctx.srtcpSSRCStates, uint32(1))
+
+	// SRTCP index of the evicted SSRC is not reused.
+	encrypted, err := ctx.EncryptRTCP(nil, rtcpPacket(1), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), getRTCPIndex(encrypted, 10))
+}
+
+func TestSSRCStateLimitEvictedSSRCsFull(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(64), SSRCStateLimit(1, 0))
+	assert.NoError(t, err)
+
+	decrypt := func(packet []byte) error {
+		_, errDecrypt := decryptCtx.DecryptRTP(nil, packet, nil)
+
+		return errDecrypt
+	}
+
+	packet1 := encryptTestRTPForSSRC(t, encryptCtx, 1, 10)
+	assert.NoError(t, decrypt(packet1))
+	for ssrc := uint32(2); ssrc <= minEvictedSSRCs+1; ssrc++ {
+		assert.NoError(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, ssrc, 1)))
+	}
+	assert.Len(t, decryptCtx.evictedSSRCs, minEvictedSSRCs)
+
+	// Index of the current SSRC cannot be remembered, so it is not evicted, and new SSRC is rejected.
+	assert.ErrorIs(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, minEvictedSSRCs+2, 1)), ErrTooManySSRCs)
+	assert.Contains(t, decryptCtx.srtpSSRCStates, uint32(minEvictedSSRCs+1))
+
+	// Evicted SSRC can appear again, its index is still remembered.
+	assert.ErrorIs(t, decrypt(packet1), errDuplicated)
+	assert.NoError(t, decrypt(encryptTestRTPForSSRC(t, encryptCtx, 1, 11)))
+	assert.Len(t, decryptCtx.evictedSSRCs, minEvictedSSRCs)
+	assert.Contains(t, decryptCtx.evictedSSRCs, uint32(minEvictedSSRCs+1))
+}
+
+func TestContextRemoveSSRC(t *testing.T) {
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, SRTPReplayProtection(64))
+	assert.NoError(t, err)
+
+	packet := encryptTestRTPForSSRC(t, encryptCtx, 1, 10)
+	_, err = decryptCtx.DecryptRTP(nil, packet, nil)
+	assert.NoError(t, err)
+
+	decryptCtx.RemoveSSRC(1)
+	assert.Empty(t, decryptCtx.srtpSSRCStates)
+	_, err = decryptCtx.DecryptRTP(nil, packet, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTPForSSRC(t, encryptCtx, 1, 11), nil)
+	assert.NoError(t, err)
+
+	// Without replay protection removed SSRC is accepted again.
+	noReplayCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	_, err = noReplayCtx.DecryptRTP(nil, packet, nil)
+	assert.NoError(t, err)
+	noReplayCtx.RemoveSSRC(1)
+	_, err = noReplayCtx.DecryptRTP(nil, packet, nil)
+	assert.NoError(t, err)
+}
+
+func TestSSRCStateLimitInvalid(t *testing.T) {
+	_, err := buildTestContext(profileCTR, SSRCStateLimit(-1, 0))
+	assert.ErrorIs(t, err, errInvalidSSRCStateLimit)
+	_, err = buildTestContext(profileCTR, SSRCStateLimit(0, -time.Second))
+	assert.ErrorIs(t, err, errInvalidSSRCStateLimit)
+}
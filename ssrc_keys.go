@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"fmt"
+	"maps"
+)
+
+// SetSSRCKeys sets master key, salt and protection profile used for SRTP and SRTCP packets of the SSRC
+// instead of the keys of the Context, both for encryption and decryption. It allows a single Context to
+// handle many senders whose keys are exchanged separately, e.g. by SFU terminating many senders keyed
+// by different SDES or EKT exchanges, or in PERC topologies. Zero profile means the profile of
+// the Context. Other options of the Context, including MKI sent in packets, apply to these keys too.
+// Keys are replaced when they are already set for the SSRC. Packets sent with EncryptRTPWithMKI and
+// EncryptRTCPWithMKI use the key selected by MKI instead, and keys of EKT Fields take precedence
+// over the SSRC keys when received packets are decrypted.
+//
+// RTPOverhead and RTCPOverhead return the maximum for the profile of the Context and profiles of all SSRCs.
+// Operation is not thread-safe, you need to provide synchronization with encrypting and decrypting packets.
+func (c *Context) SetSSRCKeys(ssrc uint32, profile ProtectionProfile, masterKey, masterSalt []byte) error {
+	if profile == 0 {
+		profile = c.profile
+	}
+	if !profile.isSupported() {
+		return fmt.Errorf("%w: %#v", ErrUnsupportedProfile, profile)
+	}
+	if profile != c.profile && c.rccMode != RCCModeNone {
+		return errUnsupportedRccMode
+	}
+
+	cipher, err := c.createCipher(profile, c.sendMKI, masterKey, masterSalt, c.encryptSRTP, c.encryptSRTCP)
+	if err != nil {
+		return err
+	}
+	if c.ssrcCiphers == nil {
+		c.ssrcCiphers = map[uint32]srtpCipher{}
+		c.ssrcProfiles = map[uint32]ProtectionProfile{}
+	}
+	c.ssrcCiphers[ssrc] = cipher
+	c.ssrcProfiles[ssrc] = profile
+
+	return nil
+}
+
+// RemoveSSRCKeys removes keys set for the SSRC by SetSSRCKeys, so its packets use the keys of the Context
+// again. SRTP and SRTCP state of the SSRC is kept, use RemoveSSRC to remove it.
+// Operation is not thread-safe, you need to provide synchronization with encrypting and decrypting packets.
+func (c *Context) RemoveSSRCKeys(ssrc uint32) {
+	delete(c.ssrcCiphers, ssrc)
+	delete(c.ssrcProfiles, ssrc)
+}
+
+// sendCipher returns cipher used to encrypt packets of the SSRC: the one set by SetSSRCKeys, or the send key.
+func (c *Context) sendCipher(ssrc uint32) srtpCipher {
+	if cipher, ok := c.ssrcCiphers[ssrc]; ok && !c.sendKeyOverridden {
+		return cipher
+	}
+
+	return c.cipher
+}
+
+// cloneSSRCCiphers returns copy of ciphers set by SetSSRCKeys, for use by a cloned Context.
+func (c *Context) cloneSSRCCiphers() map[uint32]srtpCipher {
+	if c.ssrcCiphers == nil {
+		return nil
+	}
+	ciphers := maps.Clone(c.ssrcCiphers)
+	for ssrc, cipher := range ciphers {
+		ciphers[ssrc] = cipher.clone()
+	}
+
+	return ciphers
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSSRCKeys(t *testing.T) {
+	ssrcKey := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	ssrcSalt := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+
+	encryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	assert.NoError(t, encryptCtx.SetSSRCKeys(2, profileGCM, ssrcKey, ssrcSalt))
+	decryptCtx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	assert.NoError(t, decryptCtx.SetSSRCKeys(2, profileGCM, ssrcKey, ssrcSalt))
+	// Context which has only the key of SSRC 2.
+	ssrcCtx, err := CreateContext(ssrcKey, ssrcSalt, profileGCM)
+	assert.NoError(t, err)
+
+	rtpPacket := func(ssrc uint32, seq uint16) []byte {
+		raw, err := (&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: ssrc, SequenceNumber: seq},
+			Payload: []byte{0x01, 0x02, 0x03, 0x04},
+		}).Marshal()
+		assert.NoError(t, err)
+
+		return raw
+	}
+	rtcpPacket := func(ssrc uint32) []byte {
+		return []byte{0x80, 0xc9, 0x00, 0x01, byte(ssrc >> 24), byte(ssrc >> 16), byte(ssrc >> 8), byte(ssrc)}
+	}
+
+	for _, ssrc := range []uint32{1, 2} {
+		decrypted := rtpPacket(ssrc, 1)
+		encrypted, err := encryptCtx.EncryptRTP(nil, decrypted, nil)
+		assert.NoError(t, err)
+		actual, err := decryptCtx.DecryptRTP(nil, encrypted, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, decrypted, actual)
+		_, err = ssrcCtx.DecryptRTP(nil, encrypted, nil)
+		assert.Equal(t, ssrc == 2, err == nil)
+
+		decrypted = rtcpPacket(ssrc)
+		encrypted, err = encryptCtx.EncryptRTCP(nil, decrypted, nil)
+		assert.NoError(t, err)
+		actual, err = decryptCtx.DecryptRTCP(nil, encrypted, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, decrypted, actual)
+		_, err = ssrcCtx.DecryptRTCP(nil, encrypted, nil)
+		assert.Equal(t, ssrc == 2, err == nil)
+	}
+
+	// Clone uses its own copy of SSRC keys.
+	clone := encryptCtx.Clone()
+	encrypted, err := clone.EncryptRTP(nil, rtpPacket(2, 2), nil)
+	assert.NoError(t, err)
+	_, err = ssrcCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+
+	encryptCtx.RemoveSSRCKeys(2)
+	encrypted, err = encryptCtx.EncryptRTP(nil, rtpPacket(2, 3), nil)
+	assert.NoError(t, err)
+	_, err = ssrcCtx.DecryptRTP(nil, encrypted, nil)
+	assert.Error(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.Error(t, err)
+	decryptCtx.RemoveSSRCKeys(2)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+}
+
+func TestSSRCKeysErrors(t *testing.T) {
+	ctx, err := buildTestContext(profileCTR)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, ctx.SetSSRCKeys(1, ProtectionProfile(0x1234), nil, nil), ErrUnsupportedProfile)
+	assert.ErrorIs(t, ctx.SetSSRCKeys(1, 0, make([]byte, 15), make([]byte, 14)), errShortSrtpMasterKey)
+	assert.NoError(t, ctx.SetSSRCKeys(1, 0, make([]byte, 16), make([]byte, 14)))
+
+	ctx, err = buildTestContext(profileGCM, RolloverCounterCarryingTransform(RCCMode3, 1))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, ctx.SetSSRCKeys(1, profileCTR, make([]byte, 16), make([]byte, 14)), errUnsupportedRccMode)
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Stats contains counters of packets processed by Context.
+type Stats struct {
+	SRTPEncrypted  uint64
+	SRTPDecrypted  uint64
+	SRTCPEncrypted uint64
+	SRTCPDecrypted uint64
+	// BytesEncrypted and BytesDecrypted are total sizes of SRTP and SRTCP packets, i.e. of encryption
+	// output and decryption input.
+	BytesEncrypted uint64
+	BytesDecrypted uint64
+	// DecryptFailures counts all SRTP and SRTCP packets which failed to decrypt, including ones
+	// counted by AuthFailures, ReplayDrops and MKIMisses.
+	DecryptFailures uint64
+	AuthFailures    uint64
+	ReplayDrops     uint64
+	MKIMisses       uint64
+	// ROC contains the current rollover counter of each SRTP SSRC.
+	ROC map[uint32]uint32
+}
+
+// SessionStats contains stats of both contexts of a session.
+type SessionStats struct {
+	// Local are stats of the context encrypting sent packets.
+	Local Stats
+	// Remote are stats of the context decrypting received packets.
+	Remote Stats
+}
+
+// StatsRecorder receives events of packets processed by Context, e.g. to update Prometheus metrics.
+// Methods are called synchronously, so they should not block.
+type StatsRecorder interface {
+	// PacketEncrypted is called after SRTP or SRTCP packet is encrypted. size is size of the encrypted packet.
+	PacketEncrypted(ssrc uint32, isRTCP bool, size int)
+	// PacketDecrypted is called after SRTP or SRTCP packet is decrypted. size is size of the encrypted packet.
+	PacketDecrypted(ssrc uint32, isRTCP bool, size int)
+	// DecryptFailed is called when SRTP or SRTCP packet cannot be decrypted. ssrc is read from
+	// the unauthenticated packet header, and it is zero if the packet is too short.
+	DecryptFailed(ssrc uint32, isRTCP bool, err error)
+}
+
+// contextStats are counters updated by Context.
+type contextStats struct {
+	srtpEncrypted, srtpDecrypted   uint64
+	srtcpEncrypted, srtcpDecrypted uint64
+	bytesEncrypted, bytesDecrypted uint64
+	decryptFailures                uint64
+	authFailures                   uint64
+	replayDrops                    uint64
+	mkiMisses                      uint64
+}
+
+// Stats returns counters of packets processed by the Context. Like other Context methods, it must be
+// synchronized with encrypting and decrypting packets.
+func (c *Context) Stats() Stats {
+	stats := Stats{
+		SRTPEncrypted:   c.stats.srtpEncrypted,
+		SRTPDecrypted:   c.stats.srtpDecrypted,
+		SRTCPEncrypted:  c.stats.srtcpEncrypted,
+		SRTCPDecrypted:  c.stats.srtcpDecrypted,
+		BytesEncrypted:  c.stats.bytesEncrypted,
+		BytesDecrypted:  c.stats.bytesDecrypted,
+		DecryptFailures: c.stats.decryptFailures,
+		AuthFailures:    c.stats.authFailures,
+		ReplayDrops:     c.stats.replayDrops,
+		MKIMisses:       c.stats.mkiMisses,
+		ROC:             make(map[uint32]uint32, len(c.srtpSSRCStates)),
+	}
+	for ssrc, state := range c.srtpSSRCStates {
+		stats.ROC[ssrc] = uint32(state.index >> 16) //nolint:gosec // G115
+	}
+
+	return stats
+}
+
+// add adds counters of other Stats, and merges their ROC maps.
+func (s *Stats) add(other Stats) {
+	s.SRTPEncrypted += other.SRTPEncrypted
+	s.SRTPDecrypted += other.SRTPDecrypted
+	s.SRTCPEncrypted += other.SRTCPEncrypted
+	s.SRTCPDecrypted += other.SRTCPDecrypted
+	s.BytesEncrypted += other.BytesEncrypted
+	s.BytesDecrypted += other.BytesDecrypted
+	s.DecryptFailures += other.DecryptFailures
+	s.AuthFailures += other.AuthFailures
+	s.ReplayDrops += other.ReplayDrops
+	s.MKIMisses += other.MKIMisses
+	for ssrc, roc := range other.ROC {
+		s.ROC[ssrc] = roc
+	}
+}
+
+// recordEncrypted updates stats and usage of the send key after packet was encrypted.
+func (c *Context) recordEncrypted(ssrc uint32, isRTCP bool, size int) {
+	if isRTCP {
+		c.stats.srtcpEncrypted++
+	} else {
+		c.stats.srtpEncrypted++
+	}
+	c.stats.bytesEncrypted += uint64(size) //nolint:gosec // G115
+	if _, ok := c.ssrcCiphers[ssrc]; !ok {
+		c.countKeyUsage()
+	}
+	if c.statsRecorder != nil {
+		c.statsRecorder.PacketEncrypted(ssrc, isRTCP, size)
+	}
+}
+
+// recordDecrypted updates stats after packet was decrypted, or its decryption failed.
+func (c *Context) recordDecrypted(ssrc uint32, isRTCP bool, size int, err error) {
+	if err != nil {
+		c.stats.decryptFailures++
+		switch {
+		case errors.Is(err, ErrFailedToVerifyAuthTag):
+			c.stats.authFailures++
+		case errors.Is(err, errDuplicated):
+			c.stats.replayDrops++
+		case errors.Is(err, ErrMKINotFound):
+			c.stats.mkiMisses++
+		}
+		if c.statsRecorder != nil {
+			c.statsRecorder.DecryptFailed(ssrc, isRTCP, err)
+		}
+
+		return
+	}
+
+	if isRTCP {
+		c.stats.srtcpDecrypted++
+	} else {
+		c.stats.srtpDecrypted++
+	}
+	c.stats.bytesDecrypted += uint64(size) //nolint:gosec // G115
+	if c.statsRecorder != nil {
+		c.statsRecorder.PacketDecrypted(ssrc, isRTCP, size)
+	}
+}
+
+// rtcpSSRC returns SSRC of sender of RTCP packet, or zero if the packet is too short.
+func rtcpSSRC(packet []byte) uint32 {
+	if len(packet) < srtcpHeaderSize {
+		return 0
+	}
+
+	return binary.BigEndian.Uint32(packet[4:])
+}
+
+// stats returns stats of the local and remote contexts of the session.
+func (s *session) stats() SessionStats {
+	var stats SessionStats
+
+	if s.asyncWriter != nil {
+		stats.Local = s.asyncWriter.ctx.Stats()
+	} else {
+		s.localContextMutex.Lock()
+		stats.Local = s.localContext.Stats()
+		s.localContextMutex.Unlock()
+	}
+
+	s.remoteContextMutex.Lock()
+	stats.Remote = s.remoteContext.Stats()
+	s.remoteContextMutex.Unlock()
+
+	return stats
+}
+
+// Stats returns counters of packets sent and received by the session.
+func (s *SessionSRTP) Stats() SessionStats {
+	return s.session.stats()
+}
+
+// Stats returns counters of packets sent and received by the session.
+func (s *SessionSRTCP) Stats() SessionStats {
+	return s.session.stats()
+}
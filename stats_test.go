@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatsRecorder struct {
+	encrypted, decrypted int
+	failures             []error
+}
+
+func (r *testStatsRecorder) PacketEncrypted(uint32, bool, int) { r.encrypted++ }
+
+func (r *testStatsRecorder) PacketDecrypted(uint32, bool, int) { r.decrypted++ }
+
+func (r *testStatsRecorder) DecryptFailed(_ uint32, _ bool, err error) {
+	r.failures = append(r.failures, err)
+}
+
+func TestContextStats(t *testing.T) {
+	recorder := &testStatsRecorder{}
+	encryptCtx, err := buildTestContext(profileCTR, MasterKeyIndicator([]byte{1}))
+	assert.NoError(t, err)
+	decryptCtx, err := buildTestContext(profileCTR, MasterKeyIndicator([]byte{1}),
+		SRTPReplayProtection(64), PacketStatsRecorder(recorder))
+	assert.NoError(t, err)
+	otherCtx, err := buildTestContext(profileCTR, MasterKeyIndicator([]byte{2}))
+	assert.NoError(t, err)
+
+	encrypted := encryptTestRTP(t, encryptCtx, 1)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+	assert.ErrorIs(t, err, errDuplicated)
+	_, err = decryptCtx.DecryptRTP(nil, encryptTestRTP(t, otherCtx, 2), nil)
+	assert.ErrorIs(t, err, ErrMKINotFound)
+	tampered := encryptTestRTP(t, encryptCtx, 3)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = decryptCtx.DecryptRTP(nil, tampered, nil)
+	assert.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+	rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}
+	encryptedRTCP, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+	assert.NoError(t, err)
+	_, err = decryptCtx.DecryptRTCP(nil, encryptedRTCP, nil)
+	assert.NoError(t, err)
+
+	stats := encryptCtx.Stats()
+	assert.Equal(t, uint64(2), stats.SRTPEncrypted)
+	assert.Equal(t, uint64(1), stats.SRTCPEncrypted)
+	assert.Equal(t, uint64(2*len(encrypted)+len(encryptedRTCP)), stats.BytesEncrypted)
+
+	stats = decryptCtx.Stats()
+	assert.Equal(t, Stats{
+		SRTPDecrypted:   1,
+		SRTCPDecrypted:  1,
+		BytesDecrypted:  uint64(len(encrypted) + len(encryptedRTCP)),
+		DecryptFailures: 3,
+		AuthFailures:    1,
+		ReplayDrops:     1,
+		MKIMisses:       1,
+		ROC:             map[uint32]uint32{defaultSsrc: 0},
+	}, stats)
+
+	assert.Equal(t, 0, recorder.encrypted)
+	assert.Equal(t, 2, recorder.decrypted)
+	assert.Len(t, recorder.failures, 3)
+}
+
+func TestSessionSRTPStats(t *testing.T) {
+	lim := test.TimeOut(time.Second * 5)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	aSession, bSession := buildSessionSRTPPair(t)
+
+	aWriteStream, err := aSession.OpenWriteStream()
+	assert.NoError(t, err)
+	_, err = aWriteStream.WriteRTP(&rtp.Header{SSRC: 5000}, []byte{0x00, 0x01, 0x03, 0x04})
+	assert.NoError(t, err)
+
+	bReadStream, _, err := bSession.AcceptStream()
+	assert.NoError(t, err)
+	_, err = bReadStream.Read(make([]byte, 100))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(1), aSession.Stats().Local.SRTPEncrypted)
+	bStats := bSession.Stats()
+	assert.Equal(t, uint64(1), bStats.Remote.SRTPDecrypted)
+	assert.Equal(t, map[uint32]uint32{5000: 0}, bStats.Remote.ROC)
+	assert.Equal(t, uint64(0), bStats.Local.SRTPEncrypted)
+
+	assert.NoError(t, aSession.Close())
+	assert.NoError(t, bSession.Close())
+}
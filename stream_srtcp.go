@@ -4,6 +4,7 @@
 package srtp
 
 import (
+	"cmp"
 	"errors"
 	"io"
 	"sync"
@@ -33,8 +34,8 @@ func (r *ReadStreamSRTCP) write(buf []byte) (n int, err error) {
 	n, err = r.buffer.Write(buf)
 
 	if errors.Is(err, packetio.ErrFull) {
-		// Silently drop data when the buffer is full.
-		return len(buf), nil
+		// Drop data when the buffer is full.
+		return 0, &readStreamBufferFullError{Proto: "srtcp", SSRC: r.ssrc, Size: len(buf)}
 	}
 
 	return n, err
@@ -61,6 +62,19 @@ func (r *ReadStreamSRTCP) ReadRTCP(buf []byte) (int, *rtcp.Header, error) {
 	return n, header, nil
 }
 
+// ReadRTCPPackets reads and decrypts RTCP packet from the nextConn, and returns it parsed. It returns
+// a single packet split from the received compound packet, or all packets of the compound packet
+// when Config.RTCPRawCompound is set. buf is used for reading the packet, so it must be large enough
+// to hold it.
+func (r *ReadStreamSRTCP) ReadRTCPPackets(buf []byte) ([]rtcp.Packet, error) {
+	n, err := r.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return rtcp.Unmarshal(buf[:n])
+}
+
 // Read reads and decrypts full RTCP packet from the nextConn.
 func (r *ReadStreamSRTCP) Read(buf []byte) (int, error) {
 	return r.buffer.Read(buf)
@@ -121,9 +135,9 @@ func (r *ReadStreamSRTCP) init(child streamSession, ssrc uint32) error {
 	if r.session.bufferFactory != nil {
 		r.buffer = r.session.bufferFactory(packetio.RTCPBufferPacket, ssrc)
 	} else {
-		// Create a buffer and limit it to 100KB
+		// Create a buffer and limit it to 100KB, unless other limit is configured
 		buff := packetio.NewBuffer()
-		buff.SetLimitSize(srtcpBufferSize)
+		buff.SetLimitSize(cmp.Or(r.session.bufferSize, srtcpBufferSize))
 		r.buffer = buff
 	}
 
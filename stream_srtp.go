@@ -4,10 +4,12 @@
 package srtp
 
 import (
+	"cmp"
 	"errors"
 	"io"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/rtp"
@@ -29,6 +31,9 @@ type ReadStreamSRTP struct {
 
 	buffer        io.ReadWriteCloser
 	peekedPackets [][]byte
+
+	// Counters of packets delivered to the stream.
+	packets, bytes atomic.Uint64
 }
 
 // Used by getOrCreateReadStream.
@@ -53,12 +58,12 @@ func (r *ReadStreamSRTP) init(child streamSession, ssrc uint32) error {
 	r.isInited = true
 	r.isClosed = make(chan bool)
 
-	// Create a buffer with a 1MB limit
+	// Create a buffer with a 1MB limit, unless other limit is configured
 	if r.session.bufferFactory != nil {
 		r.buffer = r.session.bufferFactory(packetio.RTPBufferPacket, ssrc)
 	} else {
 		buff := packetio.NewBuffer()
-		buff.SetLimitSize(srtpBufferSize)
+		buff.SetLimitSize(cmp.Or(r.session.bufferSize, srtpBufferSize))
 		r.buffer = buff
 	}
 
@@ -69,8 +74,12 @@ func (r *ReadStreamSRTP) write(buf []byte) (n int, err error) {
 	n, err = r.buffer.Write(buf)
 
 	if errors.Is(err, packetio.ErrFull) {
-		// Silently drop data when the buffer is full.
-		return len(buf), nil
+		// Drop data when the buffer is full.
+		return 0, &readStreamBufferFullError{Proto: "srtp", SSRC: r.ssrc, Size: len(buf)}
+	}
+	if err == nil {
+		r.packets.Add(1)
+		r.bytes.Add(uint64(len(buf)))
 	}
 
 	return n, err
@@ -121,6 +130,22 @@ func (r *ReadStreamSRTP) ReadRTP(buf []byte) (int, *rtp.Header, error) {
 	return n, header, nil
 }
 
+// ReadRTPTo reads and decrypts full RTP packet like ReadRTP, but unmarshals its header into
+// the provided one. Header slices are reused, so no memory is allocated when the header is reused
+// for subsequent packets.
+func (r *ReadStreamSRTP) ReadRTPTo(buf []byte, header *rtp.Header) (int, error) {
+	n, err := r.Read(buf)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = header.Unmarshal(buf[:n]); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
 // SetReadDeadline sets the deadline for the Read operation.
 // Setting to zero means no deadline.
 func (r *ReadStreamSRTP) SetReadDeadline(t time.Time) error {
@@ -152,6 +177,7 @@ func (r *ReadStreamSRTP) Close() error {
 		}
 
 		r.session.removeReadStream(r.ssrc)
+		r.session.closeRepairStreams(r.ssrc)
 
 		return nil
 	}
@@ -172,6 +198,14 @@ func (w *WriteStreamSRTP) WriteRTP(header *rtp.Header, payload []byte) (int, err
 	return w.session.writeRTP(header, payload)
 }
 
+// WriteRTPRaw encrypts a RTP packet with already marshaled header and writes it to the connection.
+// headerBytes must contain the whole RTP header, including CSRCs and header extensions, and nothing
+// else. Padding, if any, must be at the end of the payload. Unlike WriteRTP, the header is not
+// marshaled again, and the packet is assembled directly in a pooled buffer.
+func (w *WriteStreamSRTP) WriteRTPRaw(headerBytes, payload []byte) (int, error) {
+	return w.session.writeRTPRaw(headerBytes, payload)
+}
+
 // Write encrypts and writes a full RTP packets to the nextConn.
 func (w *WriteStreamSRTP) Write(b []byte) (int, error) {
 	return w.session.write(b)
@@ -6,6 +6,7 @@ package srtp
 import (
 	"io"
 	"net"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -220,6 +221,7 @@ func benchmarkWrite(b *testing.B, profile ProtectionProfile, size int) {
 	}
 
 	b.SetBytes(int64(len(packetRaw)))
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
@@ -296,6 +298,7 @@ func benchmarkWriteRTP(b *testing.B, profile ProtectionProfile, size int) {
 	payload := make([]byte, size)
 
 	b.SetBytes(int64(header.MarshalSize() + len(payload)))
+	b.ReportAllocs()
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
@@ -327,3 +330,139 @@ func BenchmarkWriteRTP(b *testing.B) {
 		benchmarkWriteRTP(b, profileGCM, 1000)
 	})
 }
+
+// buildReadSessionSRTP creates SessionSRTP with zero keys, its read stream for SSRC 322, and count
+// packets with given payload size encrypted for it.
+func buildReadSessionSRTP(
+	tb testing.TB, profile ProtectionProfile, size, count int,
+) (*SessionSRTP, *ReadStreamSRTP, [][]byte) {
+	tb.Helper()
+
+	keyLen, err := profile.KeyLen()
+	assert.NoError(tb, err)
+	saltLen, err := profile.SaltLen()
+	assert.NoError(tb, err)
+
+	session, err := NewSessionSRTP(newNoopConn(), &Config{
+		Keys: SessionKeys{
+			LocalMasterKey:   make([]byte, keyLen),
+			LocalMasterSalt:  make([]byte, saltLen),
+			RemoteMasterKey:  make([]byte, keyLen),
+			RemoteMasterSalt: make([]byte, saltLen),
+		},
+		Profile: profile,
+	})
+	assert.NoError(tb, err)
+	readStream, err := session.OpenReadStream(322)
+	assert.NoError(tb, err)
+
+	encryptCtx, err := CreateContext(make([]byte, keyLen), make([]byte, saltLen), profile)
+	assert.NoError(tb, err)
+	packets := make([][]byte, count)
+	for i := range packets {
+		packet := &rtp.Packet{
+			Header:  rtp.Header{Version: 2, SSRC: 322, SequenceNumber: uint16(i)}, //nolint:gosec // G115
+			Payload: make([]byte, size),
+		}
+		packetRaw, errMarshal := packet.Marshal()
+		assert.NoError(tb, errMarshal)
+		packets[i], err = encryptCtx.EncryptRTP(nil, packetRaw, nil)
+		assert.NoError(tb, err)
+	}
+
+	return session, readStream, packets
+}
+
+func benchmarkReadRTP(b *testing.B, profile ProtectionProfile, size int) {
+	b.Helper()
+
+	session, readStream, packets := buildReadSessionSRTP(b, profile, size, 1<<16)
+	buf := make([]byte, 1500)
+	readBuf := make([]byte, 1500)
+	header := &rtp.Header{}
+
+	b.SetBytes(int64(len(packets[0])))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// Packets are decrypted by the read loop of the session in the same way.
+		n := copy(buf, packets[i%len(packets)])
+		if err := session.decrypt(buf[:n]); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := readStream.ReadRTPTo(readBuf, header); err != nil {
+			b.Fatal(err)
+		}
+		if i%len(packets) == len(packets)-1 {
+			b.StopTimer()
+			session.remoteContext.srtpSSRCStates = map[uint32]*srtpSSRCState{}
+			b.StartTimer()
+		}
+	}
+
+	assert.NoError(b, session.Close())
+}
+
+func BenchmarkReadRTP(b *testing.B) {
+	b.Run("CTR-100", func(b *testing.B) {
+		benchmarkReadRTP(b, profileCTR, 100)
+	})
+	b.Run("CTR-1000", func(b *testing.B) {
+		benchmarkReadRTP(b, profileCTR, 1000)
+	})
+	b.Run("GCM-100", func(b *testing.B) {
+		benchmarkReadRTP(b, profileGCM, 100)
+	})
+	b.Run("GCM-1000", func(b *testing.B) {
+		benchmarkReadRTP(b, profileGCM, 1000)
+	})
+}
+
+func TestSessionSRTPZeroAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not reliable with the race detector")
+	}
+
+	const runs = 50
+
+	for name, profile := range map[string]ProtectionProfile{"CTR": profileCTR, "GCM": profileGCM} {
+		// MTU-sized payloads are processed by the same code paths as short ones.
+		for _, size := range []int{100, 1200} {
+			t.Run(name+"-"+strconv.Itoa(size), func(t *testing.T) {
+				session, readStream, packets := buildReadSessionSRTP(t, profile, size, runs+1)
+				writeStream, err := session.OpenWriteStream()
+				assert.NoError(t, err)
+
+				buf := make([]byte, 1500)
+				readBuf := make([]byte, 1500)
+				header := &rtp.Header{}
+				i := 0
+				assert.Zero(t, testing.AllocsPerRun(runs, func() {
+					n := copy(buf, packets[i])
+					i++
+					assert.NoError(t, session.decrypt(buf[:n]))
+					_, errRead := readStream.ReadRTPTo(readBuf, header)
+					assert.NoError(t, errRead)
+				}), "read")
+
+				writeHeader := &rtp.Header{Version: 2, SSRC: 5000}
+				payload := make([]byte, size)
+				assert.Zero(t, testing.AllocsPerRun(runs, func() {
+					writeHeader.SequenceNumber++
+					_, errWrite := writeStream.WriteRTP(writeHeader, payload)
+					assert.NoError(t, errWrite)
+				}), "WriteRTP")
+
+				packetRaw, err := (&rtp.Packet{Header: *writeHeader, Payload: payload}).Marshal()
+				assert.NoError(t, err)
+				assert.Zero(t, testing.AllocsPerRun(runs, func() {
+					_, errWrite := writeStream.Write(packetRaw)
+					assert.NoError(t, errWrite)
+				}), "Write")
+
+				assert.NoError(t, session.Close())
+			})
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"bytes"
+
+	"github.com/pion/rtp"
+)
+
+// TraceEvent describes encryption or decryption of a single SRTP or SRTCP packet. See TraceHook option.
+type TraceEvent struct {
+	Decrypt bool
+	IsRTCP  bool
+	Profile ProtectionProfile
+	SSRC    uint32
+	// SequenceNumber and ROC are set for SRTP packets, and SRTCPIndex for SRTCP ones.
+	SequenceNumber uint16
+	ROC            uint32
+	SRTCPIndex     uint32
+	// MKI of the key used for the packet, or nil when MKI is not enabled.
+	MKI []byte
+	// IV is IV used by AEAD and f8 profiles, or initial counter block of AES-CM ones. It is nil when it is
+	// not known, e.g. for custom ciphers.
+	IV []byte
+	// PacketLen is length of the protected packet, without EKT Field. Auth tag, or AEAD auth tag for AEAD
+	// profiles, and MKI are located at given offsets of the protected packet. MKIOffset is -1 when MKI
+	// is not enabled.
+	PacketLen     int
+	AuthTagOffset int
+	AuthTagLen    int
+	MKIOffset     int
+	// Err is set when decryption failed, e.g. when authentication of the packet failed.
+	Err error
+}
+
+// setLayout sets offsets of auth tag and MKI in the protected packet.
+func (e *TraceEvent) setLayout(packetLen, authTagLen, aeadAuthTagLen, mkiLen int) {
+	e.PacketLen = packetLen
+	e.MKIOffset = -1
+	mkiOffset := packetLen - authTagLen - mkiLen
+	if mkiLen > 0 {
+		e.MKIOffset = mkiOffset
+	}
+
+	switch {
+	case aeadAuthTagLen == 0:
+		e.AuthTagOffset, e.AuthTagLen = packetLen-authTagLen, authTagLen
+	case e.IsRTCP:
+		// AEAD auth tag of SRTCP packet is followed by the SRTCP index.
+		e.AuthTagOffset, e.AuthTagLen = mkiOffset-srtcpIndexSize-aeadAuthTagLen, aeadAuthTagLen
+	default:
+		e.AuthTagOffset, e.AuthTagLen = mkiOffset-aeadAuthTagLen, aeadAuthTagLen
+	}
+}
+
+// traceRTP passes TraceEvent of SRTP packet to the hook set by TraceHook option.
+func (c *Context) traceRTP(
+	decrypt bool, cipher srtpCipher, mki []byte, header *rtp.Header, roc uint32, packet []byte, err error,
+) {
+	event := TraceEvent{
+		Decrypt:        decrypt,
+		SSRC:           header.SSRC,
+		SequenceNumber: header.SequenceNumber,
+		ROC:            roc,
+		MKI:            bytes.Clone(mki),
+		Err:            err,
+	}
+
+	authTagLen, _ := cipher.AuthTagRTPLen()
+	aeadAuthTagLen, _ := cipher.AEADAuthTagLen()
+	_, authTagLen = c.hasROCInPacket(header, authTagLen)
+	event.setLayout(len(packet), authTagLen, aeadAuthTagLen, len(mki))
+
+	cipher = traceCipher(cipher, uint64(roc)<<16|uint64(header.SequenceNumber))
+	event.Profile = cipherProfile(cipher, c.profile)
+	if conformance, ok := cipher.(conformanceCipher); ok {
+		event.IV = conformance.debugRTPIV(header, roc)
+	}
+
+	c.traceHook(event)
+}
+
+// traceRTCP passes TraceEvent of SRTCP packet to the hook set by TraceHook option.
+func (c *Context) traceRTCP(
+	decrypt bool, cipher srtpCipher, mki []byte, ssrc, index uint32, packet []byte, err error,
+) {
+	event := TraceEvent{
+		Decrypt:    decrypt,
+		IsRTCP:     true,
+		SSRC:       ssrc,
+		SRTCPIndex: index,
+		MKI:        bytes.Clone(mki),
+		Err:        err,
+	}
+
+	authTagLen, _ := cipher.AuthTagRTCPLen()
+	aeadAuthTagLen, _ := cipher.AEADAuthTagLen()
+	event.setLayout(len(packet), authTagLen, aeadAuthTagLen, len(mki))
+
+	cipher = traceCipher(cipher, uint64(index))
+	event.Profile = cipherProfile(cipher, c.profile)
+	if conformance, ok := cipher.(conformanceCipher); ok {
+		event.IV, _ = conformance.debugRTCPIV(index, ssrc)
+	}
+
+	c.traceHook(event)
+}
+
+// traceCipher returns cipher which protects packet with given index, unwrapping kdrCipher.
+func traceCipher(cipher srtpCipher, index uint64) srtpCipher {
+	if k, ok := cipher.(*kdrCipher); ok {
+		if indexCipher, err := k.cipherForIndex(index); err == nil {
+			return indexCipher
+		}
+	}
+
+	return cipher
+}
+
+// cipherProfile returns protection profile of built-in cipher, or fallback for other ones.
+func cipherProfile(cipher srtpCipher, fallback ProtectionProfile) ProtectionProfile {
+	switch cipher := cipher.(type) {
+	case *srtpCipherAesCmHmacSha1:
+		return cipher.ProtectionProfile
+	case *srtpCipherAeadAesGcm:
+		return cipher.ProtectionProfile
+	case *srtpCipherDoubleAeadAesGcm:
+		return cipher.ProtectionProfile
+	default:
+		return fallback
+	}
+}
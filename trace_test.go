@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package srtp
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceHook(t *testing.T) {
+	for _, profile := range []ProtectionProfile{profileCTR, profileGCM} {
+		t.Run(profile.String(), func(t *testing.T) {
+			var events []TraceEvent
+			hook := TraceHook(func(event TraceEvent) { events = append(events, event) })
+			mki := MasterKeyIndicator([]byte{0xaa, 0xbb})
+			encryptCtx, err := buildTestContext(profile, mki, hook, UnsafeConformanceTesting())
+			require.NoError(t, err)
+			decryptCtx, err := buildTestContext(profile, mki, hook)
+			require.NoError(t, err)
+
+			header := rtp.Header{Version: 2, SSRC: 0x1234, SequenceNumber: 10}
+			decrypted, err := (&rtp.Packet{Header: header, Payload: []byte{0x01, 0x02, 0x03, 0x04}}).Marshal()
+			require.NoError(t, err)
+			encrypted, err := encryptCtx.EncryptRTP(nil, decrypted, nil)
+			require.NoError(t, err)
+			_, err = decryptCtx.DecryptRTP(nil, encrypted, nil)
+			require.NoError(t, err)
+			forged := append([]byte{}, encrypted...)
+			forged[3]++
+			_, err = decryptCtx.DecryptRTP(nil, forged, nil)
+			require.ErrorIs(t, err, ErrFailedToVerifyAuthTag)
+
+			rtcpPacket := []byte{0x80, 0xc9, 0x00, 0x01, 0x00, 0x00, 0x12, 0x34}
+			encryptedRTCP, err := encryptCtx.EncryptRTCP(nil, rtcpPacket, nil)
+			require.NoError(t, err)
+			_, err = decryptCtx.DecryptRTCP(nil, encryptedRTCP, nil)
+			require.NoError(t, err)
+
+			require.Len(t, events, 5)
+			iv, err := encryptCtx.DebugRTPIV(&header, 0)
+			require.NoError(t, err)
+			aeadAuthTagLen, err := profile.AEADAuthTagLen()
+			require.NoError(t, err)
+			authTagLen, err := profile.AuthTagRTPLen()
+			require.NoError(t, err)
+			for i, event := range events[:3] {
+				assert.Equal(t, i > 0, event.Decrypt)
+				assert.False(t, event.IsRTCP)
+				assert.Equal(t, profile, event.Profile)
+				assert.Equal(t, uint32(0x1234), event.SSRC)
+				assert.Equal(t, []byte{0xaa, 0xbb}, event.MKI)
+				assert.Equal(t, len(encrypted), event.PacketLen)
+				assert.Equal(t, len(encrypted)-authTagLen-2, event.MKIOffset)
+				assert.Equal(t, max(authTagLen, aeadAuthTagLen), event.AuthTagLen)
+				if aeadAuthTagLen > 0 {
+					assert.Equal(t, len(decrypted), event.AuthTagOffset)
+				} else {
+					assert.Equal(t, len(encrypted)-authTagLen, event.AuthTagOffset)
+				}
+			}
+			assert.Equal(t, uint16(10), events[0].SequenceNumber)
+			assert.Equal(t, iv, events[0].IV)
+			assert.Equal(t, iv, events[1].IV)
+			assert.NoError(t, events[1].Err)
+			assert.Equal(t, uint16(11), events[2].SequenceNumber)
+			assert.ErrorIs(t, events[2].Err, ErrFailedToVerifyAuthTag)
+
+			rtcpIV, err := encryptCtx.DebugRTCPIV(0x1234, 1)
+			require.NoError(t, err)
+			for i, event := range events[3:] {
+				assert.Equal(t, i > 0, event.Decrypt)
+				assert.True(t, event.IsRTCP)
+				assert.Equal(t, uint32(0x1234), event.SSRC)
+				assert.Equal(t, uint32(1), event.SRTCPIndex)
+				assert.Equal(t, rtcpIV, event.IV)
+				assert.Equal(t, len(encryptedRTCP), event.PacketLen)
+				assert.Equal(t, []byte{0xaa, 0xbb}, encryptedRTCP[event.MKIOffset:event.MKIOffset+2])
+				if aeadAuthTagLen > 0 {
+					assert.Equal(t, len(rtcpPacket), event.AuthTagOffset)
+					assert.Equal(t, aeadAuthTagLen, event.AuthTagLen)
+				} else {
+					assert.Equal(t, len(encryptedRTCP), event.AuthTagOffset+event.AuthTagLen)
+					assert.Equal(t, event.MKIOffset+2, event.AuthTagOffset)
+				}
+			}
+		})
+	}
+}